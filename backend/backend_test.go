@@ -5,20 +5,40 @@ import (
 	"crypto/ecdsa"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
+	"fmt"
 	"net"
+	"reflect"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/relab/gorums"
 	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/blockchain"
 	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/consensus/chainedhotstuff"
+	"github.com/relab/hotstuff/crypto"
+	hsecdsa "github.com/relab/hotstuff/crypto/ecdsa"
 	"github.com/relab/hotstuff/crypto/keygen"
 	"github.com/relab/hotstuff/eventloop"
+	"github.com/relab/hotstuff/internal/conformance"
+	"github.com/relab/hotstuff/internal/proto/hotstuffpb"
 	"github.com/relab/hotstuff/internal/testutil"
+	"github.com/relab/hotstuff/leaderrotation"
+	"github.com/relab/hotstuff/logging"
+	"github.com/relab/hotstuff/synchronizer"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 func TestConnect(t *testing.T) {
@@ -32,7 +52,7 @@ func TestConnect(t *testing.T) {
 		defer teardown()
 		td.builders.Build()
 
-		cfg := NewConfig(td.creds, gorums.WithDialTimeout(time.Second))
+		cfg := NewConfig(td.creds, WithManagerOptions(gorums.WithDialTimeout(time.Second)))
 
 		builder.Register(cfg)
 		builder.Build()
@@ -46,6 +66,1019 @@ func TestConnect(t *testing.T) {
 	runBoth(t, run)
 }
 
+func TestSharedManager(t *testing.T) {
+	run := func(t *testing.T, setup setupFunc) {
+		const n = 4
+		ctrl := gomock.NewController(t)
+		td := setup(t, ctrl, n)
+		teardown := createServers(t, td, ctrl)
+		defer teardown()
+		td.builders.Build()
+
+		mgr := NewManager(td.creds, gorums.WithDialTimeout(time.Second))
+		defer mgr.Close()
+
+		cfgs := make([]*Config, 2)
+		for i := range cfgs {
+			builder := consensus.NewBuilder(1, td.keys[0])
+			testutil.TestModules(t, ctrl, 1, td.keys[0], &builder)
+			cfgs[i] = NewConfigWithManager(mgr)
+			builder.Register(cfgs[i])
+			builder.Build()
+			if err := cfgs[i].Connect(td.replicas); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if cfgs[0].mgr != cfgs[1].mgr {
+			t.Error("expected configurations to share the same manager")
+		}
+		if size := cfgs[0].mgr.Size(); size != n-1 {
+			t.Errorf("expected %d connections, got %d", n-1, size)
+		}
+
+		// closing a configuration must leave the shared connections to the manager
+		for _, cfg := range cfgs {
+			cfg.Close()
+		}
+	}
+	runBoth(t, run)
+}
+
+// generationCredentials records the handshakes made with one generation of credentials,
+// and the connections that they established.
+type generationCredentials struct {
+	credentials.TransportCredentials
+	mut   sync.Mutex
+	conns []net.Conn
+}
+
+func (c *generationCredentials) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	conn, info, err := c.TransportCredentials.ClientHandshake(ctx, authority, conn)
+	if err == nil {
+		c.mut.Lock()
+		c.conns = append(c.conns, conn)
+		c.mut.Unlock()
+	}
+	return conn, info, err
+}
+
+func (c *generationCredentials) handshakes() []net.Conn {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return append([]net.Conn(nil), c.conns...)
+}
+
+func TestCredentialsProvider(t *testing.T) {
+	const n = 2
+	ctrl := gomock.NewController(t)
+	td := setupReplicas(t, ctrl, n)
+	serverTeardown := createServers(t, td, ctrl)
+	defer serverTeardown()
+
+	oldCreds := &generationCredentials{TransportCredentials: insecure.NewCredentials()}
+	newCreds := &generationCredentials{TransportCredentials: insecure.NewCredentials()}
+	var calls int32
+	provider := func() credentials.TransportCredentials {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return oldCreds
+		}
+		return newCreds
+	}
+
+	cfg := NewConfigWithCredentialsProvider(provider, WithManagerOptions(gorums.WithDialTimeout(time.Second)))
+	td.builders[0].Register(cfg)
+	td.builders.Build()
+	if err := cfg.Connect(td.replicas); err != nil {
+		t.Fatal(err)
+	}
+	defer cfg.Close()
+
+	conns := oldCreds.handshakes()
+	if len(conns) != 1 {
+		t.Fatalf("got %d connections with the initial credentials, want 1", len(conns))
+	}
+	if got := len(newCreds.handshakes()); got != 0 {
+		t.Fatalf("got %d connections with the updated credentials before reconnecting, want 0", got)
+	}
+
+	// break the connection, such that the configuration must reconnect
+	conns[0].Close()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for len(newCreds.handshakes()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for a reconnect with the updated credentials")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := len(oldCreds.handshakes()); got != 1 {
+		t.Errorf("got %d connections with the initial credentials after reconnecting, want 1", got)
+	}
+}
+
+func TestCredentialsProviderNoCredentials(t *testing.T) {
+	const n = 2
+	ctrl := gomock.NewController(t)
+	td := setupReplicas(t, ctrl, n)
+	serverTeardown := createServers(t, td, ctrl)
+	defer serverTeardown()
+
+	provider := func() credentials.TransportCredentials { return nil }
+	creds := &providedCredentials{provider: provider}
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	if _, _, err := creds.ClientHandshake(context.Background(), "", client); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("ClientHandshake: got %v, want %v", err, ErrNoCredentials)
+	}
+	if _, _, err := creds.ServerHandshake(server); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("ServerHandshake: got %v, want %v", err, ErrNoCredentials)
+	}
+
+	// the configuration must not fall back to an insecure connection.
+	cfg := NewConfigWithCredentialsProvider(provider, WithManagerOptions(gorums.WithDialTimeout(time.Second)))
+	td.builders[0].Register(cfg)
+	td.builders.Build()
+	if err := cfg.Connect(td.replicas); err == nil {
+		cfg.Close()
+		t.Fatal("expected Connect to fail without credentials")
+	}
+}
+
+func TestConfigurationConformance(t *testing.T) {
+	const n = 4
+	ctrl := gomock.NewController(t)
+	td := setupReplicas(t, ctrl, n)
+	serverTeardown := createServers(t, td, ctrl)
+	defer serverTeardown()
+
+	cfg := NewConfig(td.creds, WithManagerOptions(gorums.WithDialTimeout(time.Second)))
+	td.builders[0].Register(cfg)
+	td.builders.Build()
+	if err := cfg.Connect(td.replicas); err != nil {
+		t.Fatal(err)
+	}
+	defer cfg.Close()
+
+	conformance.RunConfigurationConformance(t, func() consensus.Configuration { return cfg })
+}
+
+func TestPrometheus(t *testing.T) {
+	run := func(t *testing.T, setup setupFunc) {
+		const n = 4
+		ctrl := gomock.NewController(t)
+		td := setup(t, ctrl, n)
+
+		servers := make([]*Server, n)
+		for i := range servers {
+			servers[i] = NewServer(WithGorumsServerOptions(gorums.WithGRPCServerOptions(grpc.Creds(td.creds))))
+			servers[i].StartOnListener(td.listeners[i])
+			td.builders[i].Register(servers[i])
+		}
+		defer func() {
+			for _, srv := range servers {
+				srv.Stop()
+			}
+		}()
+
+		reg := prometheus.NewRegistry()
+		cfg := NewConfig(td.creds, WithManagerOptions(gorums.WithDialTimeout(time.Second)), WithPrometheus(reg))
+		td.builders[0].Register(cfg)
+		td.builders.Build()
+
+		if err := cfg.Connect(td.replicas); err != nil {
+			t.Fatal(err)
+		}
+		defer cfg.Close()
+
+		const proposals = 3
+		for i := 0; i < proposals; i++ {
+			cfg.Propose(consensus.ProposeMsg{
+				ID:    1,
+				Block: consensus.NewBlock(consensus.GetGenesis().Hash(), consensus.QuorumCert{}, "foo", consensus.View(i+1), 1),
+			})
+		}
+
+		gather := func() map[string]float64 {
+			t.Helper()
+			families, err := reg.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			values := make(map[string]float64)
+			for _, family := range families {
+				for _, metric := range family.GetMetric() {
+					name := family.GetName()
+					for _, label := range metric.GetLabel() {
+						name += "/" + label.GetValue()
+					}
+					switch {
+					case metric.GetCounter() != nil:
+						values[name] = metric.GetCounter().GetValue()
+					case metric.GetGauge() != nil:
+						values[name] = metric.GetGauge().GetValue()
+					}
+				}
+			}
+			return values
+		}
+
+		values := gather()
+		want := map[string]float64{
+			"hotstuff_backend_messages_sent_total/propose": proposals * (n - 1),
+			"hotstuff_backend_connected_replicas":          n - 1,
+		}
+		for name, value := range want {
+			if got, ok := values[name]; !ok || got != value {
+				t.Errorf("%s: got %v (present: %v), want %v", name, got, ok, value)
+			}
+		}
+
+		// the number of connected replicas is updated when a replica disconnects.
+		servers[n-1].Stop()
+		const connected = "hotstuff_backend_connected_replicas"
+		deadline := time.Now().Add(10 * time.Second)
+		for gather()[connected] != n-2 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if got := gather()[connected]; got != n-2 {
+			t.Errorf("%s: got %v after a replica disconnected, want %v", connected, got, n-2)
+		}
+	}
+	runBoth(t, run)
+}
+
+func TestFetchDeduplication(t *testing.T) {
+	const n = 50
+	var (
+		calls   int32
+		started = make(chan struct{})
+		release = make(chan struct{})
+		hash    = consensus.GetGenesis().Hash()
+	)
+	fetch := func(_ context.Context, _ consensus.Hash) (*consensus.Block, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+		}
+		<-release
+		return consensus.GetGenesis(), nil
+	}
+
+	g := newFetchGroup()
+	var wg sync.WaitGroup
+	wg.Add(n)
+	// the first fetch must be in flight before the others start, such that they are combined with it.
+	go func() {
+		defer wg.Done()
+		if _, ok := g.fetch(context.Background(), hash, fetch); !ok {
+			t.Error("fetch failed")
+		}
+	}()
+	<-started
+	for i := 1; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			if _, ok := g.fetch(context.Background(), hash, fetch); !ok {
+				t.Error("fetch failed")
+			}
+		}()
+	}
+	// give the waiting fetches some time to join the call in flight.
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+}
+
+// blockingFetches returns a stream interceptor that blocks fetch requests from being sent until release is closed.
+// The started channel is closed when the first fetch request is blocked.
+func blockingFetches(started, release chan struct{}) grpc.StreamClientInterceptor {
+	var once sync.Once
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &blockingStream{ClientStream: stream, block: func() {
+			once.Do(func() { close(started) })
+			<-release
+		}}, nil
+	}
+}
+
+type blockingStream struct {
+	grpc.ClientStream
+	block func()
+}
+
+func (s *blockingStream) SendMsg(m interface{}) error {
+	if msg, ok := m.(*gorums.Message); ok {
+		if _, ok := msg.Message.(*hotstuffpb.BlockHash); ok {
+			s.block()
+		}
+	}
+	return s.ClientStream.SendMsg(m)
+}
+
+func TestFetchCanceledCaller(t *testing.T) {
+	run := func(t *testing.T, setup setupFunc) {
+		const n = 4
+		ctrl := gomock.NewController(t)
+		td := setup(t, ctrl, n)
+
+		serverTeardown := createServers(t, td, ctrl)
+		defer serverTeardown()
+
+		started, release := make(chan struct{}), make(chan struct{})
+		cfg := NewConfig(td.creds, WithManagerOptions(
+			gorums.WithDialTimeout(time.Second),
+			WithInterceptors(nil, []grpc.StreamClientInterceptor{blockingFetches(started, release)}),
+		))
+		td.builders[0].Register(cfg)
+		td.builders.Build()
+		if err := cfg.Connect(td.replicas); err != nil {
+			t.Fatal(err)
+		}
+		defer cfg.Close()
+
+		type result struct {
+			block *consensus.Block
+			ok    bool
+		}
+		fetch := func(ctx context.Context) <-chan result {
+			c := make(chan result, 1)
+			go func() {
+				block, ok := cfg.Fetch(ctx, consensus.GetGenesis().Hash())
+				c <- result{block, ok}
+			}()
+			return c
+		}
+
+		// the first caller starts the fetch, and the second caller joins it.
+		firstCtx, cancelFirst := context.WithCancel(context.Background())
+		first := fetch(firstCtx)
+		<-started
+		secondCtx, cancelSecond := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancelSecond()
+		second := fetch(secondCtx)
+		// give the second fetch some time to join the call in flight.
+		time.Sleep(10 * time.Millisecond)
+
+		cancelFirst()
+		select {
+		case r := <-first:
+			if r.ok {
+				t.Error("expected the canceled fetch to fail")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("the canceled fetch did not return")
+		}
+
+		close(release)
+		r := <-second
+		if !r.ok || r.block.Hash() != consensus.GetGenesis().Hash() {
+			t.Errorf("expected the second fetch to succeed after the first caller canceled, got %v, %v", r.block, r.ok)
+		}
+	}
+	runBoth(t, run)
+}
+
+func TestFetchFailureCache(t *testing.T) {
+	var calls int
+	fetch := func(_ context.Context, _ consensus.Hash) (*consensus.Block, error) {
+		calls++
+		return nil, errors.New("not found")
+	}
+	hash := consensus.GetGenesis().Hash()
+
+	g := newFetchGroup()
+	for i := 0; i < 3; i++ {
+		if _, ok := g.fetch(context.Background(), hash, fetch); ok {
+			t.Error("expected fetch to fail")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call while failure is cached, got %d", calls)
+	}
+
+	// after the failure expires, the network is queried again.
+	g.ttl = 0
+	g.fetch(context.Background(), hash, fetch)
+	if calls != 2 {
+		t.Errorf("expected 2 calls after failure expired, got %d", calls)
+	}
+}
+
+func TestFetchRetry(t *testing.T) {
+	hash := consensus.GetGenesis().Hash()
+	failures := func(n int, calls *int) func(context.Context, consensus.Hash) (*consensus.Block, error) {
+		return func(_ context.Context, _ consensus.Hash) (*consensus.Block, error) {
+			*calls++
+			if *calls <= n {
+				return nil, errors.New("busy")
+			}
+			return consensus.GetGenesis(), nil
+		}
+	}
+
+	var calls int
+	retry := fetchRetry{retries: 3, backoff: time.Millisecond}
+	if _, err := retry.do(context.Background(), hash, failures(2, &calls)); err != nil {
+		t.Errorf("expected fetch to succeed after retries: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+
+	calls = 0
+	if _, err := retry.do(context.Background(), hash, failures(10, &calls)); err == nil {
+		t.Error("expected fetch to fail when the retries are exhausted")
+	}
+	if calls != 4 {
+		t.Errorf("expected 4 calls, got %d", calls)
+	}
+
+	// the backoff is interrupted when the context is done.
+	calls = 0
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	retry = fetchRetry{retries: 3, backoff: time.Minute}
+	start := time.Now()
+	if _, err := retry.do(ctx, hash, failures(10, &calls)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	if calls != 1 || time.Since(start) > time.Second {
+		t.Errorf("expected a single call and an early return, got %d calls after %v", calls, time.Since(start))
+	}
+
+	// the backoff is capped, such that it does not overflow after many retries.
+	backoff := time.Millisecond
+	for i := 0; i < 100; i++ {
+		backoff = nextBackoff(backoff)
+		if backoff <= 0 || backoff > maxFetchBackoff {
+			t.Fatalf("backoff after %d retries is %v, want between 0 and %v", i+1, backoff, maxFetchBackoff)
+		}
+	}
+	if backoff != maxFetchBackoff {
+		t.Errorf("got backoff %v, want %v", backoff, maxFetchBackoff)
+	}
+
+	for _, opt := range []ConfigOption{WithFetchRetry(-1, time.Millisecond), WithFetchRetry(1, -time.Millisecond)} {
+		cfg := NewConfig(nil, opt)
+		if err := cfg.Connect(nil); err == nil {
+			t.Error("expected Connect to fail with an invalid fetch retry option")
+		}
+	}
+}
+
+func TestMaxMessageSize(t *testing.T) {
+	run := func(t *testing.T, setup setupFunc, maxSize int, wantOK bool) {
+		const n = 4
+		ctrl := gomock.NewController(t)
+		td := setup(t, ctrl, n)
+
+		serverTeardown := createServers(t, td, ctrl)
+		defer serverTeardown()
+
+		cfg := NewConfig(td.creds, WithManagerOptions(gorums.WithDialTimeout(time.Second), WithMaxMessageSize(maxSize)))
+		td.builders[0].Register(cfg)
+		td.builders.Build()
+
+		if err := cfg.Connect(td.replicas); err != nil {
+			t.Fatal(err)
+		}
+		defer cfg.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		// the genesis block is larger than 16 bytes.
+		_, ok := cfg.Fetch(ctx, consensus.GetGenesis().Hash())
+		if ok != wantOK {
+			t.Errorf("max message size %d: fetch returned %v, want %v", maxSize, ok, wantOK)
+		}
+	}
+	runBoth(t, func(t *testing.T, setup setupFunc) {
+		run(t, setup, 16, false)
+		run(t, setup, 1<<20, true)
+	})
+}
+
+func TestFailureDetector(t *testing.T) {
+	run := func(t *testing.T, setup setupFunc) {
+		const (
+			n    = 4
+			dead = hotstuff.ID(4)
+		)
+		ctrl := gomock.NewController(t)
+		td := setup(t, ctrl, n)
+
+		servers := make([]*Server, n)
+		for i := range servers {
+			servers[i] = NewServer(WithGorumsServerOptions(gorums.WithGRPCServerOptions(grpc.Creds(td.creds))))
+			servers[i].StartOnListener(td.listeners[i])
+			td.builders[i].Register(servers[i])
+		}
+		defer func() {
+			for _, srv := range servers[:n-1] {
+				srv.Stop()
+			}
+		}()
+
+		cfg := NewConfig(td.creds, WithManagerOptions(gorums.WithDialTimeout(time.Second)), WithFailureDetector(FailureDetectorOptions{
+			Interval:         50 * time.Millisecond,
+			Threshold:        3,
+			ExcludeSuspected: true,
+		}))
+		td.builders[0].Register(cfg)
+		td.builders.Build()
+
+		if err := cfg.Connect(td.replicas); err != nil {
+			t.Fatal(err)
+		}
+		defer cfg.Close()
+
+		// all replicas respond to the first probes.
+		time.Sleep(200 * time.Millisecond)
+		if suspected := cfg.Suspected(); len(suspected) != 0 {
+			t.Fatalf("replicas %v suspected before any replica failed", suspected)
+		}
+
+		servers[dead-1].Stop()
+
+		deadline := time.Now().Add(5 * time.Second)
+		for len(cfg.Suspected()) == 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		if got, want := cfg.Suspected(), []hotstuff.ID{dead}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got suspected replicas %v, want %v", got, want)
+		}
+		if got, want := cfg.ActiveReplicas(), []hotstuff.ID{1, 2, 3}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got active replicas %v, want %v", got, want)
+		}
+		for _, id := range cfg.sendConfig().NodeIDs() {
+			if hotstuff.ID(id) == dead {
+				t.Errorf("suspected replica %d is included in the active configuration", dead)
+			}
+		}
+		// the suspected replica is kept in the configuration, such that it can reconnect.
+		if _, ok := cfg.Replica(dead); !ok {
+			t.Errorf("suspected replica %d was removed from the configuration", dead)
+		}
+	}
+	runBoth(t, run)
+}
+
+func TestFailureDetectorInvalid(t *testing.T) {
+	for _, opts := range []FailureDetectorOptions{{Interval: -time.Second}, {Threshold: -1}} {
+		cfg := NewConfig(nil, WithFailureDetector(opts))
+		if err := cfg.Connect(nil); err == nil {
+			t.Errorf("expected Connect to fail with failure detector options %+v", opts)
+		}
+	}
+}
+
+func TestQuorumReachable(t *testing.T) {
+	t.Run("WithFailureDetector", func(t *testing.T) { testQuorumReachable(t, true) })
+	t.Run("WithoutFailureDetector", func(t *testing.T) { testQuorumReachable(t, false) })
+}
+
+func testQuorumReachable(t *testing.T, withDetector bool) {
+	run := func(t *testing.T, setup setupFunc) {
+		const n = 4
+		ctrl := gomock.NewController(t)
+		td := setup(t, ctrl, n)
+
+		newServer := func() *Server {
+			return NewServer(WithGorumsServerOptions(gorums.WithGRPCServerOptions(grpc.Creds(td.creds))))
+		}
+		servers := make([]*Server, n)
+		for i := range servers {
+			servers[i] = newServer()
+			servers[i].StartOnListener(td.listeners[i])
+			td.builders[i].Register(servers[i])
+		}
+		defer func() {
+			for _, srv := range servers {
+				srv.Stop()
+			}
+		}()
+
+		opts := []ConfigOption{WithManagerOptions(
+			gorums.WithDialTimeout(time.Second),
+			gorums.WithBackoff(backoff.Config{BaseDelay: 10 * time.Millisecond, Multiplier: 1, MaxDelay: 10 * time.Millisecond}),
+			gorums.WithGrpcDialOptions(grpc.WithConnectParams(grpc.ConnectParams{
+				Backoff:           backoff.Config{BaseDelay: 10 * time.Millisecond, Multiplier: 1, MaxDelay: 10 * time.Millisecond},
+				MinConnectTimeout: time.Second,
+			})),
+		)}
+		if withDetector {
+			opts = append(opts, WithFailureDetector(FailureDetectorOptions{Interval: 50 * time.Millisecond, Threshold: 2}))
+		}
+		cfg := NewConfig(td.creds, opts...)
+		td.builders[0].Register(cfg)
+		mods := td.builders.Build()
+
+		if cfg.QuorumReachable() {
+			t.Error("quorum reachable before connecting")
+		}
+		if err := cfg.Connect(td.replicas); err != nil {
+			t.Fatal(err)
+		}
+		defer cfg.Close()
+
+		if got, want := cfg.ConnectedReplicas(), []hotstuff.ID{1, 2, 3, 4}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got connected replicas %v, want %v", got, want)
+		}
+		if !cfg.QuorumReachable() {
+			t.Error("quorum not reachable with all replicas connected")
+		}
+
+		waitFor := func(reachable bool) {
+			t.Helper()
+			deadline := time.Now().Add(10 * time.Second)
+			for cfg.QuorumReachable() != reachable && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			if cfg.QuorumReachable() != reachable {
+				t.Fatalf("QuorumReachable() = %v with connected replicas %v, want %v", !reachable, cfg.ConnectedReplicas(), reachable)
+			}
+		}
+
+		// with two of four replicas disconnected, only two replicas are connected, which is less than a quorum of three.
+		addrs := make([]string, n)
+		for _, i := range []int{2, 3} {
+			addrs[i] = td.listeners[i].Addr().String()
+			servers[i].Stop()
+		}
+		waitFor(false)
+		if got, want := cfg.ConnectedReplicas(), []hotstuff.ID{1, 2}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got connected replicas %v, want %v", got, want)
+		}
+
+		// the replicas come back on the same addresses.
+		for _, i := range []int{2, 3} {
+			lis, err := net.Listen("tcp", addrs[i])
+			if err != nil {
+				t.Fatal(err)
+			}
+			servers[i] = newServer()
+			servers[i].InitConsensusModule(mods[i], nil)
+			servers[i].StartOnListener(lis)
+		}
+		waitFor(true)
+	}
+	runBoth(t, run)
+}
+
+func TestJointQuorum(t *testing.T) {
+	cfg := NewConfig(nil)
+	for id := hotstuff.ID(1); id <= 4; id++ {
+		cfg.replicas[id] = &Replica{id: id}
+	}
+	ids := func(ids ...hotstuff.ID) consensus.IDSet {
+		set := consensus.NewIDSet()
+		for _, id := range ids {
+			set.Add(id)
+		}
+		return set
+	}
+	check := func(participants consensus.IDSet, want bool) {
+		t.Helper()
+		if got := consensus.IsQuorum(cfg, participants); got != want {
+			t.Errorf("IsQuorum(%v) = %v, want %v", participants, got, want)
+		}
+	}
+
+	check(ids(1, 2, 4), true)
+	check(ids(1, 2), false)
+
+	// remove replica 4: during the transition, quorums of both {1,2,3,4} and {1,2,3} are required.
+	if err := cfg.BeginReconfiguration([]hotstuff.ID{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.BeginReconfiguration([]hotstuff.ID{1, 2}); !errors.Is(err, ErrReconfigurationInProgress) {
+		t.Errorf("expected ErrReconfigurationInProgress, got %v", err)
+	}
+	check(ids(1, 2, 4), false)
+	check(ids(1, 2, 3), true)
+	if got := cfg.QuorumSize(); got != 3 {
+		t.Errorf("got quorum size %d during reconfiguration, want 3", got)
+	}
+
+	if err := cfg.CompleteReconfiguration(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.CompleteReconfiguration(); !errors.Is(err, ErrNoReconfiguration) {
+		t.Errorf("expected ErrNoReconfiguration, got %v", err)
+	}
+	check(ids(1, 2, 3), true)
+	check(ids(1, 2, 4), false)
+
+	if err := cfg.BeginReconfiguration([]hotstuff.ID{1, 5}); err == nil {
+		t.Error("expected an error for a member that is not in the configuration")
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	run := func(t *testing.T, setup setupFunc) {
+		const n = 4
+		ctrl := gomock.NewController(t)
+		td := setup(t, ctrl, n)
+
+		payload := []byte("application message")
+		var wg sync.WaitGroup
+		wg.Add(n - 1)
+
+		servers := make([]*Server, n)
+		for i := range servers {
+			servers[i] = NewServer(WithGorumsServerOptions(gorums.WithGRPCServerOptions(grpc.Creds(td.creds))))
+			servers[i].StartOnListener(td.listeners[i])
+			td.builders[i].Register(servers[i])
+			receiver := hotstuff.ID(i + 1)
+			servers[i].SetBroadcastHandler(func(sender hotstuff.ID, got []byte) {
+				defer wg.Done()
+				if receiver == 1 {
+					t.Error("sender received its own message")
+				}
+				if sender != 1 {
+					t.Errorf("replica %d: wrong sender: got %d, want %d", receiver, sender, 1)
+				}
+				if string(got) != string(payload) {
+					t.Errorf("replica %d: got payload %q, want %q", receiver, got, payload)
+				}
+			})
+		}
+		defer func() {
+			for _, srv := range servers {
+				srv.Stop()
+			}
+		}()
+
+		cfg := NewConfig(td.creds, WithManagerOptions(gorums.WithDialTimeout(time.Second)))
+		td.builders[0].Register(cfg)
+		td.builders.Build()
+
+		if err := cfg.Connect(td.replicas); err != nil {
+			t.Fatal(err)
+		}
+		defer cfg.Close()
+
+		cfg.Broadcast(payload)
+		wg.Wait()
+	}
+	runBoth(t, run)
+}
+
+func TestBroadcastMessage(t *testing.T) {
+	var wg sync.WaitGroup
+	want := wrapperspb.String("application message")
+	testBase(t, consensus.AppMsg{}, func(cfg consensus.Configuration) {
+		wg.Add(3)
+		cfg.BroadcastMessage(want)
+		wg.Wait()
+	}, func(event interface{}) {
+		got := event.(consensus.AppMsg)
+		if got.ID != 1 {
+			t.Errorf("wrong id in application message: got: %d, want: %d", got.ID, 1)
+		}
+		if !proto.Equal(got.Message, want) {
+			t.Errorf("wrong application message: got: %v, want: %v", got.Message, want)
+		}
+		wg.Done()
+	})
+}
+
+func TestReplayWindow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	builders := testutil.CreateBuilders(t, ctrl, 1, testutil.GenerateECDSAKey(t))
+	reg := prometheus.NewRegistry()
+	srv := NewServer(WithReplayWindow(5), WithServerPrometheus(reg))
+	builders[0].Register(srv)
+	mods := builders.Build()[0]
+
+	mods.EventLoop().AddEvent(synchronizer.ViewChangeEvent{View: 20})
+	mods.EventLoop().Tick()
+
+	qc := consensus.NewQuorumCert(nil, 0, consensus.GetGenesis().Hash())
+	stale := consensus.NewBlock(consensus.GetGenesis().Hash(), qc, "foo", 2, 1)
+	recent := consensus.NewBlock(consensus.GetGenesis().Hash(), qc, "bar", 15, 1)
+
+	ctx := gorums.ServerCtx{Context: peer.NewContext(
+		metadata.NewIncomingContext(context.Background(), metadata.Pairs("id", "1")),
+		&peer.Peer{},
+	)}
+	impl := &serviceImpl{srv}
+	for _, block := range []*consensus.Block{stale, recent} {
+		mods.BlockChain().Store(block)
+		cert, err := mods.Crypto().CreatePartialCert(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		impl.Vote(ctx, hotstuffpb.PartialCertToProto(cert))
+	}
+
+	// the votes that reach the event loop are recorded instead of being passed to the voting machine.
+	var votes []consensus.VoteMsg
+	mods.EventLoop().RegisterHandler(consensus.VoteMsg{}, func(event interface{}) {
+		votes = append(votes, event.(consensus.VoteMsg))
+	})
+	for mods.EventLoop().Tick() {
+	}
+	if len(votes) != 1 || votes[0].PartialCert.BlockHash() != recent.Hash() {
+		t.Errorf("got votes %v, want only the vote for the recent block", votes)
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rejected float64
+	for _, family := range families {
+		if family.GetName() != "hotstuff_backend_replays_rejected_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			rejected += metric.GetCounter().GetValue()
+		}
+	}
+	if rejected != 1 {
+		t.Errorf("got %v rejected replays, want 1", rejected)
+	}
+
+	// the collectors of another server cannot be registered with the same registry.
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	if err := NewServer(WithServerPrometheus(reg)).StartOnListener(lis); err == nil {
+		t.Error("expected the server to fail to start when its metrics cannot be registered")
+	}
+}
+
+func TestEventSourceLimit(t *testing.T) {
+	const limit = 2
+	ctrl := gomock.NewController(t)
+	builders := testutil.CreateBuilders(t, ctrl, 1, testutil.GenerateECDSAKey(t))
+	srv := NewServer(WithEventSourceLimit(limit))
+	builders[0].Register(srv)
+	mods := builders.Build()[0]
+
+	ctx := gorums.ServerCtx{Context: peer.NewContext(
+		metadata.NewIncomingContext(context.Background(), metadata.Pairs("id", "1")),
+		&peer.Peer{},
+	)}
+	impl := &serviceImpl{srv}
+	qc := consensus.NewQuorumCert(nil, 0, consensus.GetGenesis().Hash())
+	for i := 0; i < 100; i++ {
+		impl.NewView(ctx, hotstuffpb.SyncInfoToProto(consensus.NewSyncInfo().WithQC(qc)))
+	}
+	// a local event that is added after the flood of messages from replica 1.
+	mods.EventLoop().AddEvent(synchronizer.ViewChangeEvent{View: 1})
+
+	var newViews int
+	mods.EventLoop().RegisterHandler(consensus.NewViewMsg{}, func(_ interface{}) {
+		newViews++
+	})
+	for ticks := 1; ; ticks++ {
+		event, ok := mods.EventLoop().TickEvent()
+		if !ok {
+			t.Fatal("the local event was not processed")
+		}
+		if _, ok := event.(synchronizer.ViewChangeEvent); ok {
+			if ticks > limit+1 {
+				t.Errorf("the local event was processed after %d ticks, want at most %d", ticks, limit+1)
+			}
+			break
+		}
+	}
+	if newViews > limit {
+		t.Errorf("processed %d messages from replica 1 before the local event, want at most %d", newViews, limit)
+	}
+}
+
+func TestEventSourceLimitInvalid(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	srv := NewServer(WithEventSourceLimit(-1))
+	if err := srv.StartOnListener(lis); err == nil {
+		srv.Stop()
+		t.Error("expected the server to fail to start with a negative event source limit")
+	}
+}
+
+// testCommands accepts, proposes, and executes the same command forever.
+type testCommands struct{}
+
+func (testCommands) Accept(consensus.Command) bool                 { return true }
+func (testCommands) Proposed(consensus.Command)                    {}
+func (testCommands) Get(context.Context) (consensus.Command, bool) { return "foo", true }
+func (testCommands) Exec(consensus.Command)                        {}
+func (testCommands) Fork(consensus.Command)                        {}
+
+func TestLeaderEvent(t *testing.T) {
+	const (
+		n     = 4
+		views = 8
+	)
+	ctrl := gomock.NewController(t)
+	td := setupReplicas(t, ctrl, n)
+
+	var (
+		mut      sync.Mutex
+		observed = make([]map[consensus.View]hotstuff.ID, n)
+		reached  = make(chan struct{}, n)
+		configs  = make([]*Config, n)
+		servers  = make([]*Server, n)
+		mods     = make([]*consensus.Modules, n)
+	)
+	for i := 0; i < n; i++ {
+		i := i
+		observed[i] = make(map[consensus.View]hotstuff.ID)
+		servers[i] = NewServer()
+		servers[i].StartOnListener(td.listeners[i])
+		configs[i] = NewConfig(nil, WithManagerOptions(gorums.WithDialTimeout(time.Second)))
+		builder := consensus.NewBuilder(td.replicas[i].ID, td.keys[i])
+		builder.Register(
+			blockchain.New(),
+			consensus.New(chainedhotstuff.New()),
+			crypto.NewCache(hsecdsa.New(), 100),
+			leaderrotation.NewRoundRobin(),
+			synchronizer.New(synchronizer.NewViewDuration(100, 500, 1000, 1.2)),
+			logging.New(fmt.Sprintf("hs%d", i+1)),
+			configs[i],
+			servers[i],
+			testCommands{},
+		)
+		mods[i] = builder.Build()
+		mods[i].EventLoop().RegisterObserver(synchronizer.LeaderEvent{}, func(event interface{}) {
+			e := event.(synchronizer.LeaderEvent)
+			mut.Lock()
+			defer mut.Unlock()
+			observed[i][e.View] = e.Leader
+			if e.View == views {
+				reached <- struct{}{}
+			}
+		})
+	}
+	defer func() {
+		for i := range servers {
+			configs[i].Close()
+			servers[i].Stop()
+		}
+	}()
+	for _, cfg := range configs {
+		if err := cfg.Connect(td.replicas); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for _, m := range mods {
+		go func(m *consensus.Modules) {
+			defer wg.Done()
+			m.Synchronizer().Start(ctx)
+			m.Run(ctx)
+		}(m)
+	}
+	timeout := time.After(10 * time.Second)
+wait:
+	for i := 0; i < n; i++ {
+		select {
+		case <-reached:
+		case <-timeout:
+			t.Error("timed out waiting for the replicas to reach view", views)
+			break wait
+		}
+	}
+	cancel()
+	wg.Wait()
+
+	mut.Lock()
+	defer mut.Unlock()
+	for i, leaders := range observed {
+		if _, ok := leaders[1]; !ok {
+			t.Errorf("replica %d did not observe the leader of the first view", i+1)
+		}
+		for view, leader := range leaders {
+			// round-robin chooses replica view mod n + 1 to lead each view.
+			if want := hotstuff.ID(view%n + 1); leader != want {
+				t.Errorf("replica %d observed leader %d in view %d, want %d", i+1, leader, view, want)
+			}
+		}
+	}
+}
+
 // testBase is a generic test for a unicast/multicast call
 func testBase(t *testing.T, typ interface{}, send func(consensus.Configuration), handle eventloop.EventHandler) {
 	run := func(t *testing.T, setup setupFunc) {
@@ -56,7 +1089,7 @@ func testBase(t *testing.T, typ interface{}, send func(consensus.Configuration),
 		serverTeardown := createServers(t, td, ctrl)
 		defer serverTeardown()
 
-		cfg := NewConfig(td.creds, gorums.WithDialTimeout(time.Second))
+		cfg := NewConfig(td.creds, WithManagerOptions(gorums.WithDialTimeout(time.Second)))
 		td.builders[0].Register(cfg)
 		hl := td.builders.Build()
 
@@ -216,7 +1249,7 @@ func createServers(t *testing.T, td testData, ctrl *gomock.Controller) (teardown
 	t.Helper()
 	servers := make([]*Server, td.n)
 	for i := range servers {
-		servers[i] = NewServer(gorums.WithGRPCServerOptions(grpc.Creds(td.creds)))
+		servers[i] = NewServer(WithGorumsServerOptions(gorums.WithGRPCServerOptions(grpc.Creds(td.creds))))
 		servers[i].StartOnListener(td.listeners[i])
 		td.builders[i].Register(servers[i])
 	}
@@ -226,3 +1259,130 @@ func createServers(t *testing.T, td testData, ctrl *gomock.Controller) (teardown
 		}
 	}
 }
+
+func TestSendWaiting(t *testing.T) {
+	var wg sync.WaitGroup
+	want := consensus.ProposeMsg{
+		ID: 1,
+		Block: consensus.NewBlock(
+			consensus.GetGenesis().Hash(),
+			consensus.NewQuorumCert(nil, 0, consensus.GetGenesis().Hash()),
+			"foo", 1, 1,
+		),
+	}
+	run := func(t *testing.T, setup setupFunc) {
+		const n = 4
+		ctrl := gomock.NewController(t)
+		td := setup(t, ctrl, n)
+
+		serverTeardown := createServers(t, td, ctrl)
+		defer serverTeardown()
+
+		cfg := NewConfig(td.creds, WithManagerOptions(gorums.WithDialTimeout(time.Second)), WithSendWaiting(ProposeMessage))
+		td.builders[0].Register(cfg)
+		hl := td.builders.Build()
+
+		err := cfg.Connect(td.replicas)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer cfg.Close()
+
+		if got := len(cfg.sendWaiting.callOptions(ProposeMessage)); got != 0 {
+			t.Errorf("got %d call options for proposals, want none", got)
+		}
+		replica, _ := cfg.Replica(2)
+		if got := len(replica.(*Replica).sendWaiting.callOptions(VoteMessage)); got != 1 {
+			t.Errorf("got %d call options for votes, want WithNoSendWaiting", got)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		for _, hs := range hl[1:] {
+			hs.EventLoop().RegisterHandler(want, func(event interface{}) {
+				if got := event.(consensus.ProposeMsg); got.Block.Hash() != want.Block.Hash() {
+					t.Error("block hashes do not match")
+				}
+				wg.Done()
+			})
+			go hs.Run(ctx)
+		}
+		wg.Add(n - 1)
+		cfg.Propose(want)
+		wg.Wait()
+	}
+	runBoth(t, run)
+}
+
+func TestSendWaitingFailure(t *testing.T) {
+	proposal := consensus.ProposeMsg{
+		ID: 1,
+		Block: consensus.NewBlock(
+			consensus.GetGenesis().Hash(),
+			consensus.NewQuorumCert(nil, 0, consensus.GetGenesis().Hash()),
+			"foo", 1, 1,
+		),
+	}
+	run := func(t *testing.T, setup setupFunc) {
+		const n = 4
+		ctrl := gomock.NewController(t)
+		td := setup(t, ctrl, n)
+
+		servers := make([]*Server, n)
+		for i := range servers {
+			servers[i] = NewServer(WithGorumsServerOptions(gorums.WithGRPCServerOptions(grpc.Creds(td.creds))))
+			servers[i].StartOnListener(td.listeners[i])
+			td.builders[i].Register(servers[i])
+		}
+		defer func() {
+			for _, srv := range servers {
+				srv.Stop()
+			}
+		}()
+
+		cfg := NewConfig(td.creds, WithManagerOptions(gorums.WithDialTimeout(time.Second)), WithSendWaiting(ProposeMessage))
+		td.builders[0].Register(cfg)
+		hl := td.builders.Build()
+		if err := cfg.Connect(td.replicas); err != nil {
+			t.Fatal(err)
+		}
+		defer cfg.Close()
+
+		failed := make(chan SendFailedEvent, n)
+		hl[0].EventLoop().RegisterHandler(SendFailedEvent{}, func(event interface{}) {
+			failed <- event.(SendFailedEvent)
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go hl[0].Run(ctx)
+
+		cfg.Propose(proposal)
+		select {
+		case event := <-failed:
+			t.Errorf("got %+v while all replicas are connected", event)
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		servers[3].Stop()
+		deadline := time.Now().Add(10 * time.Second)
+		for reflect.DeepEqual(cfg.ConnectedReplicas(), []hotstuff.ID{1, 2, 3, 4}) && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		cfg.Propose(proposal)
+		select {
+		case event := <-failed:
+			if want := (SendFailedEvent{Type: ProposeMessage, Replica: 4}); event != want {
+				t.Errorf("got %+v, want %+v", event, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the failed send to be reported")
+		}
+		select {
+		case event := <-failed:
+			t.Errorf("got %+v, want only replica 4 to fail", event)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	runBoth(t, run)
+}
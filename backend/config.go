@@ -6,7 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/relab/gorums"
 	"github.com/relab/hotstuff"
 	"github.com/relab/hotstuff/consensus"
@@ -16,6 +19,8 @@ import (
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
 )
 
 // Replica provides methods used by hotstuff to send messages to replicas.
@@ -26,6 +31,8 @@ type Replica struct {
 	voteCancel    context.CancelFunc
 	newViewCancel context.CancelFunc
 	md            map[string]string
+	metrics       *backendMetrics
+	sendWaiting   sendWaiting
 }
 
 // ID returns the replica's ID.
@@ -47,7 +54,10 @@ func (r *Replica) Vote(cert consensus.PartialCert) {
 	r.voteCancel()
 	ctx, r.voteCancel = context.WithCancel(context.Background())
 	pCert := hotstuffpb.PartialCertToProto(cert)
-	r.node.Vote(ctx, pCert, gorums.WithNoSendWaiting())
+	r.sendWaiting.send(VoteMessage, pCert, []*hotstuffpb.Node{r.node}, func(opts ...gorums.CallOption) {
+		r.node.Vote(ctx, pCert, opts...)
+	})
+	r.metrics.sent(string(VoteMessage), 1)
 }
 
 // NewView sends the quorum certificate to the other replica.
@@ -58,7 +68,11 @@ func (r *Replica) NewView(msg consensus.SyncInfo) {
 	var ctx context.Context
 	r.newViewCancel()
 	ctx, r.newViewCancel = context.WithCancel(context.Background())
-	r.node.NewView(ctx, hotstuffpb.SyncInfoToProto(msg), gorums.WithNoSendWaiting())
+	syncInfo := hotstuffpb.SyncInfoToProto(msg)
+	r.sendWaiting.send(NewViewMessage, syncInfo, []*hotstuffpb.Node{r.node}, func(opts ...gorums.CallOption) {
+		r.node.NewView(ctx, syncInfo, opts...)
+	})
+	r.metrics.sent(string(NewViewMessage), 1)
 }
 
 // Metadata returns the gRPC metadata from this replica's connection.
@@ -70,16 +84,67 @@ func (r *Replica) Metadata() map[string]string {
 // and some information about the local replica. It also provides methods to send messages to the other replicas.
 type Config struct {
 	opts      []gorums.ManagerOption
+	optsErr   error
 	connected bool
 
-	mgr *hotstuffpb.Manager
+	mgr    *hotstuffpb.Manager
+	shared *Manager
+	conns  *connTracker
 	subConfig
+
+	quorumMut sync.Mutex
+	quorum    quorumState
+}
+
+// Manager manages connections to replicas, and can be shared by multiple configurations,
+// such that configurations that include the same replicas reuse the same connections.
+// The manager is not closed when a configuration that uses it is closed;
+// the caller that created the manager is responsible for closing it.
+type Manager struct {
+	mut   sync.Mutex
+	opts  []gorums.ManagerOption
+	mgr   *hotstuffpb.Manager
+	conns *connTracker
+	sends *sendTracker
+}
+
+// NewManager creates a new manager that can be shared by multiple configurations.
+func NewManager(creds credentials.TransportCredentials, opts ...gorums.ManagerOption) *Manager {
+	conns, sends := newConnTracker(), newSendTracker()
+	return &Manager{opts: managerOptions(creds, conns, sends, opts), conns: conns, sends: sends}
+}
+
+// get returns the underlying gorums manager, creating it with the given metadata if necessary.
+// The metadata of the first configuration to connect is used for all connections.
+func (m *Manager) get(md metadata.MD) *hotstuffpb.Manager {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	if m.mgr == nil {
+		m.mgr = hotstuffpb.NewManager(append(m.opts, gorums.WithMetadata(md))...)
+		m.opts = nil
+	}
+	return m.mgr
+}
+
+// Close closes all connections made by the manager.
+func (m *Manager) Close() {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	if m.mgr != nil {
+		m.mgr.Close()
+	}
 }
 
 type subConfig struct {
 	mods     *consensus.Modules
 	cfg      *hotstuffpb.Configuration
 	replicas map[hotstuff.ID]consensus.Replica
+	metrics  *backendMetrics
+	fetches  *fetchGroup
+	retry    fetchRetry
+	detector *failureDetector
+	// the message types whose sends wait until the message has been sent.
+	sendWaiting sendWaiting
 }
 
 // InitConsensusModule gives the module a reference to the Modules object.
@@ -98,7 +163,68 @@ func (cfg *Config) InitConsensusModule(mods *consensus.Modules, _ *consensus.Opt
 }
 
 // NewConfig creates a new configuration.
-func NewConfig(creds credentials.TransportCredentials, opts ...gorums.ManagerOption) *Config {
+// The options can be used to tune the configuration, e.g. using WithManagerOptions and WithFetchRetry.
+func NewConfig(creds credentials.TransportCredentials, opts ...ConfigOption) *Config {
+	// initialization will be finished by InitConsensusModule
+	conns, sends := newConnTracker(), newSendTracker()
+	cfg := &Config{
+		subConfig: subConfig{
+			replicas:    make(map[hotstuff.ID]consensus.Replica),
+			fetches:     newFetchGroup(),
+			sendWaiting: sendWaiting{sends: sends},
+		},
+		conns: conns,
+	}
+	cfg.applyOptions(opts)
+	cfg.opts = managerOptions(creds, conns, sends, cfg.opts)
+	return cfg
+}
+
+// NewConfigWithManager creates a new configuration that uses the connections of a shared manager.
+func NewConfigWithManager(mgr *Manager, opts ...ConfigOption) *Config {
+	// initialization will be finished by InitConsensusModule
+	cfg := &Config{
+		subConfig: subConfig{
+			replicas:    make(map[hotstuff.ID]consensus.Replica),
+			fetches:     newFetchGroup(),
+			sendWaiting: sendWaiting{sends: mgr.sends},
+		},
+		shared: mgr,
+		conns:  mgr.conns,
+	}
+	cfg.applyOptions(opts)
+	return cfg
+}
+
+// applyOptions applies the options, and remembers the first error, such that Connect can return it.
+func (cfg *Config) applyOptions(opts []ConfigOption) {
+	for _, opt := range opts {
+		if err := opt(cfg); err != nil && cfg.optsErr == nil {
+			cfg.optsErr = err
+		}
+	}
+}
+
+// WithPrometheus returns a configuration option that enables Prometheus metrics, and registers the collectors with reg.
+// The metrics include the number of messages sent by type, send errors, the number of connected replicas,
+// and the latency of fetch requests. The number of connected replicas is updated when a connection is opened or closed.
+func WithPrometheus(reg prometheus.Registerer) ConfigOption {
+	return func(cfg *Config) error {
+		metrics, err := newBackendMetrics(reg)
+		if err != nil {
+			return fmt.Errorf("failed to register metrics: %w", err)
+		}
+		cfg.metrics = metrics
+		return nil
+	}
+}
+
+func managerOptions(
+	creds credentials.TransportCredentials,
+	conns *connTracker,
+	sends *sendTracker,
+	opts []gorums.ManagerOption,
+) []gorums.ManagerOption {
 	if creds == nil {
 		creds = insecure.NewCredentials()
 	}
@@ -106,17 +232,36 @@ func NewConfig(creds credentials.TransportCredentials, opts ...gorums.ManagerOpt
 		grpc.WithBlock(),
 		grpc.WithReturnConnectionError(),
 		grpc.WithTransportCredentials(creds),
+		grpc.WithContextDialer(conns.dial),
+		grpc.WithChainStreamInterceptor(sends.intercept),
 	}
-	opts = append(opts, gorums.WithGrpcDialOptions(grpcOpts...))
+	return append(opts, gorums.WithGrpcDialOptions(grpcOpts...))
+}
 
-	// initialization will be finished by InitConsensusModule
-	cfg := &Config{
-		subConfig: subConfig{
-			replicas: make(map[hotstuff.ID]consensus.Replica),
-		},
-		opts: opts,
+// updateConnected sets the connected replicas metric to the number of other replicas with an open connection.
+func (cfg *Config) updateConnected() {
+	count := 0
+	for _, replica := range cfg.replicas {
+		if node := replica.(*Replica).node; node != nil && cfg.conns.connected(node.Address()) {
+			count++
+		}
+	}
+	cfg.metrics.connected(count)
+}
+
+// sendFailed reports that a message could not be sent to a replica.
+func (cfg *Config) sendFailed(msgType MessageType, id hotstuff.ID) {
+	cfg.metrics.sendError(string(msgType))
+	cfg.mods.Logger().Warnf("Failed to send %s message to replica %d", msgType, id)
+	cfg.mods.EventLoop().AddEvent(SendFailedEvent{Type: msgType, Replica: id})
+}
+
+func (cfg *Config) suspicionChanged(id hotstuff.ID, suspected bool) {
+	if suspected {
+		cfg.mods.Logger().Infof("Replica %d is suspected", id)
+	} else {
+		cfg.mods.Logger().Infof("Replica %d is no longer suspected", id)
 	}
-	return cfg
 }
 
 func (cfg *Config) replicaConnected(c replicaConnected) {
@@ -177,17 +322,26 @@ type ReplicaInfo struct {
 
 // Connect opens connections to the replicas in the configuration.
 func (cfg *Config) Connect(replicas []ReplicaInfo) (err error) {
+	if cfg.optsErr != nil {
+		return fmt.Errorf("invalid configuration option: %w", cfg.optsErr)
+	}
+
 	opts := cfg.opts
 	cfg.opts = nil // options are not needed beyond this point, so we delete them.
 
+	cfg.sendWaiting.failed = cfg.sendFailed
+
 	md := mapToMetadata(cfg.mods.Options().ConnectionMetadata())
 
 	// embed own ID to allow other replicas to identify messages from this replica
 	md.Set("id", fmt.Sprintf("%d", cfg.mods.ID()))
 
-	opts = append(opts, gorums.WithMetadata(md))
-
-	cfg.mgr = hotstuffpb.NewManager(opts...)
+	if cfg.shared != nil {
+		cfg.mgr = cfg.shared.get(md)
+	} else {
+		opts = append(opts, gorums.WithMetadata(md))
+		cfg.mgr = hotstuffpb.NewManager(opts...)
+	}
 
 	// set up an ID mapping to give to gorums
 	idMapping := make(map[string]uint32, len(replicas))
@@ -199,6 +353,8 @@ func (cfg *Config) Connect(replicas []ReplicaInfo) (err error) {
 			newViewCancel: func() {},
 			voteCancel:    func() {},
 			md:            make(map[string]string),
+			metrics:       cfg.metrics,
+			sendWaiting:   cfg.sendWaiting,
 		}
 		// we do not want to connect to ourself
 		if replica.ID != cfg.mods.ID() {
@@ -209,9 +365,9 @@ func (cfg *Config) Connect(replicas []ReplicaInfo) (err error) {
 	// this will connect to the replicas
 	cfg.cfg, err = cfg.mgr.NewConfiguration(qspec{}, gorums.WithNodeMap(idMapping))
 	if err != nil {
+		cfg.metrics.sendError("connect")
 		return fmt.Errorf("failed to create configuration: %w", err)
 	}
-
 	// now we need to update the "node" field of each replica we connected to
 	for _, node := range cfg.cfg.Nodes() {
 		// the node ID should correspond with the replica ID
@@ -221,6 +377,13 @@ func (cfg *Config) Connect(replicas []ReplicaInfo) (err error) {
 		replica.node = node
 	}
 
+	if cfg.metrics != nil {
+		cfg.conns.observe(cfg.updateConnected)
+		cfg.updateConnected()
+	}
+
+	cfg.detector.start(cfg.mgr, cfg.cfg, cfg.suspicionChanged)
+
 	cfg.connected = true
 
 	// this event is sent so that any delayed `replicaConnected` events can be processed.
@@ -241,21 +404,33 @@ func (cfg *subConfig) Replica(id hotstuff.ID) (replica consensus.Replica, ok boo
 }
 
 // SubConfig returns a subconfiguration containing the replicas specified in the ids slice.
+// It returns an error if any of the replicas is not in the configuration.
 func (cfg *Config) SubConfig(ids []hotstuff.ID) (sub consensus.Configuration, err error) {
 	replicas := make(map[hotstuff.ID]consensus.Replica)
-	nids := make([]uint32, len(ids))
-	for i, id := range ids {
-		nids[i] = uint32(id)
-		replicas[id] = cfg.replicas[id]
+	nids := make([]uint32, 0, len(ids))
+	for _, id := range ids {
+		replica, ok := cfg.replicas[id]
+		if !ok {
+			return nil, fmt.Errorf("replica %d is not in the configuration", id)
+		}
+		replicas[id] = replica
+		// we are not connected to ourself, so the local replica is not a node in the gorums configuration.
+		if id != cfg.mods.ID() {
+			nids = append(nids, uint32(id))
+		}
 	}
-	newCfg, err := cfg.mgr.NewConfiguration(gorums.WithNodeIDs(nids))
+	newCfg, err := cfg.mgr.NewConfiguration(qspec{}, gorums.WithNodeIDs(nids))
 	if err != nil {
 		return nil, err
 	}
 	return &subConfig{
-		mods:     cfg.mods,
-		cfg:      newCfg,
-		replicas: replicas,
+		mods:        cfg.mods,
+		cfg:         newCfg,
+		replicas:    replicas,
+		metrics:     cfg.metrics,
+		fetches:     newFetchGroup(),
+		retry:       cfg.retry,
+		sendWaiting: cfg.sendWaiting,
 	}, nil
 }
 
@@ -275,44 +450,120 @@ func (cfg *subConfig) QuorumSize() int {
 
 // Propose sends the block to all replicas in the configuration
 func (cfg *subConfig) Propose(proposal consensus.ProposeMsg) {
-	if cfg.cfg == nil {
+	sendCfg := cfg.sendConfig()
+	if sendCfg == nil {
 		return
 	}
-	cfg.cfg.Propose(
-		cfg.mods.Synchronizer().ViewContext(),
-		hotstuffpb.ProposalToProto(proposal),
-		gorums.WithNoSendWaiting(),
-	)
+	ctx := cfg.mods.Synchronizer().ViewContext()
+	msg := hotstuffpb.ProposalToProto(proposal)
+	cfg.sendWaiting.send(ProposeMessage, msg, sendCfg.Nodes(), func(opts ...gorums.CallOption) {
+		sendCfg.Propose(ctx, msg, opts...)
+	})
+	cfg.metrics.sent(string(ProposeMessage), sendCfg.Size())
 }
 
 // Timeout sends the timeout message to all replicas.
 func (cfg *subConfig) Timeout(msg consensus.TimeoutMsg) {
-	if cfg.cfg == nil {
+	sendCfg := cfg.sendConfig()
+	if sendCfg == nil {
 		return
 	}
-	cfg.cfg.Timeout(
-		cfg.mods.Synchronizer().ViewContext(),
-		hotstuffpb.TimeoutMsgToProto(msg),
-		gorums.WithNoSendWaiting(),
-	)
+	ctx := cfg.mods.Synchronizer().ViewContext()
+	timeoutMsg := hotstuffpb.TimeoutMsgToProto(msg)
+	cfg.sendWaiting.send(TimeoutMessage, timeoutMsg, sendCfg.Nodes(), func(opts ...gorums.CallOption) {
+		sendCfg.Timeout(ctx, timeoutMsg, opts...)
+	})
+	cfg.metrics.sent(string(TimeoutMessage), sendCfg.Size())
 }
 
-// Fetch requests a block from all the replicas in the configuration
+// Broadcast sends an application message to all replicas in the configuration.
+// The message is delivered to the handler set by Server.SetBroadcastHandler on the receiving replicas,
+// and is not processed by the consensus modules.
+func (cfg *subConfig) Broadcast(msg []byte) {
+	sendCfg := cfg.sendConfig()
+	if sendCfg == nil {
+		return
+	}
+	appMsg := &hotstuffpb.AppMessage{Payload: msg}
+	cfg.sendWaiting.send(BroadcastMessage, appMsg, sendCfg.Nodes(), func(opts ...gorums.CallOption) {
+		sendCfg.Broadcast(context.Background(), appMsg, opts...)
+	})
+	cfg.metrics.sent(string(BroadcastMessage), sendCfg.Size())
+}
+
+// BroadcastMessage sends an application message to all replicas in the configuration.
+// The message is wrapped in an Any message, and is delivered to the event loops of the receiving replicas
+// as a consensus.AppMsg. It is not processed by the consensus modules.
+func (cfg *subConfig) BroadcastMessage(msg proto.Message) {
+	sendCfg := cfg.sendConfig()
+	if sendCfg == nil {
+		return
+	}
+	envelope, err := anypb.New(msg)
+	if err != nil {
+		cfg.mods.Logger().Errorf("Failed to encode application message: %v", err)
+		return
+	}
+	appMsg := &hotstuffpb.AppMessage{Message: envelope}
+	cfg.sendWaiting.send(BroadcastMessage, appMsg, sendCfg.Nodes(), func(opts ...gorums.CallOption) {
+		sendCfg.Broadcast(context.Background(), appMsg, opts...)
+	})
+	cfg.metrics.sent(string(BroadcastMessage), sendCfg.Size())
+}
+
+// sendConfig returns the configuration used to send messages to the replicas.
+// If the failure detector excludes suspected replicas, this is the active configuration.
+func (cfg *subConfig) sendConfig() *hotstuffpb.Configuration {
+	if active, ok := cfg.detector.activeConfig(); ok {
+		return active
+	}
+	return cfg.cfg
+}
+
+// Fetch requests a block from all the replicas in the configuration.
+// Concurrent requests for the same block are combined into a single request,
+// and requests for a block that recently failed to be fetched fail immediately.
+// Failed requests are retried if enabled by WithFetchRetry.
 func (cfg *subConfig) Fetch(ctx context.Context, hash consensus.Hash) (*consensus.Block, bool) {
+	return cfg.fetches.fetch(ctx, hash, func(ctx context.Context, hash consensus.Hash) (*consensus.Block, error) {
+		return cfg.retry.do(ctx, hash, cfg.fetch)
+	})
+}
+
+func (cfg *subConfig) fetch(ctx context.Context, hash consensus.Hash) (*consensus.Block, error) {
+	start := time.Now()
 	protoBlock, err := cfg.cfg.Fetch(ctx, &hotstuffpb.BlockHash{Hash: hash[:]})
+	cfg.metrics.sent("fetch", cfg.cfg.Size())
+	cfg.metrics.fetched(start)
+	cfg.detector.observeCall(err)
 	if err != nil {
-		qcErr, ok := err.(gorums.QuorumCallError)
 		// filter out context errors
-		if !ok || (qcErr.Reason != context.Canceled.Error() && qcErr.Reason != context.DeadlineExceeded.Error()) {
+		if !isContextError(err) {
+			cfg.metrics.sendError("fetch")
 			cfg.mods.Logger().Infof("Failed to fetch block: %v", err)
 		}
-		return nil, false
+		return nil, err
 	}
-	return hotstuffpb.BlockFromProto(protoBlock), true
+	return hotstuffpb.BlockFromProto(protoBlock), nil
+}
+
+// isContextError returns true if the error was caused by a canceled context or an exceeded deadline.
+func isContextError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	qcErr, ok := err.(gorums.QuorumCallError)
+	return ok && (qcErr.Reason == context.Canceled.Error() || qcErr.Reason == context.DeadlineExceeded.Error())
 }
 
 // Close closes all connections made by this configuration.
+// If the configuration uses a shared manager, the connections are left open,
+// and must instead be closed by calling Close on the manager.
 func (cfg *Config) Close() {
+	cfg.detector.stop()
+	if cfg.shared != nil {
+		return
+	}
 	cfg.mgr.Close()
 }
 
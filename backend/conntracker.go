@@ -0,0 +1,81 @@
+package backend
+
+import (
+	"context"
+	"net"
+	"sync"
+)
+
+// connTracker keeps track of the open connections to each address,
+// such that the configuration knows which replicas it is connected to without the failure detector.
+// It dials the connections on behalf of gRPC, and a connection is counted until gRPC closes it.
+type connTracker struct {
+	mut   sync.Mutex
+	conns map[string]int
+	// functions that are called when a connection is opened or closed.
+	observers []func()
+}
+
+func newConnTracker() *connTracker {
+	return &connTracker{conns: make(map[string]int)}
+}
+
+// dial opens a TCP connection to addr and tracks it until it is closed.
+func (t *connTracker) dial(ctx context.Context, addr string) (net.Conn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	t.mut.Lock()
+	t.conns[addr]++
+	t.mut.Unlock()
+	t.notify()
+	return &trackedConn{Conn: conn, addr: addr, tracker: t}, nil
+}
+
+// observe makes the tracker call fn whenever a connection is opened or closed.
+func (t *connTracker) observe(fn func()) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	t.observers = append(t.observers, fn)
+}
+
+func (t *connTracker) notify() {
+	t.mut.Lock()
+	observers := t.observers
+	t.mut.Unlock()
+	for _, fn := range observers {
+		fn()
+	}
+}
+
+// connected returns true if there is an open connection to addr.
+func (t *connTracker) connected(addr string) bool {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	return t.conns[addr] > 0
+}
+
+func (t *connTracker) closed(addr string) {
+	t.mut.Lock()
+	t.conns[addr]--
+	if t.conns[addr] <= 0 {
+		delete(t.conns, addr)
+	}
+	t.mut.Unlock()
+	t.notify()
+}
+
+// trackedConn is a connection that is removed from its tracker when it is closed.
+type trackedConn struct {
+	net.Conn
+	addr    string
+	tracker *connTracker
+	once    sync.Once
+}
+
+func (c *trackedConn) Close() error {
+	c.once.Do(func() { c.tracker.closed(c.addr) })
+	return c.Conn.Close()
+}
@@ -0,0 +1,89 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// ErrNoCredentials is returned by handshakes when the credentials provider does not return any credentials.
+var ErrNoCredentials = errors.New("credentials provider returned no credentials")
+
+// CredentialsProvider returns the transport credentials that should currently be used.
+// It is called each time a connection to a replica is established,
+// such that rotated certificates are picked up by new connections and reconnects.
+// If it returns nil, the handshake fails with ErrNoCredentials; use insecure.NewCredentials
+// to explicitly allow insecure connections.
+type CredentialsProvider func() credentials.TransportCredentials
+
+// NewConfigWithCredentialsProvider creates a new configuration that gets its transport credentials from the provider
+// each time it connects or reconnects to a replica, instead of using the same credentials for the lifetime of the configuration.
+func NewConfigWithCredentialsProvider(provider CredentialsProvider, opts ...ConfigOption) *Config {
+	return NewConfig(&providedCredentials{provider: provider}, opts...)
+}
+
+// providedCredentials implements credentials.TransportCredentials by delegating each handshake
+// to the credentials returned by the provider at the time of the handshake.
+type providedCredentials struct {
+	provider CredentialsProvider
+
+	mut sync.Mutex
+	// the credentials used by the most recent handshake.
+	last credentials.TransportCredentials
+}
+
+// current calls the provider and remembers the credentials that it returned.
+func (c *providedCredentials) current() (credentials.TransportCredentials, error) {
+	creds := c.provider()
+	if creds == nil {
+		return nil, ErrNoCredentials
+	}
+	c.mut.Lock()
+	c.last = creds
+	c.mut.Unlock()
+	return creds, nil
+}
+
+// ClientHandshake does the authentication handshake for a client connection using the current credentials.
+func (c *providedCredentials) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	creds, err := c.current()
+	if err != nil {
+		return nil, nil, err
+	}
+	return creds.ClientHandshake(ctx, authority, conn)
+}
+
+// ServerHandshake does the authentication handshake for a server connection using the current credentials.
+func (c *providedCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	creds, err := c.current()
+	if err != nil {
+		return nil, nil, err
+	}
+	return creds.ServerHandshake(conn)
+}
+
+// Info returns the protocol info of the credentials used by the most recent handshake.
+// The provider is not called, such that gRPC's use of Info does not count as a dial.
+func (c *providedCredentials) Info() credentials.ProtocolInfo {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if c.last == nil {
+		return credentials.ProtocolInfo{}
+	}
+	return c.last.Info()
+}
+
+// Clone returns a copy of the credentials that uses the same provider.
+func (c *providedCredentials) Clone() credentials.TransportCredentials {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return &providedCredentials{provider: c.provider, last: c.last}
+}
+
+// OverrideServerName is not supported, as the server name is determined by the credentials returned by the provider.
+func (c *providedCredentials) OverrideServerName(string) error {
+	return errors.New("credentials provider: overriding the server name is not supported")
+}
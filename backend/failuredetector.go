@@ -0,0 +1,308 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/relab/gorums"
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/internal/proto/hotstuffpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultProbeInterval      = time.Second
+	defaultSuspicionThreshold = 3
+)
+
+// FailureDetectorOptions configures the failure detector of a configuration.
+type FailureDetectorOptions struct {
+	// Interval is the time between keepalive probes. If zero, the replicas are probed every second.
+	Interval time.Duration
+	// Threshold is the number of consecutive failed probes or calls after which a replica is suspected.
+	// If zero, a replica is suspected after 3 failures.
+	Threshold int
+	// ExcludeSuspected removes suspected replicas from the active configuration,
+	// which is used to send proposals, timeouts, and application messages.
+	// The connections to suspected replicas are kept open, such that they can be
+	// included again once they respond to a probe.
+	ExcludeSuspected bool
+}
+
+// failureDetector suspects replicas that repeatedly fail to respond.
+// All methods are safe to call on a nil *failureDetector, in which case they do nothing.
+type failureDetector struct {
+	mut       sync.Mutex
+	opts      FailureDetectorOptions
+	mgr       *hotstuffpb.Manager
+	nodeIDs   []uint32
+	failures  map[hotstuff.ID]int
+	suspected map[hotstuff.ID]bool
+	active    *hotstuffpb.Configuration
+	cancel    context.CancelFunc
+	onChange  func(id hotstuff.ID, suspected bool)
+}
+
+func newFailureDetector(opts FailureDetectorOptions) *failureDetector {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultProbeInterval
+	}
+	if opts.Threshold <= 0 {
+		opts.Threshold = defaultSuspicionThreshold
+	}
+	return &failureDetector{
+		opts:      opts,
+		failures:  make(map[hotstuff.ID]int),
+		suspected: make(map[hotstuff.ID]bool),
+	}
+}
+
+// start begins probing the nodes of the configuration in the background.
+func (fd *failureDetector) start(mgr *hotstuffpb.Manager, cfg *hotstuffpb.Configuration, onChange func(hotstuff.ID, bool)) {
+	if fd == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	fd.mut.Lock()
+	fd.mgr = mgr
+	fd.nodeIDs = cfg.NodeIDs()
+	fd.cancel = cancel
+	fd.onChange = onChange
+	fd.mut.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(fd.opts.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			fd.probe(ctx, cfg)
+		}
+	}()
+}
+
+// stop stops the probes.
+func (fd *failureDetector) stop() {
+	if fd == nil {
+		return
+	}
+	fd.mut.Lock()
+	defer fd.mut.Unlock()
+	if fd.cancel != nil {
+		fd.cancel()
+	}
+}
+
+// probe sends a keepalive to all nodes of the configuration.
+// The keepalive is a request for a block that does not exist,
+// so any node that answers with NotFound is alive, and any node that does not answer in time is a failure.
+func (fd *failureDetector) probe(ctx context.Context, cfg *hotstuffpb.Configuration) {
+	probeCtx, cancel := context.WithTimeout(ctx, fd.opts.Interval)
+	defer cancel()
+	_, err := cfg.Fetch(probeCtx, &hotstuffpb.BlockHash{Hash: make([]byte, 32)})
+	if ctx.Err() != nil {
+		// the failure detector was stopped.
+		return
+	}
+	alive, _ := nodeResults(err)
+	var failed []hotstuff.ID
+	for _, id := range cfg.NodeIDs() {
+		if !containsID(alive, hotstuff.ID(id)) {
+			failed = append(failed, hotstuff.ID(id))
+		}
+	}
+	fd.observe(alive, failed)
+}
+
+// observeCall records the outcome of a quorum call made by the configuration.
+func (fd *failureDetector) observeCall(err error) {
+	if fd == nil {
+		return
+	}
+	fd.observe(nodeResults(err))
+}
+
+// observe resets the failure count of the replicas that responded, and counts a failure for the replicas that failed.
+func (fd *failureDetector) observe(alive, failed []hotstuff.ID) {
+	fd.mut.Lock()
+	defer fd.mut.Unlock()
+
+	var changed []hotstuff.ID
+	for _, id := range alive {
+		fd.failures[id] = 0
+		if fd.suspected[id] {
+			delete(fd.suspected, id)
+			changed = append(changed, id)
+		}
+	}
+	for _, id := range failed {
+		fd.failures[id]++
+		if fd.failures[id] >= fd.opts.Threshold && !fd.suspected[id] {
+			fd.suspected[id] = true
+			changed = append(changed, id)
+		}
+	}
+	if len(changed) == 0 {
+		return
+	}
+	fd.updateActive()
+	if fd.onChange != nil {
+		for _, id := range changed {
+			fd.onChange(id, fd.suspected[id])
+		}
+	}
+}
+
+// updateActive creates the active configuration from the nodes that are not suspected.
+// It must be called while holding the lock.
+func (fd *failureDetector) updateActive() {
+	fd.active = nil
+	if !fd.opts.ExcludeSuspected || fd.mgr == nil {
+		return
+	}
+	var ids []uint32
+	for _, id := range fd.nodeIDs {
+		if !fd.suspected[hotstuff.ID(id)] {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+	active, err := fd.mgr.NewConfiguration(qspec{}, gorums.WithNodeIDs(ids))
+	if err != nil {
+		return
+	}
+	fd.active = active
+}
+
+// activeConfig returns the configuration that should be used for sending messages.
+// If ok is false, no replicas are excluded and the full configuration should be used.
+// The active configuration is nil if all replicas are suspected.
+func (fd *failureDetector) activeConfig() (active *hotstuffpb.Configuration, ok bool) {
+	if fd == nil {
+		return nil, false
+	}
+	fd.mut.Lock()
+	defer fd.mut.Unlock()
+	if !fd.opts.ExcludeSuspected || len(fd.suspected) == 0 {
+		return nil, false
+	}
+	return fd.active, true
+}
+
+// isSuspected returns true if the replica is suspected.
+func (fd *failureDetector) isSuspected(id hotstuff.ID) bool {
+	if fd == nil {
+		return false
+	}
+	fd.mut.Lock()
+	defer fd.mut.Unlock()
+	return fd.suspected[id]
+}
+
+// nodeResults returns the replicas that responded to a fetch request, and the replicas that failed.
+// A replica that responded that it does not have the block is alive; any other error is a failure.
+func nodeResults(err error) (alive, failed []hotstuff.ID) {
+	qcErr, ok := err.(gorums.QuorumCallError)
+	if !ok {
+		return nil, nil
+	}
+	for _, nodeErr := range qcErr.Errors {
+		if isContextError(nodeErr.Cause) {
+			// the call was canceled before the replica could respond.
+			continue
+		}
+		if status.Code(nodeErr.Cause) == codes.NotFound {
+			alive = append(alive, hotstuff.ID(nodeErr.NodeID))
+		} else {
+			failed = append(failed, hotstuff.ID(nodeErr.NodeID))
+		}
+	}
+	return alive, failed
+}
+
+func containsID(ids []hotstuff.ID, id hotstuff.ID) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// WithFailureDetector returns a configuration option that enables a failure detector.
+// The failure detector probes the replicas periodically, and suspects a replica
+// after it has failed to respond to a number of consecutive probes or calls.
+func WithFailureDetector(opts FailureDetectorOptions) ConfigOption {
+	return func(cfg *Config) error {
+		if opts.Interval < 0 {
+			return fmt.Errorf("negative failure detector interval: %v", opts.Interval)
+		}
+		if opts.Threshold < 0 {
+			return fmt.Errorf("negative failure detector threshold: %d", opts.Threshold)
+		}
+		cfg.detector = newFailureDetector(opts)
+		return nil
+	}
+}
+
+// Suspected returns the IDs of the replicas that are currently suspected by the failure detector,
+// in ascending order. If the failure detector is not enabled, no replicas are suspected.
+func (cfg *Config) Suspected() []hotstuff.ID {
+	var ids []hotstuff.ID
+	for id := range cfg.replicas {
+		if cfg.detector.isSuspected(id) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// ActiveReplicas returns the IDs of the replicas that are not suspected by the failure detector,
+// including the local replica, in ascending order.
+func (cfg *Config) ActiveReplicas() []hotstuff.ID {
+	var ids []hotstuff.ID
+	for id := range cfg.replicas {
+		if !cfg.detector.isSuspected(id) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// ConnectedReplicas returns the IDs of the replicas that the configuration has an open connection to,
+// including the local replica, in ascending order. If the failure detector is enabled,
+// suspected replicas are not considered connected until they respond to a probe again.
+// No replicas are connected before Connect is called.
+func (cfg *Config) ConnectedReplicas() []hotstuff.ID {
+	if !cfg.connected {
+		return nil
+	}
+	var ids []hotstuff.ID
+	for id, replica := range cfg.replicas {
+		if id != cfg.mods.ID() && !cfg.isConnected(replica.(*Replica)) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// isConnected returns true if there is an open connection to the replica that is not suspected.
+func (cfg *Config) isConnected(replica *Replica) bool {
+	if replica.node == nil || !cfg.conns.connected(replica.node.Address()) {
+		return false
+	}
+	return !cfg.detector.isSuspected(replica.id)
+}
@@ -0,0 +1,178 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/relab/hotstuff/consensus"
+)
+
+// fetchFailureTTL is how long a failed fetch is remembered.
+// Fetch requests for the same block during this time fail without contacting the network.
+const fetchFailureTTL = 500 * time.Millisecond
+
+// maxFetchBackoff is the largest backoff between retries of a fetch request.
+const maxFetchBackoff = 10 * time.Second
+
+// fetchGroup deduplicates concurrent fetch requests for the same block,
+// and remembers recently failed requests.
+type fetchGroup struct {
+	mut      sync.Mutex
+	inflight map[consensus.Hash]*fetchCall
+	failed   map[consensus.Hash]time.Time
+	ttl      time.Duration
+}
+
+type fetchCall struct {
+	done chan struct{}
+	// cancels the call when all callers waiting for it have given up.
+	cancel  context.CancelFunc
+	waiters int
+	block   *consensus.Block
+	ok      bool
+}
+
+func newFetchGroup() *fetchGroup {
+	return &fetchGroup{
+		inflight: make(map[consensus.Hash]*fetchCall),
+		failed:   make(map[consensus.Hash]time.Time),
+		ttl:      fetchFailureTTL,
+	}
+}
+
+// fetch calls fn to fetch the block with the given hash, unless a call for the same hash is already in flight,
+// in which case it waits for that call to complete instead, or the hash was recently failed to be fetched.
+// fn returns an error if the block could not be fetched. Failures caused by context cancellation are not remembered.
+// The call is detached from the context of the caller that started it, such that each caller can give up
+// when its own context is done without failing the call for the others. The call is canceled when all callers
+// have given up.
+func (g *fetchGroup) fetch(
+	ctx context.Context,
+	hash consensus.Hash,
+	fn func(context.Context, consensus.Hash) (*consensus.Block, error),
+) (*consensus.Block, bool) {
+	g.mut.Lock()
+	if failedAt, ok := g.failed[hash]; ok {
+		if time.Since(failedAt) < g.ttl {
+			g.mut.Unlock()
+			return nil, false
+		}
+		delete(g.failed, hash)
+	}
+	call, ok := g.inflight[hash]
+	if !ok {
+		var callCtx context.Context
+		call = &fetchCall{done: make(chan struct{})}
+		callCtx, call.cancel = context.WithCancel(context.Background())
+		g.inflight[hash] = call
+		go g.run(callCtx, call, hash, fn)
+	}
+	call.waiters++
+	g.mut.Unlock()
+
+	select {
+	case <-call.done:
+		return call.block, call.ok
+	case <-ctx.Done():
+		g.mut.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			// nobody waits for the call anymore, so new callers must start a new call.
+			call.cancel()
+			if g.inflight[hash] == call {
+				delete(g.inflight, hash)
+			}
+		}
+		g.mut.Unlock()
+		return nil, false
+	}
+}
+
+// run calls fn and completes the call.
+func (g *fetchGroup) run(
+	ctx context.Context,
+	call *fetchCall,
+	hash consensus.Hash,
+	fn func(context.Context, consensus.Hash) (*consensus.Block, error),
+) {
+	block, err := fn(ctx, hash)
+	call.block, call.ok = block, err == nil
+
+	g.mut.Lock()
+	if g.inflight[hash] == call {
+		delete(g.inflight, hash)
+	}
+	if err != nil && !isContextError(err) {
+		g.failed[hash] = time.Now()
+	}
+	g.mut.Unlock()
+
+	call.cancel()
+	close(call.done)
+}
+
+// fetchRetry retries failed fetch requests with exponential backoff and jitter.
+type fetchRetry struct {
+	retries int
+	backoff time.Duration
+}
+
+// do calls fn until it succeeds, it has been retried the configured number of times, or the context is done.
+// Before the i-th retry, it waits for a random duration between half of and the full backoff*2^(i-1),
+// where the doubled backoff is capped at maxFetchBackoff.
+// Failures caused by context cancellation are not retried.
+func (r fetchRetry) do(
+	ctx context.Context,
+	hash consensus.Hash,
+	fn func(context.Context, consensus.Hash) (*consensus.Block, error),
+) (*consensus.Block, error) {
+	backoff := r.backoff
+	for attempt := 0; ; attempt++ {
+		block, err := fn(ctx, hash)
+		if err == nil || attempt >= r.retries || isContextError(err) {
+			return block, err
+		}
+		wait := backoff / 2
+		if backoff > 1 {
+			wait += time.Duration(rand.Int63n(int64(backoff - wait)))
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+		backoff = nextBackoff(backoff)
+	}
+}
+
+// nextBackoff returns the doubled backoff, capped at maxFetchBackoff.
+func nextBackoff(backoff time.Duration) time.Duration {
+	if backoff >= maxFetchBackoff/2 {
+		return maxFetchBackoff
+	}
+	return backoff * 2
+}
+
+// WithFetchRetry returns a configuration option that retries failed fetch requests up to the given number of times.
+// The first retry waits for a random duration between backoff/2 and backoff, and the backoff is doubled after each
+// retry, up to 10 seconds. Retries stop when the context passed to Fetch is done.
+func WithFetchRetry(retries int, backoff time.Duration) ConfigOption {
+	return func(cfg *Config) error {
+		if retries < 0 {
+			return fmt.Errorf("negative number of fetch retries: %d", retries)
+		}
+		if backoff < 0 {
+			return fmt.Errorf("negative fetch backoff: %v", backoff)
+		}
+		if backoff > maxFetchBackoff {
+			backoff = maxFetchBackoff
+		}
+		cfg.retry = fetchRetry{retries: retries, backoff: backoff}
+		return nil
+	}
+}
@@ -0,0 +1,105 @@
+package backend
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "hotstuff_backend"
+
+// backendMetrics contains the Prometheus collectors that instrument a configuration.
+// All methods are safe to call on a nil *backendMetrics, in which case they do nothing.
+type backendMetrics struct {
+	messagesSent      *prometheus.CounterVec
+	sendErrors        *prometheus.CounterVec
+	connectedReplicas prometheus.Gauge
+	fetchLatency      prometheus.Histogram
+}
+
+func newBackendMetrics(reg prometheus.Registerer) (*backendMetrics, error) {
+	m := &backendMetrics{
+		messagesSent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "messages_sent_total",
+			Help:      "Number of messages sent to other replicas, by message type.",
+		}, []string{"type"}),
+		sendErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "send_errors_total",
+			Help:      "Number of failed calls to other replicas, by message type.",
+		}, []string{"type"}),
+		connectedReplicas: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "connected_replicas",
+			Help:      "Number of replicas that the configuration is connected to.",
+		}),
+		fetchLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "fetch_latency_seconds",
+			Help:      "Latency of block fetch requests.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+	}
+	for _, c := range []prometheus.Collector{m.messagesSent, m.sendErrors, m.connectedReplicas, m.fetchLatency} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (m *backendMetrics) sent(msgType string, count int) {
+	if m == nil {
+		return
+	}
+	m.messagesSent.WithLabelValues(msgType).Add(float64(count))
+}
+
+func (m *backendMetrics) sendError(msgType string) {
+	if m == nil {
+		return
+	}
+	m.sendErrors.WithLabelValues(msgType).Inc()
+}
+
+func (m *backendMetrics) connected(count int) {
+	if m == nil {
+		return
+	}
+	m.connectedReplicas.Set(float64(count))
+}
+
+func (m *backendMetrics) fetched(start time.Time) {
+	if m == nil {
+		return
+	}
+	m.fetchLatency.Observe(time.Since(start).Seconds())
+}
+
+// serverMetrics contains the Prometheus collectors that instrument a server.
+// All methods are safe to call on a nil *serverMetrics, in which case they do nothing.
+type serverMetrics struct {
+	replaysRejected *prometheus.CounterVec
+}
+
+func newServerMetrics(reg prometheus.Registerer) (*serverMetrics, error) {
+	m := &serverMetrics{
+		replaysRejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "replays_rejected_total",
+			Help:      "Number of received messages rejected for referring to an old view, by message type.",
+		}, []string{"type"}),
+	}
+	if err := reg.Register(m.replaysRejected); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *serverMetrics) replayRejected(msgType string) {
+	if m == nil {
+		return
+	}
+	m.replaysRejected.WithLabelValues(msgType).Inc()
+}
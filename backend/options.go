@@ -0,0 +1,60 @@
+package backend
+
+import (
+	"errors"
+
+	"github.com/relab/gorums"
+	"google.golang.org/grpc"
+)
+
+// ConfigOption configures a configuration when it is created.
+// An invalid option makes Connect return an error.
+type ConfigOption func(*Config) error
+
+// WithManagerOptions returns a configuration option that uses the manager options to tune the connections
+// to the replicas, e.g. using WithMaxMessageSize and WithSendBuffer. A configuration that uses a shared manager
+// cannot have its own manager options; they must be given to NewManager instead.
+func WithManagerOptions(opts ...gorums.ManagerOption) ConfigOption {
+	return func(cfg *Config) error {
+		if cfg.shared != nil {
+			return errors.New("manager options must be given to the shared manager")
+		}
+		cfg.opts = append(cfg.opts, opts...)
+		return nil
+	}
+}
+
+// WithMaxMessageSize returns a manager option that sets the maximum size, in bytes,
+// of messages that can be sent to and received from other replicas.
+func WithMaxMessageSize(size int) gorums.ManagerOption {
+	return gorums.WithGrpcDialOptions(grpc.WithDefaultCallOptions(
+		grpc.MaxCallRecvMsgSize(size),
+		grpc.MaxCallSendMsgSize(size),
+	))
+}
+
+// WithSendBuffer returns a manager option that sets the number of messages
+// that can be buffered for each replica before sending blocks.
+func WithSendBuffer(size uint) gorums.ManagerOption {
+	return gorums.WithSendBufferSize(size)
+}
+
+// WithReadBufferSize returns a manager option that sets the size, in bytes,
+// of the buffer used to read from each connection.
+func WithReadBufferSize(size int) gorums.ManagerOption {
+	return gorums.WithGrpcDialOptions(grpc.WithReadBufferSize(size))
+}
+
+// WithWriteBufferSize returns a manager option that sets the size, in bytes,
+// of the buffer used to write to each connection.
+func WithWriteBufferSize(size int) gorums.ManagerOption {
+	return gorums.WithGrpcDialOptions(grpc.WithWriteBufferSize(size))
+}
+
+// WithInterceptors returns a manager option that adds interceptors to the calls made to other replicas.
+func WithInterceptors(unary []grpc.UnaryClientInterceptor, stream []grpc.StreamClientInterceptor) gorums.ManagerOption {
+	return gorums.WithGrpcDialOptions(
+		grpc.WithChainUnaryInterceptor(unary...),
+		grpc.WithChainStreamInterceptor(stream...),
+	)
+}
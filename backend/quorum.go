@@ -0,0 +1,142 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+)
+
+var (
+	// ErrReconfigurationInProgress is returned when a reconfiguration is started while another is in progress.
+	ErrReconfigurationInProgress = errors.New("reconfiguration already in progress")
+	// ErrNoReconfiguration is returned when completing a reconfiguration that was never started.
+	ErrNoReconfiguration = errors.New("no reconfiguration in progress")
+)
+
+// quorumState holds the members that are counted when deciding whether a set of replicas is a quorum.
+// During a reconfiguration, both the old and the new members must form a quorum,
+// similar to joint consensus in Raft.
+type quorumState struct {
+	// members contains the IDs of the current members. If nil, all replicas are members.
+	members consensus.IDSet
+	// joint contains the IDs of the new members during a reconfiguration, and is nil otherwise.
+	joint consensus.IDSet
+}
+
+// BeginReconfiguration starts a transition to a configuration with the given members.
+// Until CompleteReconfiguration is called, a quorum must contain a quorum of both
+// the current members and the new members. The new members must be replicas in the configuration.
+func (cfg *Config) BeginReconfiguration(members []hotstuff.ID) error {
+	if len(members) == 0 {
+		return errors.New("no members in new configuration")
+	}
+	joint := consensus.NewIDSet()
+	for _, id := range members {
+		if _, ok := cfg.replicas[id]; !ok {
+			return fmt.Errorf("replica %d is not in the configuration", id)
+		}
+		joint.Add(id)
+	}
+
+	cfg.quorumMut.Lock()
+	defer cfg.quorumMut.Unlock()
+	if cfg.quorum.joint != nil {
+		return ErrReconfigurationInProgress
+	}
+	cfg.quorum.joint = joint
+	return nil
+}
+
+// CompleteReconfiguration ends the transition started by BeginReconfiguration,
+// such that only the new members are counted in quorums.
+func (cfg *Config) CompleteReconfiguration() error {
+	cfg.quorumMut.Lock()
+	defer cfg.quorumMut.Unlock()
+	if cfg.quorum.joint == nil {
+		return ErrNoReconfiguration
+	}
+	cfg.quorum.members = cfg.quorum.joint
+	cfg.quorum.joint = nil
+	return nil
+}
+
+// Reconfiguring returns true if a reconfiguration is in progress.
+func (cfg *Config) Reconfiguring() bool {
+	cfg.quorumMut.Lock()
+	defer cfg.quorumMut.Unlock()
+	return cfg.quorum.joint != nil
+}
+
+// QuorumSize returns the size of a quorum.
+// During a reconfiguration, this is the larger of the quorum sizes of the old and the new members,
+// but a set of replicas of this size is not necessarily a quorum; use IsQuorum to check.
+func (cfg *Config) QuorumSize() int {
+	cfg.quorumMut.Lock()
+	defer cfg.quorumMut.Unlock()
+	size := hotstuff.QuorumSize(cfg.numMembers())
+	if cfg.quorum.joint != nil {
+		if jointSize := hotstuff.QuorumSize(cfg.quorum.joint.Len()); jointSize > size {
+			size = jointSize
+		}
+	}
+	return size
+}
+
+// IsQuorum returns true if the participants contain a quorum of the members.
+// During a reconfiguration, the participants must contain a quorum of both the old and the new members.
+func (cfg *Config) IsQuorum(participants consensus.IDSet) bool {
+	cfg.quorumMut.Lock()
+	defer cfg.quorumMut.Unlock()
+	if cfg.quorum.joint != nil && !hasQuorum(cfg.quorum.joint, participants) {
+		return false
+	}
+	if cfg.quorum.members == nil {
+		count := 0
+		participants.ForEach(func(id hotstuff.ID) {
+			if _, ok := cfg.replicas[id]; ok {
+				count++
+			}
+		})
+		return count >= hotstuff.QuorumSize(len(cfg.replicas))
+	}
+	return hasQuorum(cfg.quorum.members, participants)
+}
+
+// QuorumReachable returns true if the connected replicas form a quorum, such that the configuration can make progress.
+// It returns false when the configuration has lost connectivity to a quorum, or before Connect is called.
+// During a reconfiguration, the connected replicas must form a quorum of both the old and the new members.
+func (cfg *Config) QuorumReachable() bool {
+	connected := cfg.ConnectedReplicas()
+	if len(connected) == 0 || len(connected) < cfg.QuorumSize() {
+		return false
+	}
+	participants := consensus.NewIDSet()
+	for _, id := range connected {
+		participants.Add(id)
+	}
+	return cfg.IsQuorum(participants)
+}
+
+// numMembers returns the number of current members.
+// It must be called while holding the lock.
+func (cfg *Config) numMembers() int {
+	if cfg.quorum.members == nil {
+		return len(cfg.replicas)
+	}
+	return cfg.quorum.members.Len()
+}
+
+// hasQuorum returns true if the participants contain a quorum of the members.
+func hasQuorum(members, participants consensus.IDSet) bool {
+	count := 0
+	participants.ForEach(func(id hotstuff.ID) {
+		if members.Contains(id) {
+			count++
+		}
+	})
+	return count >= hotstuff.QuorumSize(members.Len())
+}
+
+var _ consensus.QuorumChecker = (*Config)(nil)
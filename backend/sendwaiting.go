@@ -0,0 +1,152 @@
+package backend
+
+import (
+	"context"
+	"sync"
+
+	"github.com/relab/gorums"
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/internal/proto/hotstuffpb"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// MessageType identifies a type of message that the configuration sends to the replicas.
+type MessageType string
+
+// The types of messages that the configuration sends without waiting, unless enabled by WithSendWaiting.
+const (
+	ProposeMessage   MessageType = "propose"
+	VoteMessage      MessageType = "vote"
+	NewViewMessage   MessageType = "newview"
+	TimeoutMessage   MessageType = "timeout"
+	BroadcastMessage MessageType = "broadcast"
+)
+
+// SendFailedEvent is added to the event loop when a message whose send waits until it has been sent
+// could not be written to the connection of a replica.
+type SendFailedEvent struct {
+	Type    MessageType
+	Replica hotstuff.ID
+}
+
+// WithSendWaiting returns a configuration option that waits until messages of the given types have been written
+// to the connections of the receiving replicas, instead of returning as soon as the messages have been queued.
+// This slows down the caller, which is usually the event loop, but ensures that a message has left the replica
+// before the next step of the protocol, which helps when diagnosing messages that never arrive.
+// Each replica that a message could not be written to is reported by a SendFailedEvent,
+// and counted as a send error by the metrics.
+func WithSendWaiting(types ...MessageType) ConfigOption {
+	return func(cfg *Config) error {
+		cfg.sendWaiting.types = make(map[MessageType]struct{}, len(types))
+		for _, t := range types {
+			cfg.sendWaiting.types[t] = struct{}{}
+		}
+		return nil
+	}
+}
+
+// sendWaiting contains the message types whose sends wait until the message has been sent,
+// and reports the replicas that such messages could not be sent to.
+type sendWaiting struct {
+	types  map[MessageType]struct{}
+	sends  *sendTracker
+	failed func(msgType MessageType, id hotstuff.ID)
+}
+
+// waits returns true if sends of the given message type wait until the message has been sent.
+func (w sendWaiting) waits(msgType MessageType) bool {
+	_, ok := w.types[msgType]
+	return ok
+}
+
+// callOptions returns the call options for sending a message of the given type.
+func (w sendWaiting) callOptions(msgType MessageType) []gorums.CallOption {
+	if w.waits(msgType) {
+		return nil
+	}
+	return []gorums.CallOption{gorums.WithNoSendWaiting()}
+}
+
+// send calls fn with the call options for the message type to send msg to the nodes.
+// If the send waits until the message has been sent, the nodes that the message was not written to are reported.
+func (w sendWaiting) send(msgType MessageType, msg proto.Message, nodes []*hotstuffpb.Node, fn func(opts ...gorums.CallOption)) {
+	if !w.waits(msgType) || w.sends == nil {
+		fn(w.callOptions(msgType)...)
+		return
+	}
+	w.sends.track(msg)
+	fn()
+	sent := w.sends.finish(msg)
+	for _, node := range nodes {
+		if _, ok := sent[node.Address()]; !ok && w.failed != nil {
+			w.failed(msgType, hotstuff.ID(node.ID()))
+		}
+	}
+}
+
+// sendTracker records the addresses that tracked messages have been written to.
+// Gorums does not report errors for one-way messages, so it observes the messages written to the streams instead.
+type sendTracker struct {
+	mut     sync.Mutex
+	pending map[proto.Message]map[string]struct{}
+}
+
+func newSendTracker() *sendTracker {
+	return &sendTracker{pending: make(map[proto.Message]map[string]struct{})}
+}
+
+// track starts recording the addresses that msg is written to.
+func (t *sendTracker) track(msg proto.Message) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	t.pending[msg] = make(map[string]struct{})
+}
+
+// finish stops recording msg and returns the addresses that it was written to.
+func (t *sendTracker) finish(msg proto.Message) map[string]struct{} {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	sent := t.pending[msg]
+	delete(t.pending, msg)
+	return sent
+}
+
+func (t *sendTracker) sent(msg proto.Message, addr string) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	if sent, ok := t.pending[msg]; ok {
+		sent[addr] = struct{}{}
+	}
+}
+
+// intercept is a stream interceptor that records the tracked messages that are written to the stream.
+func (t *sendTracker) intercept(
+	ctx context.Context,
+	desc *grpc.StreamDesc,
+	cc *grpc.ClientConn,
+	method string,
+	streamer grpc.Streamer,
+	opts ...grpc.CallOption,
+) (grpc.ClientStream, error) {
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &trackedStream{ClientStream: stream, addr: cc.Target(), tracker: t}, nil
+}
+
+// trackedStream is a stream that records the tracked messages that are written to it.
+type trackedStream struct {
+	grpc.ClientStream
+	addr    string
+	tracker *sendTracker
+}
+
+func (s *trackedStream) SendMsg(m any) error {
+	err := s.ClientStream.SendMsg(m)
+	if msg, ok := m.(*gorums.Message); ok && err == nil {
+		s.tracker.sent(msg.Message, s.addr)
+	}
+	return err
+}
@@ -5,11 +5,15 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"sync"
+	"sync/atomic"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/relab/gorums"
 	"github.com/relab/hotstuff"
 	"github.com/relab/hotstuff/consensus"
 	"github.com/relab/hotstuff/internal/proto/hotstuffpb"
+	"github.com/relab/hotstuff/synchronizer"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
@@ -22,28 +26,134 @@ import (
 type Server struct {
 	mods      *consensus.Modules
 	gorumsSrv *gorums.Server
+	// the options for the gorums server.
+	gorumsOpts []gorums.ServerOption
+	metrics    *serverMetrics
+
+	// messages that refer to a view more than replayWindow views below currentView are rejected.
+	replayWindow consensus.View
+	// the current view of the local replica, accessed atomically,
+	// since the handlers do not run on the event loop.
+	currentView uint64
+	// the maximum number of events from one replica that the event loop processes before the next replica's turn.
+	eventSourceLimit int
+
+	// the first error returned by a server option, which is returned when the server is started.
+	optsErr error
+
+	mut              sync.Mutex
+	broadcastHandler BroadcastHandler
 }
 
+// BroadcastHandler processes an application message that was broadcast by another replica.
+type BroadcastHandler func(sender hotstuff.ID, payload []byte)
+
 // InitConsensusModule gives the module a reference to the Modules object.
 // It also allows the module to set module options using the OptionsBuilder.
 func (srv *Server) InitConsensusModule(mods *consensus.Modules, _ *consensus.OptionsBuilder) {
 	srv.mods = mods
+	if srv.eventSourceLimit > 0 {
+		srv.mods.EventLoop().SetSourceLimit(srv.eventSourceLimit)
+	}
+	srv.mods.EventLoop().RegisterObserver(synchronizer.ViewChangeEvent{}, func(event interface{}) {
+		atomic.StoreUint64(&srv.currentView, uint64(event.(synchronizer.ViewChangeEvent).View))
+	})
+}
+
+// ServerOption configures a server when it is created.
+// An invalid option makes the server fail to start.
+type ServerOption func(*Server) error
+
+// WithGorumsServerOptions returns a server option that passes the gorums server options to the underlying server.
+func WithGorumsServerOptions(opts ...gorums.ServerOption) ServerOption {
+	return func(srv *Server) error {
+		srv.gorumsOpts = append(srv.gorumsOpts, opts...)
+		return nil
+	}
+}
+
+// WithReplayWindow returns a server option that rejects proposals, votes, timeouts, and new view messages that refer
+// to a view more than window views below the current view of the local replica. The rejected messages are dropped
+// before they reach the consensus modules, which protects them from old messages that are replayed by a peer.
+// Votes are only checked if the voted block is known. If window is zero, no messages are rejected.
+func WithReplayWindow(window consensus.View) ServerOption {
+	return func(srv *Server) error {
+		srv.replayWindow = window
+		return nil
+	}
+}
+
+// WithEventSourceLimit returns a server option that makes the event loop take turns between the replicas that the
+// server receives messages from, processing at most limit messages from one replica before the next replica's turn.
+// This prevents a faulty replica that floods the server with messages from starving the messages of the other
+// replicas and the local events. If limit is zero, the messages share one queue.
+func WithEventSourceLimit(limit int) ServerOption {
+	return func(srv *Server) error {
+		if limit < 0 {
+			return fmt.Errorf("negative event source limit: %d", limit)
+		}
+		srv.eventSourceLimit = limit
+		return nil
+	}
 }
 
-// NewServer creates a new Server.
-func NewServer(opts ...gorums.ServerOption) *Server {
+// WithServerPrometheus returns a server option that enables Prometheus metrics, and registers the collectors with reg.
+// The metrics include the number of messages rejected by the replay window, by type.
+// Use WithPrometheus to enable the metrics of a configuration.
+func WithServerPrometheus(reg prometheus.Registerer) ServerOption {
+	return func(srv *Server) error {
+		metrics, err := newServerMetrics(reg)
+		if err != nil {
+			return fmt.Errorf("failed to register metrics: %w", err)
+		}
+		srv.metrics = metrics
+		return nil
+	}
+}
+
+// isReplay returns true if the message of the given type, which refers to the given view, is outside the replay window.
+// Replays are logged and counted.
+func (srv *Server) isReplay(msgType string, id hotstuff.ID, view consensus.View) bool {
+	if srv.replayWindow == 0 {
+		return false
+	}
+	current := consensus.View(atomic.LoadUint64(&srv.currentView))
+	if view+srv.replayWindow >= current {
+		return false
+	}
+	srv.mods.Logger().Debugf("Rejected %s from replica %d for view %d in view %d", msgType, id, view, current)
+	srv.metrics.replayRejected(msgType)
+	return true
+}
+
+// NewServer creates a new Server with the given options.
+func NewServer(opts ...ServerOption) *Server {
 	srv := &Server{}
+	for _, opt := range opts {
+		if err := opt(srv); err != nil && srv.optsErr == nil {
+			srv.optsErr = err
+		}
+	}
 
-	opts = append(opts, gorums.WithConnectCallback(func(ctx context.Context) {
+	gorumsOpts := append(srv.gorumsOpts, gorums.WithConnectCallback(func(ctx context.Context) {
 		srv.mods.EventLoop().AddEvent(replicaConnected{ctx})
 	}))
 
-	srv.gorumsSrv = gorums.NewServer(opts...)
+	srv.gorumsSrv = gorums.NewServer(gorumsOpts...)
 
 	hotstuffpb.RegisterHotstuffServer(srv.gorumsSrv, &serviceImpl{srv})
 	return srv
 }
 
+// SetBroadcastHandler sets the handler for application messages broadcast by other replicas.
+// The handler is called directly by the server, and not by the event loop,
+// so it must be safe to call concurrently with the consensus modules.
+func (srv *Server) SetBroadcastHandler(handler BroadcastHandler) {
+	srv.mut.Lock()
+	defer srv.mut.Unlock()
+	srv.broadcastHandler = handler
+}
+
 // GetGorumsServer returns the underlying gorums Server.
 func (srv *Server) GetGorumsServer() *gorums.Server {
 	return srv.gorumsSrv
@@ -51,22 +161,29 @@ func (srv *Server) GetGorumsServer() *gorums.Server {
 
 // Start creates a listener on the configured address and starts the server.
 func (srv *Server) Start(addr string) error {
+	if srv.optsErr != nil {
+		return fmt.Errorf("invalid server option: %w", srv.optsErr)
+	}
 	lis, err := net.Listen("tcp", addr)
 	if err != nil {
 		return fmt.Errorf("failed to listen on %s: %w", addr, err)
 	}
-	srv.StartOnListener(lis)
-	return nil
+	return srv.StartOnListener(lis)
 }
 
 // StartOnListener starts the server with the given listener.
-func (srv *Server) StartOnListener(listener net.Listener) {
+// It returns an error, without serving, if one of the server options was invalid.
+func (srv *Server) StartOnListener(listener net.Listener) error {
+	if srv.optsErr != nil {
+		return fmt.Errorf("invalid server option: %w", srv.optsErr)
+	}
 	go func() {
 		err := srv.gorumsSrv.Serve(listener)
 		if err != nil {
 			srv.mods.Logger().Errorf("An error occurred while serving: %v", err)
 		}
 	}()
+	return nil
 }
 
 // GetPeerIDFromContext extracts the ID of the peer from the context.
@@ -132,8 +249,11 @@ func (impl *serviceImpl) Propose(ctx gorums.ServerCtx, proposal *hotstuffpb.Prop
 	proposal.Block.Proposer = uint32(id)
 	proposeMsg := hotstuffpb.ProposalFromProto(proposal)
 	proposeMsg.ID = id
+	if impl.srv.isReplay("propose", id, proposeMsg.Block.View()) {
+		return
+	}
 
-	impl.srv.mods.EventLoop().AddEvent(proposeMsg)
+	impl.srv.mods.EventLoop().AddEventFrom(id, proposeMsg)
 }
 
 // Vote handles an incoming vote message.
@@ -144,9 +264,14 @@ func (impl *serviceImpl) Vote(ctx gorums.ServerCtx, cert *hotstuffpb.PartialCert
 		return
 	}
 
-	impl.srv.mods.EventLoop().AddEvent(consensus.VoteMsg{
+	partialCert := hotstuffpb.PartialCertFromProto(cert)
+	if block, ok := impl.srv.mods.BlockChain().LocalGet(partialCert.BlockHash()); ok && impl.srv.isReplay("vote", id, block.View()) {
+		return
+	}
+
+	impl.srv.mods.EventLoop().AddEventFrom(id, consensus.VoteMsg{
 		ID:          id,
-		PartialCert: hotstuffpb.PartialCertFromProto(cert),
+		PartialCert: partialCert,
 	})
 }
 
@@ -158,9 +283,14 @@ func (impl *serviceImpl) NewView(ctx gorums.ServerCtx, msg *hotstuffpb.SyncInfo)
 		return
 	}
 
-	impl.srv.mods.EventLoop().AddEvent(consensus.NewViewMsg{
+	syncInfo := hotstuffpb.SyncInfoFromProto(msg)
+	if impl.srv.isReplay("newview", id, syncInfoView(syncInfo)) {
+		return
+	}
+
+	impl.srv.mods.EventLoop().AddEventFrom(id, consensus.NewViewMsg{
 		ID:       id,
-		SyncInfo: hotstuffpb.SyncInfoFromProto(msg),
+		SyncInfo: syncInfo,
 	})
 }
 
@@ -179,6 +309,38 @@ func (impl *serviceImpl) Fetch(ctx gorums.ServerCtx, pb *hotstuffpb.BlockHash) (
 	return hotstuffpb.BlockToProto(block), nil
 }
 
+// Broadcast handles an incoming application message.
+// A raw payload is passed to the broadcast handler, and a message is delivered to the event loop.
+// The message can only be decoded if its type is linked into the binary.
+func (impl *serviceImpl) Broadcast(ctx gorums.ServerCtx, msg *hotstuffpb.AppMessage) {
+	id, err := GetPeerIDFromContext(ctx, impl.srv.mods.Configuration())
+	if err != nil {
+		impl.srv.mods.Logger().Infof("Failed to get client ID: %v", err)
+		return
+	}
+
+	if msg.GetMessage() == nil {
+		impl.srv.mut.Lock()
+		handler := impl.srv.broadcastHandler
+		impl.srv.mut.Unlock()
+		if handler != nil {
+			handler(id, msg.GetPayload())
+		}
+		return
+	}
+
+	appMsg, err := msg.GetMessage().UnmarshalNew()
+	if err != nil {
+		impl.srv.mods.Logger().Infof("Failed to decode application message from replica %d: %v", id, err)
+		return
+	}
+
+	impl.srv.mods.EventLoop().AddEventFrom(id, consensus.AppMsg{
+		ID:      id,
+		Message: appMsg,
+	})
+}
+
 // Timeout handles an incoming TimeoutMsg.
 func (impl *serviceImpl) Timeout(ctx gorums.ServerCtx, msg *hotstuffpb.TimeoutMsg) {
 	var err error
@@ -187,7 +349,21 @@ func (impl *serviceImpl) Timeout(ctx gorums.ServerCtx, msg *hotstuffpb.TimeoutMs
 	if err != nil {
 		impl.srv.mods.Logger().Infof("Could not get ID of replica: %v", err)
 	}
-	impl.srv.mods.EventLoop().AddEvent(timeoutMsg)
+	if impl.srv.isReplay("timeout", timeoutMsg.ID, timeoutMsg.View) {
+		return
+	}
+	impl.srv.mods.EventLoop().AddEventFrom(timeoutMsg.ID, timeoutMsg)
+}
+
+// syncInfoView returns the highest view of the certificates in the sync info.
+func syncInfoView(syncInfo consensus.SyncInfo) (view consensus.View) {
+	if qc, ok := syncInfo.QC(); ok {
+		view = qc.View()
+	}
+	if tc, ok := syncInfo.TC(); ok && tc.View() > view {
+		view = tc.View()
+	}
+	return view
 }
 
 type replicaConnected struct {
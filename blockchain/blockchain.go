@@ -2,14 +2,15 @@
 package blockchain
 
 import (
+	"container/list"
 	"context"
 	"sync"
 
 	"github.com/relab/hotstuff/consensus"
 )
 
-// blockChain stores a limited amount of blocks in a map.
-// blocks are evicted in LRU order.
+// blockChain stores blocks in a map.
+// If the blockchain has a capacity, blocks are evicted in LRU order.
 type blockChain struct {
 	mods          *consensus.Modules
 	mut           sync.Mutex
@@ -17,33 +18,108 @@ type blockChain struct {
 	blocks        map[consensus.Hash]*consensus.Block
 	blockAtHeight map[consensus.View]*consensus.Block
 	pendingFetch  map[consensus.Hash]context.CancelFunc // allows a pending fetch operation to be cancelled
+	capacity      int                                   // the maximum number of blocks, or zero if unlimited
+	entries       map[consensus.Hash]*list.Element
+	accessOrder   list.List
+	pruneDepth    consensus.View // the number of views below the pruned height to keep blocks for, or zero to keep all blocks
+	onPrune       PruneObserver
 }
 
+// PruneObserver is called with each block that is removed from the blockchain by pruning.
+// It is called while the blockchain is locked, and must not call the blockchain.
+type PruneObserver func(block *consensus.Block)
+
 // InitConsensusModule gives the module a reference to the Modules object.
 // It also allows the module to set module options using the OptionsBuilder.
 func (chain *blockChain) InitConsensusModule(mods *consensus.Modules, _ *consensus.OptionsBuilder) {
 	chain.mods = mods
 }
 
-// New creates a new blockChain with a maximum size.
-// Blocks are dropped in least recently used order.
+// New creates a new blockChain that stores all blocks.
 func New() consensus.BlockChain {
+	return NewWithCapacity(0)
+}
+
+// NewWithCapacity creates a new blockChain that stores at most capacity blocks.
+// Blocks are dropped in least recently used order, and must be fetched from other replicas if they are needed again.
+// Blocks that are dropped before they are pruned are not reported as forked by PruneToHeight.
+// If capacity is zero, all blocks are stored.
+func NewWithCapacity(capacity int) consensus.BlockChain {
 	bc := &blockChain{
 		blocks:        make(map[consensus.Hash]*consensus.Block),
 		blockAtHeight: make(map[consensus.View]*consensus.Block),
 		pendingFetch:  make(map[consensus.Hash]context.CancelFunc),
+		capacity:      capacity,
+		entries:       make(map[consensus.Hash]*list.Element),
 	}
 	bc.Store(consensus.GetGenesis())
 	return bc
 }
 
+// NewWithPruning creates a new blockChain that stores at most capacity blocks, like NewWithCapacity,
+// and removes the blocks whose views are more than depth views below the height that the blockchain is pruned to,
+// which is the view of the most recently committed block.
+// Removed blocks must be fetched from other replicas if they are needed again.
+// The observer, if not nil, is called with each removed block.
+// If depth is zero, no blocks are removed by pruning.
+func NewWithPruning(capacity int, depth consensus.View, observer PruneObserver) consensus.BlockChain {
+	bc := NewWithCapacity(capacity).(*blockChain)
+	bc.pruneDepth = depth
+	bc.onPrune = observer
+	return bc
+}
+
+// insert adds the block to the maps, and evicts the least recently used block if the blockchain is full.
+// The caller must hold the lock.
+func (chain *blockChain) insert(block *consensus.Block) {
+	hash := block.Hash()
+	chain.blocks[hash] = block
+	chain.blockAtHeight[block.View()] = block
+	if chain.capacity == 0 {
+		return
+	}
+	if elem, ok := chain.entries[hash]; ok {
+		chain.accessOrder.MoveToFront(elem)
+		return
+	}
+	chain.entries[hash] = chain.accessOrder.PushFront(hash)
+	for len(chain.entries) > chain.capacity {
+		chain.evict()
+	}
+}
+
+// touch marks the block as recently used. The caller must hold the lock.
+func (chain *blockChain) touch(hash consensus.Hash) {
+	if elem, ok := chain.entries[hash]; ok {
+		chain.accessOrder.MoveToFront(elem)
+	}
+}
+
+// evict removes the least recently used block. The caller must hold the lock.
+func (chain *blockChain) evict() {
+	hash := chain.accessOrder.Back().Value.(consensus.Hash)
+	chain.remove(chain.blocks[hash])
+}
+
+// remove removes the block from the maps. The caller must hold the lock.
+func (chain *blockChain) remove(block *consensus.Block) {
+	hash := block.Hash()
+	if elem, ok := chain.entries[hash]; ok {
+		chain.accessOrder.Remove(elem)
+		delete(chain.entries, hash)
+	}
+	delete(chain.blocks, hash)
+	if b, ok := chain.blockAtHeight[block.View()]; ok && b == block {
+		delete(chain.blockAtHeight, block.View())
+	}
+}
+
 // Store stores a block in the blockchain
 func (chain *blockChain) Store(block *consensus.Block) {
 	chain.mut.Lock()
 	defer chain.mut.Unlock()
 
-	chain.blocks[block.Hash()] = block
-	chain.blockAtHeight[block.View()] = block
+	chain.insert(block)
 
 	// cancel any pending fetch operations
 	if cancel, ok := chain.pendingFetch[block.Hash()]; ok {
@@ -60,6 +136,7 @@ func (chain *blockChain) LocalGet(hash consensus.Hash) (*consensus.Block, bool)
 	if !ok {
 		return nil, false
 	}
+	chain.touch(hash)
 
 	return block, true
 }
@@ -76,6 +153,7 @@ func (chain *blockChain) Get(hash consensus.Hash) (block *consensus.Block, ok bo
 	chain.mut.Lock()
 	block, ok = chain.blocks[hash]
 	if ok {
+		chain.touch(hash)
 		goto done
 	}
 
@@ -96,8 +174,7 @@ func (chain *blockChain) Get(hash consensus.Hash) (block *consensus.Block, ok bo
 
 	chain.mods.Logger().Debugf("Successfully fetched block: %.8s", hash)
 
-	chain.blocks[hash] = block
-	chain.blockAtHeight[block.View()] = block
+	chain.insert(block)
 
 done:
 	defer chain.mut.Unlock()
@@ -150,6 +227,18 @@ func (chain *blockChain) PruneToHeight(height consensus.View) (forkedBlocks []*c
 		delete(chain.blockAtHeight, h)
 	}
 	chain.pruneHeight = height
+
+	if chain.pruneDepth > 0 && height > chain.pruneDepth {
+		for _, block := range chain.blocks {
+			if block.View() >= height-chain.pruneDepth {
+				continue
+			}
+			chain.remove(block)
+			if chain.onPrune != nil {
+				chain.onPrune(block)
+			}
+		}
+	}
 	return forkedBlocks
 }
 
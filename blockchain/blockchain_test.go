@@ -0,0 +1,89 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/internal/mocks"
+)
+
+func TestCapacity(t *testing.T) {
+	genesis := consensus.GetGenesis()
+	qc := consensus.NewQuorumCert(nil, 0, genesis.Hash())
+	blocks := make([]*consensus.Block, 3)
+	for i := range blocks {
+		blocks[i] = consensus.NewBlock(genesis.Hash(), qc, consensus.Command(rune('a'+i)), consensus.View(i+1), 1)
+	}
+
+	chain := NewWithCapacity(2)
+	chain.Store(blocks[0])
+	chain.Store(blocks[1])
+	// the genesis block is the least recently used, and is evicted first.
+	if _, ok := chain.LocalGet(genesis.Hash()); ok {
+		t.Error("expected the genesis block to be evicted")
+	}
+	// accessing the first block makes the second block the least recently used.
+	if _, ok := chain.LocalGet(blocks[0].Hash()); !ok {
+		t.Fatal("expected the first block to be stored")
+	}
+	chain.Store(blocks[2])
+
+	if _, ok := chain.LocalGet(blocks[1].Hash()); ok {
+		t.Error("expected the least recently used block to be evicted")
+	}
+	for _, block := range []*consensus.Block{blocks[0], blocks[2]} {
+		if _, ok := chain.LocalGet(block.Hash()); !ok {
+			t.Errorf("expected block %v to be stored", block)
+		}
+	}
+
+	chain = New()
+	for _, block := range blocks {
+		chain.Store(block)
+	}
+	for _, block := range append(blocks, genesis) {
+		if _, ok := chain.LocalGet(block.Hash()); !ok {
+			t.Errorf("expected block %v to be stored without a capacity", block)
+		}
+	}
+}
+
+func TestPruning(t *testing.T) {
+	genesis := consensus.GetGenesis()
+	chain := []*consensus.Block{genesis}
+	for i := 1; i <= 5; i++ {
+		parent := chain[i-1]
+		qc := consensus.NewQuorumCert(nil, parent.View(), parent.Hash())
+		chain = append(chain, consensus.NewBlock(parent.Hash(), qc, consensus.Command(rune('a'+i)), consensus.View(i), 1))
+	}
+	committed := chain[len(chain)-1]
+
+	for _, depth := range []consensus.View{0, 2} {
+		ctrl := gomock.NewController(t)
+		cs := mocks.NewMockConsensus(ctrl)
+		cs.EXPECT().CommittedBlock().AnyTimes().Return(committed)
+
+		pruned := make(map[consensus.Hash]bool)
+		bc := NewWithPruning(0, depth, func(block *consensus.Block) { pruned[block.Hash()] = true })
+		builder := consensus.NewBuilder(1, nil)
+		builder.Register(cs, bc)
+		builder.Build()
+		for _, block := range chain[1:] {
+			bc.Store(block)
+		}
+
+		if forked := bc.PruneToHeight(committed.View()); len(forked) != 0 {
+			t.Errorf("depth %d: got forked blocks %v, want none", depth, forked)
+		}
+		for _, block := range chain {
+			wantPruned := depth > 0 && block.View() < committed.View()-depth
+			if pruned[block.Hash()] != wantPruned {
+				t.Errorf("depth %d: block %v observed as pruned: %v, want %v", depth, block, pruned[block.Hash()], wantPruned)
+			}
+			if _, ok := bc.LocalGet(block.Hash()); ok == wantPruned {
+				t.Errorf("depth %d: block %v stored: %v, want %v", depth, block, ok, !wantPruned)
+			}
+		}
+	}
+}
@@ -1,4 +1,6 @@
 // Package chainedhotstuff implements the pipelined three-chain version of the HotStuff protocol.
+// The length of the chain can be changed by setting the chain length option,
+// which allows comparing the three-chain version with two-chain and longer variants.
 package chainedhotstuff
 
 import (
@@ -6,6 +8,9 @@ import (
 	"github.com/relab/hotstuff/modules"
 )
 
+// defaultChainLength is the chain length of the three-chain HotStuff protocol.
+const defaultChainLength = 3
+
 func init() {
 	modules.RegisterModule("chainedhotstuff", New)
 }
@@ -17,12 +22,15 @@ type ChainedHotStuff struct {
 	// protocol variables
 
 	bLock *consensus.Block // the currently locked block
+
+	chainLength int // the number of blocks that need to be chained together in order to commit
 }
 
 // New returns a new chainedhotstuff instance.
 func New() consensus.Rules {
 	return &ChainedHotStuff{
-		bLock: consensus.GetGenesis(),
+		bLock:       consensus.GetGenesis(),
+		chainLength: defaultChainLength,
 	}
 }
 
@@ -30,6 +38,11 @@ func New() consensus.Rules {
 // It also allows the module to set module options using the OptionsBuilder.
 func (hs *ChainedHotStuff) InitConsensusModule(mods *consensus.Modules, _ *consensus.OptionsBuilder) {
 	hs.mods = mods
+	if chainLength := mods.Options().ChainLength(); chainLength >= 2 {
+		hs.chainLength = chainLength
+	} else if chainLength != 0 {
+		mods.Logger().Warnf("chain length must be at least 2, using %d", hs.chainLength)
+	}
 }
 
 func (hs *ChainedHotStuff) qcRef(qc consensus.QuorumCert) (*consensus.Block, bool) {
@@ -40,37 +53,40 @@ func (hs *ChainedHotStuff) qcRef(qc consensus.QuorumCert) (*consensus.Block, boo
 }
 
 // CommitRule decides whether an ancestor of the block should be committed.
+// The chain of blocks linked by quorum certificates from the block is followed for the length of the chain.
+// The second to last block of the chain is locked, and the last block is committed
+// if all blocks in the chain are direct descendants of each other.
 func (hs *ChainedHotStuff) CommitRule(block *consensus.Block) *consensus.Block {
-	block1, ok := hs.qcRef(block.QuorumCert())
-	if !ok {
-		return nil
-	}
-
-	// Note that we do not call UpdateHighQC here.
-	// This is done through AdvanceView, which the Consensus implementation will call.
-	hs.mods.Logger().Debug("PRE_COMMIT: ", block1)
-
-	block2, ok := hs.qcRef(block1.QuorumCert())
-	if !ok {
-		return nil
-	}
+	chain := make([]*consensus.Block, 0, hs.chainLength)
+	for current := block; len(chain) < hs.chainLength; {
+		next, ok := hs.qcRef(current.QuorumCert())
+		if !ok {
+			return nil
+		}
+		chain = append(chain, next)
+		current = next
 
-	if block2.View() > hs.bLock.View() {
-		hs.mods.Logger().Debug("COMMIT: ", block2)
-		hs.bLock = block2
-	}
+		if len(chain) == 1 {
+			// Note that we do not call UpdateHighQC here.
+			// This is done through AdvanceView, which the Consensus implementation will call.
+			hs.mods.Logger().Debug("PRE_COMMIT: ", next)
+		}
 
-	block3, ok := hs.qcRef(block2.QuorumCert())
-	if !ok {
-		return nil
+		if len(chain) == hs.chainLength-1 && next.View() > hs.bLock.View() {
+			hs.mods.Logger().Debug("COMMIT: ", next)
+			hs.bLock = next
+		}
 	}
 
-	if block1.Parent() == block2.Hash() && block2.Parent() == block3.Hash() {
-		hs.mods.Logger().Debug("DECIDE: ", block3)
-		return block3
+	for i := 0; i < len(chain)-1; i++ {
+		if chain[i].Parent() != chain[i+1].Hash() {
+			return nil
+		}
 	}
 
-	return nil
+	decided := chain[len(chain)-1]
+	hs.mods.Logger().Debug("DECIDE: ", decided)
+	return decided
 }
 
 // VoteRule decides whether to vote for the proposal or not.
@@ -97,5 +113,11 @@ func (hs *ChainedHotStuff) VoteRule(proposal consensus.ProposeMsg) bool {
 
 // ChainLength returns the number of blocks that need to be chained together in order to commit.
 func (hs *ChainedHotStuff) ChainLength() int {
-	return 3
+	return hs.chainLength
+}
+
+// LockDepth returns the number of chained quorum certificates after which a replica locks on the oldest block,
+// which is one less than the chain length.
+func (hs *ChainedHotStuff) LockDepth() int {
+	return hs.chainLength - 1
 }
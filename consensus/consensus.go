@@ -27,6 +27,14 @@ type ProposeRuler interface {
 	ProposeRule(cert SyncInfo, cmd Command) (proposal ProposeMsg, ok bool)
 }
 
+// LockingRules is an optional interface that can be implemented by Rules that lock on a block before committing it,
+// and refuse to vote for blocks that conflict with the locked block. It describes the transitions that the safety
+// of the rules depends on, such that tests can generate scenarios that target them.
+type LockingRules interface {
+	// LockDepth returns the number of chained quorum certificates after which a replica locks on the oldest block.
+	LockDepth() int
+}
+
 // consensusBase provides a default implementation of the Consensus interface
 // for implementations of the ConsensusImpl interface.
 type consensusBase struct {
@@ -41,10 +49,17 @@ type consensusBase struct {
 
 // New returns a new Consensus instance based on the given Rules implementation.
 func New(impl Rules) Consensus {
+	return NewWithInitialBlock(impl, GetGenesis())
+}
+
+// NewWithInitialBlock returns a new Consensus instance like New, which starts from the given committed block
+// instead of the genesis block. Only the descendants of the block are committed.
+// The block must also be stored in the blockchain.
+func NewWithInitialBlock(impl Rules, initial *Block) Consensus {
 	return &consensusBase{
 		impl:     impl,
 		lastVote: 0,
-		bExec:    GetGenesis(),
+		bExec:    initial,
 	}
 }
 
@@ -59,6 +74,9 @@ func (cs *consensusBase) InitConsensusModule(mods *Modules, opts *OptionsBuilder
 	if mod, ok := cs.impl.(Module); ok {
 		mod.InitConsensusModule(mods, opts)
 	}
+	if chainLength := mods.Options().ChainLength(); chainLength != 0 && chainLength != cs.impl.ChainLength() {
+		cs.mods.Logger().Warnf("%T does not support a chain length of %d, using %d", cs.impl, chainLength, cs.impl.ChainLength())
+	}
 	cs.mods.EventLoop().RegisterHandler(ProposeMsg{}, func(event interface{}) {
 		cs.OnPropose(event.(ProposeMsg))
 	})
@@ -85,6 +103,11 @@ func (cs *consensusBase) Propose(cert SyncInfo) {
 		}
 	}
 
+	if bp, ok := cs.mods.CommandQueue().(Backpressure); ok && bp.Overloaded() {
+		cs.mods.Logger().Debug("Propose: Command queue overloaded")
+		return
+	}
+
 	cmd, ok := cs.mods.CommandQueue().Get(cs.mods.Synchronizer().ViewContext())
 	if !ok {
 		cs.mods.Logger().Debug("Propose: No command")
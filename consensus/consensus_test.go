@@ -2,11 +2,16 @@ package consensus_test
 
 import (
 	"context"
+	"reflect"
+	"sync"
 	"testing"
 
 	"github.com/golang/mock/gomock"
 	"github.com/relab/hotstuff"
 	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/consensus/chainedhotstuff"
+	"github.com/relab/hotstuff/crypto"
+	"github.com/relab/hotstuff/crypto/ecdsa"
 	"github.com/relab/hotstuff/internal/mocks"
 	"github.com/relab/hotstuff/internal/testutil"
 	"github.com/relab/hotstuff/synchronizer"
@@ -52,3 +57,95 @@ func TestVote(t *testing.T) {
 		t.Error("No new view event happened")
 	}
 }
+
+// batchRecorder records the sizes of the batches of votes that are verified.
+type batchRecorder struct {
+	consensus.Crypto
+	mut     sync.Mutex
+	batches []int
+}
+
+func (r *batchRecorder) InitConsensusModule(mods *consensus.Modules, opts *consensus.OptionsBuilder) {
+	if mod, ok := r.Crypto.(consensus.Module); ok {
+		mod.InitConsensusModule(mods, opts)
+	}
+}
+
+func (r *batchRecorder) VerifyPartialCerts(certs []consensus.PartialCert, block *consensus.Block) ([]bool, error) {
+	r.mut.Lock()
+	r.batches = append(r.batches, len(certs))
+	r.mut.Unlock()
+	return r.Crypto.VerifyPartialCerts(certs, block)
+}
+
+// TestVoteBatch checks that a leader verifies the votes that it receives together in a single batch.
+func TestVoteBatch(t *testing.T) {
+	const n = 4
+	ctrl := gomock.NewController(t)
+	bl := testutil.CreateBuilders(t, ctrl, n)
+	cs := mocks.NewMockConsensus(ctrl)
+	recorder := &batchRecorder{Crypto: crypto.NewCache(ecdsa.New(), 10)}
+	bl[0].Register(synchronizer.New(testutil.FixedTimeout(1000)), cs, recorder)
+	hl := bl.Build()
+	hs := hl[0]
+
+	cs.EXPECT().Propose(gomock.AssignableToTypeOf(consensus.NewSyncInfo()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	hs.EventLoop().RegisterObserver(consensus.NewViewMsg{}, func(event interface{}) {
+		cancel()
+	})
+
+	b := testutil.NewProposeMsg(
+		consensus.GetGenesis().Hash(),
+		consensus.NewQuorumCert(nil, 1, consensus.GetGenesis().Hash()),
+		"test", 1, 1,
+	)
+	hs.BlockChain().Store(b.Block)
+
+	for i, signer := range hl.Signers() {
+		pc, err := signer.CreatePartialCert(b.Block)
+		if err != nil {
+			t.Fatalf("Failed to create partial certificate: %v", err)
+		}
+		hs.EventLoop().AddEvent(consensus.VoteMsg{ID: hotstuff.ID(i + 1), PartialCert: pc})
+	}
+
+	hs.Run(ctx)
+
+	recorder.mut.Lock()
+	defer recorder.mut.Unlock()
+	if !reflect.DeepEqual(recorder.batches, []int{n}) {
+		t.Errorf("got batches of %v votes, want a single batch of %d votes", recorder.batches, n)
+	}
+}
+
+type overloadedQueue struct {
+	gets int
+}
+
+func (q *overloadedQueue) Get(_ context.Context) (cmd consensus.Command, ok bool) {
+	q.gets++
+	return "test", true
+}
+
+func (q *overloadedQueue) Overloaded() bool {
+	return true
+}
+
+// TestProposeOverloaded checks that a leader does not propose while the command queue is overloaded.
+func TestProposeOverloaded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bl := testutil.CreateBuilders(t, ctrl, 1)
+	// the mock configuration fails the test if Propose is called.
+	cfg := mocks.NewMockConfiguration(ctrl)
+	queue := &overloadedQueue{}
+	bl[0].Register(consensus.New(chainedhotstuff.New()), cfg, queue)
+	hs := bl.Build()[0]
+
+	hs.Consensus().Propose(consensus.NewSyncInfo())
+
+	if queue.gets != 0 {
+		t.Errorf("got %d calls to Get, want 0", queue.gets)
+	}
+}
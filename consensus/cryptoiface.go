@@ -16,6 +16,14 @@ type CryptoBase interface {
 	BatchVerify(signature QuorumSignature, batch map[hotstuff.ID][]byte) bool
 }
 
+// MultiVerifier is an optional interface that can be implemented by a CryptoBase
+// to verify multiple signatures of the same message in a single call.
+type MultiVerifier interface {
+	// VerifyMultiple verifies each of the given signatures against the message.
+	// The result contains one entry for each signature, in the same order.
+	VerifyMultiple(signatures []QuorumSignature, message []byte) []bool
+}
+
 // Crypto implements the methods required to create and verify signatures and certificates.
 // This is a higher level interface that is implemented by the crypto package itself.
 type Crypto interface {
@@ -30,6 +38,9 @@ type Crypto interface {
 	CreateAggregateQC(view View, timeouts []TimeoutMsg) (aggQC AggregateQC, err error)
 	// VerifyPartialCert verifies a single partial certificate.
 	VerifyPartialCert(cert PartialCert) bool
+	// VerifyPartialCerts verifies multiple partial certificates for the same block.
+	// The result contains one entry for each certificate, in the same order.
+	VerifyPartialCerts(certs []PartialCert, block *Block) (valid []bool, err error)
 	// VerifyQuorumCert verifies a quorum certificate.
 	VerifyQuorumCert(qc QuorumCert) bool
 	// VerifyTimeoutCert verifies a timeout certificate.
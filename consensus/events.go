@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/relab/hotstuff"
+	"google.golang.org/protobuf/proto"
 )
 
 // ProposeMsg is broadcast when a leader makes a proposal.
@@ -60,6 +61,18 @@ type NewViewMsg struct {
 	SyncInfo SyncInfo    // The highest QC / TC.
 }
 
+// AppMsg is an application message sent by Configuration.BroadcastMessage.
+// It is not processed by the consensus modules, but higher-level protocols
+// can receive it by registering an event handler for AppMsg.
+type AppMsg struct {
+	ID      hotstuff.ID   // The ID of the replica who sent the message.
+	Message proto.Message // The application message.
+}
+
+func (m AppMsg) String() string {
+	return fmt.Sprintf("ID %d, %s", m.ID, m.Message.ProtoReflect().Descriptor().FullName())
+}
+
 // CommitEvent is raised whenever a block is committed,
 // and includes the number of client commands that were executed.
 type CommitEvent struct {
@@ -5,6 +5,7 @@ import (
 
 	"github.com/relab/hotstuff"
 	"github.com/relab/hotstuff/modules"
+	"google.golang.org/protobuf/proto"
 )
 
 // Modules contains the modules that together implement the HotStuff protocol.
@@ -211,6 +212,14 @@ type CommandQueue interface {
 	Get(ctx context.Context) (cmd Command, ok bool)
 }
 
+// Backpressure is an optional interface that can be implemented by a CommandQueue
+// in order to signal that the system is overloaded.
+// While the command queue is overloaded, the leader will not propose new blocks.
+type Backpressure interface {
+	// Overloaded returns true if the leader should avoid proposing.
+	Overloaded() bool
+}
+
 //go:generate mockgen -destination=../internal/mocks/acceptor_mock.go -package=mocks . Acceptor
 
 // Acceptor decides if a replica should accept a command.
@@ -298,24 +307,65 @@ type Replica interface {
 // Configuration holds information about the current configuration of replicas that participate in the protocol,
 // It provides methods to send messages to the other replicas.
 type Configuration interface {
-	// Replicas returns all of the replicas in the configuration.
+	// Replicas returns all of the replicas in the configuration, keyed by their IDs.
 	Replicas() map[hotstuff.ID]Replica
 	// Replica returns a replica if present in the configuration.
 	Replica(hotstuff.ID) (replica Replica, ok bool)
-	// Len returns the number of replicas in the configuration.
+	// Len returns the number of replicas in the configuration, which is the number of replicas returned by Replicas.
 	Len() int
 	// QuorumSize returns the size of a quorum.
+	// It is more than half of Len, such that any two quorums intersect, and at most Len.
 	QuorumSize() int
 	// Propose sends the block to all replicas in the configuration.
 	Propose(proposal ProposeMsg)
 	// Timeout sends the timeout message to all replicas.
 	Timeout(msg TimeoutMsg)
+	// BroadcastMessage sends an application message to all replicas in the configuration.
+	// The message is delivered to the event loops of the other replicas as an AppMsg.
+	BroadcastMessage(msg proto.Message)
 	// Fetch requests a block from all the replicas in the configuration.
 	Fetch(ctx context.Context, hash Hash) (block *Block, ok bool)
 	// SubConfig returns a subconfiguration containing the replicas specified in the ids slice.
+	// The subconfiguration contains exactly those replicas, and its quorum size is computed from their number.
+	// An error is returned if any of the replicas is not in the configuration.
 	SubConfig(ids []hotstuff.ID) (sub Configuration, err error)
 }
 
+// QuorumChecker is an optional interface that can be implemented by a Configuration
+// whose quorums cannot be described by a quorum size alone,
+// e.g. a configuration that requires a joint quorum of the old and new replicas during reconfiguration.
+type QuorumChecker interface {
+	// IsQuorum returns true if the participants form a quorum.
+	IsQuorum(participants IDSet) bool
+}
+
+// IsQuorum returns true if the participants form a quorum in the configuration.
+// If the configuration implements QuorumChecker, its IsQuorum method decides;
+// otherwise, the number of participants is compared with the quorum size.
+func IsQuorum(cfg Configuration, participants IDSet) bool {
+	if checker, ok := cfg.(QuorumChecker); ok {
+		return checker.IsQuorum(participants)
+	}
+	return participants.Len() >= cfg.QuorumSize()
+}
+
+// FaultTolerance is an optional interface that can be implemented by a Configuration
+// whose protocol tolerates a different number of faulty replicas than a configuration of n = 3f+1 replicas.
+type FaultTolerance interface {
+	// NumFaulty returns the number of faulty replicas that the configuration tolerates.
+	NumFaulty() int
+}
+
+// NumFaulty returns the number of faulty replicas that the configuration tolerates.
+// If the configuration implements FaultTolerance, its NumFaulty method decides;
+// otherwise, it is computed from the size of the configuration, as in hotstuff.NumFaulty.
+func NumFaulty(cfg Configuration) int {
+	if ft, ok := cfg.(FaultTolerance); ok {
+		return ft.NumFaulty()
+	}
+	return hotstuff.NumFaulty(cfg.Len())
+}
+
 //go:generate mockgen -destination=../internal/mocks/consensus_mock.go -package=mocks . Consensus
 
 // Consensus implements a byzantine consensus protocol, such as HotStuff.
@@ -1,13 +1,20 @@
 package consensus
 
+import "github.com/relab/hotstuff"
+
 // Options stores runtime configuration settings.
 type Options struct {
 	shouldUseAggQC        bool
 	shouldUseHandel       bool
 	shouldVerifyVotesSync bool
 
+	shouldVerifyOwnSignatures     bool
+	shouldCheckCombinedSignatures bool
+
 	sharedRandomSeed   int64
 	connectionMetadata map[string]string
+	leaderSchedule     []hotstuff.ID
+	chainLength        int
 }
 
 // ShouldUseAggQC returns true if aggregated quorum certificates should be used.
@@ -27,6 +34,18 @@ func (c Options) ShouldVerifyVotesSync() bool {
 	return c.shouldVerifyVotesSync
 }
 
+// ShouldVerifyOwnSignatures returns true if the signatures created by the replica should be verified
+// before they are cached. This is useful for catching bugs in crypto implementations during testing.
+func (c Options) ShouldVerifyOwnSignatures() bool {
+	return c.shouldVerifyOwnSignatures
+}
+
+// ShouldCheckCombinedSignatures returns true if the signatures combined by the crypto cache should be checked
+// to be well-formed before they are returned. This guards against broken crypto implementations.
+func (c Options) ShouldCheckCombinedSignatures() bool {
+	return c.shouldCheckCombinedSignatures
+}
+
 // SharedRandomSeed returns a random number that is shared between all replicas.
 func (c Options) SharedRandomSeed() int64 {
 	return c.sharedRandomSeed
@@ -37,6 +56,17 @@ func (c Options) ConnectionMetadata() map[string]string {
 	return c.connectionMetadata
 }
 
+// LeaderSchedule returns the leader schedule used by the fixed leader rotation.
+func (c Options) LeaderSchedule() []hotstuff.ID {
+	return c.leaderSchedule
+}
+
+// ChainLength returns the chain length requested for consensus rules that support a configurable chain length.
+// If zero, the rules use their default chain length.
+func (c Options) ChainLength() int {
+	return c.chainLength
+}
+
 // OptionsBuilder is used to set the values of immutable configuration settings.
 type OptionsBuilder struct {
 	opts *Options
@@ -57,11 +87,31 @@ func (builder *OptionsBuilder) SetShouldVerifyVotesSync() {
 	builder.opts.shouldVerifyVotesSync = true
 }
 
+// SetShouldVerifyOwnSignatures sets the ShouldVerifyOwnSignatures setting to true.
+func (builder *OptionsBuilder) SetShouldVerifyOwnSignatures() {
+	builder.opts.shouldVerifyOwnSignatures = true
+}
+
+// SetShouldCheckCombinedSignatures sets the ShouldCheckCombinedSignatures setting to true.
+func (builder *OptionsBuilder) SetShouldCheckCombinedSignatures() {
+	builder.opts.shouldCheckCombinedSignatures = true
+}
+
 // SetSharedRandomSeed sets the shared random seed.
 func (builder *OptionsBuilder) SetSharedRandomSeed(seed int64) {
 	builder.opts.sharedRandomSeed = seed
 }
 
+// SetLeaderSchedule sets the leader schedule used by the fixed leader rotation.
+func (builder *OptionsBuilder) SetLeaderSchedule(schedule []hotstuff.ID) {
+	builder.opts.leaderSchedule = schedule
+}
+
+// SetChainLength sets the chain length used by consensus rules that support a configurable chain length.
+func (builder *OptionsBuilder) SetChainLength(chainLength int) {
+	builder.opts.chainLength = chainLength
+}
+
 // SetConnectionMetadata sets the value of a key in the connection metadata map.
 //
 // NOTE: if the value contains binary data, the key must have the "-bin" suffix.
@@ -88,3 +88,9 @@ func (hs *SimpleHotStuff) CommitRule(block *consensus.Block) *consensus.Block {
 func (hs *SimpleHotStuff) ChainLength() int {
 	return 3
 }
+
+// LockDepth returns the number of chained quorum certificates after which a replica locks on the oldest block.
+// The grandparent of a block is locked once the block's parent is certified.
+func (hs *SimpleHotStuff) LockDepth() int {
+	return 2
+}
@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/relab/hotstuff"
+	"golang.org/x/exp/slices"
 )
 
 // IDSet implements a set of replica IDs. It is used to show which replicas participated in some event.
@@ -378,10 +379,17 @@ func (aggQC AggregateQC) String() string {
 	return fmt.Sprintf("AggQC{ view: %d, IDs: [ %s] }", aggQC.view, &sb)
 }
 
+// writeParticipants writes the IDs of the participants in ascending order.
 func writeParticipants(wr io.Writer, participants IDSet) (err error) {
-	participants.RangeWhile(func(id hotstuff.ID) bool {
-		_, err = fmt.Fprintf(wr, "%d ", id)
-		return err == nil
+	ids := make([]hotstuff.ID, 0, participants.Len())
+	participants.ForEach(func(id hotstuff.ID) {
+		ids = append(ids, id)
 	})
-	return err
+	slices.Sort(ids)
+	for _, id := range ids {
+		if _, err = fmt.Fprintf(wr, "%d ", id); err != nil {
+			return err
+		}
+	}
+	return nil
 }
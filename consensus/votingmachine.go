@@ -1,20 +1,36 @@
 package consensus
 
-import (
-	"sync"
-)
-
 // VotingMachine collects votes.
 type VotingMachine struct {
-	mut           sync.Mutex
 	mods          *Modules
 	verifiedVotes map[Hash][]PartialCert // verified votes that could become a QC
+	pendingVotes  map[Hash]*pendingVotes // votes that wait to be verified together
+}
+
+// pendingVotes are the votes for a block that have not been verified yet.
+type pendingVotes struct {
+	block *Block
+	certs []PartialCert
+}
+
+// verifyVotes is the event that verifies the pending votes for a block.
+// The votes that are received before the event is processed are verified in a single batch.
+type verifyVotes struct {
+	hash Hash
+}
+
+// verifiedVotes is the result of verifying a batch of votes for a block outside of the event loop.
+type verifiedVotes struct {
+	block *Block
+	certs []PartialCert
+	valid []bool
 }
 
 // NewVotingMachine returns a new VotingMachine.
 func NewVotingMachine() *VotingMachine {
 	return &VotingMachine{
 		verifiedVotes: make(map[Hash][]PartialCert),
+		pendingVotes:  make(map[Hash]*pendingVotes),
 	}
 }
 
@@ -23,6 +39,8 @@ func NewVotingMachine() *VotingMachine {
 func (vm *VotingMachine) InitConsensusModule(mods *Modules, _ *OptionsBuilder) {
 	vm.mods = mods
 	vm.mods.EventLoop().RegisterHandler(VoteMsg{}, func(event interface{}) { vm.OnVote(event.(VoteMsg)) })
+	vm.mods.EventLoop().RegisterHandler(verifyVotes{}, func(event interface{}) { vm.verifyVotes(event.(verifyVotes).hash) })
+	vm.mods.EventLoop().RegisterHandler(verifiedVotes{}, func(event interface{}) { vm.collectVotes(event.(verifiedVotes)) })
 }
 
 // OnVote handles an incoming vote.
@@ -60,21 +78,56 @@ func (vm *VotingMachine) OnVote(vote VoteMsg) {
 		return
 	}
 
+	pending, ok := vm.pendingVotes[block.Hash()]
+	if !ok {
+		pending = &pendingVotes{block: block}
+		vm.pendingVotes[block.Hash()] = pending
+		vm.mods.EventLoop().AddEvent(verifyVotes{hash: block.Hash()})
+	}
+	pending.certs = append(pending.certs, cert)
+}
+
+// verifyVotes verifies the pending votes for the block with the given hash in a single batch.
+func (vm *VotingMachine) verifyVotes(hash Hash) {
+	pending, ok := vm.pendingVotes[hash]
+	if !ok {
+		return
+	}
+	delete(vm.pendingVotes, hash)
+
+	verify := func() verifiedVotes {
+		valid, err := vm.mods.Crypto().VerifyPartialCerts(pending.certs, pending.block)
+		if err != nil {
+			vm.mods.Logger().Infof("OnVote: could not verify votes for block %.8s: %v", hash, err)
+			valid = make([]bool, len(pending.certs))
+		}
+		return verifiedVotes{block: pending.block, certs: pending.certs, valid: valid}
+	}
+
 	if vm.mods.Options().ShouldVerifyVotesSync() {
-		vm.verifyCert(cert, block)
+		vm.collectVotes(verify())
 	} else {
-		go vm.verifyCert(cert, block)
+		// only the signatures are verified outside of the event loop;
+		// the result is collected by the event loop, such that the votes do not race with the other modules.
+		go func() {
+			vm.mods.EventLoop().AddEvent(verify())
+		}()
 	}
 }
 
-func (vm *VotingMachine) verifyCert(cert PartialCert, block *Block) {
-	if !vm.mods.Crypto().VerifyPartialCert(cert) {
-		vm.mods.Logger().Info("OnVote: Vote could not be verified!")
-		return
+// collectVotes collects the valid votes of a verified batch.
+func (vm *VotingMachine) collectVotes(votes verifiedVotes) {
+	for i, cert := range votes.certs {
+		if !votes.valid[i] {
+			vm.mods.Logger().Info("OnVote: Vote could not be verified!")
+			continue
+		}
+		vm.collectVote(cert, votes.block)
 	}
+}
 
-	vm.mut.Lock()
-	defer vm.mut.Unlock()
+// collectVote adds a verified vote to the votes for its block, and creates a QC once the votes form a quorum.
+func (vm *VotingMachine) collectVote(cert PartialCert, block *Block) {
 
 	// this defer will clean up any old votes in verifiedVotes
 	defer func() {
@@ -94,7 +147,11 @@ func (vm *VotingMachine) verifyCert(cert PartialCert, block *Block) {
 	votes = append(votes, cert)
 	vm.verifiedVotes[cert.BlockHash()] = votes
 
-	if len(votes) < vm.mods.Configuration().QuorumSize() {
+	signers := NewIDSet()
+	for _, vote := range votes {
+		signers.Add(vote.Signer())
+	}
+	if !IsQuorum(vm.mods.Configuration(), signers) {
 		return
 	}
 
@@ -2,7 +2,11 @@ package crypto
 
 import (
 	"container/list"
+	"crypto/hmac"
 	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash"
 	"strings"
 	"sync"
 
@@ -13,26 +17,103 @@ import (
 )
 
 type cache struct {
+	mods        *consensus.Modules
 	impl        consensus.CryptoBase
 	mut         sync.Mutex
 	capacity    int
+	newHash     func() hash.Hash
+	epoch       func() uint64
 	entries     map[string]*list.Element
 	accessOrder list.List
 }
 
-// NewCache returns a new Crypto instance that caches the results of the operations of the given CryptoBase.
-// implementation.
-func NewCache(impl consensus.CryptoBase, capacity int) consensus.Crypto {
-	return New(&cache{
+// Snapshotter is implemented by the Crypto instances returned by NewCache.
+// A snapshot contains the keys of the cached verifications, which are derived from message hashes, public
+// signatures, and validator set epochs, such that a restarted replica can restore its cache without verifying the same signatures again.
+// Because a restored key is accepted without verifying the signature, a snapshot is authenticated with an HMAC
+// under a secret key, e.g. derived from the replica's private key, such that a modified snapshot is rejected.
+type Snapshotter interface {
+	// Snapshot returns the keys of the cache, from the least to the most recently used,
+	// followed by an HMAC of the keys under the given key.
+	Snapshot(key []byte) []byte
+	// Restore adds the keys of a snapshot to the cache, if the snapshot is authenticated by the given key.
+	Restore(snapshot, key []byte) error
+}
+
+type cachedCrypto struct {
+	*crypto
+	cache *cache
+}
+
+// CacheOption configures a cache returned by NewCache.
+type CacheOption func(*cache)
+
+// WithHash returns a cache option that uses newHash, instead of SHA-256, to hash the messages
+// when computing the keys of the cache. The signatures are also part of the keys, but a cached signature is accepted
+// for any message with the same hash. Thus, the hash function must be collision resistant,
+// or a faulty replica could reuse a signature for a different message.
+func WithHash(newHash func() hash.Hash) CacheOption {
+	return func(c *cache) {
+		c.newHash = newHash
+	}
+}
+
+// WithEpoch returns a cache option that includes the epoch returned by the epoch function in the keys of the cache.
+// The epoch must identify the validator set that is active when the function is called,
+// such that a signature that was verified under one validator set is verified again under another.
+func WithEpoch(epoch func() uint64) CacheOption {
+	return func(c *cache) {
+		c.epoch = epoch
+	}
+}
+
+// NewCache returns a new Crypto instance that caches the results of the operations of the given CryptoBase
+// implementation. The returned instance implements Snapshotter.
+func NewCache(impl consensus.CryptoBase, capacity int, opts ...CacheOption) consensus.Crypto {
+	c := &cache{
 		impl:     impl,
 		capacity: capacity,
+		newHash:  sha256.New,
 		entries:  make(map[string]*list.Element, capacity),
-	})
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return cachedCrypto{crypto: &crypto{CryptoBase: c}, cache: c}
+}
+
+// Snapshot returns the keys of the cache, from the least to the most recently used,
+// followed by an HMAC of the keys under the given key.
+func (c cachedCrypto) Snapshot(key []byte) []byte {
+	b := c.cache.snapshot()
+	return append(b, snapshotMAC(b, key)...)
+}
+
+// Restore adds the keys of a snapshot to the cache, if the snapshot is authenticated by the given key.
+func (c cachedCrypto) Restore(snapshot, key []byte) error {
+	if len(key) == 0 {
+		return ErrNoSnapshotKey
+	}
+	if len(snapshot) < sha256.Size {
+		return fmt.Errorf("%w: missing HMAC", ErrInvalidSnapshot)
+	}
+	b, mac := snapshot[:len(snapshot)-sha256.Size], snapshot[len(snapshot)-sha256.Size:]
+	if !hmac.Equal(mac, snapshotMAC(b, key)) {
+		return fmt.Errorf("%w: HMAC mismatch", ErrInvalidSnapshot)
+	}
+	return c.cache.restore(b)
+}
+
+func snapshotMAC(b, key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(b)
+	return mac.Sum(nil)
 }
 
 // InitConsensusModule gives the module a reference to the Modules object.
 // It also allows the module to set module options using the OptionsBuilder.
 func (cache *cache) InitConsensusModule(mods *consensus.Modules, cfg *consensus.OptionsBuilder) {
+	cache.mods = mods
 	if mod, ok := cache.impl.(consensus.Module); ok {
 		mod.InitConsensusModule(mods, cfg)
 	}
@@ -70,62 +151,157 @@ func (cache *cache) evict() {
 	delete(cache.entries, key)
 }
 
+// snapshot encodes the keys as a sequence of length-prefixed strings, such that restoring them in order
+// preserves the access order of the cache.
+func (cache *cache) snapshot() []byte {
+	cache.mut.Lock()
+	defer cache.mut.Unlock()
+	var (
+		b   []byte
+		buf [binary.MaxVarintLen64]byte
+	)
+	for elem := cache.accessOrder.Back(); elem != nil; elem = elem.Prev() {
+		key := elem.Value.(string)
+		n := binary.PutUvarint(buf[:], uint64(len(key)))
+		b = append(b, buf[:n]...)
+		b = append(b, key...)
+	}
+	return b
+}
+
+func (cache *cache) restore(snapshot []byte) error {
+	var keys []string
+	for len(snapshot) > 0 {
+		n, size := binary.Uvarint(snapshot)
+		if size <= 0 || n > uint64(len(snapshot)-size) {
+			return fmt.Errorf("%w: key %d is truncated", ErrInvalidSnapshot, len(keys))
+		}
+		snapshot = snapshot[size:]
+		keys = append(keys, string(snapshot[:n]))
+		snapshot = snapshot[n:]
+	}
+	for _, key := range keys {
+		cache.insert(key)
+	}
+	return nil
+}
+
 // Sign signs a message and adds it to the cache for use during verification.
+// If the ShouldVerifyOwnSignatures option is set, the signature is only cached if it can be verified.
 func (cache *cache) Sign(message []byte) (sig consensus.QuorumSignature, err error) {
 	sig, err = cache.impl.Sign(message)
 	if err != nil {
 		return nil, err
 	}
-	var key strings.Builder
-	hash := sha256.Sum256(message)
-	_, _ = key.Write(hash[:])
-	_, _ = key.Write(sig.ToBytes())
-	cache.insert(key.String())
+	if cache.mods.Options().ShouldVerifyOwnSignatures() && !cache.impl.Verify(sig, message) {
+		cache.mods.Logger().Errorf("Signature created by the crypto implementation failed verification; not caching it")
+		return sig, nil
+	}
+	cache.insert(cache.key(cache.hash(message), sig))
 	return sig, nil
 }
 
 // Verify verifies the given quorum signature against the message.
 func (cache *cache) Verify(signature consensus.QuorumSignature, message []byte) bool {
-	var key strings.Builder
-	hash := sha256.Sum256(message)
-	_, _ = key.Write(hash[:])
-	_, _ = key.Write(signature.ToBytes())
+	key := cache.key(cache.hash(message), signature)
 
-	if cache.check(key.String()) {
+	if cache.check(key) {
 		return true
 	}
 
 	if cache.impl.Verify(signature, message) {
-		cache.insert(key.String())
+		cache.insert(key)
 		return true
 	}
 
 	return false
 }
 
+// VerifyMultiple verifies each of the given signatures against the message.
+// Only the signatures that are not already in the cache are verified by the underlying implementation.
+func (cache *cache) VerifyMultiple(signatures []consensus.QuorumSignature, message []byte) []bool {
+	hash := cache.hash(message)
+	epoch := cache.currentEpoch()
+	valid := make([]bool, len(signatures))
+	keys := make([]string, len(signatures))
+
+	var (
+		uncached []consensus.QuorumSignature
+		indices  []int
+	)
+	for i, sig := range signatures {
+		keys[i] = cacheKey(epoch, hash, sig)
+		if cache.check(keys[i]) {
+			valid[i] = true
+			continue
+		}
+		uncached = append(uncached, sig)
+		indices = append(indices, i)
+	}
+
+	if len(uncached) == 0 {
+		return valid
+	}
+
+	for i, ok := range verifyMultiple(cache.impl, uncached, message) {
+		if ok {
+			valid[indices[i]] = true
+			cache.insert(keys[indices[i]])
+		}
+	}
+	return valid
+}
+
+// hash returns the hash of the message, as used in the keys of the cache.
+func (cache *cache) hash(message []byte) []byte {
+	hasher := cache.newHash()
+	_, _ = hasher.Write(message)
+	return hasher.Sum(nil)
+}
+
+// currentEpoch returns the epoch of the active validator set, or zero if the cache was created without an epoch function.
+func (cache *cache) currentEpoch() uint64 {
+	if cache.epoch == nil {
+		return 0
+	}
+	return cache.epoch()
+}
+
+// key returns the key of the signature of a message with the given hash under the active validator set.
+func (cache *cache) key(hash []byte, signature consensus.QuorumSignature) string {
+	return cacheKey(cache.currentEpoch(), hash, signature)
+}
+
+func cacheKey(epoch uint64, hash []byte, signature consensus.QuorumSignature) string {
+	var (
+		key      strings.Builder
+		epochBuf [8]byte
+	)
+	binary.LittleEndian.PutUint64(epochBuf[:], epoch)
+	_, _ = key.Write(epochBuf[:])
+	_, _ = key.Write(hash)
+	_, _ = key.Write(signature.ToBytes())
+	return key.String()
+}
+
 // BatchVerify verifies the given quorum signature against the batch of messages.
 func (cache *cache) BatchVerify(signature consensus.QuorumSignature, batch map[hotstuff.ID][]byte) bool {
 	// sort the list of ids from the batch map
 	ids := maps.Keys(batch)
 	slices.Sort(ids)
-	var hash consensus.Hash
-	hasher := sha256.New()
+	hasher := cache.newHash()
 	// then hash the messages in sorted order
 	for _, id := range ids {
 		_, _ = hasher.Write(batch[id])
 	}
-	hasher.Sum(hash[:])
+	key := cache.key(hasher.Sum(nil), signature)
 
-	var key strings.Builder
-	_, _ = key.Write(hash[:])
-	_, _ = key.Write(signature.ToBytes())
-
-	if cache.check(key.String()) {
+	if cache.check(key) {
 		return true
 	}
 
 	if cache.impl.BatchVerify(signature, batch) {
-		cache.insert(key.String())
+		cache.insert(key)
 		return true
 	}
 
@@ -133,7 +309,41 @@ func (cache *cache) BatchVerify(signature consensus.QuorumSignature, batch map[h
 }
 
 // Combine combines multiple signatures together into a single signature.
+// If the ShouldCheckCombinedSignatures option is set, an error is returned if the combined signature is malformed.
 func (cache *cache) Combine(signatures ...consensus.QuorumSignature) (consensus.QuorumSignature, error) {
 	// we don't cache the result of this operation, because it is not guaranteed to be valid.
-	return cache.impl.Combine(signatures...)
+	combined, err := cache.impl.Combine(signatures...)
+	if err != nil || !cache.mods.Options().ShouldCheckCombinedSignatures() {
+		return combined, err
+	}
+	if err := cache.checkCombined(combined, signatures); err != nil {
+		return nil, err
+	}
+	return combined, nil
+}
+
+// checkCombined checks that the combined signature is non-empty, and that its participants are exactly
+// the participants of the signatures that it was combined from, all of which must be in the configuration.
+func (cache *cache) checkCombined(combined consensus.QuorumSignature, signatures []consensus.QuorumSignature) error {
+	if combined == nil || len(combined.ToBytes()) == 0 {
+		return fmt.Errorf("%w: empty signature", ErrMalformedSignature)
+	}
+	want := consensus.NewIDSet()
+	for _, sig := range signatures {
+		sig.Participants().ForEach(want.Add)
+	}
+	participants := combined.Participants()
+	if participants == nil || participants.Len() != want.Len() {
+		return fmt.Errorf("%w: expected %d participants", ErrMalformedSignature, want.Len())
+	}
+	var err error
+	participants.RangeWhile(func(id hotstuff.ID) bool {
+		if !want.Contains(id) {
+			err = fmt.Errorf("%w: unexpected participant %d", ErrMalformedSignature, id)
+		} else if _, ok := cache.mods.Configuration().Replica(id); !ok {
+			err = fmt.Errorf("%w: unknown participant %d", ErrMalformedSignature, id)
+		}
+		return err == nil
+	})
+	return err
 }
@@ -97,13 +97,49 @@ func (c crypto) VerifyPartialCert(cert consensus.PartialCert) bool {
 	return c.Verify(cert.Signature(), block.ToBytes())
 }
 
+// VerifyPartialCerts verifies multiple partial certificates for the same block.
+// Certificates for a different block are not valid.
+// If the CryptoBase implements consensus.MultiVerifier, the signatures are verified in a single call.
+func (c crypto) VerifyPartialCerts(certs []consensus.PartialCert, block *consensus.Block) (valid []bool, err error) {
+	if block == nil {
+		return nil, ErrNilBlock
+	}
+	valid = make([]bool, len(certs))
+	// only the certificates for the given block are verified
+	indices := make([]int, 0, len(certs))
+	sigs := make([]consensus.QuorumSignature, 0, len(certs))
+	for i, cert := range certs {
+		if cert.BlockHash() == block.Hash() && cert.Signature() != nil {
+			indices = append(indices, i)
+			sigs = append(sigs, cert.Signature())
+		}
+	}
+	for i, ok := range verifyMultiple(c.CryptoBase, sigs, block.ToBytes()) {
+		valid[indices[i]] = ok
+	}
+	return valid, nil
+}
+
+// verifyMultiple verifies the signatures against the message,
+// using a single call if the implementation supports it.
+func verifyMultiple(impl consensus.CryptoBase, signatures []consensus.QuorumSignature, message []byte) []bool {
+	if mv, ok := impl.(consensus.MultiVerifier); ok {
+		return mv.VerifyMultiple(signatures, message)
+	}
+	valid := make([]bool, len(signatures))
+	for i, sig := range signatures {
+		valid[i] = impl.Verify(sig, message)
+	}
+	return valid
+}
+
 // VerifyQuorumCert verifies a quorum certificate.
 func (c crypto) VerifyQuorumCert(qc consensus.QuorumCert) bool {
 	// genesis QC is always valid.
 	if qc.BlockHash() == consensus.GetGenesis().Hash() {
 		return true
 	}
-	if qc.Signature().Participants().Len() < c.mods.Configuration().QuorumSize() {
+	if !consensus.IsQuorum(c.mods.Configuration(), qc.Signature().Participants()) {
 		return false
 	}
 	block, ok := c.mods.BlockChain().Get(qc.BlockHash())
@@ -119,7 +155,7 @@ func (c crypto) VerifyTimeoutCert(tc consensus.TimeoutCert) bool {
 	if tc.View() == 0 {
 		return true
 	}
-	if tc.Signature().Participants().Len() < c.mods.Configuration().QuorumSize() {
+	if !consensus.IsQuorum(c.mods.Configuration(), tc.Signature().Participants()) {
 		return false
 	}
 	return c.Verify(tc.Signature(), tc.View().ToBytes())
@@ -139,7 +175,7 @@ func (c crypto) VerifyAggregateQC(aggQC consensus.AggregateQC) (highQC consensus
 			SyncInfo: consensus.NewSyncInfo().WithQC(qc),
 		}.ToBytes()
 	}
-	if aggQC.Sig().Participants().Len() < c.mods.Configuration().QuorumSize() {
+	if !consensus.IsQuorum(c.mods.Configuration(), aggQC.Sig().Participants()) {
 		return consensus.QuorumCert{}, false
 	}
 	// both the batched aggQC signatures and the highQC must be verified
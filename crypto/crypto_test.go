@@ -1,6 +1,9 @@
 package crypto_test
 
 import (
+	"crypto/sha512"
+	"errors"
+	"hash"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -48,6 +51,39 @@ func TestVerifyPartialCert(t *testing.T) {
 	runAll(t, run)
 }
 
+func TestVerifyPartialCerts(t *testing.T) {
+	run := func(t *testing.T, setup setupFunc) {
+		ctrl := gomock.NewController(t)
+
+		td := setup(t, ctrl, 4)
+		pcs := testutil.CreatePCs(t, td.block, td.signers)
+
+		otherBlock := consensus.NewBlock(td.block.Hash(), consensus.QuorumCert{}, "bar", 43, 1)
+		otherPC := testutil.CreatePC(t, otherBlock, td.signers[0])
+		// a certificate for another block, and a certificate with a signature for another block.
+		pcs = append(pcs, otherPC, consensus.NewPartialCert(otherPC.Signature(), td.block.Hash()))
+
+		want := []bool{true, true, true, true, false, false}
+		// verify twice, such that the second call is served from the cache, if one is used.
+		for i := 0; i < 2; i++ {
+			valid, err := td.verifiers[0].VerifyPartialCerts(pcs, td.block)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for j := range want {
+				if valid[j] != want[j] {
+					t.Errorf("certificate %d: got %v, want %v", j, valid[j], want[j])
+				}
+			}
+		}
+
+		if _, err := td.verifiers[0].VerifyPartialCerts(pcs, nil); !errors.Is(err, crypto.ErrNilBlock) {
+			t.Errorf("expected %v, got %v", crypto.ErrNilBlock, err)
+		}
+	}
+	runAll(t, run)
+}
+
 func TestCreateQuorumCert(t *testing.T) {
 	run := func(t *testing.T, setup setupFunc) {
 		ctrl := gomock.NewController(t)
@@ -162,6 +198,176 @@ func TestVerifyAggregateQC(t *testing.T) {
 	runAll(t, run)
 }
 
+// rejectingCrypto is a broken crypto backend that rejects all signatures.
+type rejectingCrypto struct {
+	consensus.CryptoBase
+}
+
+func (r rejectingCrypto) InitConsensusModule(mods *consensus.Modules, opts *consensus.OptionsBuilder) {
+	r.CryptoBase.(consensus.Module).InitConsensusModule(mods, opts)
+}
+
+func (rejectingCrypto) Verify(_ consensus.QuorumSignature, _ []byte) bool {
+	return false
+}
+
+func TestCheckCryptoEquivalence(t *testing.T) {
+	run := func(t *testing.T, a, b func() consensus.Crypto, keyFunc keyFunc) error {
+		ctrl := gomock.NewController(t)
+		bl := testutil.CreateBuilders(t, ctrl, 2, testutil.GenerateKeys(t, 2, keyFunc)...)
+		bl[0].Register(a())
+		bl[1].Register(b())
+		signers := bl.Build().Signers()
+		return crypto.CheckCryptoEquivalence(signers[0], signers[1], 10)
+	}
+
+	t.Run("Ecdsa", func(t *testing.T) {
+		if err := run(t, NewBase(ecdsa.New), NewCache(ecdsa.New), testutil.GenerateECDSAKey); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("BLS12-381", func(t *testing.T) {
+		if err := run(t, NewBase(bls12.New), NewCache(bls12.New), testutil.GenerateBLS12Key); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("Mismatch", func(t *testing.T) {
+		rejecting := func() consensus.Crypto { return crypto.New(rejectingCrypto{ecdsa.New()}) }
+		err := run(t, NewBase(ecdsa.New), rejecting, testutil.GenerateECDSAKey)
+		if !errors.Is(err, crypto.ErrNotEquivalent) {
+			t.Errorf("expected %v, got %v", crypto.ErrNotEquivalent, err)
+		}
+	})
+}
+
+func TestCacheVerifyOwnSignatures(t *testing.T) {
+	run := func(t *testing.T, impl consensus.CryptoBase, verify bool) bool {
+		ctrl := gomock.NewController(t)
+		bl := testutil.CreateBuilders(t, ctrl, 1, testutil.GenerateECDSAKey(t))
+		bl[0].Register(crypto.NewCache(impl, 10))
+		if verify {
+			bl[0].OptionsBuilder().SetShouldVerifyOwnSignatures()
+		}
+		signer := bl.Build()[0].Crypto()
+		message := []byte("foo")
+		sig, err := signer.Sign(message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signer.Verify(sig, message)
+	}
+
+	// the cache accepts a signature that it created itself, even if the implementation rejects it.
+	if !run(t, rejectingCrypto{ecdsa.New()}, false) {
+		t.Error("expected the created signature to be cached")
+	}
+	if run(t, rejectingCrypto{ecdsa.New()}, true) {
+		t.Error("expected a signature that fails verification not to be cached")
+	}
+	if !run(t, ecdsa.New(), true) {
+		t.Error("expected a valid signature to be verified")
+	}
+}
+
+func TestCacheSnapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bl := testutil.CreateBuilders(t, ctrl, 3, testutil.GenerateKeys(t, 3, testutil.GenerateECDSAKey)...)
+	bl[0].Register(crypto.NewCache(ecdsa.New(), 10))
+	bl[1].Register(crypto.NewCache(ecdsa.New(), 10))
+	// the restored cache rejects all signatures that it has not cached.
+	bl[2].Register(crypto.NewCache(rejectingCrypto{ecdsa.New()}, 10))
+	hl := bl.Build()
+	signer, verifier, restored := hl[0].Crypto(), hl[1].Crypto(), hl[2].Crypto()
+
+	message := []byte("foo")
+	sig, err := signer.Sign(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verifier.Verify(sig, message) {
+		t.Fatal("expected the signature to be verified")
+	}
+
+	key := []byte("snapshot key")
+	snapshot := verifier.(crypto.Snapshotter).Snapshot(key)
+
+	// a snapshot that is not authenticated by the key is rejected.
+	if err := restored.(crypto.Snapshotter).Restore(snapshot, []byte("other key")); !errors.Is(err, crypto.ErrInvalidSnapshot) {
+		t.Errorf("got error %v, want %v for a snapshot restored with another key", err, crypto.ErrInvalidSnapshot)
+	}
+	if err := restored.(crypto.Snapshotter).Restore(snapshot, nil); !errors.Is(err, crypto.ErrNoSnapshotKey) {
+		t.Errorf("got error %v, want %v for a snapshot restored without a key", err, crypto.ErrNoSnapshotKey)
+	}
+	forged := append([]byte(nil), snapshot...)
+	forged[1] ^= 1
+	if err := restored.(crypto.Snapshotter).Restore(forged, key); !errors.Is(err, crypto.ErrInvalidSnapshot) {
+		t.Errorf("got error %v, want %v for a modified snapshot", err, crypto.ErrInvalidSnapshot)
+	}
+	if restored.Verify(sig, message) {
+		t.Error("expected the restored cache to reject the verification of a rejected snapshot")
+	}
+
+	if err := restored.(crypto.Snapshotter).Restore(snapshot, key); err != nil {
+		t.Fatal(err)
+	}
+	if !restored.Verify(sig, message) {
+		t.Error("expected the restored cache to accept the cached verification")
+	}
+	if restored.Verify(sig, []byte("bar")) {
+		t.Error("expected the restored cache to reject an uncached verification")
+	}
+
+	if err := restored.(crypto.Snapshotter).Restore(snapshot[:len(snapshot)-1], key); !errors.Is(err, crypto.ErrInvalidSnapshot) {
+		t.Errorf("got error %v, want %v for a truncated snapshot", err, crypto.ErrInvalidSnapshot)
+	}
+}
+
+// countingHash counts the number of hashes that are computed.
+type countingHash struct {
+	hash.Hash
+	count *int
+}
+
+func (h countingHash) Sum(b []byte) []byte {
+	*h.count++
+	return h.Hash.Sum(b)
+}
+
+func TestCacheHash(t *testing.T) {
+	run := func(t *testing.T, signer consensus.Crypto) {
+		message := []byte("foo")
+		sig, err := signer.Sign(message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the implementation rejects all signatures, so only cached verifications are accepted.
+		if !signer.Verify(sig, message) {
+			t.Error("expected the signature to be cached")
+		}
+		if signer.Verify(sig, []byte("bar")) {
+			t.Error("expected the signature to be rejected for a different message")
+		}
+	}
+	build := func(t *testing.T, c consensus.Crypto) consensus.Crypto {
+		ctrl := gomock.NewController(t)
+		bl := testutil.CreateBuilders(t, ctrl, 1, testutil.GenerateECDSAKey(t))
+		bl[0].Register(c)
+		return bl.Build()[0].Crypto()
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		run(t, build(t, crypto.NewCache(rejectingCrypto{ecdsa.New()}, 10)))
+	})
+	t.Run("Injected", func(t *testing.T) {
+		var count int
+		newHash := func() hash.Hash { return countingHash{sha512.New(), &count} }
+		run(t, build(t, crypto.NewCache(rejectingCrypto{ecdsa.New()}, 10, crypto.WithHash(newHash))))
+		if count != 3 {
+			t.Errorf("got %d hashes, want 3", count)
+		}
+	})
+}
+
 func runAll(t *testing.T, run func(*testing.T, setupFunc)) {
 	t.Helper()
 	t.Run("Ecdsa", func(t *testing.T) { run(t, setup(NewBase(ecdsa.New), testutil.GenerateECDSAKey)) })
@@ -230,3 +436,146 @@ func newTestData(t *testing.T, ctrl *gomock.Controller, n int, newFunc func() co
 		block:     block,
 	}
 }
+
+// malformedCrypto is a broken crypto backend that drops all but the first signature when combining signatures.
+type malformedCrypto struct {
+	consensus.CryptoBase
+}
+
+func (m malformedCrypto) InitConsensusModule(mods *consensus.Modules, opts *consensus.OptionsBuilder) {
+	m.CryptoBase.(consensus.Module).InitConsensusModule(mods, opts)
+}
+
+func (malformedCrypto) Combine(signatures ...consensus.QuorumSignature) (consensus.QuorumSignature, error) {
+	return signatures[0], nil
+}
+
+func TestCacheCheckCombinedSignatures(t *testing.T) {
+	run := func(t *testing.T, impl consensus.CryptoBase, check bool) error {
+		ctrl := gomock.NewController(t)
+		bl := testutil.CreateBuilders(t, ctrl, 2, testutil.GenerateKeys(t, 2, testutil.GenerateECDSAKey)...)
+		bl[0].Register(crypto.NewCache(impl, 10))
+		bl[1].Register(crypto.NewCache(ecdsa.New(), 10))
+		if check {
+			bl[0].OptionsBuilder().SetShouldCheckCombinedSignatures()
+		}
+		signers := bl.Build().Signers()
+		message := []byte("foo")
+		sigs := make([]consensus.QuorumSignature, len(signers))
+		for i, signer := range signers {
+			var err error
+			if sigs[i], err = signer.Sign(message); err != nil {
+				t.Fatal(err)
+			}
+		}
+		_, err := signers[0].Combine(sigs...)
+		return err
+	}
+
+	// the malformed signature is passed through unless the check is enabled.
+	if err := run(t, malformedCrypto{ecdsa.New()}, false); err != nil {
+		t.Errorf("expected the malformed signature to be returned, got %v", err)
+	}
+	if err := run(t, malformedCrypto{ecdsa.New()}, true); !errors.Is(err, crypto.ErrMalformedSignature) {
+		t.Errorf("expected %v, got %v", crypto.ErrMalformedSignature, err)
+	}
+	if err := run(t, ecdsa.New(), true); err != nil {
+		t.Errorf("expected a well-formed signature to pass the check, got %v", err)
+	}
+}
+
+// countingCrypto counts the number of signatures that are verified by the implementation.
+type countingCrypto struct {
+	consensus.CryptoBase
+	count *int
+}
+
+func (c countingCrypto) InitConsensusModule(mods *consensus.Modules, opts *consensus.OptionsBuilder) {
+	c.CryptoBase.(consensus.Module).InitConsensusModule(mods, opts)
+}
+
+func (c countingCrypto) Verify(signature consensus.QuorumSignature, message []byte) bool {
+	*c.count++
+	return c.CryptoBase.Verify(signature, message)
+}
+
+func TestCacheEpoch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bl := testutil.CreateBuilders(t, ctrl, 2, testutil.GenerateKeys(t, 2, testutil.GenerateECDSAKey)...)
+	var (
+		epoch uint64 = 1
+		count int
+	)
+	bl[0].Register(crypto.NewCache(ecdsa.New(), 10))
+	bl[1].Register(crypto.NewCache(countingCrypto{ecdsa.New(), &count}, 10, crypto.WithEpoch(func() uint64 { return epoch })))
+	hl := bl.Build()
+	signer, verifier := hl[0].Crypto(), hl[1].Crypto()
+
+	message := []byte("foo")
+	sig, err := signer.Sign(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verifier.Verify(sig, message) || !verifier.Verify(sig, message) {
+		t.Fatal("expected the signature to be verified")
+	}
+	if count != 1 {
+		t.Errorf("got %d verifications in the same epoch, want 1", count)
+	}
+
+	// the verification of the previous epoch does not apply to the new epoch.
+	epoch = 2
+	if !verifier.Verify(sig, message) {
+		t.Fatal("expected the signature to be verified")
+	}
+	if count != 2 {
+		t.Errorf("got %d verifications after changing the epoch, want 2", count)
+	}
+
+	// both epochs have their own entry.
+	epoch = 1
+	if !verifier.Verify(sig, message) {
+		t.Fatal("expected the signature to be verified")
+	}
+	if count != 2 {
+		t.Errorf("got %d verifications after returning to the first epoch, want 2", count)
+	}
+}
+
+func TestCacheEpochWithHash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bl := testutil.CreateBuilders(t, ctrl, 2, testutil.GenerateKeys(t, 2, testutil.GenerateECDSAKey)...)
+	var (
+		epoch    uint64 = 1
+		verified int
+		hashed   int
+	)
+	newHash := func() hash.Hash { return countingHash{sha512.New(), &hashed} }
+	bl[0].Register(crypto.NewCache(ecdsa.New(), 10))
+	bl[1].Register(crypto.NewCache(countingCrypto{ecdsa.New(), &verified}, 10,
+		crypto.WithHash(newHash),
+		crypto.WithEpoch(func() uint64 { return epoch }),
+	))
+	hl := bl.Build()
+	signer, verifier := hl[0].Crypto(), hl[1].Crypto()
+
+	message := []byte("foo")
+	sig, err := signer.Sign(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verifier.Verify(sig, message) || !verifier.Verify(sig, message) {
+		t.Fatal("expected the signature to be verified")
+	}
+	epoch = 2
+	if !verifier.Verify(sig, message) {
+		t.Fatal("expected the signature to be verified")
+	}
+	// the keys are computed with the injected hash, and include the epoch.
+	if hashed != 3 {
+		t.Errorf("got %d hashes, want 3", hashed)
+	}
+	if verified != 2 {
+		t.Errorf("got %d verifications in two epochs, want 2", verified)
+	}
+}
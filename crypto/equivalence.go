@@ -0,0 +1,139 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+)
+
+// maxEquivalenceMessageSize is the maximum size of the random messages signed by CheckCryptoEquivalence.
+const maxEquivalenceMessageSize = 256
+
+type namedCrypto struct {
+	name string
+	impl consensus.CryptoBase
+}
+
+// CheckCryptoEquivalence checks that two crypto backends create signatures that are accepted by each other.
+// The backends must be initialized as modules of two different replicas in the same configuration,
+// such that each backend can verify the signatures created by the other.
+//
+// In each iteration, both backends sign random messages. The single signatures, the combined signatures,
+// and the signatures of a batch of messages are then verified by both backends,
+// which must also reject the signatures when verified against other messages.
+// The first mismatch is returned as an error wrapping ErrNotEquivalent.
+func CheckCryptoEquivalence(a, b consensus.CryptoBase, iters int) error {
+	backends := []namedCrypto{{"a", a}, {"b", b}}
+	for i := 0; i < iters; i++ {
+		if err := checkEquivalence(backends); err != nil {
+			return fmt.Errorf("%w: iteration %d: %v", ErrNotEquivalent, i, err)
+		}
+	}
+	return nil
+}
+
+func checkEquivalence(backends []namedCrypto) (err error) {
+	// the backends may panic when given a signature of a type that they do not support.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	msg, err := randomMessage()
+	if err != nil {
+		return err
+	}
+	other, err := randomMessage()
+	if err != nil {
+		return err
+	}
+
+	// both backends sign the same message, such that the signatures can be combined,
+	// and the other message, such that the signatures can be combined into a batch signature.
+	sigs := make([]consensus.QuorumSignature, len(backends))
+	otherSigs := make([]consensus.QuorumSignature, len(backends))
+	for i, signer := range backends {
+		if sigs[i], err = signer.impl.Sign(msg); err != nil {
+			return fmt.Errorf("%s: failed to sign: %v", signer.name, err)
+		}
+		if otherSigs[i], err = signer.impl.Sign(other); err != nil {
+			return fmt.Errorf("%s: failed to sign: %v", signer.name, err)
+		}
+	}
+
+	for i, signer := range backends {
+		for _, verifier := range backends {
+			if !verifier.impl.Verify(sigs[i], msg) {
+				return fmt.Errorf("%s rejected a signature created by %s", verifier.name, signer.name)
+			}
+			if verifier.impl.Verify(sigs[i], other) {
+				return fmt.Errorf("%s accepted a signature created by %s for another message", verifier.name, signer.name)
+			}
+		}
+	}
+
+	batch := map[hotstuff.ID][]byte{
+		signerID(sigs[0]):      msg,
+		signerID(otherSigs[1]): other,
+	}
+	swapped := map[hotstuff.ID][]byte{
+		signerID(sigs[0]):      other,
+		signerID(otherSigs[1]): msg,
+	}
+
+	for _, combiner := range backends {
+		combined, err := combiner.impl.Combine(sigs...)
+		if err != nil {
+			return fmt.Errorf("%s: failed to combine signatures: %v", combiner.name, err)
+		}
+		batchSig, err := combiner.impl.Combine(sigs[0], otherSigs[1])
+		if err != nil {
+			return fmt.Errorf("%s: failed to combine batch signatures: %v", combiner.name, err)
+		}
+		if n := combined.Participants().Len(); n != len(backends) {
+			return fmt.Errorf("%s: combined signature has %d participants, want %d", combiner.name, n, len(backends))
+		}
+		for _, verifier := range backends {
+			if !verifier.impl.Verify(combined, msg) {
+				return fmt.Errorf("%s rejected a signature combined by %s", verifier.name, combiner.name)
+			}
+			if verifier.impl.Verify(combined, other) {
+				return fmt.Errorf("%s accepted a signature combined by %s for another message", verifier.name, combiner.name)
+			}
+			if !verifier.impl.BatchVerify(batchSig, batch) {
+				return fmt.Errorf("%s rejected a batch signature combined by %s", verifier.name, combiner.name)
+			}
+			if verifier.impl.BatchVerify(batchSig, swapped) {
+				return fmt.Errorf("%s accepted a batch signature combined by %s for other messages", verifier.name, combiner.name)
+			}
+		}
+	}
+	return nil
+}
+
+// randomMessage returns a message of random length and content.
+func randomMessage() ([]byte, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(maxEquivalenceMessageSize))
+	if err != nil {
+		return nil, err
+	}
+	// the message must not be empty, such that two messages are unlikely to be equal.
+	msg := make([]byte, n.Int64()+8)
+	if _, err := rand.Read(msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// signerID returns the ID of a participant of the signature.
+func signerID(sig consensus.QuorumSignature) (id hotstuff.ID) {
+	sig.Participants().RangeWhile(func(i hotstuff.ID) bool {
+		id = i
+		return false
+	})
+	return id
+}
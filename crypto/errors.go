@@ -10,4 +10,19 @@ var (
 
 	// ErrCombineOverlap is used when Combine is called with signatures that have overlapping participation.
 	ErrCombineOverlap = errors.New("overlapping signatures")
+
+	// ErrMalformedSignature is used when the crypto cache finds that a combined signature is malformed.
+	ErrMalformedSignature = errors.New("malformed combined signature")
+
+	// ErrNilBlock is used when VerifyPartialCerts is called without a block.
+	ErrNilBlock = errors.New("block is nil")
+
+	// ErrNotEquivalent is used when CheckCryptoEquivalence finds that two crypto backends disagree.
+	ErrNotEquivalent = errors.New("crypto backends are not equivalent")
+
+	// ErrInvalidSnapshot is used when a cache snapshot passed to Restore cannot be decoded or authenticated.
+	ErrInvalidSnapshot = errors.New("invalid cache snapshot")
+
+	// ErrNoSnapshotKey is used when a cache snapshot is restored without a key to authenticate it.
+	ErrNoSnapshotKey = errors.New("no key to authenticate cache snapshot")
 )
@@ -13,6 +13,8 @@ import (
 	"math/big"
 	"net"
 	"os"
+	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/relab/hotstuff"
@@ -207,6 +209,9 @@ func WriteCertFile(cert *x509.Certificate, file string) (err error) {
 // ParsePrivateKey parses a PEM encoded private key.
 func ParsePrivateKey(buf []byte) (key consensus.PrivateKey, err error) {
 	b, _ := pem.Decode(buf)
+	if b == nil {
+		return nil, fmt.Errorf("failed to decode PEM block")
+	}
 	switch b.Type {
 	case ecdsacrypto.PrivateKeyFileType:
 		key, err = x509.ParseECPrivateKey(b.Bytes)
@@ -347,6 +352,69 @@ func GenerateKeyChain(id hotstuff.ID, validFor []string, crypto string, ca *x509
 	}, nil
 }
 
+// KeyChainFiles returns the paths of the files that hold the keychain of the replica with the given ID in dir.
+func KeyChainFiles(dir string, id hotstuff.ID) (privateKey, publicKey, cert, certKey string) {
+	name := filepath.Join(dir, strconv.FormatUint(uint64(id), 10))
+	return name + ".key", name + ".pub", name + ".crt", name + ".crt.key"
+}
+
+// WriteKeyChain writes the keychain of the replica with the given ID to files in dir.
+// The files are named by the ID of the replica.
+func WriteKeyChain(dir string, id hotstuff.ID, keyChain KeyChain) error {
+	privateKeyFile, publicKeyFile, certFile, certKeyFile := KeyChainFiles(dir, id)
+	files := []struct {
+		path string
+		data []byte
+		perm os.FileMode
+	}{
+		{privateKeyFile, keyChain.PrivateKey, 0600},
+		{publicKeyFile, keyChain.PublicKey, 0644},
+		{certFile, keyChain.Certificate, 0644},
+		{certKeyFile, keyChain.CertificateKey, 0600},
+	}
+	for _, f := range files {
+		err := os.WriteFile(f.path, f.data, f.perm)
+		if err != nil {
+			return fmt.Errorf("failed to write keychain of replica %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// ReadKeyChain reads the keychain of the replica with the given ID from files in dir,
+// as written by WriteKeyChain.
+func ReadKeyChain(dir string, id hotstuff.ID) (keyChain KeyChain, err error) {
+	privateKeyFile, publicKeyFile, certFile, certKeyFile := KeyChainFiles(dir, id)
+	for _, f := range []struct {
+		path string
+		data *[]byte
+	}{
+		{privateKeyFile, &keyChain.PrivateKey},
+		{publicKeyFile, &keyChain.PublicKey},
+		{certFile, &keyChain.Certificate},
+		{certKeyFile, &keyChain.CertificateKey},
+	} {
+		*f.data, err = os.ReadFile(f.path)
+		if err != nil {
+			return KeyChain{}, fmt.Errorf("failed to read keychain of replica %d: %w", id, err)
+		}
+	}
+	// check that the files contain valid keys and certificates.
+	if _, err = ParsePrivateKey(keyChain.PrivateKey); err != nil {
+		return KeyChain{}, fmt.Errorf("invalid private key for replica %d: %w", id, err)
+	}
+	if _, err = ParsePublicKey(keyChain.PublicKey); err != nil {
+		return KeyChain{}, fmt.Errorf("invalid public key for replica %d: %w", id, err)
+	}
+	if _, err = ParsePrivateKey(keyChain.CertificateKey); err != nil {
+		return KeyChain{}, fmt.Errorf("invalid certificate key for replica %d: %w", id, err)
+	}
+	if b, _ := pem.Decode(keyChain.Certificate); b == nil || b.Type != "CERTIFICATE" {
+		return KeyChain{}, fmt.Errorf("invalid certificate for replica %d", id)
+	}
+	return keyChain, nil
+}
+
 // GenerateCA returns a certificate authority for generating new certificates.
 func GenerateCA() (pk *ecdsa.PrivateKey, ca *x509.Certificate, err error) {
 	pk, err = GenerateECDSAPrivateKey()
@@ -0,0 +1,62 @@
+package keygen
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/relab/hotstuff"
+)
+
+func TestWriteReadKeyChain(t *testing.T) {
+	caKey, ca, err := GenerateCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, crypto := range []string{"ecdsa", "bls12"} {
+		t.Run(crypto, func(t *testing.T) {
+			dir := t.TempDir()
+			keyChains := make(map[hotstuff.ID]KeyChain)
+			for id := hotstuff.ID(1); id <= 4; id++ {
+				keyChain, err := GenerateKeyChain(id, []string{"localhost"}, crypto, ca, caKey)
+				if err != nil {
+					t.Fatal(err)
+				}
+				err = WriteKeyChain(dir, id, keyChain)
+				if err != nil {
+					t.Fatal(err)
+				}
+				keyChains[id] = keyChain
+			}
+
+			for id, want := range keyChains {
+				got, err := ReadKeyChain(dir, id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("replica %d: reloaded keychain differs from the written keychain", id)
+				}
+
+				// the reloaded keys must also be equivalent after parsing.
+				wantKey, err := ParsePrivateKey(want.PrivateKey)
+				if err != nil {
+					t.Fatal(err)
+				}
+				gotKey, err := ParsePrivateKey(got.PrivateKey)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(gotKey.Public(), wantKey.Public()) {
+					t.Errorf("replica %d: reloaded private key does not match the written key", id)
+				}
+			}
+		})
+	}
+}
+
+func TestReadKeyChainMissing(t *testing.T) {
+	if _, err := ReadKeyChain(t.TempDir(), 1); err == nil {
+		t.Error("expected an error when reading a keychain that was not written")
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"reflect"
 	"sync"
 	"time"
+
+	"github.com/relab/hotstuff"
 )
 
 // EventHandler processes an event.
@@ -31,6 +33,20 @@ type EventLoop struct {
 
 	tickers  map[int]*ticker
 	tickerID int
+
+	// the number of delayed events that have been added back to the queue.
+	requeued uint64
+
+	// the queues of the event sources, used if sourceLimit is set.
+	sourceMut    sync.Mutex
+	bufferSize   uint
+	sourceLimit  int
+	sourceQueues map[hotstuff.ID]*queue
+	// the queues in the order that the sources take turns, after the queue of events without a source.
+	sourceOrder []*queue
+	// the position of the source whose turn it is, and the number of events processed from it in this turn.
+	currentSource int
+	served        int
 }
 
 // New returns a new event loop with the requested buffer size.
@@ -41,10 +57,23 @@ func New(bufferSize uint) *EventLoop {
 		handlers:      make(map[reflect.Type]EventHandler),
 		observers:     make(map[reflect.Type][]EventHandler),
 		tickers:       make(map[int]*ticker),
+		bufferSize:    bufferSize,
+		sourceQueues:  make(map[hotstuff.ID]*queue),
 	}
 	return el
 }
 
+// SetSourceLimit makes the event loop take turns between the sources of events, such that a source that adds
+// a flood of events cannot starve the others. Each source added with AddEventFrom gets its own queue, and at most
+// limit events are processed from one source before the next source gets its turn. The events added with AddEvent
+// are treated as one source. If limit is zero, the events from all sources share one queue.
+// SetSourceLimit must be called before the first event is added with AddEventFrom.
+func (el *EventLoop) SetSourceLimit(limit int) {
+	el.sourceMut.Lock()
+	defer el.sourceMut.Unlock()
+	el.sourceLimit = limit
+}
+
 // RegisterHandler registers a handler for events with the same type as the 'eventType' argument.
 // There can be only one handler per event type, and the handler is executed after any observers.
 func (el *EventLoop) RegisterHandler(eventType interface{}, handler EventHandler) {
@@ -65,11 +94,61 @@ func (el *EventLoop) AddEvent(event interface{}) {
 	}
 }
 
+// AddEventFrom adds an event from the given source, such as the replica that sent it, to the event queue.
+func (el *EventLoop) AddEventFrom(source hotstuff.ID, event interface{}) {
+	if event == nil {
+		return
+	}
+	el.sourceMut.Lock()
+	if el.sourceLimit == 0 {
+		el.sourceMut.Unlock()
+		el.eventQ.push(event)
+		return
+	}
+	q, ok := el.sourceQueues[source]
+	if !ok {
+		sq := newQueue(el.bufferSize)
+		// wake up the run loop in the same way as events without a source.
+		sq.readyChan = el.eventQ.readyChan
+		q = &sq
+		el.sourceQueues[source] = q
+		el.sourceOrder = append(el.sourceOrder, q)
+	}
+	el.sourceMut.Unlock()
+	q.push(event)
+}
+
+// pop removes the next event to process from the queues, taking turns between the sources.
+func (el *EventLoop) pop() (event interface{}, ok bool) {
+	el.sourceMut.Lock()
+	defer el.sourceMut.Unlock()
+	if len(el.sourceOrder) == 0 {
+		return el.eventQ.pop()
+	}
+	n := len(el.sourceOrder) + 1
+	// visit each source once, and the current source again if it has used up its turn.
+	for i := 0; i <= n; i++ {
+		if el.served < el.sourceLimit {
+			q := &el.eventQ
+			if el.currentSource > 0 {
+				q = el.sourceOrder[el.currentSource-1]
+			}
+			if event, ok = q.pop(); ok {
+				el.served++
+				return event, true
+			}
+		}
+		el.currentSource = (el.currentSource + 1) % n
+		el.served = 0
+	}
+	return nil, false
+}
+
 // Run runs the event loop. A context object can be provided to stop the event loop.
 func (el *EventLoop) Run(ctx context.Context) {
 loop:
 	for {
-		event, ok := el.eventQ.pop()
+		event, ok := el.pop()
 		if !ok {
 			select {
 			case <-el.eventQ.ready():
@@ -86,18 +165,25 @@ loop:
 	}
 
 	// HACK: when we get cancelled, we will handle the events that were in the queue at that time before quitting.
-	l := el.eventQ.len()
+	l := el.Len()
 	for i := 0; i < l; i++ {
-		event, _ := el.eventQ.pop()
+		event, _ := el.pop()
 		el.processEvent(event)
 	}
 }
 
 // Tick processes a single event. Returns true if an event was handled.
 func (el *EventLoop) Tick() bool {
-	event, ok := el.eventQ.pop()
+	_, ok := el.TickEvent()
+	return ok
+}
+
+// TickEvent processes a single event and returns it.
+// Returns false if there were no events to process.
+func (el *EventLoop) TickEvent() (event interface{}, ok bool) {
+	event, ok = el.pop()
 	if !ok {
-		return false
+		return nil, false
 	}
 
 	if e, ok := event.(startTickerEvent); ok {
@@ -106,7 +192,7 @@ func (el *EventLoop) Tick() bool {
 		el.processEvent(event)
 	}
 
-	return true
+	return event, true
 }
 
 // processEvent dispatches the event to the correct handler.
@@ -135,11 +221,30 @@ func (el *EventLoop) dispatchDelayedEvents(t reflect.Type) {
 		for _, event := range delayed {
 			el.AddEvent(event)
 		}
+		el.requeued += uint64(len(delayed))
 		delete(el.waitingEvents, t)
 	}
 	el.mut.Unlock()
 }
 
+// Len returns the number of events in the queue, including the queues of the event sources.
+func (el *EventLoop) Len() int {
+	el.sourceMut.Lock()
+	defer el.sourceMut.Unlock()
+	l := el.eventQ.len()
+	for _, q := range el.sourceOrder {
+		l += q.len()
+	}
+	return l
+}
+
+// Requeued returns the number of events delayed by DelayUntil that have been added back to the queue.
+func (el *EventLoop) Requeued() uint64 {
+	el.mut.Lock()
+	defer el.mut.Unlock()
+	return el.requeued
+}
+
 // DelayUntil allows us to delay handling of an event until after another event has happened.
 // The eventType parameter decides the type of event to wait for, and it should be the zero value
 // of that event type. The event parameter is the event that will be delayed.
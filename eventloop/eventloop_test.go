@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/relab/hotstuff"
 	"github.com/relab/hotstuff/eventloop"
 )
 
@@ -155,4 +156,90 @@ func TestDelayedEvent(t *testing.T) {
 			t.Fatalf("timed out")
 		}
 	}
+	if requeued := el.Requeued(); requeued != 2 {
+		t.Errorf("got %d requeued events, want 2", requeued)
+	}
+}
+
+func TestLen(t *testing.T) {
+	el := eventloop.New(2)
+	if n := el.Len(); n != 0 {
+		t.Errorf("got length %d, want 0", n)
+	}
+	el.AddEvent(testEvent(1))
+	el.AddEvent(testEvent(2))
+	// the oldest event is dropped when the queue is full.
+	el.AddEvent(testEvent(3))
+	if n := el.Len(); n != 2 {
+		t.Errorf("got length %d, want 2", n)
+	}
+	el.Tick()
+	if n := el.Len(); n != 1 {
+		t.Errorf("got length %d, want 1", n)
+	}
+}
+
+func TestSourceLimit(t *testing.T) {
+	type sourceEvent struct {
+		source hotstuff.ID
+		seq    int
+	}
+
+	const (
+		flood = 100
+		limit = 3
+	)
+
+	// ticks returns the number of ticks that were needed to process the events of the well-behaved sources.
+	ticks := func(limit int) int {
+		el := eventloop.New(1000)
+		el.SetSourceLimit(limit)
+		for i := 0; i < flood; i++ {
+			el.AddEventFrom(1, sourceEvent{1, i})
+		}
+		el.AddEventFrom(2, sourceEvent{2, 0})
+		el.AddEventFrom(3, sourceEvent{3, 0})
+		el.AddEvent(testEvent(0))
+
+		pending := 3
+		last := make(map[hotstuff.ID]int)
+		for tick := 1; ; tick++ {
+			event, ok := el.TickEvent()
+			if !ok {
+				t.Fatalf("ran out of events after %d ticks", tick)
+			}
+			switch e := event.(type) {
+			case sourceEvent:
+				if prev, ok := last[e.source]; ok && e.seq != prev+1 {
+					t.Errorf("events from source %d out of order: got %d after %d", e.source, e.seq, prev)
+				}
+				last[e.source] = e.seq
+				if e.source != 1 {
+					pending--
+				}
+			case testEvent:
+				pending--
+			}
+			if pending == 0 {
+				return tick
+			}
+		}
+	}
+
+	// each of the other sources must get a turn before the flooding source has had two turns.
+	if got, max := ticks(limit), 2*limit+3; got > max {
+		t.Errorf("processed the events of the other sources after %d ticks, want at most %d", got, max)
+	}
+	if got := ticks(0); got <= flood {
+		t.Errorf("processed the events of the other sources after %d ticks without a limit, want more than %d", got, flood)
+	}
+
+	el := eventloop.New(10)
+	el.SetSourceLimit(limit)
+	el.AddEventFrom(1, sourceEvent{1, 0})
+	el.AddEventFrom(2, sourceEvent{2, 0})
+	el.AddEvent(testEvent(0))
+	if el.Len() != 3 {
+		t.Errorf("got length %d, want 3", el.Len())
+	}
 }
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"log"
@@ -53,6 +54,8 @@ func init() {
 	runCmd.Flags().Duration("client-timeout", 500*time.Millisecond, "Client timeout.")
 	runCmd.Flags().Duration("duration", 10*time.Second, "duration of the experiment")
 	runCmd.Flags().Duration("connect-timeout", 5*time.Second, "duration of the initial connection timeout")
+	runCmd.Flags().Duration("step-timeout", 0, "maximum time to wait for the workers to respond to each step of the experiment (disabled by default)")
+	runCmd.Flags().Int("max-concurrent-rpcs", 0, "maximum number of hosts to create, start, or stop replicas on at the same time (unlimited by default)")
 	runCmd.Flags().Duration("view-timeout", 100*time.Millisecond, "duration of the first view")
 	runCmd.Flags().Duration("max-timeout", 0, "upper limit on view timeouts")
 	runCmd.Flags().Int("duration-samples", 1000, "number of previous views to consider when predicting view duration")
@@ -60,6 +63,9 @@ func init() {
 	runCmd.Flags().String("consensus", "chainedhotstuff", "name of the consensus implementation")
 	runCmd.Flags().String("crypto", "ecdsa", "name of the crypto implementation")
 	runCmd.Flags().String("leader-rotation", "round-robin", "name of the leader rotation algorithm")
+	runCmd.Flags().IntSlice("leader-schedule", nil, "list of leader IDs used by the 'fixed' leader rotation, repeated as needed")
+	runCmd.Flags().Int("chain-length", 0, "number of blocks chained together to commit, for consensus implementations that support it (0 for default)")
+	runCmd.Flags().Bool("verify-own-signatures", false, "verify each signature created by a replica before caching it, to detect faulty crypto implementations")
 	runCmd.Flags().Int64("shared-seed", 0, "Shared random number generator seed")
 	runCmd.Flags().StringSlice("modules", nil, "Name additional modules to be loaded.")
 
@@ -67,15 +73,25 @@ func init() {
 	runCmd.Flags().StringSlice("hosts", nil, "the remote hosts to run the experiment on via ssh")
 	runCmd.Flags().String("exe", "", "path to the executable to deploy and run on remote workers")
 	runCmd.Flags().String("ssh-config", "", "path to ssh_config file to resolve host aliases (defaults to ~/.ssh/config)")
+	runCmd.Flags().StringToString("worker-addresses", nil, "workers started with 'hotstuff worker --listen' to connect to, as a comma separated list of 'host=address'")
+	runCmd.Flags().String("worker-token", "", "the token that the workers in --worker-addresses were started with (defaults to $HOTSTUFF_WORKER_TOKEN)")
+	runCmd.Flags().String("checkpoint", "", "file to write the progress of the experiment to, such that it can be resumed (disabled by default)")
+	runCmd.Flags().Duration("checkpoint-interval", 0, "time between rewrites of the checkpoint while the clients are running (0 to only write it after each step)")
+	runCmd.Flags().String("resume", "", "resume the experiment from a checkpoint, reconnecting to the workers that listen for controllers")
 
 	runCmd.Flags().String("output", "", "the directory to save data and profiles to (disabled by default)")
+	runCmd.Flags().String("key-output", "", "the directory to save generated keys to, and to reuse them from in later runs (disabled by default)")
 	runCmd.Flags().Bool("cpu-profile", false, "enable cpu profiling")
 	runCmd.Flags().Bool("mem-profile", false, "enable memory profiling")
 	runCmd.Flags().Bool("trace", false, "enable trace")
 	runCmd.Flags().Bool("fgprof-profile", false, "enable fgprof")
+	runCmd.Flags().Bool("collect-profiles", false, "collect cpu and heap profiles of each replica while the clients are running, and save them to the output directory (cannot be combined with cpu-profile)")
 
 	runCmd.Flags().StringSlice("metrics", []string{"client-latency", "throughput"}, "list of metrics to enable")
 	runCmd.Flags().Duration("measurement-interval", 0, "time interval between measurements")
+	runCmd.Flags().Bool("record-committed-blocks", false, "record the blocks committed by each replica, and compare them block by block if the replicas' hashes differ")
+	runCmd.Flags().Duration("commit-status-interval", 0, "time between reports of the commit heights of the replicas while the clients are running (disabled by default)")
+	runCmd.Flags().Duration("resource-sample-interval", 0, "time interval between samples of the cpu and memory usage of each replica, saved to the output directory (disabled by default)")
 	runCmd.Flags().Float64("rate-limit", math.Inf(1), "rate limit for clients (in commands/second)")
 	runCmd.Flags().Float64("rate-step", 0, "rate limit step up for clients (in commands/second)")
 	runCmd.Flags().Duration("rate-step-interval", time.Hour, "how often the client rate limit should be increased")
@@ -98,24 +114,39 @@ func runController() {
 	}
 
 	experiment := orchestration.Experiment{
-		Logger:      logging.New("ctrl"),
-		NumReplicas: viper.GetInt("replicas"),
-		NumClients:  viper.GetInt("clients"),
-		Duration:    viper.GetDuration("duration"),
-		Output:      outputDir,
+		Logger:             logging.New("ctrl"),
+		NumReplicas:        viper.GetInt("replicas"),
+		NumClients:         viper.GetInt("clients"),
+		Duration:           viper.GetDuration("duration"),
+		Output:             outputDir,
+		KeyOutputDir:       viper.GetString("key-output"),
+		CollectProfiles:    viper.GetBool("collect-profiles"),
+		StepTimeout:        viper.GetDuration("step-timeout"),
+		MaxConcurrentRPCs:  viper.GetInt("max-concurrent-rpcs"),
+		CheckpointFile:     viper.GetString("checkpoint"),
+		CheckpointInterval: viper.GetDuration("checkpoint-interval"),
+		WorkerAddresses:    viper.GetStringMapString("worker-addresses"),
+		WorkerToken:        workerToken(viper.GetString("worker-token")),
+
+		CommitStatusInterval: viper.GetDuration("commit-status-interval"),
 		ReplicaOpts: &orchestrationpb.ReplicaOpts{
-			UseTLS:            true,
-			BatchSize:         viper.GetUint32("batch-size"),
-			TimeoutMultiplier: float32(viper.GetFloat64("timeout-multiplier")),
-			Consensus:         viper.GetString("consensus"),
-			Crypto:            viper.GetString("crypto"),
-			LeaderRotation:    viper.GetString("leader-rotation"),
-			ConnectTimeout:    durationpb.New(viper.GetDuration("connect-timeout")),
-			InitialTimeout:    durationpb.New(viper.GetDuration("view-timeout")),
-			TimeoutSamples:    viper.GetUint32("duration-samples"),
-			MaxTimeout:        durationpb.New(viper.GetDuration("max-timeout")),
-			SharedSeed:        viper.GetInt64("shared-seed"),
-			Modules:           viper.GetStringSlice("modules"),
+			UseTLS:                 true,
+			BatchSize:              viper.GetUint32("batch-size"),
+			TimeoutMultiplier:      float32(viper.GetFloat64("timeout-multiplier")),
+			Consensus:              viper.GetString("consensus"),
+			Crypto:                 viper.GetString("crypto"),
+			LeaderRotation:         viper.GetString("leader-rotation"),
+			ConnectTimeout:         durationpb.New(viper.GetDuration("connect-timeout")),
+			InitialTimeout:         durationpb.New(viper.GetDuration("view-timeout")),
+			TimeoutSamples:         viper.GetUint32("duration-samples"),
+			MaxTimeout:             durationpb.New(viper.GetDuration("max-timeout")),
+			SharedSeed:             viper.GetInt64("shared-seed"),
+			Modules:                viper.GetStringSlice("modules"),
+			LeaderSchedule:         leaderSchedule(),
+			ChainLength:            viper.GetUint32("chain-length"),
+			VerifyOwnSignatures:    viper.GetBool("verify-own-signatures"),
+			ResourceSampleInterval: durationpb.New(viper.GetDuration("resource-sample-interval")),
+			RecordCommittedBlocks:  viper.GetBool("record-committed-blocks"),
 		},
 		ClientOpts: &orchestrationpb.ClientOpts{
 			UseTLS:           true,
@@ -129,9 +160,18 @@ func runController() {
 		},
 	}
 
+	experiment.OnCommitStatus = func(status orchestration.CommitStatus) {
+		experiment.Logger.Infof("Commit heights on %s after %v: %v", status.Host, status.Elapsed, status.Heights)
+	}
+
 	experiment.Byzantine, err = parseByzantine()
 	checkf("%v", err)
 
+	if resume := viper.GetString("resume"); resume != "" {
+		resumeController(&experiment, resume)
+		return
+	}
+
 	worker := viper.GetBool("worker")
 	hosts := viper.GetStringSlice("hosts")
 	exePath := viper.GetString("exe")
@@ -167,23 +207,19 @@ func runController() {
 		go stderrPipe(session.Stderr(), errors)
 	}
 
-	if worker || len(hosts) == 0 {
+	for host, addr := range experiment.WorkerAddresses {
+		experiment.Hosts[host], err = orchestration.DialWorker(context.Background(), addr, experiment.WorkerToken)
+		checkf("failed to connect to worker: %v", err)
+	}
+
+	if worker || (len(hosts) == 0 && len(experiment.WorkerAddresses) == 0) {
 
 		worker, wait := localWorker(outputDir, viper.GetStringSlice("metrics"), viper.GetDuration("measurement-interval"))
 		defer wait()
 		experiment.Hosts["localhost"] = worker
 	}
 
-	experiment.HostConfigs = make(map[string]orchestration.HostConfig)
-
-	var hostConfigs []orchestration.HostConfig
-
-	err = viper.UnmarshalKey("hosts-config", &hostConfigs)
-	checkf("failed to unmarshal hosts-config: %v", err)
-
-	for _, cfg := range hostConfigs {
-		experiment.HostConfigs[cfg.Name] = cfg
-	}
+	unmarshalExperimentConfig(&experiment)
 
 	err = experiment.Run()
 	checkf("failed to run experiment: %v", err)
@@ -205,6 +241,47 @@ func runController() {
 	checkf("failed to close ssh connections: %v", err)
 }
 
+// unmarshalExperimentConfig reads the settings of the experiment that are only available in the config file.
+func unmarshalExperimentConfig(experiment *orchestration.Experiment) {
+	experiment.HostConfigs = make(map[string]orchestration.HostConfig)
+
+	var hostConfigs []orchestration.HostConfig
+
+	err := viper.UnmarshalKey("hosts-config", &hostConfigs)
+	checkf("failed to unmarshal hosts-config: %v", err)
+
+	for _, cfg := range hostConfigs {
+		experiment.HostConfigs[cfg.Name] = cfg
+	}
+
+	err = viper.UnmarshalKey("client-groups", &experiment.ClientGroups)
+	checkf("failed to unmarshal client-groups: %v", err)
+
+	// the client groups determine the number of clients, unless it was set explicitly,
+	// in which case the experiment checks that it matches the groups.
+	if len(experiment.ClientGroups) > 0 && !viper.IsSet("clients") {
+		experiment.NumClients = 0
+	}
+
+	err = viper.UnmarshalKey("timeout-phases", &experiment.TimeoutPhases)
+	checkf("failed to unmarshal timeout-phases: %v", err)
+}
+
+// resumeController resumes an experiment from a checkpoint. The workers must have been started
+// with 'hotstuff worker --listen', such that they kept running after the previous controller failed.
+func resumeController(experiment *orchestration.Experiment, checkpointFile string) {
+	checkpoint, err := orchestration.ReadCheckpoint(checkpointFile)
+	checkf("failed to read checkpoint: %v", err)
+
+	unmarshalExperimentConfig(experiment)
+	if experiment.CheckpointFile == "" {
+		experiment.CheckpointFile = checkpointFile
+	}
+
+	err = experiment.Resume(checkpoint)
+	checkf("failed to resume experiment: %v", err)
+}
+
 func checkf(format string, args ...interface{}) {
 	for _, arg := range args {
 		if err, _ := arg.(error); err != nil {
@@ -324,3 +401,12 @@ func startLocalProfiling(output string) (stop func() error, err error) {
 	stop, err = profiling.StartProfilers(cpuProfile, memProfile, trace, fgprofProfile)
 	return
 }
+
+func leaderSchedule() []uint32 {
+	ids := viper.GetIntSlice("leader-schedule")
+	schedule := make([]uint32, 0, len(ids))
+	for _, id := range ids {
+		schedule = append(schedule, uint32(id))
+	}
+	return schedule
+}
@@ -261,6 +261,10 @@ func (ti twinsInstance) generateAndExecuteScenario() (bool, error) {
 	t := time.Now()
 
 	result, err := twins.ExecuteScenario(scenario, settings.NumNodes, settings.NumTwins, settings.Ticks, twinsConsensus)
+	if errors.Is(err, twins.ErrInvalidScenario) {
+		ti.logger.Infof("Skipping invalid scenario: %v", err)
+		return true, nil
+	}
 	if err != nil {
 		return false, err
 	}
@@ -3,6 +3,7 @@ package cli
 import (
 	"bufio"
 	"log"
+	"net"
 	"os"
 	"time"
 
@@ -22,15 +23,33 @@ var (
 
 	metrics             []string
 	measurementInterval time.Duration
+	listenAddr          string
+	listenToken         string
 )
 
+// workerTokenEnv is the environment variable that contains the token of the workers that listen for controllers,
+// if it is not given as a flag. The environment variable does not show up in the process list, unlike the flag.
+const workerTokenEnv = "HOTSTUFF_WORKER_TOKEN"
+
+// workerToken returns the given token, or the token in the environment if none is given.
+func workerToken(token string) string {
+	if token != "" {
+		return token
+	}
+	return os.Getenv(workerTokenEnv)
+}
+
 // workerCmd represents the worker command
 var workerCmd = &cobra.Command{
 	Hidden: true,
 	Use:    "worker",
 	Short:  "Run a worker.",
 	Long: `Starts a worker that reads commands from stdin and writes responses to stdout.
-This is only intended to be used by a controller (hotstuff run).`,
+This is only intended to be used by a controller (hotstuff run).
+With --listen, the worker instead accepts connections from controllers on the given address,
+and keeps running when a controller disconnects, such that the experiment can be resumed.
+The controllers must authenticate with the token given by --token or $HOTSTUFF_WORKER_TOKEN.
+The token is sent in plain text, so the address must only be reachable from a trusted network.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		runWorker()
 	},
@@ -56,6 +75,8 @@ func init() {
 
 	workerCmd.Flags().StringSliceVar(&metrics, "metrics", nil, "the metrics to enable")
 	workerCmd.Flags().DurationVar(&measurementInterval, "measurement-interval", 0, "the interval between measurements")
+	workerCmd.Flags().StringVar(&listenAddr, "listen", "", "address to accept connections from controllers on, instead of using stdin and stdout")
+	workerCmd.Flags().StringVar(&listenToken, "token", "", "the token that controllers must authenticate with when using --listen (defaults to $HOTSTUFF_WORKER_TOKEN)")
 }
 
 func runWorker() {
@@ -83,7 +104,18 @@ func runWorker() {
 	}
 
 	worker := orchestration.NewWorker(protostream.NewWriter(os.Stdout), protostream.NewReader(os.Stdin), metricsLogger, metrics, measurementInterval)
-	err = worker.Run()
+	if listenAddr != "" {
+		token := workerToken(listenToken)
+		if token == "" {
+			log.Fatalf("failed to listen: %v", orchestration.ErrMissingWorkerToken)
+		}
+		var lis net.Listener
+		lis, err = net.Listen("tcp", listenAddr)
+		checkf("failed to listen: %v", err)
+		err = worker.Serve(lis, token)
+	} else {
+		err = worker.Run()
+	}
 	if err != nil {
 		log.Println(err)
 	}
@@ -0,0 +1,139 @@
+// Package conformance provides test suites that check that implementations of the consensus interfaces
+// follow the contracts documented by the interfaces, such that the implementations behave the same way.
+package conformance
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+)
+
+// RunConfigurationConformance checks that configurations created by the factory
+// satisfy the invariants of the consensus.Configuration interface.
+// The factory is called once for each subtest, and must return a configuration with at least two replicas.
+func RunConfigurationConformance(t *testing.T, factory func() consensus.Configuration) {
+	t.Helper()
+
+	t.Run("Replicas", func(t *testing.T) {
+		cfg := factory()
+		checkReplicas(t, cfg, sortedIDs(cfg))
+	})
+
+	t.Run("UnknownReplica", func(t *testing.T) {
+		cfg := factory()
+		for _, id := range []hotstuff.ID{0, unknownID(cfg)} {
+			if replica, ok := cfg.Replica(id); ok || replica != nil {
+				t.Errorf("Replica(%d) = (%v, %v), want (nil, false)", id, replica, ok)
+			}
+		}
+	})
+
+	t.Run("QuorumSize", func(t *testing.T) {
+		checkQuorumSize(t, factory())
+	})
+
+	t.Run("SubConfig", func(t *testing.T) {
+		cfg := factory()
+		ids := sortedIDs(cfg)
+		if len(ids) < 2 {
+			t.Fatalf("the configuration must have at least two replicas, got %d", len(ids))
+		}
+		// create one subconfiguration without each of the replicas
+		for i, excluded := range ids {
+			subIDs := append(append([]hotstuff.ID(nil), ids[:i]...), ids[i+1:]...)
+			sub, err := cfg.SubConfig(subIDs)
+			if err != nil {
+				t.Fatalf("SubConfig(%v): %v", subIDs, err)
+			}
+			checkReplicas(t, sub, subIDs)
+			checkQuorumSize(t, sub)
+			if replica, ok := sub.Replica(excluded); ok {
+				t.Errorf("SubConfig(%v).Replica(%d) = (%v, true), want the excluded replica to be missing", subIDs, excluded, replica)
+			}
+		}
+		// creating subconfigurations must not change the configuration
+		checkReplicas(t, cfg, ids)
+	})
+
+	t.Run("SubConfigUnknownReplica", func(t *testing.T) {
+		cfg := factory()
+		ids := append(sortedIDs(cfg), unknownID(cfg))
+		if sub, err := cfg.SubConfig(ids); err == nil {
+			t.Errorf("SubConfig(%v) = (%v, nil), want an error", ids, sub)
+		}
+	})
+}
+
+// checkReplicas checks that Replicas, Replica, and Len agree with each other,
+// and that the configuration contains exactly the replicas with the given IDs.
+func checkReplicas(t *testing.T, cfg consensus.Configuration, want []hotstuff.ID) {
+	t.Helper()
+	replicas := cfg.Replicas()
+	if got := sortedIDs(cfg); !equalIDs(got, want) {
+		t.Errorf("Replicas() has IDs %v, want %v", got, want)
+	}
+	if cfg.Len() != len(replicas) {
+		t.Errorf("Len() = %d, want the number of replicas returned by Replicas(), %d", cfg.Len(), len(replicas))
+	}
+	for id, replica := range replicas {
+		if replica.ID() != id {
+			t.Errorf("Replicas()[%d].ID() = %d", id, replica.ID())
+		}
+		got, ok := cfg.Replica(id)
+		if !ok {
+			t.Errorf("Replica(%d) is missing, but the replica is returned by Replicas()", id)
+			continue
+		}
+		if got.ID() != id {
+			t.Errorf("Replica(%d).ID() = %d", id, got.ID())
+		}
+	}
+}
+
+// checkQuorumSize checks that any two quorums of the configuration intersect,
+// and that the configuration has enough replicas to form a quorum.
+func checkQuorumSize(t *testing.T, cfg consensus.Configuration) {
+	t.Helper()
+	n, q := cfg.Len(), cfg.QuorumSize()
+	if 2*q <= n {
+		t.Errorf("QuorumSize() = %d with %d replicas, want more than half of the replicas", q, n)
+	}
+	if q > n {
+		t.Errorf("QuorumSize() = %d with %d replicas, want at most the number of replicas", q, n)
+	}
+}
+
+// sortedIDs returns the IDs of the replicas in the configuration in increasing order.
+func sortedIDs(cfg consensus.Configuration) []hotstuff.ID {
+	ids := make([]hotstuff.ID, 0, cfg.Len())
+	for id := range cfg.Replicas() {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// unknownID returns an ID that is larger than the IDs of all replicas in the configuration.
+func unknownID(cfg consensus.Configuration) hotstuff.ID {
+	var max hotstuff.ID
+	for id := range cfg.Replicas() {
+		if id > max {
+			max = id
+		}
+	}
+	return max + 1
+}
+
+func equalIDs(a, b []hotstuff.ID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -11,6 +11,7 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	hotstuff "github.com/relab/hotstuff"
 	consensus "github.com/relab/hotstuff/consensus"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 )
 
 // MockConfiguration is a mock of Configuration interface.
@@ -36,6 +37,18 @@ func (m *MockConfiguration) EXPECT() *MockConfigurationMockRecorder {
 	return m.recorder
 }
 
+// BroadcastMessage mocks base method.
+func (m *MockConfiguration) BroadcastMessage(arg0 protoreflect.ProtoMessage) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "BroadcastMessage", arg0)
+}
+
+// BroadcastMessage indicates an expected call of BroadcastMessage.
+func (mr *MockConfigurationMockRecorder) BroadcastMessage(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BroadcastMessage", reflect.TypeOf((*MockConfiguration)(nil).BroadcastMessage), arg0)
+}
+
 // Fetch mocks base method.
 func (m *MockConfiguration) Fetch(arg0 context.Context, arg1 consensus.Hash) (*consensus.Block, bool) {
 	m.ctrl.T.Helper()
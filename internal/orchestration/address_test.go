@@ -0,0 +1,67 @@
+package orchestration
+
+import (
+	"net"
+	"testing"
+
+	"github.com/relab/hotstuff/crypto/keygen"
+	"github.com/relab/hotstuff/internal/proto/orchestrationpb"
+)
+
+func TestIPv6Addresses(t *testing.T) {
+	key, err := keygen.GenerateECDSAPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubKey, err := keygen.PublicKeyToPEM(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"::1", "::1"},
+		{"[::1]", "::1"},
+		{"[::1]:22", "::1"},
+		{"fe80::1%eth0", "fe80::1%eth0"},
+		{"[2001:db8::1]", "2001:db8::1"},
+		{"127.0.0.1", "127.0.0.1"},
+		{"localhost:22", "localhost"},
+		{"localhost", "localhost"},
+	}
+	for _, test := range tests {
+		host := hostAddress(test.host)
+		if host != test.want {
+			t.Errorf("hostAddress(%q) = %q, want %q", test.host, host, test.want)
+			continue
+		}
+
+		conf := map[uint32]*orchestrationpb.ReplicaInfo{1: {
+			ID:          1,
+			Address:     host,
+			PublicKey:   pubKey,
+			ReplicaPort: 1234,
+			ClientPort:  5678,
+		}}
+		for _, client := range []bool{false, true} {
+			replicas, err := getConfiguration(conf, client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotHost, gotPort, err := net.SplitHostPort(replicas[0].Address)
+			if err != nil {
+				t.Errorf("host %q: address %q cannot be parsed: %v", test.host, replicas[0].Address, err)
+				continue
+			}
+			wantPort := "1234"
+			if client {
+				wantPort = "5678"
+			}
+			if gotHost != test.want || gotPort != wantPort {
+				t.Errorf("host %q: got address %q, want host %q and port %s", test.host, replicas[0].Address, test.want, wantPort)
+			}
+		}
+	}
+}
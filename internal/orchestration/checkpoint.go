@@ -0,0 +1,281 @@
+package orchestration
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/internal/proto/orchestrationpb"
+	"github.com/relab/hotstuff/metrics"
+	"google.golang.org/protobuf/proto"
+)
+
+// CheckpointStage is the last step of an experiment that was completed when a checkpoint was written.
+type CheckpointStage string
+
+const (
+	// StageReplicasCreated means that the replicas have been created, but not started.
+	StageReplicasCreated CheckpointStage = "replicas-created"
+	// StageReplicasStarted means that the replicas have been started, but the clients have not.
+	StageReplicasStarted CheckpointStage = "replicas-started"
+	// StageClientsStarted means that the clients are running.
+	StageClientsStarted CheckpointStage = "clients-started"
+	// StageClientsStopped means that the clients have been stopped, but the replicas are still running.
+	StageClientsStopped CheckpointStage = "clients-stopped"
+	// StageFinished means that the experiment has finished.
+	StageFinished CheckpointStage = "finished"
+)
+
+// order returns the position of the stage in the sequence of stages.
+func (s CheckpointStage) order() int {
+	switch s {
+	case StageReplicasCreated:
+		return 1
+	case StageReplicasStarted:
+		return 2
+	case StageClientsStarted:
+		return 3
+	case StageClientsStopped:
+		return 4
+	case StageFinished:
+		return 5
+	}
+	return 0
+}
+
+// Checkpoint contains the state of a running experiment that a controller needs to resume it.
+// The hosts are identified by the same names as in Experiment.Hosts.
+type Checkpoint struct {
+	Stage CheckpointStage
+	// Updated is the time when the checkpoint was written.
+	Updated time.Time
+	// WorkerAddresses contains the addresses that the workers listen on, by host.
+	WorkerAddresses map[string]string
+	// HostsToReplicas contains the replicas assigned to each host.
+	HostsToReplicas map[string][]hotstuff.ID
+	// HostsToClients contains the clients assigned to each host.
+	HostsToClients map[string][]hotstuff.ID
+	// Replicas is the serialized orchestrationpb.ReplicaConfiguration returned by the workers.
+	Replicas []byte
+	// ReplicaOpts contains the serialized orchestrationpb.ReplicaOpts of each replica.
+	ReplicaOpts map[hotstuff.ID][]byte
+	// ClientOpts contains the serialized orchestrationpb.ClientOpts of each client.
+	ClientOpts map[hotstuff.ID][]byte
+	// CertificateAuthority is the DER encoded certificate of the certificate authority.
+	CertificateAuthority []byte
+	// ClientsStarted is the time when the clients were started.
+	ClientsStarted time.Time
+	// TimeoutPhasesApplied is the number of timeout phases that have been applied to the replicas.
+	TimeoutPhasesApplied int
+	// Latencies contains the latency histogram of the clients, once they have been stopped.
+	Latencies map[uint32]uint64
+}
+
+// ReadCheckpoint reads a checkpoint that was written by an experiment with a CheckpointFile.
+func ReadCheckpoint(path string) (*Checkpoint, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(b, &checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to decode checkpoint: %w", err)
+	}
+	return &checkpoint, nil
+}
+
+// newCheckpoint returns the checkpoint of an experiment whose replicas have been created.
+func (e *Experiment) newCheckpoint(cfg *orchestrationpb.ReplicaConfiguration) error {
+	replicas, err := proto.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	replicaOpts, err := marshalOpts(e.replicaOpts)
+	if err != nil {
+		return err
+	}
+	clientOpts, err := marshalOpts(e.clientOpts)
+	if err != nil {
+		return err
+	}
+	e.checkpoint = &Checkpoint{
+		WorkerAddresses:      e.WorkerAddresses,
+		HostsToReplicas:      e.hostsToReplicas,
+		HostsToClients:       e.hostsToClients,
+		Replicas:             replicas,
+		ReplicaOpts:          replicaOpts,
+		ClientOpts:           clientOpts,
+		CertificateAuthority: e.ca.Raw,
+	}
+	return e.saveCheckpoint(StageReplicasCreated)
+}
+
+// marshalOpts serializes the options of each replica or client.
+func marshalOpts[T proto.Message](opts map[hotstuff.ID]T) (map[hotstuff.ID][]byte, error) {
+	b := make(map[hotstuff.ID][]byte, len(opts))
+	for id, o := range opts {
+		var err error
+		if b[id], err = proto.Marshal(o); err != nil {
+			return nil, fmt.Errorf("failed to encode the options of %d: %w", id, err)
+		}
+	}
+	return b, nil
+}
+
+// unmarshalOpts decodes the options of each replica or client assigned to a host,
+// and returns an error if the options of any of them are missing.
+func unmarshalOpts[T proto.Message](b map[hotstuff.ID][]byte, hostsToIDs map[string][]hotstuff.ID, newOpts func() T) (map[hotstuff.ID]T, error) {
+	opts := make(map[hotstuff.ID]T)
+	for _, ids := range hostsToIDs {
+		for _, id := range ids {
+			data, ok := b[id]
+			if !ok {
+				return nil, fmt.Errorf("no options for %d", id)
+			}
+			o := newOpts()
+			if err := proto.Unmarshal(data, o); err != nil {
+				return nil, fmt.Errorf("failed to decode the options of %d: %w", id, err)
+			}
+			opts[id] = o
+		}
+	}
+	return opts, nil
+}
+
+// saveCheckpoint advances the checkpoint to the given stage and writes it to CheckpointFile, if set.
+// The file is replaced atomically, such that a controller that fails while writing leaves the previous checkpoint.
+func (e *Experiment) saveCheckpoint(stage CheckpointStage) (err error) {
+	if e.checkpoint == nil {
+		return nil
+	}
+	e.checkpoint.Stage = stage
+	e.checkpoint.Updated = time.Now()
+	if e.CheckpointFile == "" {
+		return nil
+	}
+	b, err := json.MarshalIndent(e.checkpoint, "", "\t")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(e.CheckpointFile), filepath.Base(e.CheckpointFile)+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+	if _, err = tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return os.Rename(tmp.Name(), e.CheckpointFile)
+}
+
+// sleepUntil waits until the deadline, rewriting the checkpoint every CheckpointInterval while waiting.
+func (e *Experiment) sleepUntil(deadline time.Time) error {
+	for e.checkpoint != nil && e.CheckpointInterval > 0 && time.Until(deadline) > e.CheckpointInterval {
+		time.Sleep(e.CheckpointInterval)
+		if err := e.saveCheckpoint(e.checkpoint.Stage); err != nil {
+			return err
+		}
+	}
+	time.Sleep(time.Until(deadline))
+	return nil
+}
+
+// Resume continues an experiment from a checkpoint that was written by a controller that failed.
+// The experiment must have the same settings as the experiment that wrote the checkpoint.
+// Hosts that are not in Experiment.Hosts are reconnected using the worker addresses of the checkpoint,
+// which requires that the workers were started with a listen address, such that they outlive the controller.
+// The steps that were completed according to the checkpoint are skipped, and the clients run until
+// the experiment's Duration has passed since they were started.
+func (e *Experiment) Resume(checkpoint *Checkpoint) (err error) {
+	if checkpoint.Stage.order() == 0 {
+		return fmt.Errorf("invalid checkpoint stage %q", checkpoint.Stage)
+	}
+	if checkpoint.Stage == StageFinished {
+		return fmt.Errorf("the experiment has already finished")
+	}
+	if e.CollectProfiles {
+		return fmt.Errorf("an experiment that collects profiles cannot be resumed")
+	}
+	if err := e.validateTimeoutPhases(); err != nil {
+		return err
+	}
+
+	if e.Hosts == nil {
+		e.Hosts = make(map[string]RemoteWorker)
+	}
+	for host := range e.Hosts {
+		if _, ok := checkpoint.HostsToReplicas[host]; !ok {
+			if _, ok := checkpoint.HostsToClients[host]; !ok {
+				return fmt.Errorf("host %s is not part of the checkpoint", host)
+			}
+		}
+	}
+	ctx, cancel := e.stepContext(0)
+	defer cancel()
+	for _, m := range []map[string][]hotstuff.ID{checkpoint.HostsToReplicas, checkpoint.HostsToClients} {
+		for host := range m {
+			if _, ok := e.Hosts[host]; ok {
+				continue
+			}
+			addr, ok := checkpoint.WorkerAddresses[host]
+			if !ok {
+				return fmt.Errorf("no address to reconnect to the worker on host %s", host)
+			}
+			worker, err := DialWorker(ctx, addr, e.WorkerToken)
+			if err != nil {
+				return e.hostError("reconnect", host, fmt.Errorf("failed to reconnect to host %s: %w", host, err))
+			}
+			e.Hosts[host] = worker
+		}
+	}
+
+	defer func() {
+		qerr := e.quit()
+		if err == nil {
+			err = qerr
+		}
+	}()
+
+	cfg := &orchestrationpb.ReplicaConfiguration{}
+	if err := proto.Unmarshal(checkpoint.Replicas, cfg); err != nil {
+		return fmt.Errorf("failed to decode replica configuration: %w", err)
+	}
+	e.ca, err = x509.ParseCertificate(checkpoint.CertificateAuthority)
+	if err != nil {
+		return fmt.Errorf("failed to decode certificate authority: %w", err)
+	}
+	e.replicaOpts, err = unmarshalOpts(checkpoint.ReplicaOpts, checkpoint.HostsToReplicas, func() *orchestrationpb.ReplicaOpts {
+		return &orchestrationpb.ReplicaOpts{}
+	})
+	if err != nil {
+		return fmt.Errorf("invalid replica options in checkpoint: %w", err)
+	}
+	e.clientOpts, err = unmarshalOpts(checkpoint.ClientOpts, checkpoint.HostsToClients, func() *orchestrationpb.ClientOpts {
+		return &orchestrationpb.ClientOpts{}
+	})
+	if err != nil {
+		return fmt.Errorf("invalid client options in checkpoint: %w", err)
+	}
+	e.hostsToReplicas = checkpoint.HostsToReplicas
+	e.hostsToClients = checkpoint.HostsToClients
+	e.WorkerAddresses = checkpoint.WorkerAddresses
+	// the latencies are empty if the clients have not been stopped, or did not record any latencies.
+	e.Latencies = metrics.LatencyHistogramFromBuckets(checkpoint.Latencies)
+	e.Latency = NewLatencyPercentiles(e.Latencies)
+	e.checkpoint = checkpoint
+
+	e.Logger.Infof("Resuming experiment after stage %s...", checkpoint.Stage)
+	return e.runFrom(cfg)
+}
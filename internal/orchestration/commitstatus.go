@@ -0,0 +1,87 @@
+package orchestration
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/internal/proto/orchestrationpb"
+	"go.uber.org/multierr"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// CommitStatus is the commit progress of the replicas on a host while the clients are running.
+type CommitStatus struct {
+	Host string
+	// Elapsed is the time since the worker started pushing updates.
+	Elapsed time.Duration
+	// Heights contains the view of the most recently committed block of each replica.
+	Heights map[hotstuff.ID]consensus.View
+}
+
+func commitStatusFromProto(host string, update *orchestrationpb.CommitStatus) CommitStatus {
+	status := CommitStatus{
+		Host:    host,
+		Elapsed: update.GetElapsed().AsDuration(),
+		Heights: make(map[hotstuff.ID]consensus.View, len(update.GetHeights())),
+	}
+	for id, height := range update.GetHeights() {
+		status.Heights[hotstuff.ID(id)] = consensus.View(height)
+	}
+	return status
+}
+
+// validateCommitStatus checks that the commit status updates can be pushed while the clients are running.
+// The updates occupy the connections to the workers, which are also needed by profiling and timeout phases.
+func (e *Experiment) validateCommitStatus() error {
+	if e.CommitStatusInterval < 0 {
+		return fmt.Errorf("invalid commit status interval: %v", e.CommitStatusInterval)
+	}
+	if e.CommitStatusInterval > 0 && (e.CollectProfiles || len(e.TimeoutPhases) > 0) {
+		return fmt.Errorf("commit status updates cannot be combined with collecting profiles or timeout phases")
+	}
+	return nil
+}
+
+// watchCommits passes the commit status updates that the workers push to OnCommitStatus,
+// until the experiment's duration has passed since start.
+func (e *Experiment) watchCommits(start time.Time) (err error) {
+	remaining := time.Until(start.Add(e.Duration))
+	ctx, cancel := e.stepContext(remaining)
+	defer cancel()
+
+	// updates from different hosts are passed to OnCommitStatus one at a time.
+	var mut sync.Mutex
+	errc := make(chan error)
+	hosts := 0
+	for host, worker := range e.Hosts {
+		ids := getIDs(host, e.hostsToReplicas)
+		if len(ids) == 0 {
+			continue
+		}
+		hosts++
+		go func(host string, worker RemoteWorker, ids []uint32) {
+			req := &orchestrationpb.WatchCommitsRequest{
+				IDs:      ids,
+				Interval: durationpb.New(e.CommitStatusInterval),
+				Duration: durationpb.New(remaining),
+			}
+			_, err := worker.WatchCommits(ctx, req, func(update *orchestrationpb.CommitStatus) {
+				mut.Lock()
+				defer mut.Unlock()
+				if e.OnCommitStatus != nil {
+					e.OnCommitStatus(commitStatusFromProto(host, update))
+				}
+			})
+			errc <- e.hostError("watch commits", host, err)
+		}(host, worker, ids)
+	}
+
+	err = e.sleepUntil(start.Add(e.Duration))
+	for i := 0; i < hosts; i++ {
+		err = multierr.Append(err, <-errc)
+	}
+	return err
+}
@@ -0,0 +1,100 @@
+package orchestration
+
+import (
+	"fmt"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/internal/proto/orchestrationpb"
+	"github.com/relab/hotstuff/replica"
+	"golang.org/x/exp/slices"
+)
+
+// committedBlocksPageSize is the maximum number of committed blocks that are fetched from a replica per request.
+const committedBlocksPageSize = 1000
+
+func committedBlockToProto(block replica.CommittedBlock) *orchestrationpb.CommittedBlock {
+	return &orchestrationpb.CommittedBlock{
+		Hash:        block.Hash[:],
+		Parent:      block.Parent[:],
+		View:        uint64(block.View),
+		Proposer:    uint32(block.Proposer),
+		CommandHash: block.CommandHash[:],
+	}
+}
+
+func committedBlockFromProto(pb *orchestrationpb.CommittedBlock) replica.CommittedBlock {
+	block := replica.CommittedBlock{
+		View:     consensus.View(pb.GetView()),
+		Proposer: hotstuff.ID(pb.GetProposer()),
+	}
+	copy(block.Hash[:], pb.GetHash())
+	copy(block.Parent[:], pb.GetParent())
+	copy(block.CommandHash[:], pb.GetCommandHash())
+	return block
+}
+
+// collectCommittedBlocks fetches the blocks committed by each replica, one page at a time.
+func (e *Experiment) collectCommittedBlocks() error {
+	e.CommittedBlocks = make(map[hotstuff.ID][]replica.CommittedBlock)
+	ctx, cancel := e.stepContext(0)
+	defer cancel()
+	for host, worker := range e.Hosts {
+		for _, id := range e.hostsToReplicas[host] {
+			var blocks []replica.CommittedBlock
+			for {
+				res, err := worker.CommittedBlocks(ctx, &orchestrationpb.CommittedBlocksRequest{
+					ID:    uint32(id),
+					Start: uint64(len(blocks)),
+					Limit: committedBlocksPageSize,
+				})
+				if err != nil {
+					return e.hostError("collect committed blocks", host, err)
+				}
+				for _, block := range res.GetBlocks() {
+					blocks = append(blocks, committedBlockFromProto(block))
+				}
+				if len(res.GetBlocks()) == 0 || uint64(len(blocks)) >= res.GetTotal() {
+					break
+				}
+			}
+			e.CommittedBlocks[id] = blocks
+		}
+	}
+	return nil
+}
+
+// CompareCommittedBlocks compares the blocks committed by the replicas block by block, and returns an error
+// describing the first position at which two replicas committed different blocks. Replicas that committed
+// fewer blocks than others are only compared up to the number of blocks that they committed.
+func CompareCommittedBlocks(blocks map[hotstuff.ID][]replica.CommittedBlock) error {
+	ids := make([]hotstuff.ID, 0, len(blocks))
+	for id := range blocks {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	for i := 0; ; i++ {
+		var (
+			first    replica.CommittedBlock
+			firstID  hotstuff.ID
+			compared bool
+		)
+		for _, id := range ids {
+			if len(blocks[id]) <= i {
+				continue
+			}
+			block := blocks[id][i]
+			if !compared {
+				first, firstID, compared = block, id, true
+				continue
+			}
+			if block != first {
+				return fmt.Errorf("position %d: replica %d committed block %.8s in view %d, but replica %d committed block %.8s in view %d",
+					i, firstID, first.Hash, first.View, id, block.Hash, block.View)
+			}
+		}
+		if !compared {
+			return nil
+		}
+	}
+}
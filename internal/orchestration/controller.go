@@ -2,21 +2,30 @@ package orchestration
 
 import (
 	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/relab/hotstuff"
 	"github.com/relab/hotstuff/crypto/keygen"
+	"github.com/relab/hotstuff/internal/profiling"
 	"github.com/relab/hotstuff/internal/proto/orchestrationpb"
+	"github.com/relab/hotstuff/leaderrotation"
 	"github.com/relab/hotstuff/logging"
+	"github.com/relab/hotstuff/metrics"
+	"github.com/relab/hotstuff/replica"
 	"go.uber.org/multierr"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 // HostConfig specifies the number of replicas and clients that should be started on a specific host.
@@ -27,6 +36,42 @@ type HostConfig struct {
 	InternalAddress string `mapstructure:"internal-address"`
 }
 
+// ClientGroup specifies a group of clients that share the same workload settings.
+// Settings that are zero are taken from the experiment's ClientOpts.
+type ClientGroup struct {
+	Name          string
+	Count         int
+	MaxConcurrent uint32  `mapstructure:"max-concurrent"`
+	PayloadSize   uint32  `mapstructure:"payload-size"`
+	RateLimit     float64 `mapstructure:"rate-limit"`
+}
+
+// TimeoutPhase specifies the view timeouts of the replicas from a point in time of the experiment.
+// Start is the time since the clients were started. Settings that are zero are taken from the experiment's ReplicaOpts.
+type TimeoutPhase struct {
+	Start             time.Duration
+	InitialTimeout    time.Duration `mapstructure:"initial-timeout"`
+	MaxTimeout        time.Duration `mapstructure:"max-timeout"`
+	TimeoutSamples    uint32        `mapstructure:"timeout-samples"`
+	TimeoutMultiplier float32       `mapstructure:"timeout-multiplier"`
+}
+
+// LatencyPercentiles contains percentiles of command latencies.
+type LatencyPercentiles struct {
+	P50  time.Duration
+	P99  time.Duration
+	P999 time.Duration
+}
+
+// NewLatencyPercentiles computes the percentiles of the latencies in the histogram.
+func NewLatencyPercentiles(h *metrics.LatencyHistogram) LatencyPercentiles {
+	return LatencyPercentiles{
+		P50:  h.Percentile(50),
+		P99:  h.Percentile(99),
+		P999: h.Percentile(99.9),
+	}
+}
+
 // Experiment holds variables for an experiment.
 type Experiment struct {
 	*orchestrationpb.ReplicaOpts
@@ -43,13 +88,118 @@ type Experiment struct {
 	Byzantine   map[string]int // number of replicas to assign to each byzantine strategy
 	Output      string         // path to output folder
 
+	// ClientGroups divides the clients into groups with different workloads.
+	// If set, the number of clients is the sum of the group counts.
+	ClientGroups []ClientGroup
+
+	// KeyOutputDir is a directory to persist the generated certificate authority and keychains to.
+	// If the directory already holds keys from a previous run, they are reused instead of generating new ones.
+	KeyOutputDir string
+
+	// CollectProfiles enables collecting CPU and heap profiles from the workers that run replicas.
+	// The CPU profile covers the time that the clients are running, and the heap profile is taken at the end.
+	// The profiles are written to the output directory as replica-<id>.cpu.pprof and replica-<id>.heap.pprof.
+	// Replicas that run on the same worker share a process, and thus also share the same profiles.
+	CollectProfiles bool
+
+	// StepTimeout is the maximum time that each step of the experiment, such as starting the replicas,
+	// may wait for the workers to respond. When profiles are collected, the profiling step may take
+	// Duration in addition to StepTimeout. If zero, the steps wait indefinitely.
+	StepTimeout time.Duration
+
+	// TimeoutPhases changes the view timeouts of the replicas while the experiment is running.
+	// The phases must be ordered by their start times, which must be less than Duration.
+	// A phase that starts at zero is included in the replicas' options when they are created,
+	// and the replicas are reconfigured when each later phase starts. Cannot be combined with CollectProfiles.
+	TimeoutPhases []TimeoutPhase
+
+	// CommitStatusInterval is the time between the commit status updates that the workers push while the clients
+	// are running, such that a long experiment can show its progress. If zero, no updates are pushed.
+	// Cannot be combined with CollectProfiles or TimeoutPhases.
+	CommitStatusInterval time.Duration
+	// OnCommitStatus is called with each commit status update. The updates from each host are passed in the order
+	// they were pushed, and the function is not called concurrently.
+	OnCommitStatus func(CommitStatus)
+
+	// MaxConcurrentRPCs is the maximum number of hosts that the requests to create, start, and stop replicas
+	// are in progress on at the same time. The requests to the remaining hosts are sent as earlier requests complete,
+	// such that an experiment with many hosts does not overwhelm the controller.
+	// If zero, the requests are sent to all hosts at once.
+	MaxConcurrentRPCs int
+
+	// CheckpointFile is a file to write the progress of the experiment to, such that the experiment can be
+	// resumed with Resume if the controller fails. The checkpoint is written after each step of the experiment.
+	CheckpointFile string
+	// CheckpointInterval is the time between rewrites of the checkpoint while the clients are running.
+	// If zero, the checkpoint is only written after each step.
+	CheckpointInterval time.Duration
+	// WorkerAddresses contains the addresses of the workers that listen for controllers, by host.
+	// They are recorded in the checkpoint, such that Resume can reconnect to the workers.
+	WorkerAddresses map[string]string
+	// WorkerToken is the token that the workers that listen for controllers were started with.
+	// It is not recorded in the checkpoint.
+	WorkerToken string
+
+	// Latencies contains the latencies of the commands sent by all clients.
+	// It is set by Run after the clients have been stopped.
+	Latencies *metrics.LatencyHistogram
+	// Latency contains percentiles of the latencies of the commands sent by all clients.
+	// It is set by Run after the clients have been stopped.
+	Latency LatencyPercentiles
+	// ResourceUsage contains the resources used by the worker of each replica while the replica was running,
+	// if ReplicaOpts.ResourceSampleInterval is set. It is set by Run after the replicas have been stopped,
+	// and is also written to the output directory as resource-usage.json.
+	// Replicas that run on the same worker share a process, and thus also share the same measurements.
+	ResourceUsage map[hotstuff.ID][]profiling.ResourceSample
+	// CommittedBlocks contains the blocks committed by each replica, in order,
+	// if ReplicaOpts.RecordCommittedBlocks is set. It is set by Run after the replicas have been stopped.
+	// The blocks of different replicas can be compared with CompareCommittedBlocks.
+	CommittedBlocks map[hotstuff.ID][]replica.CommittedBlock
+
 	// the host associated with each replica.
 	hostsToReplicas map[string][]hotstuff.ID
 	// the host associated with each client.
 	hostsToClients map[string][]hotstuff.ID
 	replicaOpts    map[hotstuff.ID]*orchestrationpb.ReplicaOpts
+	clientOpts     map[hotstuff.ID]*orchestrationpb.ClientOpts
 	caKey          *ecdsa.PrivateKey
 	ca             *x509.Certificate
+	// the progress of the experiment.
+	checkpoint *Checkpoint
+}
+
+// StepTimeoutError is returned when a worker does not respond within the experiment's StepTimeout.
+type StepTimeoutError struct {
+	Step    string
+	Host    string
+	Timeout time.Duration
+}
+
+func (err *StepTimeoutError) Error() string {
+	return fmt.Sprintf("%s: host %s did not respond within %v", err.Step, err.Host, err.Timeout)
+}
+
+// Unwrap returns context.DeadlineExceeded.
+func (err *StepTimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// stepContext returns a context for a step of the experiment that expires after StepTimeout, plus the extra time
+// that the step is expected to take. If StepTimeout is zero, the context never expires.
+func (e *Experiment) stepContext(extra time.Duration) (context.Context, context.CancelFunc) {
+	if e.StepTimeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), e.StepTimeout+extra)
+}
+
+// hostError returns a StepTimeoutError if err is caused by the step's context expiring
+// while waiting for the worker on the given host. Otherwise, err is returned unchanged.
+func (e *Experiment) hostError(step, host string, err error) error {
+	if err != nil && errors.Is(err, context.DeadlineExceeded) {
+		return &StepTimeoutError{Step: step, Host: host, Timeout: e.StepTimeout}
+	}
+	return err
 }
 
 // Run runs the experiment.
@@ -61,11 +211,40 @@ func (e *Experiment) Run() (err error) {
 		}
 	}()
 
+	if e.CollectProfiles && e.Output == "" {
+		return fmt.Errorf("an output directory is required to collect profiles")
+	}
+
+	if e.MaxConcurrentRPCs < 0 {
+		return fmt.Errorf("invalid maximum number of concurrent RPCs: %d", e.MaxConcurrentRPCs)
+	}
+
+	err = e.validateTimeoutPhases()
+	if err != nil {
+		return err
+	}
+
+	err = e.validateCommitStatus()
+	if err != nil {
+		return err
+	}
+
 	err = e.assignReplicasAndClients()
 	if err != nil {
 		return err
 	}
 
+	if schedule := e.ReplicaOpts.GetLeaderSchedule(); len(schedule) > 0 {
+		leaders := make([]hotstuff.ID, 0, len(schedule))
+		for _, id := range schedule {
+			leaders = append(leaders, hotstuff.ID(id))
+		}
+		err = leaderrotation.ValidateSchedule(leaders, e.NumReplicas)
+		if err != nil {
+			return err
+		}
+	}
+
 	if e.Output != "" {
 		err = e.writeAssignmentsFile()
 		if err != nil {
@@ -78,26 +257,77 @@ func (e *Experiment) Run() (err error) {
 	if err != nil {
 		return fmt.Errorf("failed to create replicas: %w", err)
 	}
-
-	e.Logger.Info("Starting replicas...")
-	err = e.startReplicas(cfg)
+	err = e.newCheckpoint(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to start replicas: %w", err)
+		return err
 	}
 
-	e.Logger.Info("Starting clients...")
-	err = e.startClients(cfg)
-	if err != nil {
-		return fmt.Errorf("failed to start clients: %w", err)
+	return e.runFrom(cfg)
+}
+
+// runFrom runs the steps of the experiment that follow the stage of the checkpoint.
+func (e *Experiment) runFrom(cfg *orchestrationpb.ReplicaConfiguration) (err error) {
+	stage := e.checkpoint.Stage.order()
+
+	if stage < StageReplicasStarted.order() {
+		e.Logger.Info("Starting replicas...")
+		err = e.startReplicas(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to start replicas: %w", err)
+		}
+		err = e.saveCheckpoint(StageReplicasStarted)
+		if err != nil {
+			return err
+		}
 	}
 
-	time.Sleep(e.Duration)
+	if stage < StageClientsStarted.order() {
+		e.Logger.Info("Starting clients...")
+		err = e.startClients(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to start clients: %w", err)
+		}
+		e.checkpoint.ClientsStarted = time.Now()
+		err = e.saveCheckpoint(StageClientsStarted)
+		if err != nil {
+			return err
+		}
+	}
 
-	e.Logger.Info("Stopping clients...")
-	err = e.stopClients()
-	if err != nil {
-		return fmt.Errorf("failed to stop clients: %w", err)
+	if stage < StageClientsStopped.order() {
+		if e.CollectProfiles {
+			e.Logger.Info("Collecting profiles...")
+			err = e.collectProfiles()
+			if err != nil {
+				return fmt.Errorf("failed to collect profiles: %w", err)
+			}
+		} else if e.CommitStatusInterval > 0 {
+			err = e.watchCommits(e.checkpoint.ClientsStarted)
+			if err != nil {
+				return fmt.Errorf("failed to watch commits: %w", err)
+			}
+		} else {
+			err = e.runTimeoutPhases(e.checkpoint.ClientsStarted)
+			if err != nil {
+				return fmt.Errorf("failed to reconfigure timeouts: %w", err)
+			}
+		}
+
+		e.Logger.Info("Stopping clients...")
+		err = e.stopClients()
+		if err != nil {
+			return fmt.Errorf("failed to stop clients: %w", err)
+		}
+		e.checkpoint.Latencies = e.Latencies.Buckets()
+		err = e.saveCheckpoint(StageClientsStopped)
+		if err != nil {
+			return err
+		}
 	}
+	e.Logger.Infof(
+		"Command latency (%d commands): p50: %s, p99: %s, p99.9: %s",
+		e.Latencies.Count(), e.Latency.P50, e.Latency.P99, e.Latency.P999,
+	)
 
 	wait := 5 * e.ReplicaOpts.GetInitialTimeout().AsDuration()
 	e.Logger.Infof("Waiting %s for replicas to finish.", wait)
@@ -110,19 +340,36 @@ func (e *Experiment) Run() (err error) {
 		return fmt.Errorf("failed to stop replicas: %w", err)
 	}
 
-	return nil
+	return e.saveCheckpoint(StageFinished)
 }
 
 func (e *Experiment) createReplicas() (cfg *orchestrationpb.ReplicaConfiguration, err error) {
-	e.caKey, e.ca, err = keygen.GenerateCA()
+	e.caKey, e.ca, err = e.loadOrGenerateCA()
 	if err != nil {
 		return nil, err
 	}
 
 	cfg = &orchestrationpb.ReplicaConfiguration{Replicas: make(map[uint32]*orchestrationpb.ReplicaInfo)}
 
-	for host, worker := range e.Hosts {
-		internalAddr := e.HostConfigs[host].InternalAddress
+	ctx, cancel := e.stepContext(0)
+	defer cancel()
+
+	// if creation fails on any host, the replicas that were already created on other hosts are stopped.
+	var (
+		mut     sync.Mutex
+		created []string
+	)
+	defer func() {
+		if err != nil {
+			err = multierr.Append(err, e.stopCreatedReplicas(created))
+		}
+	}()
+
+	// the keys are generated before any requests are sent.
+	reqs := make(map[string]*orchestrationpb.CreateReplicaRequest, len(e.Hosts))
+	for host := range e.Hosts {
+		hostAddr := hostAddress(host)
+		internalAddr := hostAddress(e.HostConfigs[host].InternalAddress)
 
 		req := &orchestrationpb.CreateReplicaRequest{Replicas: make(map[uint32]*orchestrationpb.ReplicaOpts)}
 		for _, id := range e.hostsToReplicas[host] {
@@ -130,11 +377,11 @@ func (e *Experiment) createReplicas() (cfg *orchestrationpb.ReplicaConfiguration
 			opts.CertificateAuthority = keygen.CertToPEM(e.ca)
 
 			// the generated certificate should be valid for the hostname and its ip addresses.
-			validFor := []string{"localhost", "127.0.0.1", host}
-			ips, err := net.LookupIP(host)
+			validFor := []string{"localhost", "127.0.0.1", "::1", hostAddr}
+			ips, err := net.LookupIP(hostAddr)
 			if err == nil {
 				for _, ip := range ips {
-					if ipStr := ip.String(); ipStr != host && ipStr != internalAddr {
+					if ipStr := ip.String(); ipStr != hostAddr && ipStr != internalAddr {
 						validFor = append(validFor, ipStr)
 					}
 				}
@@ -145,9 +392,9 @@ func (e *Experiment) createReplicas() (cfg *orchestrationpb.ReplicaConfiguration
 				validFor = append(validFor, internalAddr)
 			}
 
-			keyChain, err := keygen.GenerateKeyChain(id, validFor, e.Crypto, e.ca, e.caKey)
+			keyChain, err := e.loadOrGenerateKeyChain(id, validFor)
 			if err != nil {
-				return nil, fmt.Errorf("failed to generate keychain: %w", err)
+				return nil, err
 			}
 
 			opts.PrivateKey = keyChain.PrivateKey
@@ -156,31 +403,178 @@ func (e *Experiment) createReplicas() (cfg *orchestrationpb.ReplicaConfiguration
 			opts.CertificateKey = keyChain.CertificateKey
 			req.Replicas[opts.ID] = opts
 		}
-		wcfg, err := worker.CreateReplica(req)
+		reqs[host] = req
+	}
+
+	err = e.forEachHost(func(host string, worker RemoteWorker) error {
+		wcfg, err := worker.CreateReplica(ctx, reqs[host])
 		if err != nil {
-			return nil, err
+			return e.hostError("create replicas", host, fmt.Errorf("failed to create replicas on host %s: %w", host, err))
 		}
+		mut.Lock()
+		defer mut.Unlock()
+		created = append(created, host)
 
+		internalAddr := hostAddress(e.HostConfigs[host].InternalAddress)
 		for id, replicaCfg := range wcfg.GetReplicas() {
 			if internalAddr != "" {
 				replicaCfg.Address = internalAddr
 			} else {
-				replicaCfg.Address = host
+				replicaCfg.Address = hostAddress(host)
 			}
 			e.Logger.Debugf("Address for replica %d: %s", id, replicaCfg.Address)
 			cfg.Replicas[id] = replicaCfg
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-
 	return cfg, nil
 }
 
+// forEachHost calls f for each host in a separate goroutine, such that at most MaxConcurrentRPCs calls
+// are in progress at the same time, and returns the combined errors of the calls.
+func (e *Experiment) forEachHost(f func(host string, worker RemoteWorker) error) (err error) {
+	limit := e.MaxConcurrentRPCs
+	if limit <= 0 || limit > len(e.Hosts) {
+		limit = len(e.Hosts)
+	}
+	sem := make(chan struct{}, limit)
+	errc := make(chan error)
+	for host, worker := range e.Hosts {
+		go func(host string, worker RemoteWorker) {
+			sem <- struct{}{}
+			err := f(host, worker)
+			<-sem
+			errc <- err
+		}(host, worker)
+	}
+	for range e.Hosts {
+		err = multierr.Append(err, <-errc)
+	}
+	return err
+}
+
+// loadOrGenerateCA reads the certificate authority from KeyOutputDir if it was persisted by a previous run.
+// Otherwise, it generates a new certificate authority and persists it, if KeyOutputDir is set.
+func (e *Experiment) loadOrGenerateCA() (caKey *ecdsa.PrivateKey, ca *x509.Certificate, err error) {
+	if e.KeyOutputDir == "" {
+		return keygen.GenerateCA()
+	}
+
+	certFile := filepath.Join(e.KeyOutputDir, "ca.crt")
+	keyFile := filepath.Join(e.KeyOutputDir, "ca.key")
+
+	if _, err := os.Stat(certFile); err == nil {
+		ca, err = keygen.ReadCertFile(certFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		key, err := keygen.ReadPrivateKeyFile(keyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read CA key: %w", err)
+		}
+		caKey, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, nil, fmt.Errorf("CA key is not an ECDSA key: %T", key)
+		}
+		return caKey, ca, nil
+	}
+
+	caKey, ca, err = keygen.GenerateCA()
+	if err != nil {
+		return nil, nil, err
+	}
+	err = os.MkdirAll(e.KeyOutputDir, 0755)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create key output directory: %w", err)
+	}
+	err = keygen.WriteCertFile(ca, certFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to write CA certificate: %w", err)
+	}
+	err = keygen.WritePrivateKeyFile(caKey, keyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to write CA key: %w", err)
+	}
+	return caKey, ca, nil
+}
+
+// loadOrGenerateKeyChain reads the keychain of the replica from KeyOutputDir if it was persisted by a previous run.
+// Otherwise, it generates a new keychain that is valid for the given hosts, and persists it, if KeyOutputDir is set.
+// Note that a reused certificate is only valid for the hosts it was generated for.
+func (e *Experiment) loadOrGenerateKeyChain(id hotstuff.ID, validFor []string) (keygen.KeyChain, error) {
+	if e.KeyOutputDir != "" {
+		privateKeyFile, _, _, _ := keygen.KeyChainFiles(e.KeyOutputDir, id)
+		if _, err := os.Stat(privateKeyFile); err == nil {
+			return keygen.ReadKeyChain(e.KeyOutputDir, id)
+		}
+	}
+
+	keyChain, err := keygen.GenerateKeyChain(id, validFor, e.Crypto, e.ca, e.caKey)
+	if err != nil {
+		return keygen.KeyChain{}, fmt.Errorf("failed to generate keychain: %w", err)
+	}
+
+	if e.KeyOutputDir != "" {
+		err = keygen.WriteKeyChain(e.KeyOutputDir, id, keyChain)
+		if err != nil {
+			return keygen.KeyChain{}, err
+		}
+	}
+	return keyChain, nil
+}
+
+// stopCreatedReplicas stops the replicas on the given hosts after replica creation failed on another host.
+func (e *Experiment) stopCreatedReplicas(hosts []string) (err error) {
+	ctx, cancel := e.stepContext(0)
+	defer cancel()
+	for _, host := range hosts {
+		req := &orchestrationpb.StopReplicaRequest{IDs: getIDs(host, e.hostsToReplicas)}
+		if _, stopErr := e.Hosts[host].StopReplica(ctx, req); stopErr != nil {
+			stopErr = fmt.Errorf("failed to stop replicas on host %s: %w", host, stopErr)
+			err = multierr.Append(err, e.hostError("stop replicas", host, stopErr))
+		}
+	}
+	return err
+}
+
+// hostAddress returns the host part of addr without brackets or port,
+// such that it can be combined with a port using net.JoinHostPort.
+// The address may be a host name or an IP address, optionally bracketed and followed by a port,
+// e.g. "localhost", "[::1]", or "[::1]:22".
+func hostAddress(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+}
+
 // assignReplicasAndClients assigns replica and client ids to each host,
 // based on the requested amount of replicas/clients and the assignments for each host.
 func (e *Experiment) assignReplicasAndClients() (err error) {
 	e.hostsToReplicas = make(map[string][]hotstuff.ID)
 	e.replicaOpts = make(map[hotstuff.ID]*orchestrationpb.ReplicaOpts)
 	e.hostsToClients = make(map[string][]hotstuff.ID)
+	e.clientOpts = make(map[hotstuff.ID]*orchestrationpb.ClientOpts)
+
+	if len(e.ClientGroups) > 0 {
+		total := 0
+		for _, group := range e.ClientGroups {
+			if group.Count < 0 {
+				return fmt.Errorf("invalid client configuration: group %q has %d clients", group.Name, group.Count)
+			}
+			total += group.Count
+		}
+		if e.NumClients != 0 && e.NumClients != total {
+			return fmt.Errorf(
+				"invalid client configuration: %d clients requested, but client groups specify %d",
+				e.NumClients, total,
+			)
+		}
+		e.NumClients = total
+	}
 
 	nextReplicaID := hotstuff.ID(1)
 	nextClientID := hotstuff.ID(1)
@@ -270,6 +664,9 @@ func (e *Experiment) assignReplicasAndClients() (err error) {
 			replicaOpts := proto.Clone(e.ReplicaOpts).(*orchestrationpb.ReplicaOpts)
 			replicaOpts.ID = uint32(nextReplicaID)
 			replicaOpts.ByzantineStrategy = byzantineStrategy
+			if len(e.TimeoutPhases) > 0 && e.TimeoutPhases[0].Start == 0 {
+				setTimeoutSchedule(replicaOpts, e.timeoutSchedule(e.TimeoutPhases[0]))
+			}
 
 			e.hostsToReplicas[host] = append(e.hostsToReplicas[host], nextReplicaID)
 			e.replicaOpts[nextReplicaID] = replicaOpts
@@ -279,15 +676,157 @@ func (e *Experiment) assignReplicasAndClients() (err error) {
 
 		for i := 0; i < numClients; i++ {
 			e.hostsToClients[host] = append(e.hostsToClients[host], nextClientID)
+			e.clientOpts[nextClientID] = e.newClientOpts(nextClientID)
 			e.Logger.Infof("client %d assigned to host %s", nextClientID, host)
 			nextClientID++
 		}
 	}
 
-	// TODO: warn if not all clients/replicas were assigned
+	// TODO: warn if not all clients were assigned
+	return e.checkReplicaAssignment()
+}
+
+// checkReplicaAssignment checks that each replica ID from 1 to NumReplicas is assigned to exactly one host.
+// IDs may be skipped if the host configuration does not match the hosts, e.g. if it configures a host
+// that is not part of the experiment.
+func (e *Experiment) checkReplicaAssignment() error {
+	assigned := make(map[hotstuff.ID]string)
+	for host, ids := range e.hostsToReplicas {
+		for _, id := range ids {
+			if id < 1 || int(id) > e.NumReplicas {
+				return fmt.Errorf("invalid replica configuration: replica %d assigned to host %s, but only %d replicas requested", id, host, e.NumReplicas)
+			}
+			if other, ok := assigned[id]; ok {
+				return fmt.Errorf("invalid replica configuration: replica %d assigned to both host %s and host %s", id, other, host)
+			}
+			assigned[id] = host
+		}
+	}
+	for id := hotstuff.ID(1); int(id) <= e.NumReplicas; id++ {
+		if _, ok := assigned[id]; !ok {
+			return fmt.Errorf("invalid replica configuration: replica %d is not assigned to any host", id)
+		}
+	}
 	return nil
 }
 
+// newClientOpts returns the options for the client with the given ID.
+// The clients are assigned to the client groups in order of their IDs.
+func (e *Experiment) newClientOpts(id hotstuff.ID) *orchestrationpb.ClientOpts {
+	opts := proto.Clone(e.ClientOpts).(*orchestrationpb.ClientOpts)
+	opts.ID = uint32(id)
+
+	first := hotstuff.ID(1)
+	for _, group := range e.ClientGroups {
+		if id < first+hotstuff.ID(group.Count) {
+			if group.MaxConcurrent != 0 {
+				opts.MaxConcurrent = group.MaxConcurrent
+			}
+			if group.PayloadSize != 0 {
+				opts.PayloadSize = group.PayloadSize
+			}
+			if group.RateLimit != 0 {
+				opts.RateLimit = group.RateLimit
+			}
+			break
+		}
+		first += hotstuff.ID(group.Count)
+	}
+	return opts
+}
+
+// validateTimeoutPhases checks that the timeout phases are ordered and start within the experiment's duration.
+func (e *Experiment) validateTimeoutPhases() error {
+	if len(e.TimeoutPhases) > 0 && e.CollectProfiles {
+		return fmt.Errorf("timeout phases cannot be combined with collecting profiles")
+	}
+	for i, phase := range e.TimeoutPhases {
+		if phase.Start < 0 || phase.Start >= e.Duration {
+			return fmt.Errorf("invalid timeout phase %d: start %v is outside the experiment duration %v", i, phase.Start, e.Duration)
+		}
+		if i > 0 && phase.Start <= e.TimeoutPhases[i-1].Start {
+			return fmt.Errorf("invalid timeout phase %d: start %v is not after the previous phase", i, phase.Start)
+		}
+	}
+	return nil
+}
+
+// timeoutSchedule returns the timeout schedule of the phase, using the experiment's ReplicaOpts for unset settings.
+func (e *Experiment) timeoutSchedule(phase TimeoutPhase) *orchestrationpb.TimeoutSchedule {
+	schedule := &orchestrationpb.TimeoutSchedule{
+		InitialTimeout:    e.ReplicaOpts.GetInitialTimeout(),
+		MaxTimeout:        e.ReplicaOpts.GetMaxTimeout(),
+		TimeoutSamples:    e.ReplicaOpts.GetTimeoutSamples(),
+		TimeoutMultiplier: e.ReplicaOpts.GetTimeoutMultiplier(),
+	}
+	if phase.InitialTimeout != 0 {
+		schedule.InitialTimeout = durationpb.New(phase.InitialTimeout)
+	}
+	if phase.MaxTimeout != 0 {
+		schedule.MaxTimeout = durationpb.New(phase.MaxTimeout)
+	}
+	if phase.TimeoutSamples != 0 {
+		schedule.TimeoutSamples = phase.TimeoutSamples
+	}
+	if phase.TimeoutMultiplier != 0 {
+		schedule.TimeoutMultiplier = phase.TimeoutMultiplier
+	}
+	return schedule
+}
+
+func setTimeoutSchedule(opts *orchestrationpb.ReplicaOpts, schedule *orchestrationpb.TimeoutSchedule) {
+	opts.InitialTimeout = schedule.GetInitialTimeout()
+	opts.MaxTimeout = schedule.GetMaxTimeout()
+	opts.TimeoutSamples = schedule.GetTimeoutSamples()
+	opts.TimeoutMultiplier = schedule.GetTimeoutMultiplier()
+}
+
+// runTimeoutPhases waits until the duration of the experiment has passed since the given start time,
+// and reconfigures the timeouts of the replicas when each timeout phase starts.
+// Phases that were already applied according to the checkpoint are skipped.
+func (e *Experiment) runTimeoutPhases(start time.Time) error {
+	for i, phase := range e.TimeoutPhases {
+		if phase.Start == 0 || (e.checkpoint != nil && i < e.checkpoint.TimeoutPhasesApplied) {
+			// the replicas were created with the timeouts of the first phase.
+			continue
+		}
+		if err := e.sleepUntil(start.Add(phase.Start)); err != nil {
+			return err
+		}
+		e.Logger.Infof("Reconfiguring timeouts after %v...", phase.Start)
+		if err := e.reconfigureTimeouts(e.timeoutSchedule(phase)); err != nil {
+			return err
+		}
+		if e.checkpoint != nil {
+			e.checkpoint.TimeoutPhasesApplied = i + 1
+			if err := e.saveCheckpoint(e.checkpoint.Stage); err != nil {
+				return err
+			}
+		}
+	}
+	return e.sleepUntil(start.Add(e.Duration))
+}
+
+func (e *Experiment) reconfigureTimeouts(schedule *orchestrationpb.TimeoutSchedule) (err error) {
+	ctx, cancel := e.stepContext(0)
+	defer cancel()
+	errc := make(chan error)
+	for host, worker := range e.Hosts {
+		go func(host string, worker RemoteWorker) {
+			req := &orchestrationpb.ReconfigureTimeoutsRequest{
+				IDs:      getIDs(host, e.hostsToReplicas),
+				Schedule: schedule,
+			}
+			_, err := worker.ReconfigureTimeouts(ctx, req)
+			errc <- e.hostError("reconfigure timeouts", host, err)
+		}(host, worker)
+	}
+	for range e.Hosts {
+		err = multierr.Append(err, <-errc)
+	}
+	return err
+}
+
 type assignmentsFileContents struct {
 	// the host associated with each replica.
 	HostsToReplicas map[string][]hotstuff.ID
@@ -314,34 +853,52 @@ func (e *Experiment) writeAssignmentsFile() (err error) {
 }
 
 func (e *Experiment) startReplicas(cfg *orchestrationpb.ReplicaConfiguration) (err error) {
-	errors := make(chan error)
-	for host, worker := range e.Hosts {
-		go func(host string, worker RemoteWorker) {
-			req := &orchestrationpb.StartReplicaRequest{
-				Configuration: cfg.GetReplicas(),
-				IDs:           getIDs(host, e.hostsToReplicas),
-			}
-			_, err := worker.StartReplica(req)
-			errors <- err
-		}(host, worker)
-	}
-	for range e.Hosts {
-		err = multierr.Append(err, <-errors)
-	}
-	return err
+	ctx, cancel := e.stepContext(0)
+	defer cancel()
+	return e.forEachHost(func(host string, worker RemoteWorker) error {
+		req := &orchestrationpb.StartReplicaRequest{
+			Configuration: cfg.GetReplicas(),
+			IDs:           getIDs(host, e.hostsToReplicas),
+		}
+		_, err := worker.StartReplica(ctx, req)
+		return e.hostError("start replicas", host, err)
+	})
 }
 
 func (e *Experiment) stopReplicas() error {
 	hashes := make(map[uint32][]byte)
-	for host, worker := range e.Hosts {
+	e.ResourceUsage = make(map[hotstuff.ID][]profiling.ResourceSample)
+	ctx, cancel := e.stepContext(0)
+	defer cancel()
+	var mut sync.Mutex
+	err := e.forEachHost(func(host string, worker RemoteWorker) error {
 		req := &orchestrationpb.StopReplicaRequest{IDs: getIDs(host, e.hostsToReplicas)}
-		res, err := worker.StopReplica(req)
+		res, err := worker.StopReplica(ctx, req)
 		if err != nil {
-			return err
+			return e.hostError("stop replicas", host, err)
 		}
+		mut.Lock()
+		defer mut.Unlock()
 		for id, hash := range res.GetHashes() {
 			hashes[id] = hash
 		}
+		for id, usage := range res.GetResourceUsage() {
+			e.ResourceUsage[hotstuff.ID(id)] = resourceUsageFromProto(usage)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(e.ResourceUsage) > 0 && e.Output != "" {
+		if err := e.writeResourceUsageFile(); err != nil {
+			return fmt.Errorf("failed to write resource usage: %w", err)
+		}
+	}
+	if e.ReplicaOpts.GetRecordCommittedBlocks() {
+		if err := e.collectCommittedBlocks(); err != nil {
+			return fmt.Errorf("failed to collect committed blocks: %w", err)
+		}
 	}
 	var cmp []byte
 	for _, hash := range hashes {
@@ -349,48 +906,127 @@ func (e *Experiment) stopReplicas() error {
 			cmp = hash
 		}
 		if !bytes.Equal(cmp, hash) {
+			if err := CompareCommittedBlocks(e.CommittedBlocks); err != nil {
+				return fmt.Errorf("hash mismatch: %w", err)
+			}
 			return fmt.Errorf("hash mismatch")
 		}
 	}
 	return nil
 }
 
+func resourceUsageFromProto(usage *orchestrationpb.ResourceUsage) []profiling.ResourceSample {
+	samples := make([]profiling.ResourceSample, 0, len(usage.GetSamples()))
+	for _, sample := range usage.GetSamples() {
+		samples = append(samples, profiling.ResourceSample{
+			Elapsed:      sample.GetElapsed().AsDuration(),
+			CPUTime:      sample.GetCPUTime().AsDuration(),
+			HeapAlloc:    sample.GetHeapAlloc(),
+			Sys:          sample.GetSys(),
+			NumGoroutine: int(sample.GetNumGoroutine()),
+		})
+	}
+	return samples
+}
+
+func (e *Experiment) writeResourceUsageFile() (err error) {
+	f, err := os.OpenFile(filepath.Join(e.Output, "resource-usage.json"), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "\t")
+	return enc.Encode(e.ResourceUsage)
+}
+
 func (e *Experiment) startClients(cfg *orchestrationpb.ReplicaConfiguration) error {
+	ctx, cancel := e.stepContext(0)
+	defer cancel()
 	for host, worker := range e.Hosts {
 		req := &orchestrationpb.StartClientRequest{}
 		req.Clients = make(map[uint32]*orchestrationpb.ClientOpts)
 		req.Configuration = cfg.GetReplicas()
 		req.CertificateAuthority = keygen.CertToPEM(e.ca)
 		for _, id := range e.hostsToClients[host] {
-			clientOpts := proto.Clone(e.ClientOpts).(*orchestrationpb.ClientOpts)
-			clientOpts.ID = uint32(id)
-			req.Clients[uint32(id)] = clientOpts
+			req.Clients[uint32(id)] = e.clientOpts[id]
 		}
-		_, err := worker.StartClient(req)
+		_, err := worker.StartClient(ctx, req)
 		if err != nil {
-			return err
+			return e.hostError("start clients", host, err)
 		}
 	}
 	return nil
 }
 
+// stopClients stops the clients and merges the latencies they measured.
 func (e *Experiment) stopClients() error {
+	e.Latencies = &metrics.LatencyHistogram{}
+	ctx, cancel := e.stepContext(0)
+	defer cancel()
 	for host, worker := range e.Hosts {
 		req := &orchestrationpb.StopClientRequest{}
 		req.IDs = getIDs(host, e.hostsToClients)
-		_, err := worker.StopClient(req)
+		res, err := worker.StopClient(ctx, req)
 		if err != nil {
-			return err
+			return e.hostError("stop clients", host, err)
 		}
+		e.Latencies.Merge(metrics.LatencyHistogramFromBuckets(res.GetLatencies().GetBuckets()))
 	}
+	e.Latency = NewLatencyPercentiles(e.Latencies)
 	return nil
 }
 
+// collectProfiles collects CPU profiles for the duration of the experiment from the workers that run replicas,
+// followed by heap profiles, and writes them to a file for each replica.
+func (e *Experiment) collectProfiles() (err error) {
+	ctx, cancel := e.stepContext(e.Duration)
+	defer cancel()
+	errc := make(chan error)
+	hosts := 0
+	for host, worker := range e.Hosts {
+		ids := e.hostsToReplicas[host]
+		if len(ids) == 0 {
+			continue
+		}
+		hosts++
+		go func(host string, worker RemoteWorker, ids []hotstuff.ID) {
+			res, err := worker.Profile(ctx, &orchestrationpb.ProfileRequest{CPUDuration: durationpb.New(e.Duration)})
+			if err != nil {
+				errc <- e.hostError("collect profiles", host, fmt.Errorf("host %s: %w", host, err))
+				return
+			}
+			var werr error
+			for _, id := range ids {
+				werr = multierr.Append(werr, e.writeProfile(id, "cpu", res.GetCPUProfile()))
+				werr = multierr.Append(werr, e.writeProfile(id, "heap", res.GetHeapProfile()))
+			}
+			errc <- werr
+		}(host, worker, ids)
+	}
+	for i := 0; i < hosts; i++ {
+		err = multierr.Append(err, <-errc)
+	}
+	return err
+}
+
+// writeProfile writes a profile of the given kind for the replica to the output directory.
+func (e *Experiment) writeProfile(id hotstuff.ID, kind string, profile []byte) error {
+	path := filepath.Join(e.Output, fmt.Sprintf("replica-%d.%s.pprof", id, kind))
+	return os.WriteFile(path, profile, 0644)
+}
+
 func (e *Experiment) quit() error {
-	for _, worker := range e.Hosts {
-		err := worker.Quit()
+	ctx, cancel := e.stepContext(0)
+	defer cancel()
+	for host, worker := range e.Hosts {
+		err := worker.Quit(ctx)
 		if err != nil {
-			return err
+			return e.hostError("quit", host, err)
 		}
 	}
 	return nil
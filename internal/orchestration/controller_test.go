@@ -0,0 +1,889 @@
+package orchestration
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/crypto/keygen"
+	"github.com/relab/hotstuff/internal/profiling"
+	"github.com/relab/hotstuff/internal/proto/orchestrationpb"
+	"github.com/relab/hotstuff/internal/protostream"
+	"github.com/relab/hotstuff/logging"
+	"github.com/relab/hotstuff/metrics"
+	"github.com/relab/hotstuff/replica"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// fakeWorker records the requests sent to a worker, and fails to create replicas if fail is set.
+// If stall is set, the worker never responds to requests to start replicas.
+type fakeWorker struct {
+	mut     sync.Mutex
+	fail    bool
+	stall   bool
+	created []uint32
+	stopped []uint32
+	clients []*orchestrationpb.ClientOpts
+	// the latencies reported when the clients are stopped.
+	latencies []time.Duration
+	// the durations of the requested CPU profiles.
+	profiled []time.Duration
+	// the resource usage reported when the replicas are stopped.
+	resourceUsage map[uint32]*orchestrationpb.ResourceUsage
+	// the requests to reconfigure the timeouts of the replicas.
+	reconfigured []*orchestrationpb.ReconfigureTimeoutsRequest
+	// the commit heights pushed in response to a request to watch commits, one update per height.
+	commitHeights []uint64
+	// the blocks committed by each replica, returned at most two at a time.
+	committed map[uint32][]*orchestrationpb.CommittedBlock
+	// if set, the requests to create, start, and stop replicas take delay to handle, and are counted by rpcs.
+	rpcs  *rpcCounter
+	delay time.Duration
+}
+
+// rpcCounter records the largest number of requests that are handled by fake workers at the same time.
+type rpcCounter struct {
+	mut      sync.Mutex
+	inFlight int
+	max      int
+}
+
+func (c *rpcCounter) handle(delay time.Duration) {
+	c.mut.Lock()
+	c.inFlight++
+	if c.inFlight > c.max {
+		c.max = c.inFlight
+	}
+	c.mut.Unlock()
+	time.Sleep(delay)
+	c.mut.Lock()
+	c.inFlight--
+	c.mut.Unlock()
+}
+
+func (w *fakeWorker) serve(t *testing.T, conn net.Conn) {
+	send := protostream.NewWriter(conn)
+	recv := protostream.NewReader(conn)
+	for {
+		msg, err := recv.ReadAny()
+		if err != nil {
+			return
+		}
+		var res proto.Message
+		w.mut.Lock()
+		switch msg.(type) {
+		case *orchestrationpb.CreateReplicaRequest, *orchestrationpb.StartReplicaRequest, *orchestrationpb.StopReplicaRequest:
+			if w.rpcs != nil {
+				w.rpcs.handle(w.delay)
+			}
+		}
+		switch req := msg.(type) {
+		case *orchestrationpb.CreateReplicaRequest:
+			if w.fail {
+				res = status.New(codes.Internal, "failed to create replica").Proto()
+				break
+			}
+			resp := &orchestrationpb.CreateReplicaResponse{Replicas: make(map[uint32]*orchestrationpb.ReplicaInfo)}
+			for id := range req.GetReplicas() {
+				w.created = append(w.created, id)
+				resp.Replicas[id] = &orchestrationpb.ReplicaInfo{ID: id}
+			}
+			res = resp
+		case *orchestrationpb.StartReplicaRequest:
+			if w.stall {
+				w.mut.Unlock()
+				continue
+			}
+			res = &orchestrationpb.StartReplicaResponse{}
+		case *orchestrationpb.StartClientRequest:
+			for _, opts := range req.GetClients() {
+				w.clients = append(w.clients, opts)
+			}
+			res = &orchestrationpb.StartClientResponse{}
+		case *orchestrationpb.StopClientRequest:
+			h := &metrics.LatencyHistogram{}
+			for _, latency := range w.latencies {
+				h.Record(latency)
+			}
+			res = &orchestrationpb.StopClientResponse{
+				Latencies: &orchestrationpb.LatencyHistogram{Buckets: h.Buckets()},
+			}
+		case *orchestrationpb.StopReplicaRequest:
+			w.stopped = append(w.stopped, req.GetIDs()...)
+			res = &orchestrationpb.StopReplicaResponse{ResourceUsage: w.resourceUsage}
+		case *orchestrationpb.ProfileRequest:
+			w.profiled = append(w.profiled, req.GetCPUDuration().AsDuration())
+			res = &orchestrationpb.ProfileResponse{CPUProfile: []byte("cpu"), HeapProfile: []byte("heap")}
+		case *orchestrationpb.ReconfigureTimeoutsRequest:
+			w.reconfigured = append(w.reconfigured, req)
+			res = &orchestrationpb.ReconfigureTimeoutsResponse{}
+		case *orchestrationpb.CommittedBlocksRequest:
+			blocks := w.committed[req.GetID()]
+			start := int(req.GetStart())
+			end := start + 2
+			if end > len(blocks) {
+				end = len(blocks)
+			}
+			res = &orchestrationpb.CommittedBlocksResponse{Blocks: blocks[start:end], Total: uint64(len(blocks))}
+		case *orchestrationpb.WatchCommitsRequest:
+			for i, height := range w.commitHeights {
+				update := &orchestrationpb.CommitStatus{
+					Elapsed: durationpb.New(time.Duration(i+1) * req.GetInterval().AsDuration()),
+					Heights: make(map[uint32]uint64),
+				}
+				for _, id := range req.GetIDs() {
+					update.Heights[id] = height
+				}
+				if err := send.WriteAny(update); err != nil {
+					w.mut.Unlock()
+					return
+				}
+			}
+			res = &orchestrationpb.WatchCommitsResponse{}
+		case *orchestrationpb.QuitRequest:
+			w.mut.Unlock()
+			return
+		default:
+			t.Errorf("unexpected request: %T", msg)
+			res = status.New(codes.Unimplemented, "unexpected request").Proto()
+		}
+		w.mut.Unlock()
+		if err := send.WriteAny(res); err != nil {
+			return
+		}
+	}
+}
+
+// newFakeWorkers adds hosts that are served by fake workers to the experiment, and returns the workers.
+func newFakeWorkers(t *testing.T, e *Experiment, hosts ...string) map[string]*fakeWorker {
+	workers := make(map[string]*fakeWorker)
+	for _, host := range hosts {
+		controllerConn, workerConn := net.Pipe()
+		t.Cleanup(func() { controllerConn.Close() })
+		workers[host] = &fakeWorker{}
+		go workers[host].serve(t, workerConn)
+		e.Hosts[host] = NewRemoteWorker(protostream.NewWriter(controllerConn), protostream.NewReader(controllerConn))
+	}
+	return workers
+}
+
+// createReplicasWithFailure creates replicas on three hosts, where creation fails on host2.
+// It returns the fake workers and the error from createReplicas.
+func createReplicasWithFailure(t *testing.T) (map[string]*fakeWorker, error) {
+	e := &Experiment{
+		ReplicaOpts:     &orchestrationpb.ReplicaOpts{Crypto: "ecdsa"},
+		Logger:          logging.New("ctrl"),
+		Hosts:           make(map[string]RemoteWorker),
+		HostConfigs:     make(map[string]HostConfig),
+		hostsToReplicas: make(map[string][]hotstuff.ID),
+		replicaOpts:     make(map[hotstuff.ID]*orchestrationpb.ReplicaOpts),
+	}
+	workers := newFakeWorkers(t, e, "host1", "host2", "host3")
+	workers["host2"].fail = true
+	for i, host := range []string{"host1", "host2", "host3"} {
+		id := hotstuff.ID(i + 1)
+		e.hostsToReplicas[host] = []hotstuff.ID{id}
+		e.replicaOpts[id] = &orchestrationpb.ReplicaOpts{ID: uint32(id)}
+	}
+
+	_, err := e.createReplicas()
+	return workers, err
+}
+
+func TestCreateReplicasFailure(t *testing.T) {
+	stops := 0
+	// the hosts are visited in random order, so we try until some replicas were created before the failure.
+	for i := 0; i < 20 && stops == 0; i++ {
+		workers, err := createReplicasWithFailure(t)
+		if err == nil {
+			t.Fatal("expected replica creation to fail")
+		}
+		if !strings.Contains(err.Error(), "host2") {
+			t.Errorf("expected the error to name the failing host, got: %v", err)
+		}
+
+		for host, w := range workers {
+			w.mut.Lock()
+			if !reflect.DeepEqual(w.created, w.stopped) {
+				t.Errorf("%s: created replicas %v, but stopped %v", host, w.created, w.stopped)
+			}
+			stops += len(w.stopped)
+			w.mut.Unlock()
+		}
+	}
+	if stops == 0 {
+		t.Error("no replicas were created before the failure")
+	}
+}
+
+func TestMaxConcurrentRPCs(t *testing.T) {
+	const (
+		numHosts = 20
+		limit    = 3
+	)
+	e := &Experiment{
+		ReplicaOpts:       &orchestrationpb.ReplicaOpts{Crypto: "ecdsa"},
+		Logger:            logging.New("ctrl"),
+		Hosts:             make(map[string]RemoteWorker),
+		HostConfigs:       make(map[string]HostConfig),
+		MaxConcurrentRPCs: limit,
+		hostsToReplicas:   make(map[string][]hotstuff.ID),
+		replicaOpts:       make(map[hotstuff.ID]*orchestrationpb.ReplicaOpts),
+	}
+	hosts := make([]string, numHosts)
+	for i := range hosts {
+		hosts[i] = fmt.Sprintf("host%d", i+1)
+		id := hotstuff.ID(i + 1)
+		e.hostsToReplicas[hosts[i]] = []hotstuff.ID{id}
+		e.replicaOpts[id] = &orchestrationpb.ReplicaOpts{ID: uint32(id)}
+	}
+	workers := newFakeWorkers(t, e, hosts...)
+
+	steps := []struct {
+		name string
+		run  func() error
+	}{
+		{"create", func() (err error) {
+			_, err = e.createReplicas()
+			return err
+		}},
+		{"start", func() error { return e.startReplicas(&orchestrationpb.ReplicaConfiguration{}) }},
+		{"stop", e.stopReplicas},
+	}
+	for _, step := range steps {
+		rpcs := &rpcCounter{}
+		for _, w := range workers {
+			w.mut.Lock()
+			w.rpcs, w.delay = rpcs, 10*time.Millisecond
+			w.mut.Unlock()
+		}
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if rpcs.max > limit {
+			t.Errorf("%s: %d requests were in flight at the same time, want at most %d", step.name, rpcs.max, limit)
+		}
+		if rpcs.max < 2 {
+			t.Errorf("%s: at most %d request was in flight at a time, want the requests to be sent concurrently", step.name, rpcs.max)
+		}
+	}
+	for host, w := range workers {
+		if len(w.created) != 1 || len(w.stopped) != 1 {
+			t.Errorf("%s: created %v and stopped %v, want one replica each", host, w.created, w.stopped)
+		}
+	}
+}
+
+func TestReplicaAssignment(t *testing.T) {
+	newExperiment := func(hostConfigs map[string]HostConfig) *Experiment {
+		e := &Experiment{
+			ReplicaOpts: &orchestrationpb.ReplicaOpts{},
+			ClientOpts:  &orchestrationpb.ClientOpts{},
+			Logger:      logging.New("ctrl"),
+			Hosts:       make(map[string]RemoteWorker),
+			HostConfigs: hostConfigs,
+			NumReplicas: 4,
+		}
+		newFakeWorkers(t, e, "host1", "host2")
+		return e
+	}
+
+	e := newExperiment(map[string]HostConfig{"host1": {Replicas: 1}})
+	if err := e.assignReplicasAndClients(); err != nil {
+		t.Fatal(err)
+	}
+	if len(e.hostsToReplicas["host1"]) != 1 || len(e.hostsToReplicas["host2"]) != 3 {
+		t.Errorf("got assignments %v, want one replica on host1 and three on host2", e.hostsToReplicas)
+	}
+
+	// host3 is not part of the experiment, so its replicas are never assigned, and host2 is not auto configured.
+	e = newExperiment(map[string]HostConfig{"host1": {Replicas: 1}, "host3": {Replicas: 3}})
+	err := e.assignReplicasAndClients()
+	if err == nil || !strings.Contains(err.Error(), "replica 2 is not assigned") {
+		t.Errorf("got error %v, want an error for the skipped replica 2", err)
+	}
+
+	// the check also catches duplicates that a faulty assignment could produce.
+	e.hostsToReplicas = map[string][]hotstuff.ID{"host1": {1, 2}, "host2": {2, 3, 4}}
+	if err := e.checkReplicaAssignment(); err == nil || !strings.Contains(err.Error(), "replica 2 assigned to both") {
+		t.Errorf("got error %v, want an error for the duplicated replica 2", err)
+	}
+}
+
+func TestClientGroups(t *testing.T) {
+	e := &Experiment{
+		ReplicaOpts: &orchestrationpb.ReplicaOpts{},
+		ClientOpts:  &orchestrationpb.ClientOpts{MaxConcurrent: 250, PayloadSize: 100, RateLimit: 1000},
+		Logger:      logging.New("ctrl"),
+		Hosts:       make(map[string]RemoteWorker),
+		ClientGroups: []ClientGroup{
+			{Name: "readers", Count: 3, MaxConcurrent: 100, PayloadSize: 16},
+			{Name: "writers", Count: 2, PayloadSize: 4096},
+		},
+	}
+	workers := newFakeWorkers(t, e, "host1", "host2")
+
+	var err error
+	e.caKey, e.ca, err = keygen.GenerateCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.assignReplicasAndClients(); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.startClients(&orchestrationpb.ReplicaConfiguration{}); err != nil {
+		t.Fatal(err)
+	}
+
+	clients := make(map[uint32]*orchestrationpb.ClientOpts)
+	for _, w := range workers {
+		for _, opts := range w.clients {
+			clients[opts.GetID()] = opts
+		}
+	}
+	if len(clients) != 5 {
+		t.Fatalf("got %d clients, want 5", len(clients))
+	}
+	for id, opts := range clients {
+		want := &orchestrationpb.ClientOpts{ID: id, MaxConcurrent: 100, PayloadSize: 16, RateLimit: 1000}
+		if id > 3 {
+			want = &orchestrationpb.ClientOpts{ID: id, MaxConcurrent: 250, PayloadSize: 4096, RateLimit: 1000}
+		}
+		if !proto.Equal(opts, want) {
+			t.Errorf("client %d: got options %v, want %v", id, opts, want)
+		}
+	}
+
+	e.NumClients = 4
+	if err := e.assignReplicasAndClients(); err == nil {
+		t.Error("expected an error when the number of clients does not match the client groups")
+	}
+}
+
+func TestKeyOutputDir(t *testing.T) {
+	dir := t.TempDir()
+
+	// createReplicasWithKeys creates replicas with keys persisted to dir, and returns the options of each replica.
+	createReplicasWithKeys := func() map[hotstuff.ID]*orchestrationpb.ReplicaOpts {
+		e := &Experiment{
+			ReplicaOpts:     &orchestrationpb.ReplicaOpts{Crypto: "ecdsa"},
+			Logger:          logging.New("ctrl"),
+			Hosts:           make(map[string]RemoteWorker),
+			HostConfigs:     make(map[string]HostConfig),
+			KeyOutputDir:    dir,
+			hostsToReplicas: map[string][]hotstuff.ID{"host1": {1, 2}},
+			replicaOpts: map[hotstuff.ID]*orchestrationpb.ReplicaOpts{
+				1: {ID: 1},
+				2: {ID: 2},
+			},
+		}
+		newFakeWorkers(t, e, "host1")
+		if _, err := e.createReplicas(); err != nil {
+			t.Fatal(err)
+		}
+		return e.replicaOpts
+	}
+
+	first := createReplicasWithKeys()
+	for id := range first {
+		keyChain, err := keygen.ReadKeyChain(dir, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(keyChain.PrivateKey, first[id].GetPrivateKey()) {
+			t.Errorf("replica %d: persisted private key differs from the one sent to the worker", id)
+		}
+	}
+
+	second := createReplicasWithKeys()
+	for id, opts := range second {
+		if !proto.Equal(opts, first[id]) {
+			t.Errorf("replica %d: keys were not reused from the key output directory", id)
+		}
+	}
+}
+
+func TestClientLatencyPercentiles(t *testing.T) {
+	e := &Experiment{
+		Logger: logging.New("ctrl"),
+		Hosts:  make(map[string]RemoteWorker),
+	}
+	workers := newFakeWorkers(t, e, "host1", "host2")
+
+	// host1 reports latencies of 1-1000 ms, and host2 reports latencies of 1001-2000 ms,
+	// such that the merged latencies are uniformly distributed between 1 and 2000 ms.
+	for i := 1; i <= 1000; i++ {
+		workers["host1"].latencies = append(workers["host1"].latencies, time.Duration(i)*time.Millisecond)
+		workers["host2"].latencies = append(workers["host2"].latencies, time.Duration(1000+i)*time.Millisecond)
+	}
+
+	if err := e.stopClients(); err != nil {
+		t.Fatal(err)
+	}
+	if e.Latencies.Count() != 2000 {
+		t.Errorf("got %d latencies, want 2000", e.Latencies.Count())
+	}
+
+	want := LatencyPercentiles{P50: 1000 * time.Millisecond, P99: 1980 * time.Millisecond, P999: 1998 * time.Millisecond}
+	for _, p := range []struct {
+		name      string
+		got, want time.Duration
+	}{
+		{"p50", e.Latency.P50, want.P50},
+		{"p99", e.Latency.P99, want.P99},
+		{"p99.9", e.Latency.P999, want.P999},
+	} {
+		// the histogram is accurate to within 1%.
+		if diff := p.got - p.want; diff < -p.want/100 || diff > p.want/100 {
+			t.Errorf("%s: got %v, want %v", p.name, p.got, p.want)
+		}
+	}
+}
+
+func TestCollectProfiles(t *testing.T) {
+	dir := t.TempDir()
+	e := &Experiment{
+		Logger:          logging.New("ctrl"),
+		Duration:        time.Second,
+		Output:          dir,
+		CollectProfiles: true,
+		Hosts:           make(map[string]RemoteWorker),
+		hostsToReplicas: map[string][]hotstuff.ID{"host1": {1, 2}, "host2": {3}},
+	}
+	workers := newFakeWorkers(t, e, "host1", "host2", "clients")
+
+	if err := e.collectProfiles(); err != nil {
+		t.Fatal(err)
+	}
+
+	for host, w := range workers {
+		w.mut.Lock()
+		want := []time.Duration{e.Duration}
+		if host == "clients" {
+			want = nil
+		}
+		if !reflect.DeepEqual(w.profiled, want) {
+			t.Errorf("%s: got profile requests %v, want %v", host, w.profiled, want)
+		}
+		w.mut.Unlock()
+	}
+
+	for _, id := range []hotstuff.ID{1, 2, 3} {
+		for _, kind := range []string{"cpu", "heap"} {
+			b, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("replica-%d.%s.pprof", id, kind)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != kind {
+				t.Errorf("replica %d: got %s profile %q, want %q", id, kind, b, kind)
+			}
+		}
+	}
+}
+
+func TestResourceUsage(t *testing.T) {
+	dir := t.TempDir()
+	e := &Experiment{
+		Logger:          logging.New("ctrl"),
+		Output:          dir,
+		Hosts:           make(map[string]RemoteWorker),
+		hostsToReplicas: map[string][]hotstuff.ID{"host1": {1}, "host2": {2}},
+	}
+	workers := newFakeWorkers(t, e, "host1", "host2")
+	sample := func(elapsed time.Duration, heap uint64) *orchestrationpb.ResourceSample {
+		return &orchestrationpb.ResourceSample{
+			Elapsed:   durationpb.New(elapsed),
+			CPUTime:   durationpb.New(elapsed / 2),
+			HeapAlloc: heap,
+		}
+	}
+	workers["host1"].resourceUsage = map[uint32]*orchestrationpb.ResourceUsage{
+		1: {Samples: []*orchestrationpb.ResourceSample{sample(0, 100), sample(time.Second, 200)}},
+	}
+	workers["host2"].resourceUsage = map[uint32]*orchestrationpb.ResourceUsage{
+		2: {Samples: []*orchestrationpb.ResourceSample{sample(0, 300)}},
+	}
+
+	if err := e.stopReplicas(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[hotstuff.ID][]profiling.ResourceSample{
+		1: {{Elapsed: 0, CPUTime: 0, HeapAlloc: 100}, {Elapsed: time.Second, CPUTime: time.Second / 2, HeapAlloc: 200}},
+		2: {{Elapsed: 0, CPUTime: 0, HeapAlloc: 300}},
+	}
+	if !reflect.DeepEqual(e.ResourceUsage, want) {
+		t.Errorf("got resource usage %v, want %v", e.ResourceUsage, want)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "resource-usage.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written map[hotstuff.ID][]profiling.ResourceSample
+	if err := json.Unmarshal(b, &written); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(written, want) {
+		t.Errorf("got resource usage file %v, want %v", written, want)
+	}
+}
+
+func TestStepTimeout(t *testing.T) {
+	e := &Experiment{
+		Logger:          logging.New("ctrl"),
+		Hosts:           make(map[string]RemoteWorker),
+		StepTimeout:     50 * time.Millisecond,
+		hostsToReplicas: map[string][]hotstuff.ID{"host1": {1}, "host2": {2}},
+	}
+	workers := newFakeWorkers(t, e, "host1", "host2")
+	workers["host2"].stall = true
+
+	done := make(chan error)
+	go func() { done <- e.startReplicas(&orchestrationpb.ReplicaConfiguration{}) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("startReplicas did not return after the step timeout")
+	}
+	var timeoutErr *StepTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("got error %v, want a step timeout error", err)
+	}
+	if timeoutErr.Step != "start replicas" || timeoutErr.Host != "host2" {
+		t.Errorf("got timeout in step %q on host %s, want step %q on host2", timeoutErr.Step, timeoutErr.Host, "start replicas")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v to wrap %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestTimeoutPhases(t *testing.T) {
+	e := &Experiment{
+		ReplicaOpts: &orchestrationpb.ReplicaOpts{
+			InitialTimeout:    durationpb.New(500 * time.Millisecond),
+			MaxTimeout:        durationpb.New(time.Second),
+			TimeoutSamples:    1000,
+			TimeoutMultiplier: 1.2,
+		},
+		ClientOpts:  &orchestrationpb.ClientOpts{},
+		Logger:      logging.New("ctrl"),
+		Hosts:       make(map[string]RemoteWorker),
+		NumReplicas: 4,
+		Duration:    100 * time.Millisecond,
+		TimeoutPhases: []TimeoutPhase{
+			{Start: 0, InitialTimeout: 100 * time.Millisecond, TimeoutMultiplier: 2},
+			{Start: 50 * time.Millisecond, MaxTimeout: 5 * time.Second, TimeoutSamples: 10},
+		},
+	}
+	workers := newFakeWorkers(t, e, "host1", "host2")
+
+	if err := e.validateTimeoutPhases(); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.assignReplicasAndClients(); err != nil {
+		t.Fatal(err)
+	}
+	// the replicas are created with the timeouts of the first phase.
+	for id, opts := range e.replicaOpts {
+		if opts.GetInitialTimeout().AsDuration() != 100*time.Millisecond || opts.GetMaxTimeout().AsDuration() != time.Second ||
+			opts.GetTimeoutSamples() != 1000 || opts.GetTimeoutMultiplier() != 2 {
+			t.Errorf("replica %d: got timeouts %v, want the timeouts of the first phase", id, opts)
+		}
+	}
+
+	start := time.Now()
+	if err := e.runTimeoutPhases(start); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < e.Duration {
+		t.Errorf("timeout phases ended after %v, want at least %v", elapsed, e.Duration)
+	}
+
+	want := &orchestrationpb.TimeoutSchedule{
+		InitialTimeout:    durationpb.New(500 * time.Millisecond),
+		MaxTimeout:        durationpb.New(5 * time.Second),
+		TimeoutSamples:    10,
+		TimeoutMultiplier: 1.2,
+	}
+	for host, w := range workers {
+		if len(w.reconfigured) != 1 {
+			t.Fatalf("%s: got %d reconfigurations, want 1", host, len(w.reconfigured))
+		}
+		req := w.reconfigured[0]
+		if !reflect.DeepEqual(req.GetIDs(), getIDs(host, e.hostsToReplicas)) {
+			t.Errorf("%s: reconfigured replicas %v, want %v", host, req.GetIDs(), getIDs(host, e.hostsToReplicas))
+		}
+		if !proto.Equal(req.GetSchedule(), want) {
+			t.Errorf("%s: got schedule %v, want %v", host, req.GetSchedule(), want)
+		}
+	}
+
+	e.CollectProfiles = true
+	if err := e.validateTimeoutPhases(); err == nil {
+		t.Error("expected an error when combining timeout phases with profiles")
+	}
+	e.CollectProfiles = false
+	e.TimeoutPhases[1].Start = 0
+	if err := e.validateTimeoutPhases(); err == nil {
+		t.Error("expected an error when the timeout phases are not ordered")
+	}
+}
+
+func TestWatchCommits(t *testing.T) {
+	var got []CommitStatus
+	e := &Experiment{
+		ReplicaOpts:          &orchestrationpb.ReplicaOpts{},
+		ClientOpts:           &orchestrationpb.ClientOpts{},
+		Logger:               logging.New("ctrl"),
+		Hosts:                make(map[string]RemoteWorker),
+		NumReplicas:          4,
+		Duration:             50 * time.Millisecond,
+		CommitStatusInterval: 10 * time.Millisecond,
+		OnCommitStatus:       func(status CommitStatus) { got = append(got, status) },
+	}
+	workers := newFakeWorkers(t, e, "host1", "host2")
+	heights := []uint64{1, 2, 4, 7}
+	for _, w := range workers {
+		w.commitHeights = heights
+	}
+
+	if err := e.validateCommitStatus(); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.assignReplicasAndClients(); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := e.watchCommits(start); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < e.Duration {
+		t.Errorf("watching commits ended after %v, want at least %v", elapsed, e.Duration)
+	}
+
+	byHost := make(map[string][]CommitStatus)
+	for _, status := range got {
+		byHost[status.Host] = append(byHost[status.Host], status)
+	}
+	for host := range workers {
+		updates := byHost[host]
+		if len(updates) != len(heights) {
+			t.Fatalf("%s: got %d updates, want %d", host, len(updates), len(heights))
+		}
+		for i, status := range updates {
+			if want := time.Duration(i+1) * e.CommitStatusInterval; status.Elapsed != want {
+				t.Errorf("%s: update %d: got elapsed %v, want %v", host, i, status.Elapsed, want)
+			}
+			if len(status.Heights) != len(e.hostsToReplicas[host]) {
+				t.Errorf("%s: update %d: got heights of %d replicas, want %d", host, i, len(status.Heights), len(e.hostsToReplicas[host]))
+			}
+			for _, id := range e.hostsToReplicas[host] {
+				if status.Heights[id] != consensus.View(heights[i]) {
+					t.Errorf("%s: update %d: got height %d for replica %d, want %d", host, i, status.Heights[id], id, heights[i])
+				}
+			}
+		}
+	}
+
+	e.CollectProfiles = true
+	if err := e.validateCommitStatus(); err == nil {
+		t.Error("expected an error when combining commit status updates with profiles")
+	}
+	e.CollectProfiles = false
+	e.CommitStatusInterval = -time.Second
+	if err := e.validateCommitStatus(); err == nil {
+		t.Error("expected an error for a negative commit status interval")
+	}
+}
+
+func TestCommittedBlocks(t *testing.T) {
+	e := &Experiment{
+		ReplicaOpts: &orchestrationpb.ReplicaOpts{RecordCommittedBlocks: true},
+		ClientOpts:  &orchestrationpb.ClientOpts{},
+		Logger:      logging.New("ctrl"),
+		Hosts:       make(map[string]RemoteWorker),
+		NumReplicas: 4,
+	}
+	workers := newFakeWorkers(t, e, "host1", "host2")
+	if err := e.assignReplicasAndClients(); err != nil {
+		t.Fatal(err)
+	}
+
+	// chain returns a chain of n blocks, where the command of the block at position diverge is different.
+	chain := func(n, diverge int) (blocks []replica.CommittedBlock) {
+		parent := consensus.GetGenesis().Hash()
+		for i := 0; i < n; i++ {
+			cmd := consensus.Command(fmt.Sprint(i))
+			if i == diverge {
+				cmd = "forged"
+			}
+			block := consensus.NewBlock(parent, consensus.QuorumCert{}, cmd, consensus.View(i+1), hotstuff.ID(i%4+1))
+			blocks = append(blocks, replica.CommittedBlock{
+				Hash:        block.Hash(),
+				Parent:      parent,
+				View:        block.View(),
+				Proposer:    block.Proposer(),
+				CommandHash: sha256.Sum256([]byte(cmd)),
+			})
+			parent = block.Hash()
+		}
+		return blocks
+	}
+	want := map[hotstuff.ID][]replica.CommittedBlock{
+		1: chain(5, -1),
+		2: chain(5, -1),
+		3: chain(5, -1),
+		// replica 4 has committed fewer blocks than the others.
+		4: chain(3, -1),
+	}
+	for host, w := range workers {
+		w.committed = make(map[uint32][]*orchestrationpb.CommittedBlock)
+		for _, id := range e.hostsToReplicas[host] {
+			for _, block := range want[id] {
+				w.committed[uint32(id)] = append(w.committed[uint32(id)], committedBlockToProto(block))
+			}
+		}
+	}
+
+	if err := e.stopReplicas(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(e.CommittedBlocks, want) {
+		t.Errorf("got committed blocks %v, want %v", e.CommittedBlocks, want)
+	}
+	if err := CompareCommittedBlocks(e.CommittedBlocks); err != nil {
+		t.Errorf("unexpected difference between the committed blocks: %v", err)
+	}
+
+	e.CommittedBlocks[3] = chain(5, 2)
+	err := CompareCommittedBlocks(e.CommittedBlocks)
+	if err == nil || !strings.HasPrefix(err.Error(), "position 2: replica 1") || !strings.Contains(err.Error(), "replica 3") {
+		t.Errorf("got error %v, want a difference between replicas 1 and 3 at position 2", err)
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	newExperiment := func() (*Experiment, map[string]*fakeWorker) {
+		e := &Experiment{
+			ReplicaOpts: &orchestrationpb.ReplicaOpts{Crypto: "ecdsa"},
+			ClientOpts:  &orchestrationpb.ClientOpts{},
+			Logger:      logging.New("ctrl"),
+			Hosts:       make(map[string]RemoteWorker),
+			NumReplicas: 4,
+			NumClients:  2,
+			Duration:    50 * time.Millisecond,
+
+			CheckpointFile:     filepath.Join(t.TempDir(), "checkpoint.json"),
+			CheckpointInterval: 10 * time.Millisecond,
+			WorkerAddresses:    map[string]string{"host1": "host1:4000", "host2": "host2:4000"},
+		}
+		return e, newFakeWorkers(t, e, "host1", "host2")
+	}
+
+	e, _ := newExperiment()
+	if err := e.Run(); err != nil {
+		t.Fatal(err)
+	}
+	checkpoint, err := ReadCheckpoint(e.CheckpointFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint.Stage != StageFinished {
+		t.Errorf("got stage %q, want %q", checkpoint.Stage, StageFinished)
+	}
+	if !reflect.DeepEqual(checkpoint.HostsToReplicas, e.hostsToReplicas) || !reflect.DeepEqual(checkpoint.WorkerAddresses, e.WorkerAddresses) {
+		t.Errorf("got checkpoint %+v, want the assignments and addresses of the experiment", checkpoint)
+	}
+	if err := e.Resume(checkpoint); err == nil {
+		t.Error("expected an error when resuming a finished experiment")
+	}
+
+	// a new controller resumes the experiment after each stage, and only runs the steps that follow it.
+	for _, tc := range []struct {
+		stage CheckpointStage
+		// whether the clients recorded no latencies.
+		noLatencies bool
+	}{
+		{stage: StageReplicasCreated},
+		{stage: StageReplicasStarted},
+		{stage: StageClientsStarted},
+		{stage: StageClientsStopped},
+		{stage: StageClientsStopped, noLatencies: true},
+	} {
+		stage := tc.stage
+		checkpoint, err := ReadCheckpoint(e.CheckpointFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkpoint.Stage = stage
+		if tc.noLatencies {
+			checkpoint.Latencies = nil
+		}
+		checkpoint.ClientsStarted = time.Now()
+		resumed, workers := newExperiment()
+		start := time.Now()
+		if err := resumed.Resume(checkpoint); err != nil {
+			t.Fatalf("%s: %v", stage, err)
+		}
+		if elapsed := time.Since(start); stage != StageClientsStopped && elapsed < resumed.Duration {
+			t.Errorf("%s: resumed experiment ended after %v, want at least %v", stage, elapsed, resumed.Duration)
+		}
+		for host, w := range workers {
+			if len(w.created) != 0 {
+				t.Errorf("%s: %s: created replicas %v after resuming", stage, host, w.created)
+			}
+			var clients []uint32
+			for _, opts := range w.clients {
+				clients = append(clients, opts.GetID())
+			}
+			var want []uint32
+			if stage.order() < StageClientsStarted.order() {
+				want = getIDs(host, e.hostsToClients)
+			}
+			if !reflect.DeepEqual(clients, want) {
+				t.Errorf("%s: %s: started clients %v, want %v", stage, host, clients, want)
+			}
+			if !reflect.DeepEqual(w.stopped, getIDs(host, e.hostsToReplicas)) {
+				t.Errorf("%s: %s: stopped replicas %v, want %v", stage, host, w.stopped, getIDs(host, e.hostsToReplicas))
+			}
+		}
+		checkpoint, err = ReadCheckpoint(resumed.CheckpointFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if checkpoint.Stage != StageFinished {
+			t.Errorf("%s: got stage %q after resuming, want %q", stage, checkpoint.Stage, StageFinished)
+		}
+	}
+
+	// a checkpoint without the options of the clients cannot be resumed.
+	checkpoint, err = ReadCheckpoint(e.CheckpointFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkpoint.Stage = StageReplicasStarted
+	checkpoint.ClientOpts = nil
+	resumed, _ := newExperiment()
+	if err := resumed.Resume(checkpoint); err == nil {
+		t.Error("expected an error when resuming a checkpoint without client options")
+	}
+}
@@ -1,6 +1,8 @@
 package orchestration_test
 
 import (
+	"context"
+	"errors"
 	"io"
 	"math"
 	"net"
@@ -20,6 +22,7 @@ import (
 	"github.com/relab/hotstuff/modules"
 	"github.com/relab/iago/iagotest"
 	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 func TestOrchestration(t *testing.T) {
@@ -183,3 +186,72 @@ func compileBinary(t *testing.T) string {
 	}
 	return exe
 }
+
+func TestWorkerReconnect(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	worker := orchestration.NewWorker(nil, nil, modules.NopLogger(), nil, 0)
+	if err := worker.Serve(lis, ""); !errors.Is(err, orchestration.ErrMissingWorkerToken) {
+		t.Fatalf("got %v when serving without a token, want %v", err, orchestration.ErrMissingWorkerToken)
+	}
+	c := make(chan error)
+	go func() {
+		c <- worker.Serve(lis, "secret")
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// a controller that does not send the token is disconnected.
+	conn, err := net.Dial("tcp", lis.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	unauthenticated := orchestration.NewRemoteWorker(protostream.NewWriter(conn), protostream.NewReader(conn))
+	if _, err := unauthenticated.StopClient(ctx, &orchestrationpb.StopClientRequest{}); err == nil {
+		t.Error("expected a controller without the token to be rejected")
+	}
+	conn.Close()
+
+	// a controller with the wrong token is disconnected.
+	wrong, err := orchestration.DialWorker(ctx, lis.Addr().String(), "wrong")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wrong.StopClient(ctx, &orchestrationpb.StopClientRequest{}); err == nil {
+		t.Error("expected a controller with the wrong token to be rejected")
+	}
+
+	// the first controller disconnects without quitting.
+	conn, err = net.Dial("tcp", lis.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	send := protostream.NewWriter(conn)
+	if err := send.WriteAny(wrapperspb.String("secret")); err != nil {
+		t.Fatal(err)
+	}
+	first := orchestration.NewRemoteWorker(send, protostream.NewReader(conn))
+	if _, err := first.StopClient(ctx, &orchestrationpb.StopClientRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	// the worker accepts a new controller, and exits when it quits.
+	second, err := orchestration.DialWorker(ctx, lis.Addr().String(), "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := second.StopClient(ctx, &orchestrationpb.StopClientRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Quit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-c; err != nil {
+		t.Errorf("expected the worker to exit without an error, got %v", err)
+	}
+}
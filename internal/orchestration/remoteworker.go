@@ -1,19 +1,25 @@
 package orchestration
 
 import (
+	"context"
 	"fmt"
+	"net"
 
 	"github.com/relab/hotstuff/internal/proto/orchestrationpb"
 	"github.com/relab/hotstuff/internal/protostream"
 	spb "google.golang.org/genproto/googleapis/rpc/status"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 // RemoteWorker is a proxy for a remote worker.
 type RemoteWorker struct {
 	send *protostream.Writer
 	recv *protostream.Reader
+	// held while a request is in progress, such that a request that is abandoned when its context is done
+	// still reads its own response before the next request is sent.
+	busy chan struct{}
 }
 
 // NewRemoteWorker returns a new remote worker proxy.
@@ -21,18 +27,72 @@ func NewRemoteWorker(send *protostream.Writer, recv *protostream.Reader) RemoteW
 	return RemoteWorker{
 		send: send,
 		recv: recv,
+		busy: make(chan struct{}, 1),
 	}
 }
 
-func (w RemoteWorker) rpc(req proto.Message) (res proto.Message, err error) {
-	err = w.send.WriteAny(req)
+// DialWorker connects to a worker that listens on the given address, and authenticates with the worker's token.
+func DialWorker(ctx context.Context, addr, token string) (RemoteWorker, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
 	if err != nil {
-		return nil, err
+		return RemoteWorker{}, err
 	}
-	res, err = w.recv.ReadAny()
+	send := protostream.NewWriter(conn)
+	if err := send.WriteAny(wrapperspb.String(token)); err != nil {
+		_ = conn.Close()
+		return RemoteWorker{}, fmt.Errorf("failed to send token: %w", err)
+	}
+	return NewRemoteWorker(send, protostream.NewReader(conn)), nil
+}
+
+// rpc sends the request and waits for the response, or until the context is done.
+func (w RemoteWorker) rpc(ctx context.Context, req proto.Message) (res proto.Message, err error) {
+	return w.stream(ctx, req, nil)
+}
+
+// stream sends the request and waits for the response, or until the context is done.
+// Commit status messages that the worker pushes before the response are passed to onStatus, in order.
+func (w RemoteWorker) stream(ctx context.Context, req proto.Message, onStatus func(*orchestrationpb.CommitStatus)) (res proto.Message, err error) {
+	select {
+	case w.busy <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	type result struct {
+		res proto.Message
+		err error
+	}
+	c := make(chan result, 1)
+	go func() {
+		defer func() { <-w.busy }()
+		res, err := w.roundTrip(req, onStatus)
+		c <- result{res, err}
+	}()
+	select {
+	case r := <-c:
+		return r.res, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (w RemoteWorker) roundTrip(req proto.Message, onStatus func(*orchestrationpb.CommitStatus)) (res proto.Message, err error) {
+	err = w.send.WriteAny(req)
 	if err != nil {
 		return nil, err
 	}
+	for {
+		res, err = w.recv.ReadAny()
+		if err != nil {
+			return nil, err
+		}
+		update, ok := res.(*orchestrationpb.CommitStatus)
+		if !ok || onStatus == nil {
+			break
+		}
+		onStatus(update)
+	}
 	// unpack status errors
 	if s, ok := res.(*spb.Status); ok {
 		return nil, status.FromProto(s).Err()
@@ -42,8 +102,8 @@ func (w RemoteWorker) rpc(req proto.Message) (res proto.Message, err error) {
 
 // CreateReplica requests that the remote worker creates the specified replicas,
 // returning details about the created replicas.
-func (w RemoteWorker) CreateReplica(req *orchestrationpb.CreateReplicaRequest) (res *orchestrationpb.CreateReplicaResponse, err error) {
-	msg, err := w.rpc(req)
+func (w RemoteWorker) CreateReplica(ctx context.Context, req *orchestrationpb.CreateReplicaRequest) (res *orchestrationpb.CreateReplicaResponse, err error) {
+	msg, err := w.rpc(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -55,8 +115,8 @@ func (w RemoteWorker) CreateReplica(req *orchestrationpb.CreateReplicaRequest) (
 }
 
 // StartReplica requests that the remote worker starts the specified replicas.
-func (w RemoteWorker) StartReplica(req *orchestrationpb.StartReplicaRequest) (res *orchestrationpb.StartReplicaResponse, err error) {
-	msg, err := w.rpc(req)
+func (w RemoteWorker) StartReplica(ctx context.Context, req *orchestrationpb.StartReplicaRequest) (res *orchestrationpb.StartReplicaResponse, err error) {
+	msg, err := w.rpc(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -68,8 +128,8 @@ func (w RemoteWorker) StartReplica(req *orchestrationpb.StartReplicaRequest) (re
 }
 
 // StopReplica requests that the remote worker stops the specified replica.
-func (w RemoteWorker) StopReplica(req *orchestrationpb.StopReplicaRequest) (res *orchestrationpb.StopReplicaResponse, err error) {
-	msg, err := w.rpc(req)
+func (w RemoteWorker) StopReplica(ctx context.Context, req *orchestrationpb.StopReplicaRequest) (res *orchestrationpb.StopReplicaResponse, err error) {
+	msg, err := w.rpc(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -81,8 +141,8 @@ func (w RemoteWorker) StopReplica(req *orchestrationpb.StopReplicaRequest) (res
 }
 
 // StartClient requests that the remote worker starts the specified clients.
-func (w RemoteWorker) StartClient(req *orchestrationpb.StartClientRequest) (res *orchestrationpb.StartClientResponse, err error) {
-	msg, err := w.rpc(req)
+func (w RemoteWorker) StartClient(ctx context.Context, req *orchestrationpb.StartClientRequest) (res *orchestrationpb.StartClientResponse, err error) {
+	msg, err := w.rpc(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -94,8 +154,8 @@ func (w RemoteWorker) StartClient(req *orchestrationpb.StartClientRequest) (res
 }
 
 // StopClient requests that the remote worker stops the specified clients.
-func (w RemoteWorker) StopClient(req *orchestrationpb.StopClientRequest) (res *orchestrationpb.StopClientResponse, err error) {
-	msg, err := w.rpc(req)
+func (w RemoteWorker) StopClient(ctx context.Context, req *orchestrationpb.StopClientRequest) (res *orchestrationpb.StopClientResponse, err error) {
+	msg, err := w.rpc(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -106,7 +166,67 @@ func (w RemoteWorker) StopClient(req *orchestrationpb.StopClientRequest) (res *o
 	return res, nil
 }
 
+// Profile requests that the remote worker collects CPU and heap profiles.
+func (w RemoteWorker) Profile(ctx context.Context, req *orchestrationpb.ProfileRequest) (res *orchestrationpb.ProfileResponse, err error) {
+	msg, err := w.rpc(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res, ok := msg.(*orchestrationpb.ProfileResponse)
+	if !ok {
+		return nil, fmt.Errorf("wrong type for response message: got %T, wanted: %T", msg, res)
+	}
+	return res, nil
+}
+
+// ReconfigureTimeouts requests that the remote worker changes the view timeouts of the specified replicas.
+func (w RemoteWorker) ReconfigureTimeouts(ctx context.Context, req *orchestrationpb.ReconfigureTimeoutsRequest) (res *orchestrationpb.ReconfigureTimeoutsResponse, err error) {
+	msg, err := w.rpc(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res, ok := msg.(*orchestrationpb.ReconfigureTimeoutsResponse)
+	if !ok {
+		return nil, fmt.Errorf("wrong type for response message: got %T, wanted: %T", msg, res)
+	}
+	return res, nil
+}
+
+// CommittedBlocks requests a page of the blocks committed by a replica of the remote worker.
+func (w RemoteWorker) CommittedBlocks(ctx context.Context, req *orchestrationpb.CommittedBlocksRequest) (res *orchestrationpb.CommittedBlocksResponse, err error) {
+	msg, err := w.rpc(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res, ok := msg.(*orchestrationpb.CommittedBlocksResponse)
+	if !ok {
+		return nil, fmt.Errorf("wrong type for response message: got %T, wanted: %T", msg, res)
+	}
+	return res, nil
+}
+
+// WatchCommits requests that the remote worker pushes the commit heights of the specified replicas
+// at each interval, and passes each update to onStatus, in the order they are received.
+// It returns when the requested duration has passed and the worker has sent its response.
+func (w RemoteWorker) WatchCommits(ctx context.Context, req *orchestrationpb.WatchCommitsRequest, onStatus func(*orchestrationpb.CommitStatus)) (res *orchestrationpb.WatchCommitsResponse, err error) {
+	msg, err := w.stream(ctx, req, onStatus)
+	if err != nil {
+		return nil, err
+	}
+	res, ok := msg.(*orchestrationpb.WatchCommitsResponse)
+	if !ok {
+		return nil, fmt.Errorf("wrong type for response message: got %T, wanted: %T", msg, res)
+	}
+	return res, nil
+}
+
 // Quit requests that the remote worker exits.
-func (w RemoteWorker) Quit() (err error) {
+func (w RemoteWorker) Quit(ctx context.Context) (err error) {
+	select {
+	case w.busy <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-w.busy }()
 	return w.send.WriteAny(&orchestrationpb.QuitRequest{})
 }
@@ -2,8 +2,10 @@ package orchestration
 
 import (
 	"crypto/rand"
+	"crypto/subtle"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -20,6 +22,7 @@ import (
 	"github.com/relab/hotstuff/crypto"
 	"github.com/relab/hotstuff/crypto/keygen"
 	"github.com/relab/hotstuff/handel"
+	"github.com/relab/hotstuff/internal/profiling"
 	"github.com/relab/hotstuff/internal/proto/orchestrationpb"
 	"github.com/relab/hotstuff/internal/protostream"
 	"github.com/relab/hotstuff/logging"
@@ -32,6 +35,8 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	// imported modules
 	_ "github.com/relab/hotstuff/consensus/chainedhotstuff"
@@ -54,6 +59,12 @@ type Worker struct {
 
 	replicas map[hotstuff.ID]*replica.Replica
 	clients  map[hotstuff.ID]*client.Client
+	// the latencies of the commands sent by each client.
+	latencies map[hotstuff.ID]*metrics.LatencyHistogram
+	// the interval between resource samples of each replica that should be sampled.
+	resourceSampleIntervals map[hotstuff.ID]time.Duration
+	// the resource samplers of the running replicas.
+	resourceSamplers map[hotstuff.ID]*profiling.ResourceSampler
 }
 
 // Run runs the worker until it receives a command to quit.
@@ -76,6 +87,14 @@ func (w *Worker) Run() error {
 			res, err = w.startClients(req)
 		case *orchestrationpb.StopClientRequest:
 			res, err = w.stopClients(req)
+		case *orchestrationpb.ProfileRequest:
+			res, err = w.profile(req)
+		case *orchestrationpb.ReconfigureTimeoutsRequest:
+			res, err = w.reconfigureTimeouts(req)
+		case *orchestrationpb.CommittedBlocksRequest:
+			res, err = w.committedBlocks(req)
+		case *orchestrationpb.WatchCommitsRequest:
+			res, err = w.watchCommits(req)
 		case *orchestrationpb.QuitRequest:
 			return nil
 		}
@@ -92,16 +111,73 @@ func (w *Worker) Run() error {
 	}
 }
 
+// ErrMissingWorkerToken is returned by Worker.Serve if it is not given a token to authenticate controllers with.
+var ErrMissingWorkerToken = errors.New("a token is required to accept connections from controllers")
+
+// handshakeTimeout is the time that a controller has to send its token after connecting to a worker.
+const handshakeTimeout = 10 * time.Second
+
+// Serve accepts connections from controllers on the listener, and runs the commands of one controller at a time
+// until it receives a command to quit. Unlike Run, the replicas and clients keep running when a controller
+// disconnects, such that a restarted controller can reconnect and resume the experiment.
+// A controller must send the token as the first message after connecting, as done by DialWorker,
+// and connections that do not are closed. The token is sent in plain text,
+// so the listener must only be reachable from a trusted network.
+func (w *Worker) Serve(l net.Listener, token string) error {
+	if token == "" {
+		return ErrMissingWorkerToken
+	}
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		recv := protostream.NewReader(conn)
+		if !authenticateController(conn, recv, token) {
+			_ = conn.Close()
+			continue
+		}
+		w.send = protostream.NewWriter(conn)
+		w.recv = recv
+		err = w.Run()
+		_ = conn.Close()
+		if err == nil {
+			return nil
+		}
+		// the controller disconnected; wait for it to reconnect.
+	}
+}
+
+// authenticateController reads the token sent by the controller, and returns true if it matches the worker's token.
+func authenticateController(conn net.Conn, recv *protostream.Reader, token string) bool {
+	if err := conn.SetReadDeadline(time.Now().Add(handshakeTimeout)); err != nil {
+		return false
+	}
+	msg, err := recv.ReadAny()
+	if err != nil {
+		return false
+	}
+	got, ok := msg.(*wrapperspb.StringValue)
+	if !ok || subtle.ConstantTimeCompare([]byte(got.GetValue()), []byte(token)) != 1 {
+		return false
+	}
+	return conn.SetReadDeadline(time.Time{}) == nil
+}
+
 // NewWorker returns a new worker.
-func NewWorker(send *protostream.Writer, recv *protostream.Reader, dl modules.MetricsLogger, metrics []string, measurementInterval time.Duration) Worker {
+func NewWorker(send *protostream.Writer, recv *protostream.Reader, dl modules.MetricsLogger, metricNames []string, measurementInterval time.Duration) Worker {
 	return Worker{
 		send:                send,
 		recv:                recv,
 		metricsLogger:       dl,
-		metrics:             metrics,
+		metrics:             metricNames,
 		measurementInterval: measurementInterval,
 		replicas:            make(map[hotstuff.ID]*replica.Replica),
 		clients:             make(map[hotstuff.ID]*client.Client),
+		latencies:           make(map[hotstuff.ID]*metrics.LatencyHistogram),
+
+		resourceSampleIntervals: make(map[hotstuff.ID]time.Duration),
+		resourceSamplers:        make(map[hotstuff.ID]*profiling.ResourceSampler),
 	}
 }
 
@@ -131,8 +207,13 @@ func (w *Worker) createReplicas(req *orchestrationpb.CreateReplicaRequest) (*orc
 			return nil, err
 		}
 
-		r.StartServers(replicaListener, clientListener)
+		if err := r.StartServers(replicaListener, clientListener); err != nil {
+			return nil, fmt.Errorf("failed to start servers: %w", err)
+		}
 		w.replicas[hotstuff.ID(cfg.GetID())] = r
+		if interval := cfg.GetResourceSampleInterval().AsDuration(); interval > 0 {
+			w.resourceSampleIntervals[hotstuff.ID(cfg.GetID())] = interval
+		}
 
 		resp.Replicas[cfg.GetID()] = &orchestrationpb.ReplicaInfo{
 			ID:          cfg.GetID(),
@@ -167,25 +248,27 @@ func (w *Worker) createReplica(opts *orchestrationpb.ReplicaOpts) (*replica.Repl
 
 	consensusRules, ok := modules.GetModule[consensus.Rules](opts.GetConsensus())
 	if !ok {
-		return nil, fmt.Errorf("invalid consensus name: '%s'", opts.GetConsensus())
+		return nil, fmt.Errorf("invalid consensus name: '%s'; available: %v", opts.GetConsensus(), modules.ListModulesOf[consensus.Rules]())
 	}
 
 	if opts.GetByzantineStrategy() != "" {
 		if byz, ok := modules.GetModule[byzantine.Byzantine](opts.GetByzantineStrategy()); ok {
 			consensusRules = byz.Wrap(consensusRules)
 		} else {
-			return nil, fmt.Errorf("invalid byzantine strategy: '%s'", opts.GetByzantineStrategy())
+			return nil, fmt.Errorf("invalid byzantine strategy: '%s'; available: %v",
+				opts.GetByzantineStrategy(), modules.ListModulesOf[byzantine.Byzantine]())
 		}
 	}
 
 	cryptoImpl, ok := modules.GetModule[consensus.CryptoBase](opts.GetCrypto())
 	if !ok {
-		return nil, fmt.Errorf("invalid crypto name: '%s'", opts.GetCrypto())
+		return nil, fmt.Errorf("invalid crypto name: '%s'; available: %v", opts.GetCrypto(), modules.ListModulesOf[consensus.CryptoBase]())
 	}
 
 	leaderRotation, ok := modules.GetModule[consensus.LeaderRotation](opts.GetLeaderRotation())
 	if !ok {
-		return nil, fmt.Errorf("invalid leader-rotation algorithm: '%s'", opts.GetLeaderRotation())
+		return nil, fmt.Errorf("invalid leader-rotation algorithm: '%s'; available: %v",
+			opts.GetLeaderRotation(), modules.ListModulesOf[consensus.LeaderRotation]())
 	}
 
 	sync := synchronizer.New(synchronizer.NewViewDuration(
@@ -207,6 +290,22 @@ func (w *Worker) createReplica(opts *orchestrationpb.ReplicaOpts) (*replica.Repl
 
 	builder.OptionsBuilder().SetSharedRandomSeed(opts.GetSharedSeed())
 
+	if schedule := opts.GetLeaderSchedule(); len(schedule) > 0 {
+		leaders := make([]hotstuff.ID, 0, len(schedule))
+		for _, id := range schedule {
+			leaders = append(leaders, hotstuff.ID(id))
+		}
+		builder.OptionsBuilder().SetLeaderSchedule(leaders)
+	}
+
+	if chainLength := opts.GetChainLength(); chainLength > 0 {
+		builder.OptionsBuilder().SetChainLength(int(chainLength))
+	}
+
+	if opts.GetVerifyOwnSignatures() {
+		builder.OptionsBuilder().SetShouldVerifyOwnSignatures()
+	}
+
 	if w.measurementInterval > 0 {
 		replicaMetrics := metrics.GetReplicaMetrics(w.metrics...)
 		builder.Register(replicaMetrics...)
@@ -222,12 +321,13 @@ func (w *Worker) createReplica(opts *orchestrationpb.ReplicaOpts) (*replica.Repl
 	}
 
 	c := replica.Config{
-		ID:          hotstuff.ID(opts.GetID()),
-		PrivateKey:  privKey,
-		TLS:         opts.GetUseTLS(),
-		Certificate: &certificate,
-		RootCAs:     rootCAs,
-		BatchSize:   opts.GetBatchSize(),
+		ID:                    hotstuff.ID(opts.GetID()),
+		PrivateKey:            privKey,
+		TLS:                   opts.GetUseTLS(),
+		Certificate:           &certificate,
+		RootCAs:               rootCAs,
+		BatchSize:             opts.GetBatchSize(),
+		RecordCommittedBlocks: opts.GetRecordCommittedBlocks(),
 		ManagerOptions: []gorums.ManagerOption{
 			gorums.WithDialTimeout(opts.GetConnectTimeout().AsDuration()),
 			gorums.WithGrpcDialOptions(grpc.WithReturnConnectionError()),
@@ -263,6 +363,9 @@ func (w *Worker) startReplicas(req *orchestrationpb.StartReplicaRequest) (*orche
 
 		defer func(id uint32) {
 			w.metricsLogger.Log(&types.StartEvent{Event: types.NewReplicaEvent(id, time.Now())})
+			if interval, ok := w.resourceSampleIntervals[hotstuff.ID(id)]; ok {
+				w.resourceSamplers[hotstuff.ID(id)] = profiling.StartResourceSampler(interval)
+			}
 			replica.Start()
 		}(id)
 	}
@@ -271,7 +374,8 @@ func (w *Worker) startReplicas(req *orchestrationpb.StartReplicaRequest) (*orche
 
 func (w *Worker) stopReplicas(req *orchestrationpb.StopReplicaRequest) (*orchestrationpb.StopReplicaResponse, error) {
 	res := &orchestrationpb.StopReplicaResponse{
-		Hashes: make(map[uint32][]byte),
+		Hashes:        make(map[uint32][]byte),
+		ResourceUsage: make(map[uint32]*orchestrationpb.ResourceUsage),
 	}
 	for _, id := range req.GetIDs() {
 		r, ok := w.replicas[hotstuff.ID(id)]
@@ -280,11 +384,29 @@ func (w *Worker) stopReplicas(req *orchestrationpb.StopReplicaRequest) (*orchest
 		}
 		r.Stop()
 		res.Hashes[id] = r.GetHash()
+		if sampler, ok := w.resourceSamplers[hotstuff.ID(id)]; ok {
+			delete(w.resourceSamplers, hotstuff.ID(id))
+			res.ResourceUsage[id] = resourceUsageToProto(sampler.Stop())
+		}
 		// TODO: return test results
 	}
 	return res, nil
 }
 
+func resourceUsageToProto(samples []profiling.ResourceSample) *orchestrationpb.ResourceUsage {
+	usage := &orchestrationpb.ResourceUsage{Samples: make([]*orchestrationpb.ResourceSample, 0, len(samples))}
+	for _, sample := range samples {
+		usage.Samples = append(usage.Samples, &orchestrationpb.ResourceSample{
+			Elapsed:      durationpb.New(sample.Elapsed),
+			CPUTime:      durationpb.New(sample.CPUTime),
+			HeapAlloc:    sample.HeapAlloc,
+			Sys:          sample.Sys,
+			NumGoroutine: uint32(sample.NumGoroutine),
+		})
+	}
+	return usage
+}
+
 func (w *Worker) startClients(req *orchestrationpb.StartClientRequest) (*orchestrationpb.StartClientResponse, error) {
 	ca := req.GetCertificateAuthority()
 	cp := x509.NewCertPool()
@@ -315,6 +437,8 @@ func (w *Worker) startClients(req *orchestrationpb.StartClientRequest) (*orchest
 			mods.Register(metrics.NewTicker(w.measurementInterval))
 		}
 
+		latencies := &metrics.LatencyHistogram{}
+		mods.Register(latencyRecorder{latencies})
 		mods.Register(w.metricsLogger)
 		mods.Register(logging.New("cli" + strconv.Itoa(int(opts.GetID()))))
 		cli := client.New(c, mods)
@@ -329,19 +453,71 @@ func (w *Worker) startClients(req *orchestrationpb.StartClientRequest) (*orchest
 		cli.Start()
 		w.metricsLogger.Log(&types.StartEvent{Event: types.NewClientEvent(opts.GetID(), time.Now())})
 		w.clients[hotstuff.ID(opts.GetID())] = cli
+		w.latencies[hotstuff.ID(opts.GetID())] = latencies
 	}
 	return &orchestrationpb.StartClientResponse{}, nil
 }
 
 func (w *Worker) stopClients(req *orchestrationpb.StopClientRequest) (*orchestrationpb.StopClientResponse, error) {
+	latencies := &metrics.LatencyHistogram{}
 	for _, id := range req.GetIDs() {
 		cli, ok := w.clients[hotstuff.ID(id)]
 		if !ok {
 			return nil, status.Errorf(codes.NotFound, "the client with ID %d was not found", id)
 		}
 		cli.Stop()
+		// the client's event loop has stopped, so its latencies can be read safely.
+		latencies.Merge(w.latencies[hotstuff.ID(id)])
+	}
+	return &orchestrationpb.StopClientResponse{
+		Latencies: &orchestrationpb.LatencyHistogram{Buckets: latencies.Buckets()},
+	}, nil
+}
+
+// profile collects a CPU profile for the requested duration, followed by a heap profile.
+func (w *Worker) profile(req *orchestrationpb.ProfileRequest) (*orchestrationpb.ProfileResponse, error) {
+	cpuProfile, err := profiling.CPUProfile(req.GetCPUDuration().AsDuration())
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "failed to collect CPU profile: %v", err)
 	}
-	return &orchestrationpb.StopClientResponse{}, nil
+	heapProfile, err := profiling.HeapProfile()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to collect heap profile: %v", err)
+	}
+	return &orchestrationpb.ProfileResponse{
+		CPUProfile:  cpuProfile,
+		HeapProfile: heapProfile,
+	}, nil
+}
+
+// reconfigureTimeouts changes the view timeouts of the replicas while they are running.
+func (w *Worker) reconfigureTimeouts(req *orchestrationpb.ReconfigureTimeoutsRequest) (*orchestrationpb.ReconfigureTimeoutsResponse, error) {
+	schedule := req.GetSchedule()
+	for _, id := range req.GetIDs() {
+		r, ok := w.replicas[hotstuff.ID(id)]
+		if !ok {
+			return nil, status.Errorf(codes.NotFound, "The replica with ID %d was not found.", id)
+		}
+		r.Modules().EventLoop().AddEvent(synchronizer.ReconfigureTimeoutsEvent{
+			SampleSize:   uint64(schedule.GetTimeoutSamples()),
+			StartTimeout: float64(schedule.GetInitialTimeout().AsDuration().Nanoseconds()) / float64(time.Millisecond),
+			MaxTimeout:   float64(schedule.GetMaxTimeout().AsDuration().Nanoseconds()) / float64(time.Millisecond),
+			Multiplier:   float64(schedule.GetTimeoutMultiplier()),
+		})
+	}
+	return &orchestrationpb.ReconfigureTimeoutsResponse{}, nil
+}
+
+// latencyRecorder records the latency of each command sent by a client in a histogram.
+type latencyRecorder struct {
+	latencies *metrics.LatencyHistogram
+}
+
+// InitModule gives the module access to the other modules.
+func (lr latencyRecorder) InitModule(mods *modules.Modules) {
+	mods.EventLoop().RegisterObserver(client.LatencyMeasurementEvent{}, func(event interface{}) {
+		lr.latencies.Record(event.(client.LatencyMeasurementEvent).Latency)
+	})
 }
 
 func getConfiguration(conf map[uint32]*orchestrationpb.ReplicaInfo, client bool) ([]backend.ReplicaInfo, error) {
@@ -377,3 +553,55 @@ func getPort(lis net.Listener) (uint32, error) {
 	}
 	return uint32(port), nil
 }
+
+func (w *Worker) committedBlocks(req *orchestrationpb.CommittedBlocksRequest) (*orchestrationpb.CommittedBlocksResponse, error) {
+	r, ok := w.replicas[hotstuff.ID(req.GetID())]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "The replica with ID %d was not found.", req.GetID())
+	}
+	blocks, total := r.CommittedBlocks(int(req.GetStart()), int(req.GetLimit()))
+	res := &orchestrationpb.CommittedBlocksResponse{
+		Blocks: make([]*orchestrationpb.CommittedBlock, 0, len(blocks)),
+		Total:  uint64(total),
+	}
+	for _, block := range blocks {
+		res.Blocks = append(res.Blocks, committedBlockToProto(block))
+	}
+	return res, nil
+}
+
+// watchCommits pushes the commit heights of the requested replicas to the controller at each interval,
+// until the requested duration has passed.
+func (w *Worker) watchCommits(req *orchestrationpb.WatchCommitsRequest) (*orchestrationpb.WatchCommitsResponse, error) {
+	interval := req.GetInterval().AsDuration()
+	if interval <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid commit status interval: %v", interval)
+	}
+	replicas := make(map[uint32]*replica.Replica, len(req.GetIDs()))
+	for _, id := range req.GetIDs() {
+		r, ok := w.replicas[hotstuff.ID(id)]
+		if !ok {
+			return nil, status.Errorf(codes.NotFound, "The replica with ID %d was not found.", id)
+		}
+		replicas[id] = r
+	}
+
+	start := time.Now()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for elapsed := time.Duration(0); elapsed+interval <= req.GetDuration().AsDuration(); {
+		<-ticker.C
+		elapsed = time.Since(start)
+		update := &orchestrationpb.CommitStatus{
+			Elapsed: durationpb.New(elapsed),
+			Heights: make(map[uint32]uint64, len(replicas)),
+		}
+		for id, r := range replicas {
+			update.Heights[id] = uint64(r.Modules().Consensus().CommittedBlock().View())
+		}
+		if err := w.send.WriteAny(update); err != nil {
+			return nil, err
+		}
+	}
+	return &orchestrationpb.WatchCommitsResponse{}, nil
+}
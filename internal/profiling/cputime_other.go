@@ -0,0 +1,10 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package profiling
+
+import "time"
+
+// cpuTime returns zero, since the CPU time of the process cannot be measured on this platform.
+func cpuTime() time.Duration {
+	return 0
+}
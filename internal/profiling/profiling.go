@@ -2,10 +2,12 @@
 package profiling
 
 import (
+	"bytes"
 	"os"
 	"runtime"
 	"runtime/pprof"
 	"runtime/trace"
+	"time"
 
 	"github.com/felixge/fgprof"
 )
@@ -45,6 +47,27 @@ func WriteMemProfile(memProfilePath string) error {
 	return err
 }
 
+// CPUProfile collects a CPU profile for the given duration and returns it in pprof format.
+func CPUProfile(duration time.Duration) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := pprof.StartCPUProfile(&buf); err != nil {
+		return nil, err
+	}
+	time.Sleep(duration)
+	pprof.StopCPUProfile()
+	return buf.Bytes(), nil
+}
+
+// HeapProfile returns a heap profile in pprof format.
+func HeapProfile() ([]byte, error) {
+	var buf bytes.Buffer
+	runtime.GC() // get up-to-date statistics
+	if err := pprof.WriteHeapProfile(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 // StartTrace starts a program trace using the "runtime/trace" package.
 // Returns a function to stop the trace.
 func StartTrace(tracePath string) (stop func() error, err error) {
@@ -0,0 +1,82 @@
+package profiling
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// ResourceSample is a measurement of the resources used by the process.
+type ResourceSample struct {
+	// Elapsed is the time since sampling started.
+	Elapsed time.Duration
+	// CPUTime is the user and system CPU time used by the process since it started.
+	// It is zero on platforms where it cannot be measured.
+	CPUTime time.Duration
+	// HeapAlloc is the number of bytes of allocated heap objects.
+	HeapAlloc uint64
+	// Sys is the number of bytes of memory obtained from the operating system.
+	Sys uint64
+	// NumGoroutine is the number of goroutines.
+	NumGoroutine int
+}
+
+// ResourceSampler periodically samples the resources used by the process.
+type ResourceSampler struct {
+	start   time.Time
+	stop    chan struct{}
+	done    chan struct{}
+	mut     sync.Mutex
+	samples []ResourceSample
+}
+
+// StartResourceSampler takes a sample immediately, and then once every interval until the sampler is stopped.
+func StartResourceSampler(interval time.Duration) *ResourceSampler {
+	s := &ResourceSampler{
+		start: time.Now(),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	s.sample()
+	go s.run(interval)
+	return s
+}
+
+func (s *ResourceSampler) run(interval time.Duration) {
+	defer close(s.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.sample()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func (s *ResourceSampler) sample() {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	sample := ResourceSample{
+		Elapsed:      time.Since(s.start),
+		CPUTime:      cpuTime(),
+		HeapAlloc:    memStats.HeapAlloc,
+		Sys:          memStats.Sys,
+		NumGoroutine: runtime.NumGoroutine(),
+	}
+	s.mut.Lock()
+	s.samples = append(s.samples, sample)
+	s.mut.Unlock()
+}
+
+// Stop takes a final sample, stops the sampler, and returns the samples in the order they were taken.
+func (s *ResourceSampler) Stop() []ResourceSample {
+	close(s.stop)
+	<-s.done
+	s.sample()
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.samples
+}
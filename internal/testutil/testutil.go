@@ -121,7 +121,10 @@ func CreateBuilders(t *testing.T, ctrl *gomock.Controller, n int, keys ...consen
 			key = GenerateECDSAKey(t)
 		}
 
-		builder := network.GetNodeBuilder(twins.NodeID{ReplicaID: id, NetworkID: uint32(id)}, key)
+		builder, err := network.GetNodeBuilder(twins.NodeID{ReplicaID: id, NetworkID: uint32(id)}, key)
+		if err != nil {
+			t.Fatal(err)
+		}
 		TestModules(t, ctrl, id, key, &builder)
 		builder.Register(network.NewConfiguration())
 		builders[i] = &builder
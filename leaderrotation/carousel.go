@@ -38,7 +38,7 @@ func (c carousel) GetLeader(round consensus.View) hotstuff.ID {
 
 	var (
 		block       = commitHead
-		f           = hotstuff.NumFaulty(c.mods.Configuration().Len())
+		f           = consensus.NumFaulty(c.mods.Configuration())
 		i           = 0
 		lastAuthors = consensus.NewIDSet()
 		ok          = true
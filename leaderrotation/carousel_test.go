@@ -0,0 +1,92 @@
+package leaderrotation
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/blockchain"
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/consensus/chainedhotstuff"
+	"github.com/relab/hotstuff/internal/mocks"
+)
+
+// participantSignature is a quorum signature that only knows its participants.
+type participantSignature struct {
+	consensus.IDSet
+}
+
+func (s participantSignature) ToBytes() []byte               { return nil }
+func (s participantSignature) Participants() consensus.IDSet { return s.IDSet }
+
+// committedConsensus is a consensus implementation whose most recently committed block is fixed.
+type committedConsensus struct {
+	consensus.Consensus
+	committed *consensus.Block
+}
+
+func (c committedConsensus) InitConsensusModule(mods *consensus.Modules, opts *consensus.OptionsBuilder) {
+	if mod, ok := c.Consensus.(consensus.Module); ok {
+		mod.InitConsensusModule(mods, opts)
+	}
+}
+
+func (c committedConsensus) CommittedBlock() *consensus.Block {
+	return c.committed
+}
+
+func TestCarouselChainLength(t *testing.T) {
+	const round = consensus.View(10)
+	// the only replica that may be chosen by the carousel, which differs from the round-robin choice.
+	const carouselLeader = hotstuff.ID(2)
+	roundRobinLeader := chooseRoundRobin(round, 4)
+	if roundRobinLeader == carouselLeader {
+		t.Fatal("the carousel leader must differ from the round-robin leader")
+	}
+
+	tests := []struct {
+		chainLength int
+		commitView  consensus.View
+		want        hotstuff.ID
+	}{
+		// the default chain length of chained HotStuff is 3.
+		{chainLength: 0, commitView: round - 3, want: carouselLeader},
+		{chainLength: 0, commitView: round - 2, want: roundRobinLeader},
+		{chainLength: 2, commitView: round - 2, want: carouselLeader},
+		{chainLength: 2, commitView: round - 3, want: roundRobinLeader},
+		{chainLength: 4, commitView: round - 4, want: carouselLeader},
+		{chainLength: 4, commitView: round - 3, want: roundRobinLeader},
+	}
+
+	for _, test := range tests {
+		ctrl := gomock.NewController(t)
+		cfg := mocks.NewMockConfiguration(ctrl)
+		cfg.EXPECT().Len().Return(4).AnyTimes()
+
+		qc := consensus.NewQuorumCert(
+			participantSignature{consensus.NewIDSet()},
+			test.commitView-1,
+			consensus.GetGenesis().Hash(),
+		)
+		qc.Signature().Participants().Add(carouselLeader)
+		committed := consensus.NewBlock(consensus.GetGenesis().Hash(), qc, "", test.commitView, 1)
+
+		builder := consensus.NewBuilder(1, nil)
+		if test.chainLength != 0 {
+			builder.OptionsBuilder().SetChainLength(test.chainLength)
+		}
+		builder.Register(
+			cfg,
+			blockchain.New(),
+			committedConsensus{Consensus: consensus.New(chainedhotstuff.New()), committed: committed},
+			NewCarousel(),
+		)
+		mods := builder.Build()
+
+		if got := mods.LeaderRotation().GetLeader(round); got != test.want {
+			t.Errorf("chain length %d, commit view %d: got leader %d, want %d",
+				test.chainLength, test.commitView, got, test.want)
+		}
+		ctrl.Finish()
+	}
+}
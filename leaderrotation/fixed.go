@@ -1,6 +1,8 @@
 package leaderrotation
 
 import (
+	"fmt"
+
 	"github.com/relab/hotstuff"
 	"github.com/relab/hotstuff/consensus"
 	"github.com/relab/hotstuff/modules"
@@ -13,16 +15,51 @@ func init() {
 }
 
 type fixed struct {
-	leader hotstuff.ID
+	leader   hotstuff.ID
+	schedule []hotstuff.ID
 }
 
-// GetLeader returns the id of the leader in the given view
-func (f fixed) GetLeader(_ consensus.View) hotstuff.ID {
-	return f.leader
+// InitConsensusModule gives the module a reference to the Modules object.
+// It also allows the module to set module options using the OptionsBuilder.
+func (f *fixed) InitConsensusModule(mods *consensus.Modules, _ *consensus.OptionsBuilder) {
+	if f.schedule == nil {
+		f.schedule = mods.Options().LeaderSchedule()
+	}
 }
 
+// GetLeader returns the id of the leader in the given view
+func (f *fixed) GetLeader(view consensus.View) hotstuff.ID {
+	if len(f.schedule) == 0 {
+		return f.leader
+	}
+	if view == 0 {
+		return f.schedule[0]
+	}
+	return f.schedule[(view-1)%consensus.View(len(f.schedule))]
+}
 
 // NewFixed returns a new fixed-leader leader rotation implementation.
+// If a leader schedule is set in the options, the leaders are instead chosen from the schedule.
 func NewFixed(leader hotstuff.ID) consensus.LeaderRotation {
-	return fixed{leader}
+	return &fixed{leader: leader}
+}
+
+// NewFixedSchedule returns a leader rotation that chooses the leader of each view from the schedule,
+// starting over once the schedule is exhausted.
+func NewFixedSchedule(schedule []hotstuff.ID) consensus.LeaderRotation {
+	return &fixed{schedule: schedule}
+}
+
+// ValidateSchedule checks that the schedule is not empty,
+// and that every leader in it is one of the replicas with IDs 1 to numReplicas.
+func ValidateSchedule(schedule []hotstuff.ID, numReplicas int) error {
+	if len(schedule) == 0 {
+		return fmt.Errorf("leader schedule is empty")
+	}
+	for i, id := range schedule {
+		if id < 1 || int(id) > numReplicas {
+			return fmt.Errorf("leader schedule: entry %d: replica %d does not exist", i, id)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,55 @@
+package leaderrotation
+
+import (
+	"testing"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+)
+
+func TestFixedSchedule(t *testing.T) {
+	schedule := []hotstuff.ID{3, 1, 1, 2}
+
+	builder := consensus.NewBuilder(1, nil)
+	builder.OptionsBuilder().SetLeaderSchedule(schedule)
+	builder.Register(NewFixed(1))
+	mods := builder.Build()
+
+	for view := consensus.View(1); view <= 8; view++ {
+		want := schedule[(view-1)%4]
+		if got := mods.LeaderRotation().GetLeader(view); got != want {
+			t.Errorf("view %d: got leader %d, want %d", view, got, want)
+		}
+	}
+}
+
+func TestFixedWithoutSchedule(t *testing.T) {
+	builder := consensus.NewBuilder(1, nil)
+	builder.Register(NewFixed(2))
+	mods := builder.Build()
+
+	for view := consensus.View(1); view <= 4; view++ {
+		if got := mods.LeaderRotation().GetLeader(view); got != 2 {
+			t.Errorf("view %d: got leader %d, want %d", view, got, 2)
+		}
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	tests := []struct {
+		schedule []hotstuff.ID
+		valid    bool
+	}{
+		{[]hotstuff.ID{1, 2, 3, 4}, true},
+		{[]hotstuff.ID{4, 4, 1}, true},
+		{nil, false},
+		{[]hotstuff.ID{1, 0}, false},
+		{[]hotstuff.ID{1, 5}, false},
+	}
+	for _, test := range tests {
+		err := ValidateSchedule(test.schedule, 4)
+		if (err == nil) != test.valid {
+			t.Errorf("schedule %v: got error %v, want valid: %v", test.schedule, err, test.valid)
+		}
+	}
+}
@@ -227,9 +227,12 @@ func New(name string) Logger {
 }
 
 // NewWithDest returns a new logger for the given destination with the given name.
+// The log entries are not timestamped, such that the same sequence of entries always gives the same output.
 func NewWithDest(dest io.Writer, name string) Logger {
 	atom := zap.NewAtomicLevelAt(logLevel)
-	core := zapcore.NewCore(zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig()), zapcore.AddSync(dest), atom)
+	encoderConfig := zap.NewDevelopmentEncoderConfig()
+	encoderConfig.TimeKey = ""
+	core := zapcore.NewCore(zapcore.NewConsoleEncoder(encoderConfig), zapcore.AddSync(dest), atom)
 	l := zap.New(core, zap.AddCallerSkip(1))
 	return &wrapper{inner: l.Sugar().Named(name), level: atom}
 }
@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"math"
+	"math/bits"
+	"time"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// subBucketBits is the number of bits used to divide each power of two into linear sub-buckets.
+// With 7 bits, each recorded value is accurate to within 1/128 (less than 1%) of the true value.
+const subBucketBits = 7
+
+const subBuckets = 1 << subBucketBits
+
+// LatencyHistogram is a mergeable histogram of latencies, similar to an HDR histogram.
+// Latencies are recorded with microsecond resolution in logarithmic buckets,
+// such that the relative error of the computed percentiles is bounded, regardless of the range of latencies.
+// The zero value is an empty histogram.
+type LatencyHistogram struct {
+	buckets map[uint32]uint64
+	count   uint64
+}
+
+// LatencyHistogramFromBuckets returns a histogram with the given bucket counts, as returned by Buckets.
+func LatencyHistogramFromBuckets(buckets map[uint32]uint64) *LatencyHistogram {
+	h := &LatencyHistogram{}
+	for index, count := range buckets {
+		h.add(index, count)
+	}
+	return h
+}
+
+// Record adds a latency to the histogram.
+func (h *LatencyHistogram) Record(latency time.Duration) {
+	if latency < 0 {
+		latency = 0
+	}
+	h.add(bucketIndex(uint64(latency/time.Microsecond)), 1)
+}
+
+// Merge adds the latencies recorded by the other histogram to this histogram.
+func (h *LatencyHistogram) Merge(other *LatencyHistogram) {
+	if other == nil {
+		return
+	}
+	for index, count := range other.buckets {
+		h.add(index, count)
+	}
+}
+
+// Count returns the number of recorded latencies.
+func (h *LatencyHistogram) Count() uint64 {
+	return h.count
+}
+
+// Buckets returns the number of latencies in each non-empty bucket.
+func (h *LatencyHistogram) Buckets() map[uint32]uint64 {
+	return maps.Clone(h.buckets)
+}
+
+// Percentile returns the latency below which the given percentage (between 0 and 100) of the latencies fall.
+// It returns zero if the histogram is empty.
+func (h *LatencyHistogram) Percentile(p float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(p / 100 * float64(h.count)))
+	if rank == 0 {
+		rank = 1
+	}
+	indices := maps.Keys(h.buckets)
+	slices.Sort(indices)
+	var seen uint64
+	for _, index := range indices {
+		seen += h.buckets[index]
+		if seen >= rank {
+			return bucketValue(index)
+		}
+	}
+	return bucketValue(indices[len(indices)-1])
+}
+
+func (h *LatencyHistogram) add(index uint32, count uint64) {
+	if count == 0 {
+		return
+	}
+	if h.buckets == nil {
+		h.buckets = make(map[uint32]uint64)
+	}
+	h.buckets[index] += count
+	h.count += count
+}
+
+// bucketIndex returns the index of the bucket containing the value.
+// Values below subBuckets have their own bucket. Larger values share each power of two with subBuckets-1 other buckets.
+func bucketIndex(v uint64) uint32 {
+	if v < subBuckets {
+		return uint32(v)
+	}
+	shift := bits.Len64(v) - 1 - subBucketBits
+	sub := (v >> shift) - subBuckets
+	return uint32((shift+1)*subBuckets) + uint32(sub)
+}
+
+// bucketValue returns the midpoint of the bucket with the given index.
+func bucketValue(index uint32) time.Duration {
+	if index < subBuckets {
+		return time.Duration(index) * time.Microsecond
+	}
+	shift := index/subBuckets - 1
+	lower := uint64(subBuckets+index%subBuckets) << shift
+	width := uint64(1) << shift
+	return time.Duration(lower+width/2) * time.Microsecond
+}
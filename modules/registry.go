@@ -3,6 +3,7 @@ package modules
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"sync"
 )
 
@@ -16,8 +17,7 @@ var (
 // For example:
 //  RegisterModule("chainedhotstuff", func() consensus.Rules { return chainedhotstuff.New() })
 func RegisterModule[T any](name string, constructor func() T) {
-	var t T
-	moduleType := reflect.TypeOf(t)
+	moduleType := typeOf[T]()
 
 	registryMut.Lock()
 	defer registryMut.Unlock()
@@ -41,7 +41,7 @@ func RegisterModule[T any](name string, constructor func() T) {
 // For example:
 //  rules, ok := GetModule[consensus.Rules]("chainedhotstuff")
 func GetModule[T any](name string) (out T, ok bool) {
-	targetType := reflect.TypeOf(out)
+	targetType := typeOf[T]()
 
 	registryMut.Lock()
 	defer registryMut.Unlock()
@@ -91,3 +91,24 @@ func ListModules() map[string][]string {
 
 	return modules
 }
+
+// ListModulesOf returns the sorted names of the modules registered for the type T.
+// For example:
+//  names := ListModulesOf[consensus.Rules]()
+func ListModulesOf[T any]() []string {
+	registryMut.Lock()
+	defer registryMut.Unlock()
+
+	m := byInterface[typeOf[T]()]
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// typeOf returns the type T. Unlike reflect.TypeOf of a zero value, this also works for interface types.
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
@@ -23,6 +23,30 @@ func TestModuleRegistry(t *testing.T) {
 	}
 }
 
+func TestListModules(t *testing.T) {
+	modules.RegisterModule("b", func() listedIface { return nil })
+	modules.RegisterModule("a", func() listedIface { return nil })
+	modules.RegisterModule("c", func() moduleIface { return module{} })
+
+	names := modules.ListModulesOf[listedIface]()
+	if len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Errorf("got %v, want [a b]", names)
+	}
+
+	if _, ok := modules.GetModule[moduleIface]("a"); ok {
+		t.Error("module of a different type was found")
+	}
+
+	all := modules.ListModules()
+	if got := all["(github.com/relab/hotstuff/modules_test).listedIface"]; len(got) != 2 {
+		t.Errorf("got %v, want the modules of listedIface", got)
+	}
+}
+
+type listedIface interface {
+	list()
+}
+
 type moduleIface interface {
 	frobulate(i *int)
 }
@@ -0,0 +1,56 @@
+package replica
+
+import (
+	"crypto/sha256"
+	"sync"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+)
+
+// CommittedBlock summarizes a block committed by the replica.
+type CommittedBlock struct {
+	Hash     consensus.Hash
+	Parent   consensus.Hash
+	View     consensus.View
+	Proposer hotstuff.ID
+	// CommandHash is the SHA-256 hash of the block's command.
+	CommandHash consensus.Hash
+}
+
+// commitLog records the blocks committed by the replica, before passing their commands on to the executor.
+type commitLog struct {
+	executor consensus.Executor
+
+	mut    sync.Mutex
+	blocks []CommittedBlock
+}
+
+// Exec executes the command in the block.
+func (l *commitLog) Exec(block *consensus.Block) {
+	l.mut.Lock()
+	l.blocks = append(l.blocks, CommittedBlock{
+		Hash:        block.Hash(),
+		Parent:      block.Parent(),
+		View:        block.View(),
+		Proposer:    block.Proposer(),
+		CommandHash: sha256.Sum256([]byte(block.Command())),
+	})
+	l.mut.Unlock()
+	l.executor.Exec(block.Command())
+}
+
+// page returns at most limit blocks, starting at the given position, and the total number of committed blocks.
+func (l *commitLog) page(start, limit int) (blocks []CommittedBlock, total int) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+	total = len(l.blocks)
+	if start >= total {
+		return nil, total
+	}
+	end := total
+	if limit > 0 && start+limit < end {
+		end = start + limit
+	}
+	return append([]CommittedBlock(nil), l.blocks[start:end]...), total
+}
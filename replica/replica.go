@@ -6,6 +6,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"net"
+	"time"
 
 	"github.com/relab/gorums"
 	"github.com/relab/hotstuff"
@@ -42,11 +43,18 @@ type Config struct {
 	ReplicaServerOptions []gorums.ServerOption
 	// Options for the replica manager.
 	ManagerOptions []gorums.ManagerOption
+	// The number of times a failed block fetch is retried.
+	FetchRetries int
+	// The backoff before the first retry of a failed block fetch. It is doubled after each retry.
+	FetchBackoff time.Duration
+	// Controls whether the committed blocks are recorded, such that they can be retrieved with CommittedBlocks.
+	RecordCommittedBlocks bool
 }
 
 // Replica is a participant in the consensus protocol.
 type Replica struct {
 	clientSrv *clientSrv
+	commitLog *commitLog
 	cfg       *backend.Config
 	hsSrv     *backend.Server
 	hs        *consensus.Modules
@@ -86,7 +94,7 @@ func New(conf Config, builder consensus.Builder) (replica *Replica) {
 		))
 	}
 
-	srv.hsSrv = backend.NewServer(replicaSrvOpts...)
+	srv.hsSrv = backend.NewServer(backend.WithGorumsServerOptions(replicaSrvOpts...))
 
 	var creds credentials.TransportCredentials
 	managerOpts := conf.ManagerOptions
@@ -96,7 +104,10 @@ func New(conf Config, builder consensus.Builder) (replica *Replica) {
 			Certificates: []tls.Certificate{*conf.Certificate},
 		})
 	}
-	srv.cfg = backend.NewConfig(creds, managerOpts...)
+	srv.cfg = backend.NewConfig(creds,
+		backend.WithManagerOptions(managerOpts...),
+		backend.WithFetchRetry(conf.FetchRetries, conf.FetchBackoff),
+	)
 
 	builder.Register(
 		srv.cfg,                // configuration
@@ -104,6 +115,11 @@ func New(conf Config, builder consensus.Builder) (replica *Replica) {
 		srv.clientSrv,          // executor
 		srv.clientSrv.cmdCache, // acceptor and command queue
 	)
+	if conf.RecordCommittedBlocks {
+		// replaces the client server as the executor.
+		srv.commitLog = &commitLog{executor: srv.clientSrv}
+		builder.Register(srv.commitLog)
+	}
 	srv.hs = builder.Build()
 
 	return srv
@@ -115,9 +131,12 @@ func (srv *Replica) Modules() *consensus.Modules {
 }
 
 // StartServers starts the client and replica servers.
-func (srv *Replica) StartServers(replicaListen, clientListen net.Listener) {
-	srv.hsSrv.StartOnListener(replicaListen)
+func (srv *Replica) StartServers(replicaListen, clientListen net.Listener) error {
+	if err := srv.hsSrv.StartOnListener(replicaListen); err != nil {
+		return err
+	}
 	srv.clientSrv.StartOnListener(clientListen)
+	return nil
 }
 
 // Connect connects to the other replicas.
@@ -155,6 +174,16 @@ func (srv *Replica) Close() {
 	srv.hsSrv.Stop()
 }
 
+// CommittedBlocks returns at most limit of the blocks committed by the replica, starting at the given position,
+// and the total number of committed blocks. If limit is zero, all blocks from the position are returned.
+// The blocks are only recorded if Config.RecordCommittedBlocks is set; otherwise, no blocks are returned.
+func (srv *Replica) CommittedBlocks(start, limit int) (blocks []CommittedBlock, total int) {
+	if srv.commitLog == nil {
+		return nil, 0
+	}
+	return srv.commitLog.page(start, limit)
+}
+
 // GetHash returns the hash of all executed commands.
 func (srv *Replica) GetHash() (b []byte) {
 	return srv.clientSrv.hash.Sum(b)
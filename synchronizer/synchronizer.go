@@ -48,6 +48,10 @@ func (s *Synchronizer) InitConsensusModule(mods *consensus.Modules, opts *consen
 		}
 	})
 
+	s.mods.EventLoop().RegisterHandler(ReconfigureTimeoutsEvent{}, func(event interface{}) {
+		s.reconfigureTimeouts(event.(ReconfigureTimeoutsEvent))
+	})
+
 	s.mods.EventLoop().RegisterHandler(consensus.NewViewMsg{}, func(event interface{}) {
 		newViewMsg := event.(consensus.NewViewMsg)
 		s.OnNewView(newViewMsg)
@@ -100,8 +104,11 @@ func (s *Synchronizer) Start(ctx context.Context) {
 		s.timer.Stop()
 	}()
 
+	leader := s.mods.LeaderRotation().GetLeader(s.currentView)
+	s.mods.EventLoop().AddEvent(LeaderEvent{View: s.currentView, Leader: leader})
+
 	// start the initial proposal
-	if s.currentView == 1 && s.mods.LeaderRotation().GetLeader(s.currentView) == s.mods.ID() {
+	if s.currentView == 1 && leader == s.mods.ID() {
 		s.mods.Consensus().Propose(s.SyncInfo())
 	}
 }
@@ -210,7 +217,11 @@ func (s *Synchronizer) OnRemoteTimeout(timeout consensus.TimeoutMsg) {
 		timeouts[timeout.ID] = timeout
 	}
 
-	if len(timeouts) < s.mods.Configuration().QuorumSize() {
+	senders := consensus.NewIDSet()
+	for id := range timeouts {
+		senders.Add(id)
+	}
+	if !consensus.IsQuorum(s.mods.Configuration(), senders) {
 		return
 	}
 
@@ -324,6 +335,7 @@ func (s *Synchronizer) AdvanceView(syncInfo consensus.SyncInfo) {
 	s.mods.EventLoop().AddEvent(ViewChangeEvent{View: s.currentView, Timeout: timeout})
 
 	leader := s.mods.LeaderRotation().GetLeader(s.currentView)
+	s.mods.EventLoop().AddEvent(LeaderEvent{View: s.currentView, Leader: leader})
 	if leader == s.mods.ID() {
 		s.mods.Consensus().Propose(syncInfo)
 	} else if replica, ok := s.mods.Configuration().Replica(leader); ok {
@@ -361,6 +373,18 @@ func (s *Synchronizer) updateHighTC(tc consensus.TimeoutCert) {
 	}
 }
 
+// reconfigureTimeouts changes the parameters of the view duration, if it supports reconfiguration.
+// The new parameters take effect from the next view.
+func (s *Synchronizer) reconfigureTimeouts(event ReconfigureTimeoutsEvent) {
+	duration, ok := s.duration.(ReconfigurableViewDuration)
+	if !ok {
+		s.mods.Logger().Warnf("Cannot reconfigure timeouts: %T does not support reconfiguration", s.duration)
+		return
+	}
+	duration.Reconfigure(event.SampleSize, event.StartTimeout, event.MaxTimeout, event.Multiplier)
+	s.mods.Logger().Infof("Reconfigured timeouts: %+v", event)
+}
+
 func (s *Synchronizer) newCtx(duration time.Duration) {
 	s.cancelCtx()
 	s.viewCtx, s.cancelCtx = context.WithTimeout(context.Background(), duration)
@@ -374,6 +398,23 @@ type ViewChangeEvent struct {
 	Timeout bool
 }
 
+// LeaderEvent is sent on the eventloop whenever the replica enters a view, including the first view.
+// It contains the leader of the view, as chosen by the replica's leader rotation module.
+type LeaderEvent struct {
+	View   consensus.View
+	Leader hotstuff.ID
+}
+
+// ReconfigureTimeoutsEvent can be added to the eventloop to change the parameters of the view duration,
+// as given to NewViewDuration, while the replica is running. It is ignored with a warning if the
+// synchronizer's view duration does not implement ReconfigurableViewDuration.
+type ReconfigureTimeoutsEvent struct {
+	SampleSize   uint64
+	StartTimeout float64 // milliseconds
+	MaxTimeout   float64 // milliseconds
+	Multiplier   float64
+}
+
 // TimeoutEvent is sent on the eventloop when a local timeout occurs.
 type TimeoutEvent struct {
 	View consensus.View
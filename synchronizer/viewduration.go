@@ -20,11 +20,19 @@ type ViewDuration interface {
 	ViewTimeout()
 }
 
+// ReconfigurableViewDuration is a ViewDuration whose parameters can be changed while the replica is running.
+type ReconfigurableViewDuration interface {
+	ViewDuration
+	// Reconfigure replaces the parameters of the view duration, as given to NewViewDuration,
+	// and discards the measurements of previous views.
+	Reconfigure(sampleSize uint64, startTimeout, maxTimeout, multiplier float64)
+}
+
 // NewViewDuration returns a ViewDuration that approximates the view duration based on durations of previous views.
 // sampleSize determines the number of previous views that should be considered.
 // startTimeout determines the view duration of the first views.
 // When a timeout occurs, the next view duration will be multiplied by the multiplier.
-func NewViewDuration(sampleSize uint64, startTimeout, maxTimeout, multiplier float64) ViewDuration {
+func NewViewDuration(sampleSize uint64, startTimeout, maxTimeout, multiplier float64) ReconfigurableViewDuration {
 	return &viewDuration{
 		limit: sampleSize,
 		mean:  startTimeout,
@@ -53,6 +61,18 @@ func (v *viewDuration) InitConsensusModule(mods *consensus.Modules, _ *consensus
 	v.mods = mods
 }
 
+// Reconfigure replaces the parameters of the view duration and discards the measurements of previous views.
+// The duration of the current view is still measured.
+func (v *viewDuration) Reconfigure(sampleSize uint64, startTimeout, maxTimeout, multiplier float64) {
+	v.limit = sampleSize
+	v.mean = startTimeout
+	v.max = maxTimeout
+	v.mul = multiplier
+	v.count = 0
+	v.m2 = 0
+	v.prevM2 = 0
+}
+
 // ViewSucceeded calculates the duration of the view
 // and updates the internal values used for mean and variance calculations.
 func (v *viewDuration) ViewSucceeded() {
@@ -0,0 +1,67 @@
+package twins
+
+import (
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// AbsentLeaderRecovery describes how the nodes handled a view whose leader does not have any node,
+// as allowed by ScenarioOptions.AbsentLeaders.
+type AbsentLeaderRecovery struct {
+	Leader hotstuff.ID
+	// TimedOut contains the nodes that left the view due to a timeout, sorted by network ID.
+	TimedOut []NodeID
+	// RecoveryTick is the tick during which the first node entered a later view,
+	// or -1 if no node left the view before the scenario ended.
+	RecoveryTick int
+}
+
+// Recovered returns true if a node left the view with the absent leader.
+func (r AbsentLeaderRecovery) Recovered() bool {
+	return r.RecoveryTick >= 0
+}
+
+// absentLeaderRecoveries returns the recovery of each view of the scenario whose leader does not have any node.
+// It returns nil if the scenario does not allow absent leaders.
+func (n *Network) absentLeaderRecoveries(allowed bool) map[consensus.View]AbsentLeaderRecovery {
+	if !allowed {
+		return nil
+	}
+	recoveries := make(map[consensus.View]AbsentLeaderRecovery)
+	for i := range n.views {
+		view := consensus.View(i + 1)
+		leader := n.leader(view)
+		if _, ok := n.replicas[leader]; ok || leader == 0 {
+			continue
+		}
+		recoveries[view] = n.absentLeaderRecovery(view, leader)
+	}
+	return recoveries
+}
+
+// absentLeaderRecovery finds the nodes that timed out in the view, and the first tick in which a node left it.
+func (n *Network) absentLeaderRecovery(view consensus.View, leader hotstuff.ID) AbsentLeaderRecovery {
+	recovery := AbsentLeaderRecovery{Leader: leader, RecoveryTick: -1}
+	ids := maps.Keys(n.nodes)
+	slices.Sort(ids)
+	for _, id := range ids {
+		node := n.nodes[id]
+		// the nodes start in view 1, which is not recorded in their view history.
+		current := consensus.View(1)
+		for _, transition := range node.viewHistory {
+			if current <= view && transition.View > view {
+				if transition.Timeout && current == view {
+					recovery.TimedOut = append(recovery.TimedOut, node.id)
+				}
+				if recovery.RecoveryTick < 0 || transition.Tick < recovery.RecoveryTick {
+					recovery.RecoveryTick = transition.Tick
+				}
+				break
+			}
+			current = transition.View
+		}
+	}
+	return recovery
+}
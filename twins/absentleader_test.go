@@ -0,0 +1,53 @@
+package twins
+
+import (
+	"testing"
+
+	"github.com/relab/hotstuff/consensus"
+	_ "github.com/relab/hotstuff/consensus/chainedhotstuff"
+)
+
+func TestAbsentLeaders(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	scenario := Scenario{
+		{Leader: 1, Partitions: []NodeSet{allNodes}},
+		{Leader: 2, Partitions: []NodeSet{allNodes}},
+		// replica 5 does not exist.
+		{Leader: 5, Partitions: []NodeSet{allNodes}},
+		{Leader: 3, Partitions: []NodeSet{allNodes}},
+		{Leader: 4, Partitions: []NodeSet{allNodes}},
+		{Leader: 1, Partitions: []NodeSet{allNodes}},
+		{Leader: 2, Partitions: []NodeSet{allNodes}},
+	}
+
+	_, err := ExecuteScenarioWithOptions(scenario, 4, 0, 200, "chainedhotstuff", ScenarioOptions{})
+	if err == nil {
+		t.Fatal("expected an error for an absent leader without ScenarioOptions.AbsentLeaders")
+	}
+
+	result, err := ExecuteScenarioWithOptions(scenario, 4, 0, 200, "chainedhotstuff", ScenarioOptions{AbsentLeaders: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Safe {
+		t.Error("expected a safe result")
+	}
+	if len(result.AbsentLeaders) != 1 {
+		t.Fatalf("got absent leaders %v, want only view 3", result.AbsentLeaders)
+	}
+	recovery, ok := result.AbsentLeaders[consensus.View(3)]
+	if !ok {
+		t.Fatalf("got absent leaders %v, want view 3", result.AbsentLeaders)
+	}
+	if recovery.Leader != 5 || !recovery.Recovered() {
+		t.Errorf("got %+v, want a recovery from the absent leader 5", recovery)
+	}
+	// without a proposal, all nodes must time out to leave the view.
+	if len(recovery.TimedOut) != 4 {
+		t.Errorf("got %v nodes timing out in view 3, want all 4 nodes", recovery.TimedOut)
+	}
+	// the nodes make progress after the absent leader.
+	if result.Commits == 0 {
+		t.Error("expected the nodes to commit blocks after recovering from the absent leader")
+	}
+}
@@ -0,0 +1,201 @@
+package twins
+
+import (
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/bits"
+
+	"github.com/relab/hotstuff"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// The encodings of the node sets of a binary scenario.
+const (
+	// each node set is a bitmask, where bit i is set if node i+1 is in the set.
+	bitmaskEncoding byte = iota
+	// each node set is its size followed by the differences between its sorted node IDs, starting from zero.
+	listEncoding
+)
+
+var (
+	_ encoding.BinaryMarshaler   = Scenario(nil)
+	_ encoding.BinaryUnmarshaler = (*Scenario)(nil)
+)
+
+// MarshalBinary encodes the scenario in a compact binary form, which is much smaller and faster to decode than JSON.
+// The encoding starts with a byte that tells how node sets are encoded, followed by the number of views,
+// and for each view, the leader, the number of partitions plus one, and the node set of each partition, all as varints.
+// A view whose partitions are nil has zero in place of the number of partitions, so that it is not decoded as an empty list.
+// If all node IDs are between 1 and 64, each node set is encoded as a bitmask; otherwise, as a list of node IDs.
+func (s Scenario) MarshalBinary() ([]byte, error) {
+	setEncoding := bitmaskEncoding
+	for _, view := range s {
+		for _, partition := range view.Partitions {
+			for id := range partition {
+				if id < 1 || id > 64 {
+					setEncoding = listEncoding
+				}
+			}
+		}
+	}
+
+	buf := []byte{setEncoding}
+	buf = appendUvarint(buf, uint64(len(s)))
+	for _, view := range s {
+		buf = appendUvarint(buf, uint64(view.Leader))
+		if view.Partitions == nil {
+			buf = appendUvarint(buf, 0)
+			continue
+		}
+		buf = appendUvarint(buf, uint64(len(view.Partitions))+1)
+		for _, partition := range view.Partitions {
+			if setEncoding == bitmaskEncoding {
+				var mask uint64
+				for id := range partition {
+					mask |= 1 << (id - 1)
+				}
+				buf = appendUvarint(buf, mask)
+				continue
+			}
+			ids := maps.Keys(partition)
+			slices.Sort(ids)
+			buf = appendUvarint(buf, uint64(len(ids)))
+			var prev uint32
+			for _, id := range ids {
+				buf = appendUvarint(buf, uint64(id-prev))
+				prev = id
+			}
+		}
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary decodes a scenario encoded by MarshalBinary.
+func (s *Scenario) UnmarshalBinary(data []byte) error {
+	d := binaryDecoder{data: data}
+	if len(data) == 0 {
+		return fmt.Errorf("%w: empty input", ErrMalformedScenario)
+	}
+	setEncoding := data[0]
+	d.data = data[1:]
+	if setEncoding != bitmaskEncoding && setEncoding != listEncoding {
+		return fmt.Errorf("%w: unknown node set encoding %d", ErrMalformedScenario, setEncoding)
+	}
+
+	numViews := d.count()
+	var scenario Scenario
+	if numViews > 0 {
+		scenario = make(Scenario, 0, numViews)
+	}
+	for i := 0; i < numViews && d.err == nil; i++ {
+		view := View{Leader: hotstuff.ID(d.uint32())}
+		numPartitions, ok := d.optionalCount()
+		if ok {
+			view.Partitions = make([]NodeSet, 0, numPartitions)
+		}
+		for j := 0; j < numPartitions && d.err == nil; j++ {
+			partition := make(NodeSet)
+			if setEncoding == bitmaskEncoding {
+				for mask := d.uvarint(); mask != 0; mask &= mask - 1 {
+					partition.Add(uint32(bits.TrailingZeros64(mask)) + 1)
+				}
+			} else {
+				var id uint32
+				for k, n := 0, d.count(); k < n && d.err == nil; k++ {
+					id += d.uint32()
+					partition.Add(id)
+				}
+			}
+			view.Partitions = append(view.Partitions, partition)
+		}
+		scenario = append(scenario, view)
+	}
+	if d.err != nil {
+		return d.err
+	}
+	if len(d.data) > 0 {
+		return fmt.Errorf("%w: %d trailing bytes", ErrMalformedScenario, len(d.data))
+	}
+	*s = scenario
+	return nil
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	return append(buf, tmp[:n]...)
+}
+
+// binaryDecoder reads varints from a binary scenario, remembering the first error.
+type binaryDecoder struct {
+	data []byte
+	err  error
+}
+
+func (d *binaryDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.err = fmt.Errorf("%w: truncated or overflowing varint", ErrMalformedScenario)
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *binaryDecoder) uint32() uint32 {
+	v := d.uvarint()
+	if v > uint64(^uint32(0)) && d.err == nil {
+		d.err = fmt.Errorf("%w: value %d does not fit in 32 bits", ErrMalformedScenario, v)
+	}
+	return uint32(v)
+}
+
+// count reads the length of a sequence. Each element takes at least one byte,
+// so a length larger than the remaining input is rejected before anything is allocated.
+func (d *binaryDecoder) count() int {
+	v := d.uvarint()
+	if v > uint64(len(d.data)) && d.err == nil {
+		d.err = fmt.Errorf("%w: length %d exceeds the remaining input", ErrMalformedScenario, v)
+	}
+	if d.err != nil {
+		return 0
+	}
+	return int(v)
+}
+
+// optionalCount reads the length of a sequence that may be absent, encoded as the length plus one, or zero if absent.
+func (d *binaryDecoder) optionalCount() (n int, ok bool) {
+	v := d.uvarint()
+	if v == 0 || d.err != nil {
+		return 0, false
+	}
+	if v-1 > uint64(len(d.data)) {
+		d.err = fmt.Errorf("%w: length %d exceeds the remaining input", ErrMalformedScenario, v-1)
+		return 0, false
+	}
+	return int(v - 1), true
+}
+
+// ScenarioJSONToBinary converts a scenario from its JSON form to its binary form.
+func ScenarioJSONToBinary(data []byte) ([]byte, error) {
+	var s Scenario
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return s.MarshalBinary()
+}
+
+// ScenarioBinaryToJSON converts a scenario from its binary form to its JSON form.
+func ScenarioBinaryToJSON(data []byte) ([]byte, error) {
+	var s Scenario
+	if err := s.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return json.Marshal(s)
+}
@@ -0,0 +1,87 @@
+package twins
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/relab/hotstuff/logging"
+)
+
+func TestScenarioBinaryRoundTrip(t *testing.T) {
+	g := NewGenerator(logging.New(""), Settings{NumNodes: 4, NumTwins: 1, Partitions: 2, Views: 3})
+	g.Shuffle(1)
+	var generated []Scenario
+	for i := 0; i < 20; i++ {
+		scenario, err := g.NextScenario()
+		if err != nil {
+			t.Fatal(err)
+		}
+		generated = append(generated, scenario)
+	}
+	large := Scenario{
+		{Leader: 70, Partitions: []NodeSet{{1: {}, 65: {}, 300: {}}, {70: {}}}},
+		{Leader: 1, Partitions: []NodeSet{{1: {}, 2: {}, 3: {}, 65: {}, 70: {}, 300: {}}}},
+	}
+	// empty and nil partitions are both preserved.
+	empty := Scenario{
+		{Leader: 1, Partitions: []NodeSet{}},
+		{Leader: 2},
+	}
+	for i, scenario := range append(generated, large, empty) {
+		encoded, err := scenario.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded Scenario
+		if err := decoded.UnmarshalBinary(encoded); err != nil {
+			t.Fatalf("scenario %d: %v", i, err)
+		}
+		want, _ := json.Marshal(scenario)
+		got, _ := json.Marshal(decoded)
+		if !bytes.Equal(got, want) {
+			t.Errorf("scenario %d: got %s, want %s", i, got, want)
+		}
+		if len(encoded) >= len(want) {
+			t.Errorf("scenario %d: binary form is %d bytes, but JSON form is only %d bytes", i, len(encoded), len(want))
+		}
+
+		// the converters give the same results.
+		converted, err := ScenarioJSONToBinary(want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(converted, encoded) {
+			t.Errorf("scenario %d: converted %x, want %x", i, converted, encoded)
+		}
+		back, err := ScenarioBinaryToJSON(converted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(back, want) {
+			t.Errorf("scenario %d: converted back to %s, want %s", i, back, want)
+		}
+	}
+}
+
+func TestScenarioBinaryMalformed(t *testing.T) {
+	scenario := Scenario{{Leader: 1, Partitions: []NodeSet{{1: {}, 2: {}}, {3: {}, 4: {}}}}}
+	encoded, err := scenario.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string][]byte{
+		"empty":      nil,
+		"encoding":   append([]byte{9}, encoded[1:]...),
+		"truncated":  encoded[:len(encoded)-1],
+		"trailing":   append(append([]byte(nil), encoded...), 0),
+		"length":     {bitmaskEncoding, 100},
+		"partitions": {bitmaskEncoding, 1, 1, 100},
+	} {
+		var decoded Scenario
+		if err := decoded.UnmarshalBinary(data); !errors.Is(err, ErrMalformedScenario) {
+			t.Errorf("%s: got error %v, want %v", name, err, ErrMalformedScenario)
+		}
+	}
+}
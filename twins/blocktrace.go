@@ -0,0 +1,124 @@
+package twins
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/relab/hotstuff/consensus"
+)
+
+// BlockEventType is the type of a BlockEvent.
+type BlockEventType uint8
+
+const (
+	// BlockProposed means that the node proposed the block.
+	BlockProposed BlockEventType = iota
+	// BlockDropped means that the network dropped a proposal of the block that was sent to the node.
+	BlockDropped
+	// BlockRejected means that the node rejected a proposal of the block because it exceeded the node's maximum block size.
+	BlockRejected
+	// BlockDelivered means that a proposal of the block was delivered to the node.
+	BlockDelivered
+	// BlockVoted means that the node voted for the block.
+	BlockVoted
+	// BlockCommitted means that the node committed the block.
+	BlockCommitted
+)
+
+func (t BlockEventType) String() string {
+	switch t {
+	case BlockProposed:
+		return "proposed"
+	case BlockDropped:
+		return "dropped"
+	case BlockRejected:
+		return "rejected"
+	case BlockDelivered:
+		return "delivered"
+	case BlockVoted:
+		return "voted"
+	case BlockCommitted:
+		return "committed"
+	}
+	return fmt.Sprintf("BlockEventType(%d)", uint8(t))
+}
+
+// BlockEvent is something that happened to a block at a node.
+type BlockEvent struct {
+	// Tick is the network tick during which the event happened.
+	Tick int
+	Type BlockEventType
+	// Node is the node that proposed, received, voted for, or committed the block.
+	// For dropped proposals, it is the node that the proposal was sent to.
+	Node NodeID
+}
+
+func (e BlockEvent) String() string {
+	return fmt.Sprintf("tick %d: %v %v", e.Tick, e.Type, e.Node)
+}
+
+// BlockTrace contains the events of a block, in the order they happened.
+type BlockTrace struct {
+	Hash   consensus.Hash
+	Events []BlockEvent
+}
+
+// Nodes returns the nodes for which an event of the given type happened, in the order of their first such event.
+func (t BlockTrace) Nodes(eventType BlockEventType) []NodeID {
+	var nodes []NodeID
+	seen := make(NodeSet)
+	for _, event := range t.Events {
+		if event.Type == eventType && !seen.Contains(event.Node.NetworkID) {
+			seen.Add(event.Node.NetworkID)
+			nodes = append(nodes, event.Node)
+		}
+	}
+	return nodes
+}
+
+func (t BlockTrace) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "block %.8s", t.Hash)
+	for _, event := range t.Events {
+		fmt.Fprintf(&sb, "\n  %v", event)
+	}
+	return sb.String()
+}
+
+// BlockTrace returns the events of the block with the given hash.
+// A block that was never proposed has no events.
+func (r ScenarioResult) BlockTrace(hash consensus.Hash) BlockTrace {
+	return BlockTrace{Hash: hash, Events: r.blockEvents[hash]}
+}
+
+// recordBlockEvent records an event of the block with the given hash.
+func (n *Network) recordBlockEvent(hash consensus.Hash, eventType BlockEventType, node NodeID) {
+	if n.blockEvents == nil {
+		n.blockEvents = make(map[consensus.Hash][]BlockEvent)
+	}
+	n.blockEvents[hash] = append(n.blockEvents[hash], BlockEvent{Tick: n.currentTick, Type: eventType, Node: node})
+}
+
+// voteRecorder records the blocks that a node votes for.
+type voteRecorder struct {
+	consensus.Crypto
+	node    *node
+	network *Network
+}
+
+// InitConsensusModule gives the module a reference to the Modules object.
+// It also allows the module to set module options using the OptionsBuilder.
+func (r *voteRecorder) InitConsensusModule(mods *consensus.Modules, opts *consensus.OptionsBuilder) {
+	if mod, ok := r.Crypto.(consensus.Module); ok {
+		mod.InitConsensusModule(mods, opts)
+	}
+}
+
+// CreatePartialCert signs a single block and returns the partial certificate, recording the vote.
+func (r *voteRecorder) CreatePartialCert(block *consensus.Block) (cert consensus.PartialCert, err error) {
+	cert, err = r.Crypto.CreatePartialCert(block)
+	if err == nil {
+		r.network.recordBlockEvent(block.Hash(), BlockVoted, r.node.id)
+	}
+	return cert, err
+}
@@ -0,0 +1,64 @@
+package twins
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+	_ "github.com/relab/hotstuff/consensus/chainedhotstuff"
+	"golang.org/x/exp/slices"
+)
+
+func TestBlockTrace(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	// the proposal of the first view does not reach node 4.
+	scenario := Scenario{{Leader: 1, Partitions: []NodeSet{{1: {}, 2: {}, 3: {}}, {4: {}}}}}
+	for i := 1; i < 10; i++ {
+		scenario = append(scenario, View{Leader: hotstuff.ID(i%4 + 1), Partitions: []NodeSet{allNodes}})
+	}
+
+	result, err := ExecuteScenario(scenario, 4, 0, 100, "chainedhotstuff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	commits := result.NodeCommits[NodeID{ReplicaID: 1, NetworkID: 1}]
+	if len(commits) == 0 {
+		t.Fatal("expected node 1 to commit")
+	}
+	block := commits[0]
+	if block.View() != 1 {
+		t.Fatalf("got first committed block from view %d, want view 1", block.View())
+	}
+	trace := result.BlockTrace(block.Hash())
+	t.Log(trace)
+
+	node := func(id uint32) NodeID { return NodeID{ReplicaID: hotstuff.ID(id), NetworkID: id} }
+	if len(trace.Events) == 0 || trace.Events[0] != (BlockEvent{Tick: 0, Type: BlockProposed, Node: node(1)}) {
+		t.Errorf("got events %v, want the proposal by node 1 first", trace.Events)
+	}
+	for i := 1; i < len(trace.Events); i++ {
+		if trace.Events[i].Tick < trace.Events[i-1].Tick {
+			t.Errorf("events %v and %v are out of order", trace.Events[i-1], trace.Events[i])
+		}
+	}
+	if got, want := trace.Nodes(BlockDropped), []NodeID{node(4)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got proposal dropped to %v, want %v", got, want)
+	}
+	// proposals are broadcast in no particular order.
+	delivered := trace.Nodes(BlockDelivered)
+	slices.SortFunc(delivered, func(a, b NodeID) bool { return a.NetworkID < b.NetworkID })
+	if want := []NodeID{node(2), node(3)}; !reflect.DeepEqual(delivered, want) {
+		t.Errorf("got proposal delivered to %v, want %v", delivered, want)
+	}
+	if got := trace.Nodes(BlockVoted); len(got) != 3 {
+		t.Errorf("got votes from %v, want votes from nodes 1, 2, and 3", got)
+	}
+	if got := trace.Nodes(BlockCommitted); len(got) != 4 {
+		t.Errorf("got commits by %v, want commits by all nodes", got)
+	}
+
+	if events := result.BlockTrace(consensus.Hash{}).Events; len(events) != 0 {
+		t.Errorf("got events %v for an unknown block, want none", events)
+	}
+}
@@ -0,0 +1,121 @@
+package twins
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// ScenarioFailure describes a scenario in a corpus that violated safety or could not be executed.
+type ScenarioFailure struct {
+	// Index is the position of the scenario in the corpus.
+	Index    int
+	Scenario Scenario
+	// Detail describes the blocks that the replicas committed at the position where they diverged,
+	// or the initial blocks of the nodes if they did not agree on the genesis block.
+	Detail string
+	// Err is the error returned when executing the scenario, if any.
+	Err error
+}
+
+func (f ScenarioFailure) String() string {
+	if f.Err != nil {
+		return fmt.Sprintf("scenario %d failed: %v\n%v", f.Index, f.Err, f.Scenario)
+	}
+	return fmt.Sprintf("scenario %d is unsafe: %s\n%v", f.Index, f.Detail, f.Scenario)
+}
+
+// CheckCorpus executes the scenarios in parallel and returns the ones that violated safety, ordered by index.
+// Scenarios that could not be executed are also returned, since they cannot be shown to be safe.
+// A test can assert that a corpus is safe by checking that the returned slice is empty.
+func CheckCorpus(scenarios []Scenario, settings Settings, consensusName string, opts ScenarioOptions) []ScenarioFailure {
+	var (
+		mut      sync.Mutex
+		failures []ScenarioFailure
+		wg       sync.WaitGroup
+	)
+
+	indices := make(chan int)
+	numWorkers := runtime.NumCPU()
+	wg.Add(numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		go func() {
+			defer wg.Done()
+			for index := range indices {
+				failure, ok := checkScenario(scenarios[index], settings, consensusName, opts)
+				if !ok {
+					failure.Index = index
+					mut.Lock()
+					failures = append(failures, failure)
+					mut.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range scenarios {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	slices.SortFunc(failures, func(a, b ScenarioFailure) bool { return a.Index < b.Index })
+	return failures
+}
+
+// checkScenario executes a scenario and returns false if it failed or was unsafe.
+func checkScenario(scenario Scenario, settings Settings, consensusName string, opts ScenarioOptions) (ScenarioFailure, bool) {
+	result, err := ExecuteScenarioWithOptions(scenario, settings.NumNodes, settings.NumTwins, settings.Ticks, consensusName, opts)
+	if err != nil {
+		return ScenarioFailure{Scenario: scenario, Err: err}, false
+	}
+	if result.Safe {
+		return ScenarioFailure{}, true
+	}
+	return ScenarioFailure{Scenario: scenario, Detail: result.divergence()}, false
+}
+
+// divergence describes the block that each replica without a twin committed at the position where the replicas diverged.
+// For unsafe results, Commits is the index of that position.
+// If the nodes did not agree on the genesis block, it instead describes the initial block of each node.
+func (r ScenarioResult) divergence() string {
+	if !r.GenesisAgreed {
+		return r.genesisDisagreement()
+	}
+	numNodes := make(map[uint32]int)
+	for id := range r.NodeCommits {
+		numNodes[uint32(id.ReplicaID)]++
+	}
+	ids := make([]NodeID, 0, len(r.NodeCommits))
+	for id := range r.NodeCommits {
+		if numNodes[uint32(id.ReplicaID)] == 1 {
+			ids = append(ids, id)
+		}
+	}
+	slices.SortFunc(ids, func(a, b NodeID) bool { return a.NetworkID < b.NetworkID })
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "replicas diverge at position %d:", r.Commits)
+	for _, id := range ids {
+		if blocks := r.NodeCommits[id]; len(blocks) > r.Commits {
+			fmt.Fprintf(&sb, " %v: %s", id, describeBlock(blocks[r.Commits]))
+		}
+	}
+	return sb.String()
+}
+
+// genesisDisagreement describes the initial block of each node, for results where the nodes started from different blocks.
+func (r ScenarioResult) genesisDisagreement() string {
+	ids := maps.Keys(r.NodeInitialBlocks)
+	slices.SortFunc(ids, func(a, b NodeID) bool { return a.NetworkID < b.NetworkID })
+
+	var sb strings.Builder
+	sb.WriteString("nodes disagree on the genesis block:")
+	for _, id := range ids {
+		fmt.Fprintf(&sb, " %v: %s", id, describeBlock(r.NodeInitialBlocks[id]))
+	}
+	return sb.String()
+}
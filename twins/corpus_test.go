@@ -0,0 +1,46 @@
+package twins_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/twins"
+)
+
+func TestCheckCorpus(t *testing.T) {
+	src, err := twins.FromJSON(strings.NewReader(fhsBugScenario))
+	if err != nil {
+		t.Fatalf("failed to read JSON: %v", err)
+	}
+	badScenario, err := src.NextScenario()
+	if err != nil {
+		t.Fatalf("failed to get scenario: %v", err)
+	}
+
+	allNodes := twins.NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	goodScenario := make(twins.Scenario, len(badScenario))
+	for i := range goodScenario {
+		goodScenario[i] = twins.View{Leader: hotstuff.ID(i%4 + 1), Partitions: []twins.NodeSet{allNodes}}
+	}
+
+	const badIndex = 2
+	corpus := []twins.Scenario{goodScenario, goodScenario, badScenario, goodScenario}
+	settings := twins.Settings{NumNodes: 4, NumTwins: 0, Ticks: 100}
+
+	failures := twins.CheckCorpus(corpus, settings, vulnerableModule, twins.ScenarioOptions{})
+	if len(failures) != 1 {
+		t.Fatalf("got %d failures, want 1: %v", len(failures), failures)
+	}
+	failure := failures[0]
+	if failure.Index != badIndex {
+		t.Errorf("got failure for scenario %d, want scenario %d", failure.Index, badIndex)
+	}
+	if failure.Err != nil {
+		t.Errorf("unexpected error: %v", failure.Err)
+	}
+	if !strings.HasPrefix(failure.Detail, "replicas diverge at position") {
+		t.Errorf("unexpected detail: %q", failure.Detail)
+	}
+	t.Log(failure)
+}
@@ -0,0 +1,169 @@
+package twins
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/relab/hotstuff"
+)
+
+// ParseError describes a syntax error in a scenario passed to ParseScenario.
+type ParseError struct {
+	// Line is the line of the error, starting at 1.
+	Line int
+	// Column is the column of the error, starting at 1.
+	Column int
+	// Msg describes the error.
+	Msg string
+}
+
+func (err *ParseError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", err.Line, err.Column, err.Msg)
+}
+
+// ParseScenario parses a scenario written with one view per line, such as:
+//
+//	view 1 leader 2 partitions [1 2] [3 4]
+//	view 2 leader 3 partitions [1 2 3 4]
+//
+// The views must be numbered consecutively, starting at 1.
+// Partitions contain network IDs, and a view may have any number of partitions.
+// Empty lines and lines starting with '#' are ignored.
+func ParseScenario(s string) (Scenario, error) {
+	var scenario Scenario
+	for i, line := range strings.Split(s, "\n") {
+		p := lineParser{line: []rune(line), lineNumber: i + 1}
+		p.skipSpace()
+		if p.done() || p.line[p.pos] == '#' {
+			continue
+		}
+		view, err := p.parseView(len(scenario) + 1)
+		if err != nil {
+			return nil, err
+		}
+		scenario = append(scenario, view)
+	}
+	return scenario, nil
+}
+
+// lineParser parses a single line of a scenario.
+type lineParser struct {
+	line       []rune
+	lineNumber int
+	pos        int
+}
+
+// token is a word, a number, or a bracket, along with the column where it starts.
+type token struct {
+	text   string
+	column int
+}
+
+func (p *lineParser) errorf(column int, format string, args ...interface{}) error {
+	return &ParseError{Line: p.lineNumber, Column: column, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *lineParser) done() bool {
+	return p.pos >= len(p.line)
+}
+
+func (p *lineParser) skipSpace() {
+	for !p.done() && unicode.IsSpace(p.line[p.pos]) {
+		p.pos++
+	}
+}
+
+// next returns the next token, or a token with empty text at the end of the line.
+func (p *lineParser) next() token {
+	p.skipSpace()
+	start := p.pos
+	if p.done() {
+		return token{column: start + 1}
+	}
+	if r := p.line[p.pos]; r == '[' || r == ']' {
+		p.pos++
+		return token{text: string(r), column: start + 1}
+	}
+	for !p.done() && !unicode.IsSpace(p.line[p.pos]) && p.line[p.pos] != '[' && p.line[p.pos] != ']' {
+		p.pos++
+	}
+	return token{text: string(p.line[start:p.pos]), column: start + 1}
+}
+
+// describe returns a description of the token for use in error messages.
+func (t token) describe() string {
+	if t.text == "" {
+		return "end of line"
+	}
+	return strconv.Quote(t.text)
+}
+
+func (p *lineParser) expectKeyword(keyword string) error {
+	if t := p.next(); t.text != keyword {
+		return p.errorf(t.column, "expected %q, found %s", keyword, t.describe())
+	}
+	return nil
+}
+
+func (p *lineParser) parseNumber(what string) (uint32, token, error) {
+	t := p.next()
+	n, err := strconv.ParseUint(t.text, 10, 32)
+	if err != nil || n == 0 {
+		return 0, t, p.errorf(t.column, "expected %s (a positive integer), found %s", what, t.describe())
+	}
+	return uint32(n), t, nil
+}
+
+func (p *lineParser) parseView(want int) (View, error) {
+	if err := p.expectKeyword("view"); err != nil {
+		return View{}, err
+	}
+	number, t, err := p.parseNumber("view number")
+	if err != nil {
+		return View{}, err
+	}
+	if int(number) != want {
+		return View{}, p.errorf(t.column, "expected view %d, found view %d", want, number)
+	}
+	if err := p.expectKeyword("leader"); err != nil {
+		return View{}, err
+	}
+	leader, _, err := p.parseNumber("leader ID")
+	if err != nil {
+		return View{}, err
+	}
+	if err := p.expectKeyword("partitions"); err != nil {
+		return View{}, err
+	}
+
+	view := View{Leader: hotstuff.ID(leader)}
+	seen := make(NodeSet)
+	for {
+		t := p.next()
+		if t.text == "" {
+			return view, nil
+		}
+		if t.text != "[" {
+			return View{}, p.errorf(t.column, "expected \"[\" or end of line, found %s", t.describe())
+		}
+		partition := make(NodeSet)
+		for {
+			t = p.next()
+			if t.text == "]" {
+				break
+			}
+			id, err := strconv.ParseUint(t.text, 10, 32)
+			if err != nil || id == 0 {
+				return View{}, p.errorf(t.column, "expected node ID (a positive integer) or \"]\", found %s", t.describe())
+			}
+			if seen.Contains(uint32(id)) {
+				return View{}, p.errorf(t.column, "node %d is in multiple partitions", id)
+			}
+			seen.Add(uint32(id))
+			partition.Add(uint32(id))
+		}
+		view.Partitions = append(view.Partitions, partition)
+	}
+}
@@ -0,0 +1,65 @@
+package twins_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/relab/hotstuff/twins"
+)
+
+func TestParseScenario(t *testing.T) {
+	const input = `
+# the network is split in the first view
+view 1 leader 2 partitions [1 2] [3 4]
+	view 2 leader 3 partitions [1 2 3 4]
+
+view 3 leader 1 partitions
+view 4 leader 4 partitions [1][2 3]
+`
+	want := twins.Scenario{
+		{Leader: 2, Partitions: []twins.NodeSet{{1: {}, 2: {}}, {3: {}, 4: {}}}},
+		{Leader: 3, Partitions: []twins.NodeSet{{1: {}, 2: {}, 3: {}, 4: {}}}},
+		{Leader: 1},
+		{Leader: 4, Partitions: []twins.NodeSet{{1: {}}, {2: {}, 3: {}}}},
+	}
+
+	got, err := twins.ParseScenario(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got:\n%v\nwant:\n%v", got, want)
+	}
+}
+
+func TestParseScenarioErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		line   int
+		column int
+	}{
+		{input: "leader 1 partitions [1 2 3 4]", line: 1, column: 1},
+		{input: "view 1 leader 1 partitions [1 2 3 4]\nview 3 leader 1 partitions [1 2 3 4]", line: 2, column: 6},
+		{input: "view 1 leader x partitions [1 2 3 4]", line: 1, column: 15},
+		{input: "view 1 leader 0 partitions [1 2 3 4]", line: 1, column: 15},
+		{input: "view 1 leader 1", line: 1, column: 16},
+		{input: "view 1 leader 1 partitions 1 2 3 4", line: 1, column: 28},
+		{input: "view 1 leader 1 partitions [1 2] [3 4", line: 1, column: 38},
+		{input: "view 1 leader 1 partitions [1 2] [2 3]", line: 1, column: 35},
+		{input: "view 1 leader 1 partitions [1 two]", line: 1, column: 31},
+	}
+
+	for _, test := range tests {
+		_, err := twins.ParseScenario(test.input)
+		var parseErr *twins.ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%q: got error %v, want a parse error", test.input, err)
+			continue
+		}
+		if parseErr.Line != test.line || parseErr.Column != test.column {
+			t.Errorf("%q: got error at line %d, column %d (%v), want line %d, column %d",
+				test.input, parseErr.Line, parseErr.Column, err, test.line, test.column)
+		}
+	}
+}
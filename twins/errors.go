@@ -0,0 +1,34 @@
+package twins
+
+import "errors"
+
+var (
+	// ErrUnknownConsensus is returned when the requested consensus implementation is not registered.
+	ErrUnknownConsensus = errors.New("unknown consensus module")
+
+	// ErrUnknownLeaderRotation is returned when the requested leader rotation implementation is not registered.
+	ErrUnknownLeaderRotation = errors.New("unknown leader rotation module")
+
+	// ErrInvalidScenario is returned when a scenario or its options are inconsistent with the number of nodes.
+	ErrInvalidScenario = errors.New("invalid scenario")
+
+	// ErrDuplicateNetworkID is returned when a node is added to a network that already has a node with the same network ID.
+	ErrDuplicateNetworkID = errors.New("duplicate network ID")
+
+	// ErrKeyGen is returned when the private key of a node could not be generated.
+	ErrKeyGen = errors.New("key generation failed")
+
+	// ErrDuplicateProposal is returned when a node proposes more than once in the same view,
+	// and the scenario was executed with FailOnDuplicateProposals.
+	ErrDuplicateProposal = errors.New("duplicate proposal")
+
+	// ErrEventCascade is returned when a node processes too many events during a single tick,
+	// which indicates that its event loop is stuck in an infinite cascade of events.
+	ErrEventCascade = errors.New("event cascade")
+
+	// ErrMalformedScenario is returned when decoding a binary scenario that was not encoded by Scenario.MarshalBinary.
+	ErrMalformedScenario = errors.New("malformed binary scenario")
+
+	// ErrNoEventTrace is returned when replaying a scenario result that does not contain an event trace.
+	ErrNoEventTrace = errors.New("no event trace recorded")
+)
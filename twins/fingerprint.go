@@ -0,0 +1,81 @@
+package twins
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/relab/hotstuff/consensus"
+	"golang.org/x/exp/slices"
+)
+
+// ChainFingerprint returns a stable hash of the sequence of blocks that the replicas agreed to commit.
+// Block hashes cannot be used directly, since they depend on signatures that differ between runs.
+// Instead, the fingerprint covers the view, proposer, and command of each committed block.
+func (r ScenarioResult) ChainFingerprint() string {
+	hasher := sha256.New()
+	var buf [8]byte
+	for _, block := range r.agreedChain() {
+		binary.LittleEndian.PutUint64(buf[:], uint64(block.View()))
+		_, _ = hasher.Write(buf[:])
+		binary.LittleEndian.PutUint32(buf[:4], uint32(block.Proposer()))
+		_, _ = hasher.Write(buf[:4])
+		binary.LittleEndian.PutUint64(buf[:], uint64(len(block.Command())))
+		_, _ = hasher.Write(buf[:])
+		_, _ = hasher.Write([]byte(block.Command()))
+	}
+	return fmt.Sprintf("%x", hasher.Sum(nil))
+}
+
+// DiffChains compares the sequences of blocks that were agreed to be committed in two scenario results.
+// It returns an empty string if the sequences are equal,
+// and otherwise a description of the position where they diverge.
+func DiffChains(a, b ScenarioResult) string {
+	chainA, chainB := a.agreedChain(), b.agreedChain()
+	for i := 0; i < len(chainA) && i < len(chainB); i++ {
+		if !sameBlock(chainA[i], chainB[i]) {
+			return fmt.Sprintf("chains diverge at position %d: %s != %s", i, describeBlock(chainA[i]), describeBlock(chainB[i]))
+		}
+	}
+	switch {
+	case len(chainA) > len(chainB):
+		return fmt.Sprintf("first chain has %d additional blocks, starting with %s", len(chainA)-len(chainB), describeBlock(chainA[len(chainB)]))
+	case len(chainB) > len(chainA):
+		return fmt.Sprintf("second chain has %d additional blocks, starting with %s", len(chainB)-len(chainA), describeBlock(chainB[len(chainA)]))
+	}
+	return ""
+}
+
+// agreedChain returns the first Commits blocks committed by the replicas that do not have twins.
+func (r ScenarioResult) agreedChain() []*consensus.Block {
+	numNodes := make(map[uint32]int)
+	for id := range r.NodeCommits {
+		numNodes[uint32(id.ReplicaID)]++
+	}
+	ids := make([]NodeID, 0, len(r.NodeCommits))
+	for id := range r.NodeCommits {
+		if numNodes[uint32(id.ReplicaID)] == 1 {
+			ids = append(ids, id)
+		}
+	}
+	slices.SortFunc(ids, func(a, b NodeID) bool { return a.NetworkID < b.NetworkID })
+
+	chain := make([]*consensus.Block, 0, r.Commits)
+	for i := 0; i < r.Commits; i++ {
+		for _, id := range ids {
+			if blocks := r.NodeCommits[id]; len(blocks) > i {
+				chain = append(chain, blocks[i])
+				break
+			}
+		}
+	}
+	return chain
+}
+
+func sameBlock(a, b *consensus.Block) bool {
+	return a.View() == b.View() && a.Proposer() == b.Proposer() && a.Command() == b.Command()
+}
+
+func describeBlock(block *consensus.Block) string {
+	return fmt.Sprintf("{view: %d, proposer: %d, command: %q}", block.View(), block.Proposer(), block.Command())
+}
@@ -0,0 +1,49 @@
+package twins
+
+import (
+	"testing"
+
+	_ "github.com/relab/hotstuff/consensus/chainedhotstuff"
+)
+
+func TestChainFingerprint(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}, 5: {}}
+	scenario := Scenario{
+		{Leader: 1, Partitions: []NodeSet{allNodes}},
+		{Leader: 2, Partitions: []NodeSet{allNodes}},
+		{Leader: 3, Partitions: []NodeSet{allNodes}},
+		{Leader: 4, Partitions: []NodeSet{allNodes}},
+		{Leader: 1, Partitions: []NodeSet{allNodes}},
+		{Leader: 2, Partitions: []NodeSet{allNodes}},
+	}
+	execute := func(numTicks int) ScenarioResult {
+		result, err := ExecuteScenario(scenario, 4, 1, numTicks, "chainedhotstuff")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	a, b := execute(100), execute(100)
+	if a.Commits == 0 {
+		t.Fatal("expected some commits")
+	}
+	if a.ChainFingerprint() != b.ChainFingerprint() {
+		t.Errorf("fingerprints differ between identical runs: %s", DiffChains(a, b))
+	}
+	if diff := DiffChains(a, b); diff != "" {
+		t.Errorf("unexpected diff between identical runs: %s", diff)
+	}
+
+	// stopping the scenario early results in a shorter chain.
+	c := execute(4)
+	if c.Commits >= a.Commits {
+		t.Fatalf("expected fewer commits with fewer ticks: got %d, want less than %d", c.Commits, a.Commits)
+	}
+	if a.ChainFingerprint() == c.ChainFingerprint() {
+		t.Error("expected fingerprints of different chains to differ")
+	}
+	if diff := DiffChains(a, c); diff == "" {
+		t.Error("expected a diff between different chains")
+	}
+}
@@ -0,0 +1,85 @@
+package twins
+
+import (
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+)
+
+// Bounds on the scenarios produced by DecodeFuzzInput.
+const (
+	fuzzMinNodes      = 4
+	fuzzMaxNodes      = 7
+	fuzzMaxTwins      = 1
+	fuzzMaxPartitions = 3
+	fuzzMaxViews      = 8
+	fuzzTicks         = 100
+)
+
+// FuzzInput contains a scenario and the parameters needed to execute it.
+type FuzzInput struct {
+	Scenario Scenario
+	NumNodes uint8
+	NumTwins uint8
+	NumTicks int
+	Options  ScenarioOptions
+}
+
+// DecodeFuzzInput maps arbitrary bytes to a valid scenario.
+// Every input, including an empty one, results in a scenario that can be executed:
+// the number of nodes, twins, partitions, and views is bounded,
+// every leader is a valid replica, and every node is assigned to exactly one partition.
+// This makes it suitable for use with Go's native fuzzer.
+func DecodeFuzzInput(data []byte) FuzzInput {
+	rd := fuzzReader{data: data}
+
+	numNodes := fuzzMinNodes + rd.next()%(fuzzMaxNodes-fuzzMinNodes+1)
+	numTwins := rd.next() % (fuzzMaxTwins + 1)
+	numPartitions := 1 + rd.next()%fuzzMaxPartitions
+	numViews := 1 + rd.next()%fuzzMaxViews
+
+	var opts ScenarioOptions
+	if rd.next()%2 == 1 {
+		// permit view synchronization messages between partitions
+		opts.DropTypes = []interface{}{consensus.ProposeMsg{}, consensus.VoteMsg{}, consensus.Hash{}}
+	}
+
+	numNetworkNodes := uint32(numNodes) + uint32(numTwins)
+
+	scenario := make(Scenario, numViews)
+	for i := range scenario {
+		partitions := make([]NodeSet, numPartitions)
+		for j := range partitions {
+			partitions[j] = make(NodeSet)
+		}
+		for id := uint32(1); id <= numNetworkNodes; id++ {
+			partitions[rd.next()%numPartitions].Add(id)
+		}
+		scenario[i] = View{
+			Leader:     hotstuff.ID(1 + rd.next()%numNodes),
+			Partitions: partitions,
+		}
+	}
+
+	return FuzzInput{
+		Scenario: scenario,
+		NumNodes: numNodes,
+		NumTwins: numTwins,
+		NumTicks: fuzzTicks,
+		Options:  opts,
+	}
+}
+
+// fuzzReader reads bytes from data, returning zero once data is exhausted.
+type fuzzReader struct {
+	data []byte
+	pos  int
+}
+
+func (rd *fuzzReader) next() uint8 {
+	if rd.pos >= len(rd.data) {
+		return 0
+	}
+	b := rd.data[rd.pos]
+	rd.pos++
+	return b
+}
@@ -0,0 +1,50 @@
+package twins
+
+import (
+	"testing"
+
+	_ "github.com/relab/hotstuff/consensus/chainedhotstuff"
+)
+
+func FuzzScenario(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{0, 1, 1, 7, 0})
+	f.Add([]byte{3, 1, 2, 5, 1, 0, 1, 0, 1, 0, 1, 2, 1, 1, 0, 0, 1, 3})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		in := DecodeFuzzInput(data)
+		result, err := ExecuteScenarioWithOptions(in.Scenario, in.NumNodes, in.NumTwins, in.NumTicks, "chainedhotstuff", in.Options)
+		if err != nil {
+			t.Fatalf("failed to execute scenario: %v", err)
+		}
+		if !result.Safe {
+			t.Errorf("safety violation in scenario:\n%v\nNetwork log:\n%s", in.Scenario, result.NetworkLog)
+		}
+	})
+}
+
+func TestDecodeFuzzInput(t *testing.T) {
+	inputs := [][]byte{nil, {255, 255, 255, 255, 255}, {1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}}
+	for _, data := range inputs {
+		in := DecodeFuzzInput(data)
+		if in.NumNodes < fuzzMinNodes || in.NumNodes > fuzzMaxNodes || in.NumTwins > fuzzMaxTwins {
+			t.Errorf("invalid node counts: %d nodes, %d twins", in.NumNodes, in.NumTwins)
+		}
+		if len(in.Scenario) == 0 || len(in.Scenario) > fuzzMaxViews {
+			t.Errorf("invalid number of views: %d", len(in.Scenario))
+		}
+		numNetworkNodes := int(in.NumNodes) + int(in.NumTwins)
+		for _, view := range in.Scenario {
+			if view.Leader < 1 || int(view.Leader) > int(in.NumNodes) {
+				t.Errorf("invalid leader: %d", view.Leader)
+			}
+			total := 0
+			for _, partition := range view.Partitions {
+				total += len(partition)
+			}
+			if total != numNetworkNodes {
+				t.Errorf("expected %d nodes in partitions, got %d", numNetworkNodes, total)
+			}
+		}
+	}
+}
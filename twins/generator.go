@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
 	"github.com/relab/hotstuff/logging"
 )
 
@@ -23,13 +24,26 @@ type Generator struct {
 }
 
 func assignNodeIDs(numNodes, numTwins uint8) (nodes, twins []NodeID) {
-	replicaID := hotstuff.ID(1)
+	twinIDs := make([]hotstuff.ID, 0, numTwins)
+	for id := hotstuff.ID(1); id <= hotstuff.ID(numTwins); id++ {
+		twinIDs = append(twinIDs, id)
+	}
+	return assignTwinIDs(numNodes, twinIDs)
+}
+
+// assignTwinIDs assigns IDs to numNodes replicas, where the replicas in twinIDs are given a twin.
+// Network IDs are assigned in order of replica ID, such that a replica and its twin have consecutive network IDs.
+func assignTwinIDs(numNodes uint8, twinIDs []hotstuff.ID) (nodes, twins []NodeID) {
+	isTwin := consensus.NewIDSet()
+	for _, id := range twinIDs {
+		isTwin.Add(id)
+	}
+
 	networkID := uint32(1)
-	remainingTwins := numTwins
 
 	// assign IDs to nodes
-	for i := uint8(0); i < numNodes; i++ {
-		if remainingTwins > 0 {
+	for replicaID := hotstuff.ID(1); replicaID <= hotstuff.ID(numNodes); replicaID++ {
+		if isTwin.Contains(replicaID) {
 			twins = append(twins, NodeID{
 				ReplicaID: replicaID,
 				NetworkID: networkID,
@@ -39,7 +53,6 @@ func assignNodeIDs(numNodes, numTwins uint8) (nodes, twins []NodeID) {
 				ReplicaID: replicaID,
 				NetworkID: networkID,
 			})
-			remainingTwins--
 		} else {
 			nodes = append(nodes, NodeID{
 				ReplicaID: replicaID,
@@ -47,7 +60,6 @@ func assignNodeIDs(numNodes, numTwins uint8) (nodes, twins []NodeID) {
 			})
 		}
 		networkID++
-		replicaID++
 	}
 
 	return
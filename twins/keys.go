@@ -0,0 +1,88 @@
+package twins
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/relab/hotstuff/consensus"
+	ecdsacrypto "github.com/relab/hotstuff/crypto/ecdsa"
+)
+
+// deriveKey derives the private key of a node from the seed and the node's ID,
+// such that the same seed always gives a node the same key.
+func deriveKey(seed int64, id NodeID) *ecdsa.PrivateKey {
+	var buf [16]byte
+	binary.LittleEndian.PutUint64(buf[0:8], uint64(seed))
+	binary.LittleEndian.PutUint32(buf[8:12], uint32(id.ReplicaID))
+	binary.LittleEndian.PutUint32(buf[12:16], id.NetworkID)
+	hash := sha256.Sum256(append([]byte("twins private key"), buf[:]...))
+
+	curve := elliptic.P256()
+	d := scalar(curve, hash[:])
+	pk := &ecdsa.PrivateKey{D: d}
+	pk.PublicKey.Curve = curve
+	pk.PublicKey.X, pk.PublicKey.Y = curve.ScalarBaseMult(d.Bytes())
+	return pk
+}
+
+// scalar maps the bytes to a number in [1, N-1], where N is the order of the curve.
+func scalar(curve elliptic.Curve, b []byte) *big.Int {
+	n := new(big.Int).Sub(curve.Params().N, big.NewInt(1))
+	k := new(big.Int).SetBytes(b)
+	k.Mod(k, n)
+	return k.Add(k, big.NewInt(1))
+}
+
+// seededSigner creates ECDSA signatures with a nonce derived from the private key and the message,
+// instead of a random nonce, such that a node with a derived key always creates the same signature of a message.
+type seededSigner struct {
+	consensus.CryptoBase
+	mods *consensus.Modules
+}
+
+// InitConsensusModule gives the module a reference to the Modules object.
+// It also allows the module to set module options using the OptionsBuilder.
+func (s *seededSigner) InitConsensusModule(mods *consensus.Modules, opts *consensus.OptionsBuilder) {
+	s.mods = mods
+	if mod, ok := s.CryptoBase.(consensus.Module); ok {
+		mod.InitConsensusModule(mods, opts)
+	}
+}
+
+// Sign creates a cryptographic signature of the given message.
+func (s *seededSigner) Sign(message []byte) (signature consensus.QuorumSignature, err error) {
+	pk, ok := s.mods.PrivateKey().(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("seeded signer: unsupported private key type %T", s.mods.PrivateKey())
+	}
+	hash := sha256.Sum256(message)
+	curve := pk.Curve
+	n := curve.Params().N
+	e := new(big.Int).SetBytes(hash[:])
+
+	mac := hmac.New(sha256.New, pk.D.Bytes())
+	for {
+		mac.Write(hash[:])
+		k := scalar(curve, mac.Sum(nil))
+		x, _ := curve.ScalarBaseMult(k.Bytes())
+		r := new(big.Int).Mod(x, n)
+		if r.Sign() == 0 {
+			continue
+		}
+		// s = k^-1 (e + r*d) mod n
+		sig := new(big.Int).Mul(r, pk.D)
+		sig.Add(sig, e)
+		sig.Mul(sig, new(big.Int).ModInverse(k, n))
+		sig.Mod(sig, n)
+		if sig.Sign() == 0 {
+			continue
+		}
+		id := s.mods.ID()
+		return ecdsacrypto.RestoreMultiSignature([]*ecdsacrypto.Signature{ecdsacrypto.RestoreSignature(r, sig, id)}), nil
+	}
+}
@@ -0,0 +1,83 @@
+package twins
+
+import (
+	"testing"
+
+	_ "github.com/relab/hotstuff/consensus/chainedhotstuff"
+)
+
+func TestDeriveKey(t *testing.T) {
+	id := NodeID{ReplicaID: 1, NetworkID: 1}
+	twin := NodeID{ReplicaID: 1, NetworkID: 5}
+	if !deriveKey(1, id).Equal(deriveKey(1, id)) {
+		t.Error("got different keys from the same seed")
+	}
+	if deriveKey(1, id).Equal(deriveKey(2, id)) {
+		t.Error("got the same key from different seeds")
+	}
+	if deriveKey(1, id).Equal(deriveKey(1, twin)) {
+		t.Error("got the same key for a replica and its twin")
+	}
+}
+
+func TestSeededKeys(t *testing.T) {
+	scenario, err := NewCleanScenario(4, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the hashes of the committed blocks depend on the signatures in their quorum certificates.
+	commits := func(seed int64) []string {
+		result, err := ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{Seed: seed})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Safe || result.Commits == 0 {
+			t.Fatalf("seed %d: got safe=%v with %d commits, want a safe result with commits", seed, result.Safe, result.Commits)
+		}
+		var hashes []string
+		for _, block := range result.NodeCommits[NodeID{ReplicaID: 1, NetworkID: 1}] {
+			hashes = append(hashes, block.Hash().String())
+		}
+		return hashes
+	}
+
+	first, second := commits(42), commits(42)
+	if len(first) != len(second) {
+		t.Fatalf("got %d and %d commits with the same seed", len(first), len(second))
+	}
+	for i := range first {
+		if first[i] != second[i] {
+			t.Errorf("commit %d: got different blocks %s and %s with the same seed", i, first[i], second[i])
+		}
+	}
+
+	// without a seed, the signatures, and thus the blocks, differ between executions.
+	random := commits(0)
+	if len(random) > 1 && len(first) > 1 && random[1] == first[1] {
+		t.Error("got the same block with random keys as with a seed")
+	}
+}
+
+func TestSeededLogs(t *testing.T) {
+	scenario, err := NewCleanScenario(4, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the same seed gives the same messages in the same order, and thus identical logs.
+	execute := func() ScenarioResult {
+		result, err := ExecuteScenarioWithOptions(scenario, 4, 1, 100, "chainedhotstuff", ScenarioOptions{Seed: 42})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	first, second := execute(), execute()
+	if first.NetworkLog != second.NetworkLog {
+		t.Errorf("got different network logs with the same seed:\n%s\n%s", first.NetworkLog, second.NetworkLog)
+	}
+	for id, log := range first.NodeLogs {
+		if log != second.NodeLogs[id] {
+			t.Errorf("node %v: got different logs with the same seed:\n%s\n%s", id, log, second.NodeLogs[id])
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package twins
+
+import (
+	"fmt"
+
+	"github.com/relab/hotstuff"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// maxLivenessNodes is the largest number of nodes that FindLivenessBreakingPartition can search.
+	maxLivenessNodes = 16
+	// livenessViews is the number of views in the scenarios executed by FindLivenessBreakingPartition.
+	livenessViews = 10
+	// livenessTicks is the number of ticks that each scenario executed by FindLivenessBreakingPartition runs for.
+	livenessTicks = 200
+)
+
+// FindLivenessBreakingPartition searches for a partition of numNodes nodes that prevents the nodes from committing.
+// Each candidate partition is kept for the entire scenario, and the leaders rotate among the replicas
+// of the partition with the most stake, such that a partition stalls only if no part of the network
+// can make progress on its own. A partition stalls if none of the nodes commit, or if the liveness check
+// of the result fails.
+//
+// The candidates are generated lazily by the number of partitions, starting with two, and the candidates with the same
+// number of partitions are tried in order of the fewest links between partitions. Like the scenario generator,
+// the candidates differ only in the sizes of the partitions, and the nodes are assigned to them in order of ID.
+// The scenarios are executed with the named consensus implementation, using the given options.
+// The returned view contains the partition and the first leader that was used.
+// It returns false if every partition allows the nodes to commit, and an error if a scenario cannot be executed.
+func FindLivenessBreakingPartition(numNodes int, consensusName string, opts ScenarioOptions) (View, bool, error) {
+	if numNodes < 1 || numNodes > maxLivenessNodes {
+		return View{}, false, fmt.Errorf("%w: %d nodes; must be between 1 and %d", ErrInvalidScenario, numNodes, maxLivenessNodes)
+	}
+	nodes, _ := assignNodeIDs(uint8(numNodes), 0)
+
+	for k := 2; k <= numNodes; k++ {
+		candidates := partitionsOfSize(nodes, k)
+		slices.SortStableFunc(candidates, func(a, b []NodeSet) bool { return cutEdges(a) < cutEdges(b) })
+
+		for _, partitions := range candidates {
+			scenario := livenessScenario(partitions, opts.Stake)
+			result, err := ExecuteScenarioWithOptions(scenario, uint8(numNodes), 0, livenessTicks, consensusName, opts)
+			if err != nil {
+				return View{}, false, err
+			}
+			if !result.Live || !hasCommits(result) {
+				return scenario[0], true, nil
+			}
+		}
+	}
+	return View{}, false, nil
+}
+
+// partitionsOfSize returns the ways to divide the nodes into exactly k non-empty partitions that differ in their sizes.
+func partitionsOfSize(nodes []NodeID, k int) (candidates [][]NodeSet) {
+	for _, partitions := range genPartitionScenarios(nil, nodes, uint8(k), 1) {
+		// the generator leaves partitions empty when there are fewer than k.
+		nonEmpty := make([]NodeSet, 0, k)
+		for _, partition := range partitions {
+			if len(partition) > 0 {
+				nonEmpty = append(nonEmpty, partition)
+			}
+		}
+		if len(nonEmpty) == k {
+			candidates = append(candidates, nonEmpty)
+		}
+	}
+	return candidates
+}
+
+// livenessScenario returns a scenario that keeps the partitions for all views,
+// with the leaders rotating among the replicas of the partition with the most stake.
+func livenessScenario(partitions []NodeSet, stake map[hotstuff.ID]uint64) Scenario {
+	var (
+		leaders   []hotstuff.ID
+		bestStake uint64
+	)
+	for _, partition := range partitions {
+		var s uint64
+		for id := range partition {
+			if w, ok := stake[hotstuff.ID(id)]; ok {
+				s += w
+			} else {
+				s++
+			}
+		}
+		if s > bestStake {
+			bestStake = s
+			leaders = leaders[:0]
+			for id := range partition {
+				leaders = append(leaders, hotstuff.ID(id))
+			}
+		}
+	}
+	slices.Sort(leaders)
+
+	scenario := make(Scenario, livenessViews)
+	for i := range scenario {
+		scenario[i] = View{Leader: leaders[i%len(leaders)], Partitions: partitions}
+	}
+	return scenario
+}
+
+// hasCommits returns true if any node in the result committed a block.
+func hasCommits(result ScenarioResult) bool {
+	for _, commits := range result.NodeCommits {
+		if len(commits) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// cutEdges returns the number of links between nodes in different partitions.
+func cutEdges(partitions []NodeSet) int {
+	var n, inside int
+	for _, partition := range partitions {
+		n += len(partition)
+		inside += len(partition) * len(partition)
+	}
+	return (n*n - inside) / 2
+}
@@ -0,0 +1,64 @@
+package twins
+
+import (
+	"errors"
+	"testing"
+
+	_ "github.com/relab/hotstuff/consensus/chainedhotstuff"
+)
+
+func TestFindLivenessBreakingPartition(t *testing.T) {
+	view, ok, err := FindLivenessBreakingPartition(4, "chainedhotstuff", ScenarioOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("expected to find a partition that breaks liveness")
+	}
+	// a single node can be isolated without breaking liveness, but splitting the nodes in half leaves no quorum.
+	if len(view.Partitions) != 2 {
+		t.Fatalf("got %d partitions, want 2: %v", len(view.Partitions), view)
+	}
+	for _, partition := range view.Partitions {
+		if len(partition) != 2 {
+			t.Errorf("got partition of size %d, want 2: %v", len(partition), view)
+		}
+	}
+	if cutEdges(view.Partitions) != 4 {
+		t.Errorf("got %d links between partitions, want 4", cutEdges(view.Partitions))
+	}
+	t.Log(view)
+}
+
+func TestFindLivenessBreakingPartitionErrors(t *testing.T) {
+	if _, _, err := FindLivenessBreakingPartition(4, "unknown", ScenarioOptions{}); !errors.Is(err, ErrUnknownConsensus) {
+		t.Errorf("got %v, want %v", err, ErrUnknownConsensus)
+	}
+	for _, n := range []int{0, maxLivenessNodes + 1} {
+		if _, _, err := FindLivenessBreakingPartition(n, "chainedhotstuff", ScenarioOptions{}); !errors.Is(err, ErrInvalidScenario) {
+			t.Errorf("%d nodes: got %v, want %v", n, err, ErrInvalidScenario)
+		}
+	}
+}
+
+func TestPartitionsOfSize(t *testing.T) {
+	nodes, _ := assignNodeIDs(6, 0)
+	// the number of ways to write 6 as a sum of k positive integers.
+	for k, want := range map[int]int{1: 1, 2: 3, 3: 3, 4: 2, 5: 1, 6: 1} {
+		candidates := partitionsOfSize(nodes, k)
+		if len(candidates) != want {
+			t.Errorf("%d partitions: got %d candidates, want %d", k, len(candidates), want)
+		}
+		for _, partitions := range candidates {
+			seen := make(NodeSet)
+			for _, partition := range partitions {
+				for id := range partition {
+					seen.Add(id)
+				}
+			}
+			if len(partitions) != k || len(seen) != len(nodes) {
+				t.Errorf("%d partitions: got %v", k, partitions)
+			}
+		}
+	}
+}
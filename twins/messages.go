@@ -0,0 +1,106 @@
+package twins
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// MessageAction is what the network did with a message.
+type MessageAction uint8
+
+const (
+	// MessageSent means that the network accepted the message for delivery.
+	MessageSent MessageAction = iota
+	// MessageDropped means that the network dropped the message.
+	MessageDropped
+	// MessageHeld means that the network held the message until the partition of the sender and receiver heals.
+	MessageHeld
+	// MessageReleased means that the network released a held message for delivery.
+	MessageReleased
+	// MessageSuppressed means that the message was not sent, because the sender is a learner.
+	MessageSuppressed
+)
+
+func (a MessageAction) String() string {
+	switch a {
+	case MessageSent:
+		return "sent"
+	case MessageDropped:
+		return "dropped"
+	case MessageHeld:
+		return "held"
+	case MessageReleased:
+		return "released"
+	case MessageSuppressed:
+		return "suppressed"
+	}
+	return fmt.Sprintf("MessageAction(%d)", uint8(a))
+}
+
+// MessageEvent is something that the network did with a message sent from one node to another.
+// A fragmented message is recorded once, when its first fragment is sent.
+type MessageEvent struct {
+	// Tick is the network tick during which the event happened.
+	Tick   int
+	Action MessageAction
+	From   NodeID
+	// To is the node that the message was sent to.
+	// For suppressed messages, only the replica ID is set, since the message was not sent to any node.
+	To NodeID
+	// Message is the message, such as a consensus.ProposeMsg or a consensus.TimeoutMsg.
+	Message interface{}
+	// Reason is the reason that a dropped message was dropped.
+	Reason DropReason
+}
+
+// Is returns true if the message of the event has the same type as the given message.
+func (e MessageEvent) Is(message interface{}) bool {
+	return reflect.TypeOf(e.Message) == reflect.TypeOf(message)
+}
+
+func (e MessageEvent) String() string {
+	return fmt.Sprintf("tick %d: %v -> %v: %v %T", e.Tick, e.From, e.To, e.Action, e.Message)
+}
+
+// CountEvents returns the number of message events for which predicate returns true.
+func (r ScenarioResult) CountEvents(predicate func(MessageEvent) bool) int {
+	count := 0
+	for _, event := range r.MessageEvents {
+		if predicate(event) {
+			count++
+		}
+	}
+	return count
+}
+
+// CountSent returns the number of messages with the same type as the given message that the network accepted
+// for delivery, including held messages that were later released. Each receiver is counted separately.
+func (r ScenarioResult) CountSent(message interface{}) int {
+	return r.CountEvents(func(e MessageEvent) bool {
+		return (e.Action == MessageSent || e.Action == MessageReleased) && e.Is(message)
+	})
+}
+
+// CountDropped returns the number of messages with the same type as the given message that the network dropped.
+// Each receiver is counted separately.
+func (r ScenarioResult) CountDropped(message interface{}) int {
+	return r.CountEvents(func(e MessageEvent) bool {
+		return e.Action == MessageDropped && e.Is(message)
+	})
+}
+
+// recordMessageEvent records what the network did with a message or fragment.
+// Fragments other than the first fragment of a message are not recorded.
+func (n *Network) recordMessageEvent(action MessageAction, from, to NodeID, message interface{}, reason DropReason) {
+	if f, ok := message.(fragment); ok && f.index > 0 {
+		return
+	}
+	n.messageEvents = append(n.messageEvents, MessageEvent{
+		Tick:    n.currentTick,
+		Action:  action,
+		From:    from,
+		To:      to,
+		Message: payload(message),
+		Reason:  reason,
+	})
+}
@@ -0,0 +1,52 @@
+package twins
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+	_ "github.com/relab/hotstuff/consensus/chainedhotstuff"
+)
+
+func TestCountEvents(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	// node 4 is isolated in the first view.
+	scenario := Scenario{{Leader: 1, Partitions: []NodeSet{{1: {}, 2: {}, 3: {}}, {4: {}}}}}
+	for i := 1; i < 10; i++ {
+		scenario = append(scenario, View{Leader: hotstuff.ID(i%4 + 1), Partitions: []NodeSet{allNodes}})
+	}
+
+	result, err := ExecuteScenario(scenario, 4, 0, 100, "chainedhotstuff")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the proposal of the first view is dropped on its way to node 4.
+	node4 := NodeID{ReplicaID: 4, NetworkID: 4}
+	dropped := result.CountEvents(func(e MessageEvent) bool {
+		proposal, ok := e.Message.(consensus.ProposeMsg)
+		return e.Action == MessageDropped && e.To == node4 && ok && proposal.Block.View() == 1
+	})
+	if dropped != 1 {
+		t.Errorf("got %d dropped proposals for view 1 to node 4, want 1", dropped)
+	}
+	if got := result.CountDropped(consensus.ProposeMsg{}); got < dropped {
+		t.Errorf("got %d dropped proposals, want at least %d", got, dropped)
+	}
+	if got := result.CountSent(consensus.ProposeMsg{}); got == 0 {
+		t.Error("expected proposals to be sent")
+	}
+	if got := result.CountSent(consensus.VoteMsg{}); got == 0 {
+		t.Error("expected votes to be sent")
+	}
+
+	// the events match the messages in the network log.
+	sent := result.CountEvents(func(e MessageEvent) bool { return e.Action == MessageSent })
+	if want := strings.Count(result.NetworkLog, ": SEND "); sent != want {
+		t.Errorf("got %d sent messages, want %d as in the network log", sent, want)
+	}
+	if got := result.CountEvents(func(e MessageEvent) bool { return e.Action == MessageSuppressed }); got != 0 {
+		t.Errorf("got %d suppressed messages without learners, want 0", got)
+	}
+}
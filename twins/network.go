@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"reflect"
 	"strings"
 	"time"
@@ -19,6 +20,7 @@ import (
 	"github.com/relab/hotstuff/synchronizer"
 	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
+	"google.golang.org/protobuf/proto"
 )
 
 // NodeID is an ID that is unique to a node in the network.
@@ -37,13 +39,159 @@ type node struct {
 	id             NodeID
 	mods           *consensus.Modules
 	executedBlocks []*consensus.Block
-	effectiveView  consensus.View
-	log            strings.Builder
+	// the committed block that the node starts from.
+	initialBlock  *consensus.Block
+	effectiveView consensus.View
+	viewHistory   []ViewTransition
+	// the block that caused the most recent commit.
+	commitTrigger *consensus.Block
+	// the depth of the quorum certificate chain behind each committed block.
+	commitDepths map[consensus.Hash]int
+	log          strings.Builder
+
+	// the events processed by the node, recorded if the network's recordEvents is set.
+	eventTrace []EventRecord
+	// the messages delivered to the node, recorded if the network's recordEvents is set.
+	deliveries []EventRecord
+	// the signatures created by the node, recorded if the network's recordEvents is set.
+	signatures map[consensus.Hash]consensus.QuorumSignature
+	// the position of the next recorded message to deliver when replaying.
+	replayPos int
+	// the command queue of the node, or nil if the node has an unlimited supply of commands.
+	commands *commandQueue
+	// the number of fragments received of each fragmented message that has not been reassembled yet.
+	fragments map[uint64]int
+	// the number of times the node's view has timed out.
+	timeouts int
+	// the number of empty blocks that the node has proposed.
+	emptyProposals int
+	// the number of blocks that the node has fetched from other nodes.
+	fetches int
+	// the number of blocks removed from the blockchain by pruning.
+	prunedBlocks int
+	// the timeout certificates formed by the node.
+	timeoutCerts []TimeoutCertRecord
+	// the number of proposals that the node rejected because their blocks exceeded its maximum block size.
+	oversizedProposals int
+	// statistics about the node's event loop.
+	eventStats EventStats
+	// the view that the node sent messages from when its partitions were last checked.
+	partitionView consensus.View
+}
+
+// EventStats contains statistics about the event loop of a node.
+type EventStats struct {
+	// Processed is the number of events that the node processed.
+	Processed int
+	// Requeued is the number of events that were delayed until another event had been processed, and then added back to the queue.
+	Requeued int
+	// PeakDepth is the largest number of events that were waiting in the node's event queue at the same time.
+	PeakDepth int
+}
+
+// ViewTransition records a view change made by a node.
+type ViewTransition struct {
+	// View is the view that the node entered.
+	View consensus.View
+	// Timeout is true if the node entered the view due to a timeout.
+	Timeout bool
+	// Tick is the network tick during which the node entered the view.
+	Tick int
 }
 
 type pendingMessage struct {
 	message  interface{}
+	sender   uint32
 	receiver uint32
+	// the number of ticks remaining before the message is delivered.
+	delay int
+}
+
+// fragment is a part of a message that was split because its size exceeds the network's fragment size.
+type fragment struct {
+	// the ID of the fragmented message, which is unique within the network.
+	messageID uint64
+	index     int
+	count     int
+	// the fragmented message, which is delivered when all fragments have been received.
+	message interface{}
+}
+
+func (f fragment) String() string {
+	return fmt.Sprintf("fragment %d/%d of message %d", f.index+1, f.count, f.messageID)
+}
+
+// outgoingFragment is a fragment that has yet to leave its sender.
+type outgoingFragment struct {
+	sender   *node
+	receiver *node
+	fragment fragment
+	// the number of ticks remaining before the fragment is sent.
+	wait int
+}
+
+// payload returns the message carried by a fragment, or the message itself if it is not a fragment.
+func payload(message interface{}) interface{} {
+	if f, ok := message.(fragment); ok {
+		return f.message
+	}
+	return message
+}
+
+// messageSize returns the size of the message in bytes, as considered for fragmentation and block size limits.
+// Only proposals are fragmented, so other messages have a size of zero.
+func messageSize(message interface{}) int {
+	if proposal, ok := message.(consensus.ProposeMsg); ok && proposal.Block != nil {
+		return len(proposal.Block.ToBytes())
+	}
+	return 0
+}
+
+// Link is a directed link between two nodes, identified by their network IDs.
+type Link struct {
+	From uint32
+	To   uint32
+}
+
+// RegionKey is the connection metadata key that holds the region of a node.
+const RegionKey = "region"
+
+// RegionLink is a directed link between two regions.
+type RegionLink struct {
+	From string
+	To   string
+}
+
+// Latency specifies additional delays, measured in ticks, for messages sent in the network.
+// The delay of a message is the sum of the delay of its link, the delay between the regions of its sender and receiver,
+// and the delay of its type.
+// Without any delay, a message is delivered in the tick after it was sent.
+type Latency struct {
+	// Links contains the delay of each link.
+	Links map[Link]int
+	// Regions contains the delay between each pair of regions, where the region of a node is
+	// the RegionKey entry of its metadata (see ScenarioOptions.Metadata).
+	// If a pair of regions is not present, the delay of the reverse pair is used.
+	// Nodes without a region do not have any delay between regions.
+	Regions map[RegionLink]int
+	// Types contains the delay of each message type, e.g. reflect.TypeOf(consensus.ProposeMsg{}).
+	Types map[reflect.Type]int
+}
+
+// delay returns the delay of a message of the given type sent on the given link between the given regions.
+func (l Latency) delay(link Link, regions RegionLink, message interface{}) int {
+	return l.Links[link] + l.regionDelay(regions) + l.Types[reflect.TypeOf(message)]
+}
+
+// regionDelay returns the delay between the regions, or zero if either region is unknown.
+func (l Latency) regionDelay(regions RegionLink) int {
+	if regions.From == "" || regions.To == "" {
+		return 0
+	}
+	if delay, ok := l.Regions[regions]; ok {
+		return delay
+	}
+	return l.Regions[RegionLink{From: regions.To, To: regions.From}]
 }
 
 // Network is a simulated network that supports twins.
@@ -53,11 +201,135 @@ type Network struct {
 	replicas map[hotstuff.ID][]*node
 	// For each view (starting at 1), contains the list of partitions for that view.
 	views []View
+	// decides the partitions of each view, if set.
+	partitionFunc PartitionFunc
+	// whether the partitions of each view have been decided by partitionFunc.
+	partitionsDecided []bool
 
 	// the message types to drop
 	dropTypes map[reflect.Type]struct{}
+	// the message types to hold, instead of dropping them, until the partition heals.
+	holdTypes map[reflect.Type]struct{}
+	// the messages held on each link, in the order they were sent.
+	heldMessages map[Link][]interface{}
+
+	// replicas that never vote or propose.
+	learners consensus.IDSet
+
+	// the stake of each replica. Replicas that are not present have a stake of 1.
+	stake map[hotstuff.ID]uint64
 
 	pendingMessages []pendingMessage
+	// whether messages on the same link are delivered in the order they were sent, regardless of their latency.
+	fifoLinks bool
+
+	// the maximum size of a message in bytes before it is fragmented, or zero if messages are never fragmented.
+	fragmentSize int
+	// the fragments that have yet to leave their senders.
+	outgoingFragments []outgoingFragment
+	// the ID of the next fragmented message.
+	nextMessageID uint64
+
+	// the number of ticks that each node needs to persist a block before voting for it, by network ID.
+	persistenceDelays map[uint32]int
+	// the votes that have yet to leave their senders, because their blocks are being persisted.
+	deferredVotes []deferredVote
+
+	// the additional delay of messages.
+	latency Latency
+
+	// the capacity of each node's command queue, or zero if the nodes have unlimited commands.
+	commandQueueCapacity int
+	// the mean number of commands that arrive at each node per tick, or zero if commands are always available.
+	commandArrivalRate float64
+	// the number of independent command streams.
+	streams int
+	// what the nodes do when their command queues are empty.
+	starvationPolicy StarvationPolicy
+	// the seed of the command arrivals.
+	arrivalSeed int64
+	// the seed from which the private keys of the nodes are derived, or zero if the keys are random.
+	keySeed int64
+	// the rate at which each node's clock advances per tick, by network ID. Nodes that are not present have a rate of 1.
+	clockRates map[uint32]float64
+	// the additional delay of the proposals sent by each node, by network ID.
+	proposalDelays map[uint32]int
+	// the maximum number of blocks stored by each node, by network ID. Nodes that are not present store all blocks.
+	blockCacheSizes map[uint32]int
+	// the number of views below its committed block for which each node keeps blocks, or zero to keep all blocks.
+	pruneDepth int
+	// the blocks that were pruned while they could still be needed.
+	pruneViolations []PruneViolation
+	// the first node to prune each pruned block.
+	prunedBy map[consensus.Hash]prunedBlock
+	// the maximum size in bytes of the blocks accepted by each node, by network ID. Nodes that are not present accept all blocks.
+	maxBlockSizes map[uint32]int
+	// the connection metadata of each node, by network ID.
+	metadata map[uint32]map[string]string
+	// the network ID of the node that starts from a forged initial block, or zero if all nodes start from genesis.
+	genesisMismatch uint32
+	// the forged block injected into a node's committed chain, or nil if no block is forged.
+	commitMismatch *CommitMismatch
+
+	// the name of the leader rotation module used by the nodes, or empty if the leaders are taken from the views.
+	leaderRotation string
+	// the leaders chosen by the leader rotation module, or nil if the leaders are taken from the views.
+	leaders map[consensus.View]hotstuff.ID
+	// the chain length passed to the consensus implementation, or zero to use its default.
+	chainLength int
+	// the number of faulty replicas tolerated by the protocol.
+	faultModel FaultModel
+	// whether the nodes verify votes in separate goroutines.
+	asyncVoteVerification bool
+
+	// whether to record the events processed by each node.
+	recordEvents bool
+	// if set, the network delivers recorded messages instead of the messages sent by the nodes.
+	replay *replayData
+
+	// the number of ticks that have been performed.
+	currentTick int
+	// true once the initial proposals have been made.
+	started bool
+	// whether to stop running once the replicas have committed different blocks.
+	abortOnSafetyViolation bool
+	// the first block committed at each position by a replica without a twin, recorded if abortOnSafetyViolation is set.
+	committedHashes map[int]consensus.Hash
+	// true once the replicas have committed different blocks, if abortOnSafetyViolation is set.
+	safetyViolated bool
+	// the maximum number of messages to accept for delivery, or zero for no limit.
+	maxMessages int
+	// the number of messages accepted for delivery.
+	acceptedMessages int
+	// true once a message was dropped because maxMessages had been reached.
+	budgetExceeded bool
+	// true during the extra tick performed by checkQuiescence, in which messages sent by nodes are not delivered.
+	checkingQuiescence bool
+	// the number of messages that the nodes tried to send while checkingQuiescence was set.
+	sendsAfterEnd int
+	// called for each event processed by a node, if set.
+	onEvent func(id NodeID, event interface{})
+
+	// the events of each block that was proposed.
+	blockEvents map[consensus.Hash][]BlockEvent
+	// the messages sent between nodes, in the order they were sent.
+	messageEvents []MessageEvent
+	// the tick of the first proposal in each view.
+	proposalTicks map[consensus.View]int
+	// the tick at which a quorum certificate was first formed in each view.
+	quorumTicks map[consensus.View]int
+
+	// the number of dropped messages, by reason.
+	drops map[DropReason]int
+	// the number of messages delivered between nodes in different partitions.
+	partitionCrossings int
+
+	// how to handle nodes that propose more than once in the same view.
+	duplicateProposals DuplicateProposalPolicy
+	// the views in which each node (by network ID) has proposed.
+	proposedViews map[uint32]map[consensus.View]struct{}
+	// the first duplicate proposal detected with FailOnDuplicateProposals.
+	duplicateProposalErr error
 
 	logger logging.Logger
 	// the destination of the logger
@@ -70,6 +342,8 @@ func NewSimpleNetwork() *Network {
 		nodes:     make(map[uint32]*node),
 		replicas:  make(map[hotstuff.ID][]*node),
 		dropTypes: make(map[reflect.Type]struct{}),
+		holdTypes: make(map[reflect.Type]struct{}),
+		learners:  consensus.NewIDSet(),
 	}
 }
 
@@ -81,6 +355,8 @@ func NewPartitionedNetwork(views []View, dropTypes ...interface{}) *Network {
 		replicas:  make(map[hotstuff.ID][]*node),
 		views:     views,
 		dropTypes: make(map[reflect.Type]struct{}),
+		holdTypes: make(map[reflect.Type]struct{}),
+		learners:  consensus.NewIDSet(),
 	}
 	n.logger = logging.NewWithDest(&n.log, "network")
 	for _, t := range dropTypes {
@@ -89,117 +365,532 @@ func NewPartitionedNetwork(views []View, dropTypes ...interface{}) *Network {
 	return n
 }
 
+// SetLogWriter makes the network logger write to w in addition to the buffer that is returned in ScenarioResult.NetworkLog.
+// This can be used to follow the messages of a scenario while it is running.
+func (n *Network) SetLogWriter(w io.Writer) {
+	n.logger = logging.NewWithDest(io.MultiWriter(&n.log, w), "network")
+}
+
 // GetNodeBuilder returns a consensus.Builder instance for a node in the network.
-func (n *Network) GetNodeBuilder(id NodeID, pk consensus.PrivateKey) consensus.Builder {
+// It returns ErrDuplicateNetworkID if the network already has a node with the same network ID.
+func (n *Network) GetNodeBuilder(id NodeID, pk consensus.PrivateKey) (consensus.Builder, error) {
+	if existing, ok := n.nodes[id.NetworkID]; ok {
+		return consensus.Builder{}, fmt.Errorf("%w: cannot add node %v, network ID %d is used by node %v",
+			ErrDuplicateNetworkID, id, id.NetworkID, existing.id)
+	}
 	node := node{
-		id: id,
+		id:           id,
+		initialBlock: consensus.GetGenesis(),
+		commitDepths: make(map[consensus.Hash]int),
+		signatures:   make(map[consensus.Hash]consensus.QuorumSignature),
 	}
 	n.nodes[id.NetworkID] = &node
 	n.replicas[id.ReplicaID] = append(n.replicas[id.ReplicaID], &node)
 	builder := consensus.NewBuilder(id.ReplicaID, pk)
 	// register node as an anonymous module because that allows configuration to obtain it.
 	builder.Register(&node)
-	return builder
+	return builder, nil
+}
+
+// newCommandQueue returns a command queue for the node with the network's capacity and arrival rate,
+// or nil if the nodes should have an unlimited supply of commands.
+func (n *Network) newCommandQueue(id NodeID) *commandQueue {
+	if n.commandQueueCapacity == 0 && n.commandArrivalRate == 0 {
+		return nil
+	}
+	q := &commandQueue{
+		capacity:     n.commandQueueCapacity,
+		inFlight:     make(map[consensus.Command]struct{}),
+		proposeEmpty: n.starvationPolicy == ProposeEmptyBlocks,
+	}
+	if n.commandArrivalRate > 0 {
+		q.arrivals = newArrivalProcess(n.commandArrivalRate, n.arrivalSeed+int64(id.NetworkID))
+	}
+	return q
+}
+
+// clockRate returns the rate at which the node's clock advances per tick.
+func (n *Network) clockRate(id NodeID) float64 {
+	if rate, ok := n.clockRates[id.NetworkID]; ok {
+		return rate
+	}
+	return 1
+}
+
+// generateKey generates the private key of a node. It can be replaced by tests.
+var generateKey = func() (consensus.PrivateKey, error) {
+	return keygen.GenerateECDSAPrivateKey()
 }
 
 func (n *Network) createTwinsNodes(nodes []NodeID, scenario Scenario, consensusName string) error {
-	cg := &commandGenerator{}
+	streams := newCommandStreams(n.streams)
 	for _, nodeID := range nodes {
 
-		var err error
-		pk, err := keygen.GenerateECDSAPrivateKey()
+		var (
+			pk  consensus.PrivateKey
+			err error
+		)
+		if n.replay != nil {
+			pk = n.replay.keys[nodeID.NetworkID]
+		} else if n.keySeed != 0 {
+			pk = deriveKey(n.keySeed, nodeID)
+		} else {
+			pk, err = generateKey()
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrKeyGen, err)
+			}
+		}
+
+		builder, err := n.GetNodeBuilder(nodeID, pk)
 		if err != nil {
 			return err
 		}
-
-		builder := n.GetNodeBuilder(nodeID, pk)
 		node := n.nodes[nodeID.NetworkID]
+		node.commands = n.newCommandQueue(nodeID)
 
 		consensusModule, ok := modules.GetModule[consensus.Rules](consensusName)
 		if !ok {
-			return fmt.Errorf("unknown consensus module: '%s'", consensusName)
+			return fmt.Errorf("%w: '%s'; available: %v", ErrUnknownConsensus, consensusName, modules.ListModulesOf[consensus.Rules]())
+		}
+		var leaderRotationModule consensus.LeaderRotation = leaderRotation(n.views)
+		if n.leaderRotation != "" {
+			rotation, ok := modules.GetModule[consensus.LeaderRotation](n.leaderRotation)
+			if !ok {
+				return fmt.Errorf("%w: '%s'; available: %v",
+					ErrUnknownLeaderRotation, n.leaderRotation, modules.ListModulesOf[consensus.LeaderRotation]())
+			}
+			leaderRotationModule = recordingLeaderRotation{LeaderRotation: rotation, network: n}
+		}
+		initialBlock := consensus.GetGenesis()
+		if n.genesisMismatch == nodeID.NetworkID {
+			initialBlock = forgeInitialBlock(initialBlock)
+		}
+		var cryptoImpl consensus.CryptoBase = ecdsa.New()
+		if n.keySeed != 0 {
+			cryptoImpl = &seededSigner{CryptoBase: cryptoImpl}
+		}
+		if n.recordEvents {
+			signer := &traceSigner{CryptoBase: cryptoImpl, node: node}
+			if n.replay != nil {
+				signer.replay = n.replay.signatures[nodeID.NetworkID]
+			}
+			cryptoImpl = signer
 		}
 		builder.Register(
-			blockchain.New(),
-			consensus.New(consensusModule),
-			crypto.NewCache(ecdsa.New(), 100),
+			blockchain.NewWithPruning(n.blockCacheSizes[nodeID.NetworkID], consensus.View(n.pruneDepth), n.pruneObserver(node)),
+			consensus.NewWithInitialBlock(observeCommits(consensusModule, node), initialBlock),
+			&timeoutCertRecorder{
+				Crypto:  &voteRecorder{Crypto: crypto.NewCache(cryptoImpl, 100), node: node, network: n},
+				node:    node,
+				network: n,
+			},
 			synchronizer.New(FixedTimeout(0)),
 			logging.NewWithDest(&node.log, fmt.Sprintf("r%dn%d", nodeID.ReplicaID, nodeID.NetworkID)),
 			// twins-specific:
 			&configuration{network: n, node: node},
-			leaderRotation(n.views),
-			commandModule{commandGenerator: cg, node: node},
-			&timeoutManager{network: n, node: node, timeout: 5},
+			leaderRotationModule,
+			commandModule{streams: streams, network: n, node: node, queue: node.commands, mismatch: n.commitMismatchAt(nodeID)},
+			// the countdown starts immediately, such that the first view times out if its leader does not propose.
+			&timeoutManager{network: n, node: node, timeout: 5, countdown: 5, rate: n.clockRate(nodeID)},
 		)
-		builder.OptionsBuilder().SetShouldVerifyVotesSync()
+		if !n.asyncVoteVerification {
+			builder.OptionsBuilder().SetShouldVerifyVotesSync()
+		}
+		for key, value := range n.metadata[nodeID.NetworkID] {
+			builder.OptionsBuilder().SetConnectionMetadata(key, value)
+		}
+		if n.chainLength > 0 {
+			builder.OptionsBuilder().SetChainLength(n.chainLength)
+		}
 		node.mods = builder.Build()
+		if n.genesisMismatch == nodeID.NetworkID {
+			node.mods.BlockChain().Store(initialBlock)
+			node.mods.Logger().Infof("debug: starting from forged initial block %v", initialBlock)
+		}
+		node.initialBlock = node.mods.Consensus().CommittedBlock()
 	}
 	return nil
 }
 
-func (n *Network) run(ticks int) {
-	// kick off the initial proposal(s)
-	for _, node := range n.nodes {
-		if node.mods.LeaderRotation().GetLeader(1) == node.id.ReplicaID {
-			node.mods.Consensus().Propose(node.mods.Synchronizer().(*synchronizer.Synchronizer).SyncInfo())
-		}
+// forgeInitialBlock returns a block that differs from the given initial block,
+// to be used as the initial block of a node as injected by ScenarioOptions.DebugInjectGenesisMismatch.
+func forgeInitialBlock(block *consensus.Block) *consensus.Block {
+	return consensus.NewBlock(block.Parent(), block.QuorumCert(), block.Command()+"genesis-forged", block.View(), block.Proposer())
+}
+
+// commitMismatchAt returns the forged block to be committed by the node, or nil if the node commits the blocks it is given.
+func (n *Network) commitMismatchAt(id NodeID) *CommitMismatch {
+	if n.commitMismatch == nil || n.commitMismatch.NetworkID != id.NetworkID {
+		return nil
 	}
+	return n.commitMismatch
+}
 
+// maxEventsPerTick is the maximum number of events that a single node may process during one tick.
+// A node that exceeds this limit is most likely stuck in an infinite cascade of events.
+const maxEventsPerTick = 100_000
+
+func (n *Network) run(ticks int) error {
+	return n.runContext(context.Background(), ticks)
+}
+
+// runContext runs the network for the specified number of ticks.
+// It stops early if the context is canceled, or if a node processes too many events during a single tick.
+// It also stops without an error after a tick in which a safety violation was detected by monitorCommit,
+// or in which the message budget was exceeded.
+func (n *Network) runContext(ctx context.Context, ticks int) error {
 	for tick := 0; tick < ticks; tick++ {
-		n.tick()
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("stopped after %d ticks: %w", n.currentTick, err)
+		}
+		if err := n.step(); err != nil {
+			return err
+		}
+		if n.safetyViolated || n.budgetExceeded {
+			break
+		}
+	}
+	return nil
+}
+
+// checkQuiescence performs one more tick after the scenario has ended, in which the messages that the nodes send
+// are logged, but not delivered. It returns true if no node tried to send a message during that tick.
+// The network should not be used afterwards.
+func (n *Network) checkQuiescence() (bool, error) {
+	n.checkingQuiescence = true
+	n.logger.Infof("checking that the network is quiescent after %d ticks", n.currentTick)
+	if err := n.step(); err != nil {
+		return false, err
 	}
+	return n.sendsAfterEnd == 0, nil
+}
+
+// step advances the network by one tick, kicking off the initial proposals before the first tick.
+func (n *Network) step() error {
+	if !n.started {
+		n.started = true
+		for _, node := range n.sortedNodes() {
+			if node.mods.LeaderRotation().GetLeader(1) == node.id.ReplicaID {
+				node.mods.Consensus().Propose(node.mods.Synchronizer().(*synchronizer.Synchronizer).SyncInfo())
+			}
+		}
+	}
+	if err := n.tick(); err != nil {
+		return err
+	}
+	n.currentTick++
+	return nil
+}
+
+// sortedNodes returns the nodes of the network, sorted by network ID.
+func (n *Network) sortedNodes() []*node {
+	ids := maps.Keys(n.nodes)
+	slices.Sort(ids)
+	nodes := make([]*node, 0, len(ids))
+	for _, id := range ids {
+		nodes = append(nodes, n.nodes[id])
+	}
+	return nodes
+}
+
+// sortedReplicaIDs returns the IDs of the replicas of the network, sorted,
+// such that messages are sent to the replicas in the same order in every execution.
+func (n *Network) sortedReplicaIDs() []hotstuff.ID {
+	ids := maps.Keys(n.replicas)
+	slices.Sort(ids)
+	return ids
 }
 
 // tick performs one tick for each node
-func (n *Network) tick() {
+func (n *Network) tick() error {
+	n.releaseHeldMessages()
+
+	var delayed []pendingMessage
 	for _, msg := range n.pendingMessages {
-		n.nodes[msg.receiver].mods.EventLoop().AddEvent(msg.message)
+		if msg.delay > 0 {
+			msg.delay--
+			delayed = append(delayed, msg)
+			continue
+		}
+		if n.replay == nil {
+			n.receive(n.nodes[msg.receiver], msg.message)
+		}
 	}
-	n.pendingMessages = nil
+	n.pendingMessages = delayed
+
+	// each fragment leaves its sender in its own tick, after the fragments sent earlier have been delivered.
+	var waiting []outgoingFragment
+	for _, f := range n.outgoingFragments {
+		if f.wait > 0 {
+			f.wait--
+			waiting = append(waiting, f)
+			continue
+		}
+		n.send(f.sender, f.receiver, f.fragment)
+	}
+	n.outgoingFragments = waiting
+
+	n.sendDeferredVotes()
 
-	for _, node := range n.nodes {
+	// nodes are processed in a fixed order, such that executions are reproducible.
+	nodes := n.sortedNodes()
+	if n.replay != nil {
+		for _, node := range nodes {
+			n.deliverReplay(node)
+		}
+	}
+
+	for _, node := range nodes {
+		node.commands.tick()
 		node.mods.EventLoop().AddEvent(tick{})
 		// run each event loop as long as it has events
-		for node.mods.EventLoop().Tick() {
+		events := 0
+		for {
+			if depth := node.mods.EventLoop().Len(); depth > node.eventStats.PeakDepth {
+				node.eventStats.PeakDepth = depth
+			}
+			event, ok := node.mods.EventLoop().TickEvent()
+			if !ok {
+				break
+			}
+			if n.recordEvents {
+				node.eventTrace = append(node.eventTrace, EventRecord{Tick: n.currentTick, Event: event})
+			}
+			if n.onEvent != nil {
+				n.onEvent(node.id, event)
+			}
+			n.observeQuorum(node, event)
+			n.checkPartitionChange(node)
+			events++
+			node.eventStats.Processed++
+			if events > maxEventsPerTick {
+				return fmt.Errorf("%w: node %v processed more than %d events in tick %d",
+					ErrEventCascade, node.id, maxEventsPerTick, n.currentTick)
+			}
 		}
 	}
+	return nil
+}
+
+// checkDuplicateProposal detects if the node has already proposed in the view of the proposal,
+// and handles it according to the network's DuplicateProposalPolicy.
+func (n *Network) checkDuplicateProposal(node *node, proposal consensus.ProposeMsg) {
+	if n.duplicateProposals == IgnoreDuplicateProposals {
+		return
+	}
+	if n.proposedViews == nil {
+		n.proposedViews = make(map[uint32]map[consensus.View]struct{})
+	}
+	views, ok := n.proposedViews[node.id.NetworkID]
+	if !ok {
+		views = make(map[consensus.View]struct{})
+		n.proposedViews[node.id.NetworkID] = views
+	}
+	view := proposal.Block.View()
+	if _, ok := views[view]; !ok {
+		views[view] = struct{}{}
+		return
+	}
+	n.logger.Infof("node %v: DUPLICATE PROPOSAL in view %d: %v", node.id, view, proposal)
+	if n.duplicateProposals == FailOnDuplicateProposals && n.duplicateProposalErr == nil {
+		n.duplicateProposalErr = fmt.Errorf("%w: node %v proposed more than once in view %d", ErrDuplicateProposal, node.id, view)
+	}
+}
+
+// DropReason describes why the network dropped a message.
+type DropReason uint8
+
+const (
+	// NotDropped means that the message was delivered.
+	NotDropped DropReason = iota
+	// DropPartition means that the sender and the receiver were in different partitions,
+	// and the type of the message is one of the dropped types.
+	DropPartition
+	// DropPostScenario means that the sender was in a view after the last view of the scenario,
+	// in which case all messages are dropped.
+	DropPostScenario
+	// DropBudget means that the network had already accepted the maximum number of messages for delivery.
+	DropBudget
+)
+
+func (r DropReason) String() string {
+	switch r {
+	case NotDropped:
+		return "not dropped"
+	case DropPartition:
+		return "partition"
+	case DropPostScenario:
+		return "post-scenario"
+	case DropBudget:
+		return "budget"
+	}
+	return fmt.Sprintf("DropReason(%d)", uint8(r))
 }
 
-// shouldDrop decides if the sender should drop the message, based on the current view of the sender and the
-// partitions configured for that view.
-func (n *Network) shouldDrop(sender, receiver uint32, message interface{}) bool {
+// dropReason decides if the sender should drop the message, based on the current view of the sender and the
+// partitions configured for that view. If the message is dropped, the reason is returned.
+// crossesPartition is true if the sender and the receiver are in different partitions.
+func (n *Network) dropReason(sender, receiver uint32, message interface{}) (reason DropReason, crossesPartition bool) {
 	node, ok := n.nodes[sender]
 	if !ok {
 		panic(fmt.Errorf("node matching sender id %d was not found", sender))
 	}
 
 	// Index into viewPartitions.
-	i := -1
-	if node.effectiveView > node.mods.Synchronizer().View() {
-		i += int(node.effectiveView)
-	} else {
-		i += int(node.mods.Synchronizer().View())
-	}
+	i := int(n.sendingView(node)) - 1
 
 	if i < 0 {
-		return false
+		return NotDropped, false
 	}
 
 	// will default to dropping all messages from views that don't have any specified partitions.
 	if i >= len(n.views) {
-		return true
+		return DropPostScenario, false
 	}
 
-	partitions := n.views[i].Partitions
+	if samePartition(n.partitions(i), sender, receiver) {
+		return NotDropped, false
+	}
+
+	if _, ok = n.dropTypes[reflect.TypeOf(payload(message))]; ok {
+		return DropPartition, true
+	}
+	return NotDropped, true
+}
+
+// samePartition returns true if a and b are in the same partition.
+func samePartition(partitions []NodeSet, a, b uint32) bool {
 	for _, partition := range partitions {
-		if partition.Contains(sender) && partition.Contains(receiver) {
-			return false
+		if partition.Contains(a) && partition.Contains(b) {
+			return true
 		}
 	}
+	return false
+}
 
-	_, ok = n.dropTypes[reflect.TypeOf(message)]
+// sendingView returns the view whose partitions apply to the messages sent by the node.
+// This is the node's effective view, if it is ahead of the node's synchronizer.
+func (n *Network) sendingView(node *node) consensus.View {
+	if node.effectiveView > node.mods.Synchronizer().View() {
+		return node.effectiveView
+	}
+	return node.mods.Synchronizer().View()
+}
 
-	return ok
+// ReachableFrom returns the nodes that are in the same partition as the given node in the given view,
+// sorted by network ID. Messages of the dropped types that the node sends in that view are delivered only to these nodes.
+// If view is zero, the view that the node currently sends messages from is used.
+// All nodes are reachable before the first view, and no nodes are reachable after the last view of the scenario.
+func (n *Network) ReachableFrom(id NodeID, view consensus.View) []NodeID {
+	if view == 0 {
+		node, ok := n.nodes[id.NetworkID]
+		if !ok {
+			return nil
+		}
+		view = n.sendingView(node)
+	}
+
+	var partition NodeSet
+	switch i := int(view) - 1; {
+	case i < 0:
+		partition = make(NodeSet)
+		for networkID := range n.nodes {
+			partition.Add(networkID)
+		}
+	case i >= len(n.views):
+		return nil
+	default:
+		for _, p := range n.partitions(i) {
+			if p.Contains(id.NetworkID) {
+				partition = p
+				break
+			}
+		}
+	}
+
+	var reachable []NodeID
+	for _, node := range n.sortedNodes() {
+		if node.id.NetworkID != id.NetworkID && partition.Contains(node.id.NetworkID) {
+			reachable = append(reachable, node.id)
+		}
+	}
+	return reachable
+}
+
+// countDrop records the outcome of sending a message.
+func (n *Network) countDrop(reason DropReason, crossesPartition bool) {
+	if reason != NotDropped {
+		if n.drops == nil {
+			n.drops = make(map[DropReason]int)
+		}
+		n.drops[reason]++
+	} else if crossesPartition {
+		n.partitionCrossings++
+	}
+}
+
+// stakeOf returns the stake of the replica. Learners do not have any stake.
+func (n *Network) stakeOf(id hotstuff.ID) uint64 {
+	if n.learners.Contains(id) {
+		return 0
+	}
+	if stake, ok := n.stake[id]; ok {
+		return stake
+	}
+	return 1
+}
+
+// hasQuorum returns true if the replicas in the partition hold a quorum of the total stake.
+func (n *Network) hasQuorum(partition NodeSet) bool {
+	var total, stake uint64
+	for id := range n.replicas {
+		total += n.stakeOf(id)
+	}
+	counted := consensus.NewIDSet()
+	for networkID := range partition {
+		node, ok := n.nodes[networkID]
+		if !ok || counted.Contains(node.id.ReplicaID) {
+			// twins share the same stake
+			continue
+		}
+		counted.Add(node.id.ReplicaID)
+		stake += n.stakeOf(node.id.ReplicaID)
+	}
+	// this is the weighted version of FaultModel.QuorumSize
+	return total > 0 && stake >= total-uint64(n.faultModel.NumFaulty(int(total)))
+}
+
+// leader returns the leader of the view, as chosen by the leader rotation module if the network uses one,
+// or as specified by the scenario otherwise. It returns 0 if the leader is unknown.
+func (n *Network) leader(view consensus.View) hotstuff.ID {
+	if n.leaders != nil {
+		return n.leaders[view]
+	}
+	return leaderRotation(n.views).GetLeader(view)
+}
+
+// scenarioLeaders returns the leader of each view of the scenario that has a known leader.
+func (n *Network) scenarioLeaders() map[consensus.View]hotstuff.ID {
+	leaders := make(map[consensus.View]hotstuff.ID)
+	for i := range n.views {
+		view := consensus.View(i + 1)
+		if leader := n.leader(view); leader != 0 {
+			leaders[view] = leader
+		}
+	}
+	return leaders
+}
+
+// leaderQuorumPartition returns the partition of the view containing the leader, if that partition holds a quorum.
+func (n *Network) leaderQuorumPartition(leader hotstuff.ID, view View) (NodeSet, bool) {
+	for _, partition := range view.Partitions {
+		for _, node := range n.replicas[leader] {
+			if partition.Contains(node.id.NetworkID) && n.hasQuorum(partition) {
+				return partition, true
+			}
+		}
+	}
+	return nil, false
 }
 
 // NewConfiguration returns a new Configuration module for this network.
@@ -222,47 +913,257 @@ func (c *configuration) InitConsensusModule(mods *consensus.Modules, _ *consensu
 }
 
 func (c *configuration) broadcastMessage(message interface{}) {
-	for id := range c.network.replicas {
+	for _, id := range c.network.sortedReplicaIDs() {
 		if id == c.node.id.ReplicaID {
 			// do not send message to self or twin
 			continue
-		} else if c.subConfig == nil || c.subConfig.Contains(id) {
+		} else if c.contains(id) {
 			c.sendMessage(id, message)
 		}
 	}
 }
 
+// contains returns true if the replica is part of the (sub)configuration.
+func (c *configuration) contains(id hotstuff.ID) bool {
+	return c.subConfig == nil || c.subConfig.Contains(id)
+}
+
 func (c *configuration) sendMessage(id hotstuff.ID, message interface{}) {
 	nodes, ok := c.network.replicas[id]
 	if !ok {
 		panic(fmt.Errorf("attempt to send message to replica %d, but this replica does not exist", id))
 	}
+	if c.network.checkingQuiescence {
+		c.network.logger.Infof("node %v -> replica %v: AFTER END %T(%v)", c.node.id, id, message, message)
+		c.network.sendsAfterEnd++
+		return
+	}
+	if c.network.learners.Contains(c.node.id.ReplicaID) && !learnerMayPublish(message) {
+		c.network.logger.Infof("node %v -> replica %v: SUPPRESS %T(%v) (learner)", c.node.id, id, message, message)
+		c.network.recordMessageEvent(MessageSuppressed, c.node.id, NodeID{ReplicaID: id}, message, NotDropped)
+		return
+	}
 	for _, node := range nodes {
-		if c.shouldDrop(node.id, message) {
-			c.network.logger.Infof("node %v -> node %v: DROP %T(%v)", c.node.id, node.id, message, message)
+		c.network.sendFragmented(c.node, node, message)
+	}
+}
+
+// sendFragmented sends the message from the sender to the receiver.
+// If the message exceeds the fragment size, it is split into fragments that leave the sender in consecutive ticks.
+// The first fragment is sent immediately.
+func (n *Network) sendFragmented(sender, receiver *node, message interface{}) {
+	if n.fragmentSize == 0 {
+		n.send(sender, receiver, message)
+		return
+	}
+	size := messageSize(message)
+	if size <= n.fragmentSize {
+		n.send(sender, receiver, message)
+		return
+	}
+	count := (size + n.fragmentSize - 1) / n.fragmentSize
+	id := n.nextMessageID
+	n.nextMessageID++
+	for i := 0; i < count; i++ {
+		f := fragment{messageID: id, index: i, count: count, message: message}
+		if i == 0 {
+			n.send(sender, receiver, f)
 			continue
 		}
-		c.network.logger.Infof("node %v -> node %v: SEND %T(%v)", c.node.id, node.id, message, message)
-		c.network.pendingMessages = append(
-			c.network.pendingMessages,
-			pendingMessage{
-				receiver: uint32(node.id.NetworkID),
-				message:  message,
-			},
-		)
+		n.outgoingFragments = append(n.outgoingFragments, outgoingFragment{
+			sender:   sender,
+			receiver: receiver,
+			fragment: f,
+			wait:     i - 1,
+		})
 	}
 }
 
-// shouldDrop checks if a message to the node identified by id should be dropped.
-func (c *configuration) shouldDrop(id NodeID, message interface{}) bool {
+// send sends a message or fragment from the sender to the receiver, unless it is dropped.
+func (n *Network) send(sender, receiver *node, message interface{}) {
+	reason, crossesPartition := n.dropReason(sender.id.NetworkID, receiver.id.NetworkID, message)
+	if _, ok := n.holdTypes[reflect.TypeOf(payload(message))]; ok && crossesPartition {
+		n.logger.Infof("node %v -> node %v: HOLD %T(%v)", sender.id, receiver.id, message, message)
+		n.recordMessageEvent(MessageHeld, sender.id, receiver.id, message, NotDropped)
+		link := Link{From: sender.id.NetworkID, To: receiver.id.NetworkID}
+		if n.heldMessages == nil {
+			n.heldMessages = make(map[Link][]interface{})
+		}
+		n.heldMessages[link] = append(n.heldMessages[link], message)
+		return
+	}
+	if reason == NotDropped && !n.withinBudget(message) {
+		reason = DropBudget
+	}
+	n.countDrop(reason, crossesPartition)
+	if reason != NotDropped {
+		n.logger.Infof("node %v -> node %v: DROP %T(%v) (%v)", sender.id, receiver.id, message, message, reason)
+		n.recordMessageEvent(MessageDropped, sender.id, receiver.id, message, reason)
+		if proposal, ok := payload(message).(consensus.ProposeMsg); ok {
+			n.recordBlockEvent(proposal.Block.Hash(), BlockDropped, receiver.id)
+		}
+		return
+	}
+	if crossesPartition {
+		n.logger.Infof("node %v -> node %v: SEND %T(%v) (crosses partition)", sender.id, receiver.id, message, message)
+	} else {
+		n.logger.Infof("node %v -> node %v: SEND %T(%v)", sender.id, receiver.id, message, message)
+	}
+	n.recordMessageEvent(MessageSent, sender.id, receiver.id, message, NotDropped)
+	n.enqueue(Link{From: sender.id.NetworkID, To: receiver.id.NetworkID}, message)
+}
+
+// withinBudget counts the message against the message budget, and returns false if the budget is exhausted,
+// in which case the message must be dropped. Fragments other than the first fragment of a message are not counted,
+// but are dropped once the budget has been exceeded.
+func (n *Network) withinBudget(message interface{}) bool {
+	if n.maxMessages == 0 {
+		return true
+	}
+	if n.budgetExceeded {
+		return false
+	}
+	if f, ok := message.(fragment); ok && f.index > 0 {
+		return true
+	}
+	if n.acceptedMessages == n.maxMessages {
+		n.budgetExceeded = true
+		n.logger.Infof("message budget of %d messages exceeded in tick %d, aborting", n.maxMessages, n.currentTick)
+		return false
+	}
+	n.acceptedMessages++
+	return true
+}
+
+// enqueue adds the message to the pending messages of the link, to be delivered after the link's latency.
+// If the network preserves the order of each link, the message is delayed until the messages that are
+// pending on the same link are delivered.
+func (n *Network) enqueue(link Link, message interface{}) {
+	delay := n.latency.delay(link, n.regions(link), payload(message)) + n.proposalDelay(link.From, message)
+	if n.fifoLinks {
+		// pending messages with the same delay are delivered in the order they were enqueued.
+		for _, msg := range n.pendingMessages {
+			if msg.sender == link.From && msg.receiver == link.To && msg.delay > delay {
+				delay = msg.delay
+			}
+		}
+	}
+	n.pendingMessages = append(
+		n.pendingMessages,
+		pendingMessage{
+			sender:   link.From,
+			receiver: link.To,
+			message:  message,
+			delay:    delay,
+		},
+	)
+}
+
+// proposalDelay returns the additional delay of the message if it is a proposal sent by the given node.
+func (n *Network) proposalDelay(sender uint32, message interface{}) int {
+	if _, ok := payload(message).(consensus.ProposeMsg); ok {
+		return n.proposalDelays[sender]
+	}
+	return 0
+}
+
+// regions returns the regions of the sender and receiver of the link, as given by their metadata.
+func (n *Network) regions(link Link) RegionLink {
+	return RegionLink{
+		From: n.metadata[link.From][RegionKey],
+		To:   n.metadata[link.To][RegionKey],
+	}
+}
+
+// releaseHeldMessages sends the messages held on each link whose sender and receiver are in the same partition
+// in the sender's current view. Messages held on a link whose sender is past the last view are never released.
+func (n *Network) releaseHeldMessages() {
+	links := maps.Keys(n.heldMessages)
+	slices.SortFunc(links, func(a, b Link) bool {
+		return a.From < b.From || (a.From == b.From && a.To < b.To)
+	})
+	for _, link := range links {
+		i := int(n.sendingView(n.nodes[link.From])) - 1
+		if i >= len(n.views) || (i >= 0 && !samePartition(n.partitions(i), link.From, link.To)) {
+			continue
+		}
+		for _, message := range n.heldMessages[link] {
+			if !n.withinBudget(message) {
+				n.logger.Infof("node %v -> node %v: DROP %T(%v) (%v)", n.nodes[link.From].id, n.nodes[link.To].id, message, message, DropBudget)
+				n.recordMessageEvent(MessageDropped, n.nodes[link.From].id, n.nodes[link.To].id, message, DropBudget)
+				n.countDrop(DropBudget, true)
+				continue
+			}
+			n.logger.Infof("node %v -> node %v: RELEASE %T(%v)", n.nodes[link.From].id, n.nodes[link.To].id, message, message)
+			n.recordMessageEvent(MessageReleased, n.nodes[link.From].id, n.nodes[link.To].id, message, NotDropped)
+			n.enqueue(link, message)
+		}
+		delete(n.heldMessages, link)
+	}
+}
+
+// heldMessageCount returns the number of messages that are held until their partition heals.
+func (n *Network) heldMessageCount() int {
+	count := 0
+	for _, messages := range n.heldMessages {
+		count += len(messages)
+	}
+	return count
+}
+
+// receive delivers a message to the node.
+// Fragments are held back until all fragments of the message have been received.
+// Proposals whose blocks exceed the node's maximum block size are rejected.
+func (n *Network) receive(node *node, message interface{}) {
+	if f, ok := message.(fragment); ok {
+		if node.fragments == nil {
+			node.fragments = make(map[uint64]int)
+		}
+		node.fragments[f.messageID]++
+		if node.fragments[f.messageID] < f.count {
+			return
+		}
+		delete(node.fragments, f.messageID)
+		n.logger.Infof("node %v: REASSEMBLED %T(%v) from %d fragments", node.id, f.message, f.message, f.count)
+		message = f.message
+	}
+	if limit, ok := n.maxBlockSizes[node.id.NetworkID]; ok {
+		if size := messageSize(message); size > limit {
+			n.logger.Infof("node %v: REJECT %T(%v) (block size %d exceeds %d)", node.id, message, message, size, limit)
+			node.oversizedProposals++
+			n.recordBlockEvent(message.(consensus.ProposeMsg).Block.Hash(), BlockRejected, node.id)
+			return
+		}
+	}
+	n.deliver(node, message)
+}
+
+// learnerMayPublish returns true if a learner is allowed to send the message.
+// Learners must not take part in the protocol, so they cannot propose, vote, or time out.
+func learnerMayPublish(message interface{}) bool {
+	switch message.(type) {
+	case consensus.ProposeMsg, consensus.VoteMsg, consensus.TimeoutMsg:
+		return false
+	}
+	return true
+}
+
+// dropReason checks if a message to the node identified by id should be dropped, and counts the outcome.
+func (c *configuration) dropReason(id NodeID, message interface{}) (reason DropReason, crossesPartition bool) {
 	// retrieve the drop config for this node.
-	return c.network.shouldDrop(c.node.id.NetworkID, id.NetworkID, message)
+	reason, crossesPartition = c.network.dropReason(c.node.id.NetworkID, id.NetworkID, message)
+	c.network.countDrop(reason, crossesPartition)
+	return reason, crossesPartition
 }
 
 // Replicas returns all of the replicas in the configuration.
+// Learners are not included.
 func (c *configuration) Replicas() map[hotstuff.ID]consensus.Replica {
 	m := make(map[hotstuff.ID]consensus.Replica)
-	for id := range c.network.replicas {
+	for _, id := range c.network.sortedReplicaIDs() {
+		if c.network.learners.Contains(id) || !c.contains(id) {
+			continue
+		}
 		m[id] = &replica{
 			config: c,
 			id:     id,
@@ -273,7 +1174,7 @@ func (c *configuration) Replicas() map[hotstuff.ID]consensus.Replica {
 
 // Replica returns a replica if present in the configuration.
 func (c *configuration) Replica(id hotstuff.ID) (r consensus.Replica, ok bool) {
-	if _, ok = c.network.replicas[id]; ok {
+	if _, ok = c.network.replicas[id]; ok && c.contains(id) {
 		return &replica{
 			config: c,
 			id:     id,
@@ -283,9 +1184,13 @@ func (c *configuration) Replica(id hotstuff.ID) (r consensus.Replica, ok bool) {
 }
 
 // SubConfig returns a subconfiguration containing the replicas specified in the ids slice.
+// It returns an error if any of the replicas is not in the configuration.
 func (c *configuration) SubConfig(ids []hotstuff.ID) (sub consensus.Configuration, err error) {
 	subConfig := consensus.NewIDSet()
 	for _, id := range ids {
+		if _, ok := c.Replica(id); !ok {
+			return nil, fmt.Errorf("replica %d is not in the configuration", id)
+		}
 		subConfig.Add(id)
 	}
 	return &configuration{
@@ -296,17 +1201,37 @@ func (c *configuration) SubConfig(ids []hotstuff.ID) (sub consensus.Configuratio
 }
 
 // Len returns the number of replicas in the configuration.
+// Learners are not counted.
 func (c *configuration) Len() int {
-	return len(c.network.replicas)
+	if c.subConfig == nil {
+		return len(c.network.replicas) - c.network.learners.Len()
+	}
+	n := 0
+	c.subConfig.ForEach(func(id hotstuff.ID) {
+		if !c.network.learners.Contains(id) {
+			n++
+		}
+	})
+	return n
 }
 
-// QuorumSize returns the size of a quorum.
+// QuorumSize returns the size of a quorum, according to the network's fault model.
 func (c *configuration) QuorumSize() int {
-	return hotstuff.QuorumSize(c.Len())
+	return c.network.faultModel.QuorumSize(c.Len())
+}
+
+// NumFaulty returns the number of faulty replicas tolerated by the configuration, according to the network's fault model.
+func (c *configuration) NumFaulty() int {
+	return c.network.faultModel.NumFaulty(c.Len())
 }
 
+var _ consensus.FaultTolerance = (*configuration)(nil)
+
 // Propose sends the block to all replicas in the configuration.
 func (c *configuration) Propose(proposal consensus.ProposeMsg) {
+	c.network.checkDuplicateProposal(c.node, proposal)
+	c.network.recordBlockEvent(proposal.Block.Hash(), BlockProposed, c.node.id)
+	c.network.recordProposalTick(proposal.Block)
 	c.broadcastMessage(proposal)
 }
 
@@ -315,19 +1240,35 @@ func (c *configuration) Timeout(msg consensus.TimeoutMsg) {
 	c.broadcastMessage(msg)
 }
 
+// BroadcastMessage sends an application message to all replicas in the configuration.
+// The receivers get a copy of the message, as they would if it had been serialized.
+func (c *configuration) BroadcastMessage(msg proto.Message) {
+	c.broadcastMessage(consensus.AppMsg{
+		ID:      c.node.mods.ID(),
+		Message: proto.Clone(msg),
+	})
+}
+
 // Fetch requests a block from all the replicas in the configuration.
 func (c *configuration) Fetch(_ context.Context, hash consensus.Hash) (block *consensus.Block, ok bool) {
-	for _, replica := range c.network.replicas {
-		for _, node := range replica {
-			if c.shouldDrop(node.id, hash) {
+	for _, id := range c.network.sortedReplicaIDs() {
+		if !c.contains(id) {
+			continue
+		}
+		for _, node := range c.network.replicas[id] {
+			if reason, _ := c.dropReason(node.id, hash); reason != NotDropped {
 				continue
 			}
 			block, ok = node.mods.BlockChain().LocalGet(hash)
 			if ok {
+				if node != c.node {
+					c.node.fetches++
+				}
 				return block, true
 			}
 		}
 	}
+	c.network.checkFailedFetch(c.node, hash)
 	return nil, false
 }
 
@@ -350,7 +1291,7 @@ func (r *replica) PublicKey() consensus.PublicKey {
 
 // Vote sends the partial certificate to the other replica.
 func (r *replica) Vote(cert consensus.PartialCert) {
-	r.config.sendMessage(r.id, consensus.VoteMsg{
+	r.config.network.vote(r.config, r.id, consensus.VoteMsg{
 		ID:          r.config.node.mods.ID(),
 		PartialCert: cert,
 	})
@@ -408,19 +1349,24 @@ func (s *NodeSet) UnmarshalJSON(data []byte) error {
 type tick struct{}
 
 type timeoutManager struct {
-	mods      *consensus.Modules
-	node      *node
-	network   *Network
-	countdown int
-	timeout   int
+	mods    *consensus.Modules
+	node    *node
+	network *Network
+	// the remaining time before the view times out, measured by the node's clock.
+	countdown float64
+	// the duration of a view, measured by the node's clock.
+	timeout int
+	// the amount that the node's clock advances per tick.
+	rate float64
 }
 
 func (tm *timeoutManager) advance() {
-	tm.countdown--
-	if tm.countdown == 0 {
+	tm.countdown -= tm.rate
+	if tm.countdown <= 0 {
 		view := tm.mods.Synchronizer().View()
 		tm.mods.EventLoop().AddEvent(synchronizer.TimeoutEvent{View: view})
-		tm.countdown = tm.timeout
+		tm.countdown = float64(tm.timeout)
+		tm.node.timeouts++
 		if tm.node.effectiveView <= view {
 			tm.node.effectiveView = view + 1
 			tm.network.logger.Infof("node %v effective view is %d due to timeout", tm.node.id, tm.node.effectiveView)
@@ -429,7 +1375,12 @@ func (tm *timeoutManager) advance() {
 }
 
 func (tm *timeoutManager) viewChange(event synchronizer.ViewChangeEvent) {
-	tm.countdown = tm.timeout
+	tm.countdown = float64(tm.timeout)
+	tm.node.viewHistory = append(tm.node.viewHistory, ViewTransition{
+		View:    event.View,
+		Timeout: event.Timeout,
+		Tick:    tm.network.currentTick,
+	})
 	if event.Timeout {
 		tm.network.logger.Infof("node %v entered view %d after timeout", tm.node.id, event.View)
 	} else {
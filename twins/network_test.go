@@ -0,0 +1,186 @@
+package twins
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+	_ "github.com/relab/hotstuff/consensus/chainedhotstuff"
+	"github.com/relab/hotstuff/internal/conformance"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestReachableFrom(t *testing.T) {
+	views := []View{
+		{Leader: 1, Partitions: []NodeSet{{1: {}, 2: {}, 3: {}, 4: {}, 5: {}}}},
+		{Leader: 1, Partitions: []NodeSet{{1: {}, 3: {}, 5: {}}, {2: {}, 4: {}}}},
+	}
+	network := NewPartitionedNetwork(views)
+	// replica 1 and its twin have network IDs 1 and 2.
+	nodes, twins := assignNodeIDs(4, 1)
+	for _, id := range append(nodes, twins...) {
+		if _, err := network.GetNodeBuilder(id, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	id := func(networkID uint32) NodeID {
+		return network.nodes[networkID].id
+	}
+
+	tests := []struct {
+		node uint32
+		view consensus.View
+		want []NodeID
+	}{
+		{node: 1, view: 1, want: []NodeID{id(2), id(3), id(4), id(5)}},
+		{node: 1, view: 2, want: []NodeID{id(3), id(5)}},
+		{node: 2, view: 2, want: []NodeID{id(4)}},
+		{node: 5, view: 2, want: []NodeID{id(1), id(3)}},
+		// nothing is delivered after the last view of the scenario.
+		{node: 3, view: 3, want: nil},
+	}
+	for _, test := range tests {
+		if got := network.ReachableFrom(id(test.node), test.view); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("node %v, view %d: got %v, want %v", id(test.node), test.view, got, test.want)
+		}
+	}
+}
+
+func TestDuplicateNetworkID(t *testing.T) {
+	network := NewPartitionedNetwork(nil)
+	first := NodeID{ReplicaID: 1, NetworkID: 1}
+	if _, err := network.GetNodeBuilder(first, nil); err != nil {
+		t.Fatal(err)
+	}
+	_, err := network.GetNodeBuilder(NodeID{ReplicaID: 2, NetworkID: 1}, nil)
+	if !errors.Is(err, ErrDuplicateNetworkID) {
+		t.Fatalf("got error %v, want %v", err, ErrDuplicateNetworkID)
+	}
+	if got := network.nodes[1].id; got != first {
+		t.Errorf("node with network ID 1 was replaced by %v", got)
+	}
+	if _, ok := network.replicas[2]; ok {
+		t.Error("replica 2 was added to the network")
+	}
+
+	network = NewPartitionedNetwork(nil)
+	nodes := []NodeID{first, {ReplicaID: 2, NetworkID: 2}, {ReplicaID: 3, NetworkID: 2}}
+	if err := network.createTwinsNodes(nodes, nil, "chainedhotstuff"); !errors.Is(err, ErrDuplicateNetworkID) {
+		t.Errorf("got error %v, want %v", err, ErrDuplicateNetworkID)
+	}
+}
+
+func TestLogWriter(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	scenario := Scenario{
+		{Leader: 1, Partitions: []NodeSet{allNodes}},
+		{Leader: 2, Partitions: []NodeSet{allNodes}},
+	}
+	var buf bytes.Buffer
+	result, err := ExecuteScenarioWithOptions(scenario, 4, 0, 10, "chainedhotstuff", ScenarioOptions{LogWriter: &buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() == 0 {
+		t.Fatal("expected the network log to be written to the log writer")
+	}
+	if buf.String() != result.NetworkLog {
+		t.Error("expected the log writer to receive the same log as the result")
+	}
+}
+
+func TestNodeMetadata(t *testing.T) {
+	network := NewPartitionedNetwork(nil)
+	network.metadata = map[uint32]map[string]string{
+		1: {"region": "eu-north"},
+		2: {"region": "us-west", "rack": "b"},
+	}
+	nodes, _ := assignNodeIDs(3, 0)
+	if err := network.createTwinsNodes(nodes, nil, "chainedhotstuff"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range nodes {
+		mods := network.nodes[id.NetworkID].mods
+		want := network.metadata[id.NetworkID]
+		if want == nil {
+			want = map[string]string{}
+		}
+		if got := mods.Options().ConnectionMetadata(); !reflect.DeepEqual(got, want) {
+			t.Errorf("node %v: got metadata %v, want %v", id, got, want)
+		}
+	}
+
+	// the metadata of a node is visible to the other nodes through its replica.
+	mods := network.nodes[3].mods
+	replica, ok := mods.Configuration().Replica(2)
+	if !ok {
+		t.Fatal("replica 2 not found")
+	}
+	if got, want := replica.Metadata(), network.metadata[2]; !reflect.DeepEqual(got, want) {
+		t.Errorf("got metadata %v of replica 2, want %v", got, want)
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	views := []View{{Leader: 1, Partitions: []NodeSet{{1: {}, 2: {}, 3: {}}, {4: {}}}}}
+	network := NewPartitionedNetwork(views, DefaultDropTypes()...)
+	nodes, _ := assignNodeIDs(4, 0)
+	if err := network.createTwinsNodes(nodes, views, "chainedhotstuff"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := wrapperspb.String("application message")
+	received := make(map[uint32]consensus.AppMsg)
+	for _, id := range nodes {
+		networkID := id.NetworkID
+		network.nodes[networkID].mods.EventLoop().RegisterHandler(consensus.AppMsg{}, func(event interface{}) {
+			received[networkID] = event.(consensus.AppMsg)
+		})
+	}
+
+	network.nodes[1].mods.Configuration().BroadcastMessage(want)
+	if err := network.tick(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, networkID := range []uint32{2, 3} {
+		msg, ok := received[networkID]
+		if !ok {
+			t.Errorf("node %d did not receive the message", networkID)
+			continue
+		}
+		if msg.ID != hotstuff.ID(1) {
+			t.Errorf("node %d: got sender %d, want 1", networkID, msg.ID)
+		}
+		if !proto.Equal(msg.Message, want) {
+			t.Errorf("node %d: got message %v, want %v", networkID, msg.Message, want)
+		}
+	}
+	// the sender does not receive its own message, and node 4 is in another partition.
+	for _, networkID := range []uint32{1, 4} {
+		if _, ok := received[networkID]; ok {
+			t.Errorf("node %d received the message", networkID)
+		}
+	}
+	if got := network.drops[DropPartition]; got != 1 {
+		t.Errorf("got %d messages dropped by the partition, want 1", got)
+	}
+}
+
+func TestConfigurationConformance(t *testing.T) {
+	network := NewPartitionedNetwork(nil)
+	nodes, _ := assignNodeIDs(4, 0)
+	for _, id := range nodes {
+		if _, err := network.GetNodeBuilder(id, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conformance.RunConfigurationConformance(t, func() consensus.Configuration {
+		return &configuration{network: network, node: network.nodes[1]}
+	})
+}
@@ -0,0 +1,88 @@
+package twins
+
+import (
+	"fmt"
+
+	"github.com/relab/hotstuff/consensus"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// PartitionChangeEvent is recorded in the event trace of a node when the view that the node sends messages from
+// advances into a view whose partitions differ from those of the previous view.
+// It is not processed by the node.
+type PartitionChangeEvent struct {
+	// From is the view that the node sent messages from before the change.
+	From consensus.View
+	// To is the view that the node sends messages from after the change.
+	To consensus.View
+	// Partitions contains the partitions of the new view, sorted by their smallest network ID.
+	// It is nil if the new view is after the last view of the scenario, in which case all messages are dropped.
+	Partitions [][]uint32
+}
+
+func (e PartitionChangeEvent) String() string {
+	if e.Partitions == nil {
+		return fmt.Sprintf("PartitionChange(view %d -> %d: disconnected)", e.From, e.To)
+	}
+	return fmt.Sprintf("PartitionChange(view %d -> %d: %v)", e.From, e.To, e.Partitions)
+}
+
+// checkPartitionChange records a PartitionChangeEvent in the node's event trace if the view that the node sends
+// messages from has advanced into a view with a different partition layout since the previous check.
+func (n *Network) checkPartitionChange(node *node) {
+	view := n.sendingView(node)
+	if node.partitionView == 0 {
+		node.partitionView = view
+		return
+	}
+	if view <= node.partitionView {
+		return
+	}
+	from := node.partitionView
+	node.partitionView = view
+	before, after := n.partitionLayout(from), n.partitionLayout(view)
+	if slices.EqualFunc(before, after, func(a, b []uint32) bool { return slices.Equal(a, b) }) && (before == nil) == (after == nil) {
+		return
+	}
+	event := PartitionChangeEvent{From: from, To: view, Partitions: after}
+	n.logger.Infof("node %v: %v", node.id, event)
+	if n.recordEvents {
+		node.eventTrace = append(node.eventTrace, EventRecord{Tick: n.currentTick, Event: event})
+	}
+	if n.onEvent != nil {
+		n.onEvent(node.id, event)
+	}
+}
+
+// partitionLayout returns the partitions that apply to the messages sent in the given view,
+// with each partition sorted, and the partitions sorted by their smallest network ID.
+// Before the first view, all nodes are in the same partition.
+// After the last view of the scenario, it returns nil, since no messages are delivered.
+func (n *Network) partitionLayout(view consensus.View) [][]uint32 {
+	i := int(view) - 1
+	if i >= len(n.views) {
+		return nil
+	}
+	var partitions []NodeSet
+	if i < 0 {
+		all := make(NodeSet)
+		for id := range n.nodes {
+			all.Add(id)
+		}
+		partitions = []NodeSet{all}
+	} else {
+		partitions = n.partitions(i)
+	}
+	layout := make([][]uint32, 0, len(partitions))
+	for _, partition := range partitions {
+		ids := maps.Keys(partition)
+		if len(ids) == 0 {
+			continue
+		}
+		slices.Sort(ids)
+		layout = append(layout, ids)
+	}
+	slices.SortFunc(layout, func(a, b []uint32) bool { return a[0] < b[0] })
+	return layout
+}
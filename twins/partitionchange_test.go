@@ -0,0 +1,61 @@
+package twins
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/relab/hotstuff/consensus"
+	_ "github.com/relab/hotstuff/consensus/chainedhotstuff"
+)
+
+func TestPartitionChangeEvents(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	split := []NodeSet{{1: {}, 2: {}, 3: {}}, {4: {}}}
+	scenario := Scenario{
+		{Leader: 1, Partitions: []NodeSet{allNodes}},
+		{Leader: 2, Partitions: []NodeSet{allNodes}},
+		{Leader: 3, Partitions: split},
+		{Leader: 1, Partitions: split},
+		{Leader: 2, Partitions: []NodeSet{allNodes}},
+	}
+
+	result, err := ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{RecordEvents: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node := NodeID{ReplicaID: 1, NetworkID: 1}
+	var changes []EventRecord
+	for _, record := range result.NodeEventTrace[node] {
+		if _, ok := record.Event.(PartitionChangeEvent); ok {
+			changes = append(changes, record)
+		}
+	}
+	t.Log(changes)
+	if len(changes) < 2 {
+		t.Fatalf("got %d partition changes, want at least 2", len(changes))
+	}
+
+	// the views with the same partitions as the previous view do not produce events.
+	want := []PartitionChangeEvent{
+		{From: 2, To: 3, Partitions: [][]uint32{{1, 2, 3}, {4}}},
+		{From: 4, To: 5, Partitions: [][]uint32{{1, 2, 3, 4}}},
+	}
+	for i, w := range want {
+		if got := changes[i].Event; !reflect.DeepEqual(got, w) {
+			t.Errorf("change %d: got %v, want %v", i, got, w)
+		}
+	}
+
+	// the first change happens in the tick where the node entered view 3.
+	var enteredTick = -1
+	for _, transition := range result.NodeViewHistory[node] {
+		if transition.View == consensus.View(3) {
+			enteredTick = transition.Tick
+			break
+		}
+	}
+	if changes[0].Tick != enteredTick {
+		t.Errorf("got partition change at tick %d, want tick %d where the node entered view 3", changes[0].Tick, enteredTick)
+	}
+}
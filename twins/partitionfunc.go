@@ -0,0 +1,54 @@
+package twins
+
+import (
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+)
+
+// NetworkState is the state of the network that a PartitionFunc can use to decide the partitions of a view.
+type NetworkState struct {
+	// Tick is the current tick of the network.
+	Tick int
+	// Leader is the leader of the view whose partitions are decided, or 0 if it is unknown.
+	Leader hotstuff.ID
+	// NodeViews contains the view that each node sends messages from.
+	NodeViews map[NodeID]consensus.View
+}
+
+// PartitionFunc decides the partitions of a view based on the state of the network, such as to isolate
+// whichever node is the leader of the view. It is called once for each view of the scenario, when the partitions
+// of the view are first needed, which is usually when the first node enters the view.
+// If it returns nil, the partitions given by the scenario are used.
+type PartitionFunc func(view consensus.View, state NetworkState) []NodeSet
+
+// SetPartitionFunc makes the network decide the partitions of each view using the given function,
+// instead of using the partitions of the scenario. The leaders and the number of views are still given by the scenario.
+// It must be called before the nodes are created.
+func (n *Network) SetPartitionFunc(f PartitionFunc) {
+	n.partitionFunc = f
+	// the partitions are written to the views as they are decided, so the scenario must not be shared.
+	n.views = append([]View(nil), n.views...)
+	n.partitionsDecided = make([]bool, len(n.views))
+}
+
+// partitions returns the partitions of the view with the given index into the scenario,
+// calling the partition function if the partitions have yet to be decided.
+func (n *Network) partitions(i int) []NodeSet {
+	if n.partitionFunc != nil && !n.partitionsDecided[i] {
+		n.partitionsDecided[i] = true
+		view := consensus.View(i + 1)
+		state := NetworkState{
+			Tick:      n.currentTick,
+			Leader:    n.leader(view),
+			NodeViews: make(map[NodeID]consensus.View, len(n.nodes)),
+		}
+		for _, node := range n.nodes {
+			state.NodeViews[node.id] = n.sendingView(node)
+		}
+		if partitions := n.partitionFunc(view, state); partitions != nil {
+			n.views[i].Partitions = partitions
+		}
+		n.logger.Infof("partitions of view %d: %v", view, n.views[i].Partitions)
+	}
+	return n.views[i].Partitions
+}
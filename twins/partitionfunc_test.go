@@ -0,0 +1,59 @@
+package twins
+
+import (
+	"testing"
+
+	"github.com/relab/hotstuff/consensus"
+	_ "github.com/relab/hotstuff/consensus/chainedhotstuff"
+)
+
+func TestPartitionFunc(t *testing.T) {
+	scenario, err := NewCleanScenario(4, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	isolateLeader := func(view consensus.View, state NetworkState) []NodeSet {
+		leader, others := make(NodeSet), make(NodeSet)
+		for id := range state.NodeViews {
+			if id.ReplicaID == state.Leader {
+				leader.Add(id.NetworkID)
+			} else {
+				others.Add(id.NetworkID)
+			}
+		}
+		return []NodeSet{leader, others}
+	}
+
+	result, err := ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{PartitionFunc: isolateLeader})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Commits != 0 {
+		t.Errorf("got %d commits, want none when the leader is always isolated", result.Commits)
+	}
+	proposals := result.CountEvents(func(e MessageEvent) bool { return e.Action == MessageSent && e.Is(consensus.ProposeMsg{}) })
+	if proposals != 0 {
+		t.Errorf("got %d proposals sent, want all proposals to be dropped", proposals)
+	}
+	// the leader of each view that the nodes reached proposed, and its proposals were dropped.
+	views := make(map[consensus.View]struct{})
+	for _, event := range result.MessageEvents {
+		if !event.Is(consensus.ProposeMsg{}) {
+			continue
+		}
+		if event.Action != MessageDropped || event.Reason != DropPartition {
+			t.Errorf("got %v, want the proposal to be dropped by the partition", event)
+		}
+		views[event.Message.(consensus.ProposeMsg).Block.View()] = struct{}{}
+	}
+	if len(views) < 2 {
+		t.Errorf("got dropped proposals in %d views, want proposals in several views", len(views))
+	}
+
+	// the scenario itself is not modified.
+	for i, view := range scenario {
+		if len(view.Partitions) != 1 {
+			t.Errorf("view %d: got partitions %v, want the partitions of the scenario to be unchanged", i+1, view.Partitions)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package twins
+
+import (
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+)
+
+// deferredVote is a vote that its node sends once it has persisted the voted block.
+type deferredVote struct {
+	config *configuration
+	leader hotstuff.ID
+	vote   consensus.VoteMsg
+	// the number of ticks left before the vote is sent.
+	wait int
+}
+
+// vote sends the vote from the node of the configuration to the leader, or defers it by the node's persistence delay.
+func (n *Network) vote(c *configuration, leader hotstuff.ID, vote consensus.VoteMsg) {
+	delay := n.persistenceDelays[c.node.id.NetworkID]
+	if delay == 0 {
+		c.sendMessage(leader, vote)
+		return
+	}
+	n.logger.Infof("node %v -> replica %v: DEFER %T(%v) for %d ticks (persistence)", c.node.id, leader, vote, vote, delay)
+	n.deferredVotes = append(n.deferredVotes, deferredVote{config: c, leader: leader, vote: vote, wait: delay - 1})
+}
+
+// sendDeferredVotes sends the deferred votes whose blocks have been persisted.
+func (n *Network) sendDeferredVotes() {
+	var waiting []deferredVote
+	for _, v := range n.deferredVotes {
+		if v.wait > 0 {
+			v.wait--
+			waiting = append(waiting, v)
+			continue
+		}
+		v.config.sendMessage(v.leader, v.vote)
+	}
+	n.deferredVotes = waiting
+}
@@ -0,0 +1,66 @@
+package twins
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/relab/hotstuff/consensus"
+	_ "github.com/relab/hotstuff/consensus/chainedhotstuff"
+)
+
+func TestPersistenceDelays(t *testing.T) {
+	scenario, err := NewCleanScenario(4, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// firstVote returns the tick in which node 2 first sent a vote.
+	firstVote := func(result ScenarioResult) int {
+		for _, e := range result.MessageEvents {
+			if e.Action == MessageSent && e.From.NetworkID == 2 && e.Is(consensus.VoteMsg{}) {
+				return e.Tick
+			}
+		}
+		t.Fatal("node 2 did not vote")
+		return 0
+	}
+
+	instant, err := ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const delay = 2
+	slow, err := ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{
+		PersistenceDelays: map[uint32]int{2: delay},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := firstVote(slow), firstVote(instant)+delay; got != want {
+		t.Errorf("node 2 first voted in tick %d, want %d", got, want)
+	}
+	if !slow.Safe || slow.Commits == 0 {
+		t.Errorf("got safe: %v, commits: %d with a short persistence delay, want a safe result with commits", slow.Safe, slow.Commits)
+	}
+
+	// if three nodes take longer to persist a block than a view lasts, no leader collects a quorum of votes in time.
+	stalled, err := ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{
+		PersistenceDelays: map[uint32]int{2: 10, 3: 10, 4: 10},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stalled.Safe {
+		t.Error("expected a safe result")
+	}
+	if stalled.Commits != 0 {
+		t.Errorf("got %d commits, want none when votes are persisted slower than the view timeout", stalled.Commits)
+	}
+
+	for _, delays := range []map[uint32]int{{5: 1}, {1: -1}} {
+		_, err := ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{PersistenceDelays: delays})
+		if !errors.Is(err, ErrInvalidScenario) {
+			t.Errorf("got error %v, want %v for persistence delays %v", err, ErrInvalidScenario, delays)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package twins
+
+import (
+	"github.com/relab/hotstuff/blockchain"
+	"github.com/relab/hotstuff/consensus"
+	"golang.org/x/exp/slices"
+)
+
+// PruneViolation describes a block that a node pruned from its blockchain while another node could still need it.
+type PruneViolation struct {
+	// Node is the node that pruned the block.
+	Node NodeID
+	// Tick is the tick in which the block was pruned, or in which the fetch of the block failed.
+	Tick  int
+	Block consensus.Hash
+	View  consensus.View
+	// Needer is the node that could still need the block, as its committed block was below the pruned block.
+	Needer NodeID
+	// Committed is the view of the committed block of Needer at the time of the violation.
+	Committed consensus.View
+	// FailedFetch is true if Needer failed to fetch the block after it was pruned.
+	FailedFetch bool
+}
+
+// prunedBlock identifies the first node that pruned a block, and the view of the block.
+type prunedBlock struct {
+	node NodeID
+	view consensus.View
+}
+
+// pruneObserver returns an observer that counts the blocks pruned by the node, and records a violation for each
+// other node whose committed block is below the pruned block, and that does not have the block, since that node
+// must fetch the block to commit it. A node whose locked block is below the pruned block is included,
+// since the locked block is not below the committed block.
+func (n *Network) pruneObserver(node *node) blockchain.PruneObserver {
+	return func(block *consensus.Block) {
+		node.prunedBlocks++
+		if n.prunedBy == nil {
+			n.prunedBy = make(map[consensus.Hash]prunedBlock)
+		}
+		if _, ok := n.prunedBy[block.Hash()]; !ok {
+			n.prunedBy[block.Hash()] = prunedBlock{node: node.id, view: block.View()}
+		}
+		for _, other := range n.nodes {
+			if other == node {
+				continue
+			}
+			committed := other.committedView()
+			if committed >= block.View() {
+				continue
+			}
+			if _, ok := other.mods.BlockChain().LocalGet(block.Hash()); ok {
+				// the other node can commit the block without fetching it.
+				continue
+			}
+			n.logger.Infof("node %v: PRUNED block %.8s in view %d, which node %v still needs with committed view %d",
+				node.id, block.Hash(), block.View(), other.id, committed)
+			n.pruneViolations = append(n.pruneViolations, PruneViolation{
+				Node:      node.id,
+				Tick:      n.currentTick,
+				Block:     block.Hash(),
+				View:      block.View(),
+				Needer:    other.id,
+				Committed: committed,
+			})
+		}
+	}
+}
+
+// checkFailedFetch records a violation if the node failed to fetch a block that was pruned by another node.
+func (n *Network) checkFailedFetch(node *node, hash consensus.Hash) {
+	pruned, ok := n.prunedBy[hash]
+	if !ok {
+		return
+	}
+	committed := node.committedView()
+	n.logger.Infof("node %v: FAILED to fetch block %.8s, which was pruned by node %v", node.id, hash, pruned.node)
+	n.pruneViolations = append(n.pruneViolations, PruneViolation{
+		Node:        pruned.node,
+		Tick:        n.currentTick,
+		Block:       hash,
+		View:        pruned.view,
+		Needer:      node.id,
+		Committed:   committed,
+		FailedFetch: true,
+	})
+}
+
+// committedView returns the view of the last block executed by the node.
+// It does not ask the consensus module, which may be fetching a block while it commits.
+func (node *node) committedView() consensus.View {
+	if len(node.executedBlocks) == 0 {
+		return node.initialBlock.View()
+	}
+	return node.executedBlocks[len(node.executedBlocks)-1].View()
+}
+
+// sortedPruneViolations returns the prune violations ordered by the network ID of the pruning node, tick, view,
+// and the network ID of the needing node.
+func (n *Network) sortedPruneViolations() []PruneViolation {
+	violations := slices.Clone(n.pruneViolations)
+	slices.SortFunc(violations, func(a, b PruneViolation) bool {
+		if a.Node.NetworkID != b.Node.NetworkID {
+			return a.Node.NetworkID < b.Node.NetworkID
+		}
+		if a.Tick != b.Tick {
+			return a.Tick < b.Tick
+		}
+		if a.View != b.View {
+			return a.View < b.View
+		}
+		if a.Needer.NetworkID != b.Needer.NetworkID {
+			return a.Needer.NetworkID < b.Needer.NetworkID
+		}
+		return !a.FailedFetch && b.FailedFetch
+	})
+	return violations
+}
@@ -0,0 +1,88 @@
+package twins
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/relab/hotstuff"
+	_ "github.com/relab/hotstuff/consensus/chainedhotstuff"
+)
+
+func TestPruneDepth(t *testing.T) {
+	// node 4 lags behind while it is partitioned from the others, and must catch up after the partition heals.
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	quorum := NodeSet{1: {}, 2: {}, 3: {}}
+	var scenario Scenario
+	for i := 0; i < 6; i++ {
+		scenario = append(scenario, View{Leader: hotstuff.ID(i%3 + 1), Partitions: []NodeSet{quorum, {4: {}}}})
+	}
+	for i := 0; i < 8; i++ {
+		scenario = append(scenario, View{Leader: hotstuff.ID(i%4 + 1), Partitions: []NodeSet{allNodes}})
+	}
+
+	unpruned, err := ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, n := range unpruned.NodePrunedBlocks {
+		if n != 0 {
+			t.Errorf("node %v pruned %d blocks without a prune depth", id, n)
+		}
+	}
+
+	// the nodes keep enough blocks for the lagging node to fetch the blocks that it is missing.
+	result, err := ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{PruneDepth: 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Safe {
+		t.Errorf("expected no safety violations, diverged at position %d", result.Commits)
+	}
+	if len(result.PruneViolations) != 0 {
+		t.Errorf("got prune violations %v, want none", result.PruneViolations)
+	}
+	pruned := 0
+	for _, n := range result.NodePrunedBlocks {
+		pruned += n
+	}
+	if pruned == 0 {
+		t.Error("expected the nodes to prune their blockchains")
+	}
+	// the committed blocks are recorded before they are pruned, so all nodes, including the lagging node,
+	// commit the same blocks as without pruning.
+	for id, commits := range unpruned.NodeCommits {
+		if got, want := len(result.NodeCommits[id]), len(commits); got != want {
+			t.Errorf("node %v committed %d blocks with pruning, want %d", id, got, want)
+		}
+	}
+
+	// the nodes prune the blocks that the lagging node needs before it can fetch them, which must be reported.
+	stranded, err := ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{PruneDepth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lagging := NodeID{ReplicaID: 4, NetworkID: 4}
+	var needed, failedFetches int
+	for _, v := range stranded.PruneViolations {
+		if v.Needer != lagging {
+			t.Errorf("got prune violation %+v for a node that did not lag behind", v)
+		}
+		if v.Committed >= v.View {
+			t.Errorf("got prune violation %+v for a block that is not above the committed block", v)
+		}
+		if v.FailedFetch {
+			failedFetches++
+		} else {
+			needed++
+		}
+	}
+	if needed == 0 || failedFetches == 0 {
+		t.Errorf("got %d pruned blocks needed by the lagging node and %d failed fetches, want both to be reported",
+			needed, failedFetches)
+	}
+
+	_, err = ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{PruneDepth: -1})
+	if !errors.Is(err, ErrInvalidScenario) {
+		t.Errorf("got error %v for a negative prune depth, want ErrInvalidScenario", err)
+	}
+}
@@ -0,0 +1,39 @@
+package twins
+
+import (
+	"strings"
+	"testing"
+
+	_ "github.com/relab/hotstuff/consensus/chainedhotstuff"
+)
+
+func TestQuiescentAtEnd(t *testing.T) {
+	// the nodes keep proposing and voting until the end of a scenario without partitions.
+	scenario, err := NewCleanScenario(4, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := ExecuteScenario(scenario, 4, 0, 20, "chainedhotstuff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.QuiescentAtEnd {
+		t.Error("expected the nodes to send messages after the end of the scenario")
+	}
+	if !strings.Contains(result.NetworkLog, "AFTER END") {
+		t.Error("expected the messages sent after the end to be logged")
+	}
+
+	// isolated nodes are idle until their views time out.
+	isolated := Scenario{{Leader: 1, Partitions: []NodeSet{{1: {}}, {2: {}}, {3: {}}, {4: {}}}}}
+	result, err = ExecuteScenario(isolated, 4, 0, 2, "chainedhotstuff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.QuiescentAtEnd {
+		t.Errorf("expected isolated nodes to be quiescent:\n%s", result.NetworkLog)
+	}
+	if result.Ticks != 2 {
+		t.Errorf("got %d ticks, want the extra tick not to be counted", result.Ticks)
+	}
+}
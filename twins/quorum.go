@@ -0,0 +1,68 @@
+package twins
+
+import (
+	"github.com/relab/hotstuff/consensus"
+	"golang.org/x/exp/slices"
+)
+
+// QuorumNeverFormed is the value of ScenarioResult.QuorumFormationTicks for a view
+// in which a block was proposed, but no quorum certificate was formed for it before the scenario ended.
+const QuorumNeverFormed = -1
+
+// recordProposalTick records the tick of the first proposal in the view of the block.
+func (n *Network) recordProposalTick(block *consensus.Block) {
+	if n.proposalTicks == nil {
+		n.proposalTicks = make(map[consensus.View]int)
+	}
+	if _, ok := n.proposalTicks[block.View()]; !ok {
+		n.proposalTicks[block.View()] = n.currentTick
+	}
+}
+
+// observeQuorum records the tick at which a quorum certificate was first formed in each view.
+// A node that forms a quorum certificate from the votes it has collected adds a NewViewMsg with its own ID
+// to its event loop, so formed certificates are observed as the node processes such events.
+// Thus, certificates formed from votes that are verified asynchronously are observed in the same way.
+func (n *Network) observeQuorum(node *node, event interface{}) {
+	msg, ok := event.(consensus.NewViewMsg)
+	if !ok || msg.ID != node.mods.ID() {
+		return
+	}
+	qc, ok := msg.SyncInfo.QC()
+	if !ok {
+		return
+	}
+	if n.quorumTicks == nil {
+		n.quorumTicks = make(map[consensus.View]int)
+	}
+	if _, ok := n.quorumTicks[qc.View()]; !ok {
+		n.quorumTicks[qc.View()] = n.currentTick
+	}
+}
+
+// quorumFormationTicks returns the number of ticks between the first proposal and the first quorum certificate
+// of each view with a proposal, or QuorumNeverFormed if no quorum certificate was formed.
+func (n *Network) quorumFormationTicks() map[consensus.View]int {
+	formation := make(map[consensus.View]int, len(n.proposalTicks))
+	for view, proposed := range n.proposalTicks {
+		if formed, ok := n.quorumTicks[view]; ok {
+			formation[view] = formed - proposed
+		} else {
+			formation[view] = QuorumNeverFormed
+		}
+	}
+	return formation
+}
+
+// slowQuorums returns the views, in increasing order, in which no quorum certificate was formed
+// within the given number of ticks after the first proposal.
+func slowQuorums(formation map[consensus.View]int, window int) []consensus.View {
+	var views []consensus.View
+	for view, ticks := range formation {
+		if ticks == QuorumNeverFormed || ticks > window {
+			views = append(views, view)
+		}
+	}
+	slices.Sort(views)
+	return views
+}
@@ -0,0 +1,54 @@
+package twins
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/relab/hotstuff/consensus"
+	_ "github.com/relab/hotstuff/consensus/chainedhotstuff"
+	"golang.org/x/exp/slices"
+)
+
+func TestQuorumFormationTicks(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	scenario := Scenario{
+		{Leader: 1, Partitions: []NodeSet{allNodes}},
+		{Leader: 2, Partitions: []NodeSet{allNodes}},
+		{Leader: 3, Partitions: []NodeSet{{1: {}, 2: {}}, {3: {}, 4: {}}}},
+		{Leader: 4, Partitions: []NodeSet{allNodes}},
+	}
+
+	result, err := ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{QuorumWindow: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(result.QuorumFormationTicks)
+
+	for _, view := range []consensus.View{1, 2} {
+		ticks, ok := result.QuorumFormationTicks[view]
+		if !ok || ticks == QuorumNeverFormed {
+			t.Errorf("view %d: no quorum formed, want a quorum", view)
+		} else if ticks > 2 {
+			t.Errorf("view %d: quorum formed after %d ticks, want at most 2", view, ticks)
+		}
+	}
+	// the votes for the proposal of view 3 are sent to the leader of view 4,
+	// which is partitioned from half of the replicas.
+	if ticks, ok := result.QuorumFormationTicks[3]; !ok || ticks != QuorumNeverFormed {
+		t.Errorf("view 3: got %d (proposed: %v), want %d", ticks, ok, QuorumNeverFormed)
+	}
+	if !slices.Contains(result.SlowQuorums, 3) {
+		t.Errorf("got slow quorums %v, want view 3", result.SlowQuorums)
+	}
+	if slices.Contains(result.SlowQuorums, 1) || slices.Contains(result.SlowQuorums, 2) {
+		t.Errorf("got slow quorums %v, want neither view 1 nor view 2", result.SlowQuorums)
+	}
+}
+
+func TestQuorumWindowInvalid(t *testing.T) {
+	scenario := Scenario{{Leader: 1, Partitions: []NodeSet{{1: {}, 2: {}, 3: {}, 4: {}}}}}
+	_, err := ExecuteScenarioWithOptions(scenario, 4, 0, 10, "chainedhotstuff", ScenarioOptions{QuorumWindow: -1})
+	if !errors.Is(err, ErrInvalidScenario) {
+		t.Errorf("got error %v, want %v for a negative quorum window", err, ErrInvalidScenario)
+	}
+}
@@ -0,0 +1,109 @@
+package twins
+
+import (
+	"context"
+
+	"github.com/relab/hotstuff/consensus"
+)
+
+// ScenarioRunner executes a twins scenario one tick at a time, such that the state of the nodes
+// can be inspected between ticks. ExecuteScenario is implemented using the same steps.
+type ScenarioRunner struct {
+	network *Network
+	opts    ScenarioOptions
+	events  []StepEvent
+	err     error
+}
+
+// StepEvent is an event processed by a node during a step of a ScenarioRunner.
+type StepEvent struct {
+	// Node is the node that processed the event.
+	Node NodeID
+	// Event is the event that was processed, or a PartitionChangeEvent.
+	Event interface{}
+}
+
+// NodeState describes the state of a node between the steps of a ScenarioRunner.
+type NodeState struct {
+	// View is the current view of the node.
+	View consensus.View
+	// CommittedHeight is the number of blocks that the node has committed.
+	CommittedHeight int
+}
+
+// NewScenarioRunner creates the nodes of a twins scenario, without executing any ticks.
+func NewScenarioRunner(
+	scenario Scenario,
+	numNodes, numTwins uint8,
+	consensusName string,
+	opts ScenarioOptions,
+) (*ScenarioRunner, error) {
+	return newScenarioRunner(scenario, numNodes, numTwins, consensusName, opts, nil)
+}
+
+func newScenarioRunner(
+	scenario Scenario,
+	numNodes, numTwins uint8,
+	consensusName string,
+	opts ScenarioOptions,
+	replay *replayData,
+) (*ScenarioRunner, error) {
+	network, err := newScenarioNetwork(scenario, numNodes, numTwins, consensusName, opts, replay)
+	if err != nil {
+		return nil, err
+	}
+	return &ScenarioRunner{network: network, opts: opts}, nil
+}
+
+// Step advances the scenario by one tick, and returns the events that the nodes processed during the tick,
+// ordered by network ID. Once a step has failed, the same error is returned by all later steps.
+func (r *ScenarioRunner) Step() ([]StepEvent, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	r.events = nil
+	r.network.onEvent = func(id NodeID, event interface{}) {
+		r.events = append(r.events, StepEvent{Node: id, Event: event})
+	}
+	defer func() { r.network.onEvent = nil }()
+	if err := r.network.step(); err != nil {
+		r.err = err
+		return nil, err
+	}
+	return r.events, nil
+}
+
+// run performs the specified number of steps without collecting the events.
+// It stops early if the context is canceled.
+func (r *ScenarioRunner) run(ctx context.Context, ticks int) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.err = r.network.runContext(ctx, ticks)
+	return r.err
+}
+
+// Tick returns the number of ticks that have been performed.
+func (r *ScenarioRunner) Tick() int {
+	return r.network.currentTick
+}
+
+// State returns the current state of each node.
+func (r *ScenarioRunner) State() map[NodeID]NodeState {
+	state := make(map[NodeID]NodeState, len(r.network.nodes))
+	for _, node := range r.network.nodes {
+		state[node.id] = NodeState{
+			View:            node.mods.Synchronizer().View(),
+			CommittedHeight: len(node.executedBlocks),
+		}
+	}
+	return state
+}
+
+// Result returns the result of the scenario, as if it had ended after the ticks performed so far.
+func (r *ScenarioRunner) Result() (ScenarioResult, error) {
+	if r.err != nil {
+		return ScenarioResult{}, r.err
+	}
+	return scenarioResult(r.network, r.opts)
+}
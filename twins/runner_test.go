@@ -0,0 +1,69 @@
+package twins
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/relab/hotstuff"
+	_ "github.com/relab/hotstuff/consensus/chainedhotstuff"
+)
+
+func TestScenarioRunner(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	var scenario Scenario
+	for i := 0; i < 10; i++ {
+		scenario = append(scenario, View{Leader: hotstuff.ID(i%4 + 1), Partitions: []NodeSet{allNodes}})
+	}
+	const numTicks = 100
+
+	runner, err := NewScenarioRunner(scenario, 4, 0, "chainedhotstuff", ScenarioOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, state := range runner.State() {
+		if state.View != 1 || state.CommittedHeight != 0 {
+			t.Errorf("node %v: got initial state %+v, want view 1 without commits", id, state)
+		}
+	}
+
+	var numEvents int
+	heights := make(map[NodeID]int)
+	for runner.Tick() < numTicks {
+		events, err := runner.Step()
+		if err != nil {
+			t.Fatal(err)
+		}
+		numEvents += len(events)
+		for id, state := range runner.State() {
+			if state.CommittedHeight < heights[id] {
+				t.Fatalf("tick %d: committed height of node %v decreased from %d to %d", runner.Tick(), id, heights[id], state.CommittedHeight)
+			}
+			heights[id] = state.CommittedHeight
+		}
+	}
+	if numEvents == 0 {
+		t.Error("expected the steps to return the processed events")
+	}
+
+	got, err := runner.Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ExecuteScenario(scenario, 4, 0, numTicks, "chainedhotstuff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Commits == 0 || got.Commits != want.Commits {
+		t.Errorf("got %d commits, want %d commits as with ExecuteScenario", got.Commits, want.Commits)
+	}
+	for id, height := range heights {
+		if height != len(got.NodeCommits[id]) {
+			t.Errorf("node %v: got committed height %d, want %d", id, height, len(got.NodeCommits[id]))
+		}
+	}
+
+	_, err = NewScenarioRunner(scenario, 4, 0, "chainedhotstuff", ScenarioOptions{FragmentSize: -1})
+	if !errors.Is(err, ErrInvalidScenario) {
+		t.Errorf("got error %v, want %v", err, ErrInvalidScenario)
+	}
+}
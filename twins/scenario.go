@@ -3,12 +3,17 @@ package twins
 import (
 	"context"
 	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
 
 	"github.com/relab/hotstuff"
 	"github.com/relab/hotstuff/consensus"
+	"golang.org/x/exp/slices"
 )
 
 // View specifies the leader id and the partition scenario for a single view.
@@ -17,6 +22,21 @@ type View struct {
 	Partitions []NodeSet   `json:"partitions"`
 }
 
+// ReachabilityMatrix returns a matrix whose element [i][j] is true if the messages sent from nodes[i] to nodes[j]
+// in the view are delivered regardless of their type, that is, if the two nodes are in the same partition.
+// Since partitions are undirected, the matrix is symmetric. A node that is not in any partition reaches no nodes,
+// not even itself.
+func (v View) ReachabilityMatrix(nodes []uint32) [][]bool {
+	matrix := make([][]bool, len(nodes))
+	for i, from := range nodes {
+		matrix[i] = make([]bool, len(nodes))
+		for j, to := range nodes {
+			matrix[i][j] = samePartition(v.Partitions, from, to)
+		}
+	}
+	return matrix
+}
+
 // Scenario specifies the nodes, partitions and leaders for a twins scenario.
 type Scenario []View
 
@@ -37,52 +57,736 @@ func (s Scenario) String() string {
 	return sb.String()
 }
 
+// NewCleanScenario returns a scenario with the given number of views, where all nodes are in the same partition
+// in every view, and the leader is chosen in a round-robin fashion, starting with replica 1.
+// It returns an error if there are no nodes or the number of views is negative.
+func NewCleanScenario(numNodes uint8, numViews int) (Scenario, error) {
+	if numNodes == 0 {
+		return nil, fmt.Errorf("%w: no nodes", ErrInvalidScenario)
+	}
+	if numViews < 0 {
+		return nil, fmt.Errorf("%w: negative number of views %d", ErrInvalidScenario, numViews)
+	}
+	scenario := make(Scenario, numViews)
+	for i := range scenario {
+		allNodes := make(NodeSet)
+		for id := uint32(1); id <= uint32(numNodes); id++ {
+			allNodes.Add(id)
+		}
+		scenario[i] = View{
+			Leader:     hotstuff.ID(i%int(numNodes) + 1),
+			Partitions: []NodeSet{allNodes},
+		}
+	}
+	return scenario, nil
+}
+
 // ScenarioResult contains the result and logs from executing a scenario.
 type ScenarioResult struct {
-	Safe        bool
-	Commits     int
-	NetworkLog  string
-	NodeLogs    map[NodeID]string
+	Safe       bool
+	Commits    int
+	NetworkLog string
+	NodeLogs   map[NodeID]string
+	// NodeCommits contains the blocks executed by each node, in order.
+	// Empty blocks, proposed under the ProposeEmptyBlocks policy, have an empty command.
 	NodeCommits map[NodeID][]*consensus.Block
+	// NodeViewHistory contains the view transitions made by each node, in the order they happened.
+	NodeViewHistory map[NodeID][]ViewTransition
+	// CommitQCDepth contains, for each committed block, the number of quorum certificates
+	// linking the block that caused the commit to the committed block.
+	// If nodes committed the same block at different depths, the largest depth is used.
+	CommitQCDepth map[consensus.Hash]int
+	// QCParticipantCounts contains, for each committed block, the number of replicas that participated in
+	// the quorum certificate carried by the block, i.e. the number of votes aggregated by the QC for its parent.
+	// It shows whether the QCs were formed by a bare quorum or by more replicas.
+	// Blocks that carry the QC for the genesis block are not included, as that QC has no signature.
+	QCParticipantCounts map[consensus.Hash]int
+	// Live is false if a partition held a quorum of stake for long enough to commit a block,
+	// but none of the replicas in that partition committed anything.
+	Live bool
+	// NodeEventTrace contains the events processed by each node, in the order they were processed,
+	// interleaved with a PartitionChangeEvent whenever the partitions that apply to the node's messages change.
+	// It is only recorded if ScenarioOptions.RecordEvents is set.
+	NodeEventTrace map[NodeID][]EventRecord
+	// Drops contains the number of messages dropped by the network, by reason.
+	Drops map[DropReason]int
+	// PartitionCrossings is the number of messages that were sent between nodes in different partitions,
+	// but delivered because their type is not one of the dropped types.
+	PartitionCrossings int
+	// PartialMessages is the number of fragmented messages that had been received only in part
+	// when the scenario ended, summed over all receivers.
+	PartialMessages int
+	// HeldMessages is the number of messages that were still held by the network when the scenario ended,
+	// because their partition never healed.
+	HeldMessages int
+	// NodeTimeouts contains the number of times the view of each node timed out.
+	NodeTimeouts map[NodeID]int
+	// NodeFetches contains the number of blocks that each node fetched from other nodes.
+	NodeFetches map[NodeID]int
+	// NodePrunedBlocks contains the number of blocks that each node removed from its blockchain by pruning.
+	NodePrunedBlocks map[NodeID]int
+	// PruneViolations contains the blocks that were pruned while some node could still need them,
+	// and the failed fetches of pruned blocks, ordered by the network ID of the pruning node and tick. It is empty unless ScenarioOptions.PruneDepth is set.
+	PruneViolations []PruneViolation
+	// NodeEmptyProposals contains the number of empty blocks proposed by each node
+	// because its command queue was empty.
+	NodeEmptyProposals map[NodeID]int
+	// NodeOversizedProposals contains the number of proposals that each node rejected
+	// because their blocks exceeded the node's maximum block size.
+	NodeOversizedProposals map[NodeID]int
+	// NodeEventStats contains statistics about the event loop of each node.
+	NodeEventStats map[NodeID]EventStats
+	// NodeTimeoutCerts contains the timeout certificates formed by each node, in the order they were formed.
+	NodeTimeoutCerts map[NodeID][]TimeoutCertRecord
+	// TimeoutCertsValid is false if a node formed a timeout certificate without a quorum of valid timeout messages.
+	TimeoutCertsValid bool
+	// ChainsValid is false if a node executed a block that does not extend the block it executed before it.
+	// Unlike Safe, which compares the blocks executed by different replicas, it checks each node on its own,
+	// including twins, and thus detects nodes that execute their blocks out of order.
+	ChainsValid bool
+	// ChainViolations contains the executed blocks that do not extend the previously executed block,
+	// ordered by network ID and position.
+	ChainViolations []ChainViolation
+	// GenesisAgreed is false if the nodes, including twins, did not start from the same initial committed block.
+	// The result is then unsafe with zero commits, and the committed blocks are not compared, as they extend different chains.
+	GenesisAgreed bool
+	// NodeInitialBlocks contains the committed block that each node started from.
+	NodeInitialBlocks map[NodeID]*consensus.Block
+	// Ticks is the number of ticks that were executed.
+	// It is less than the requested number of ticks if the scenario was aborted by ScenarioOptions.AbortOnSafetyViolation
+	// or ScenarioOptions.MaxMessages.
+	Ticks int
+	// QuiescentAtEnd is true if no node tried to send a message during an extra tick that is performed after
+	// the scenario has ended. During that tick, the messages that were already in transit are delivered,
+	// but the messages that the nodes send are only logged. The extra tick is only reflected in the logs of the result.
+	// A node that is still making progress or timing out sends messages, so a scenario is only expected to be quiescent
+	// if its nodes have stopped, and other activity, such as retransmissions, may indicate a bug.
+	// It is only checked by ExecuteScenario and its variants, not by ScenarioRunner.Result.
+	QuiescentAtEnd bool
+	// BudgetExceeded is true if the scenario was aborted because the network was about to deliver
+	// more than ScenarioOptions.MaxMessages messages.
+	BudgetExceeded bool
+	// Leaders contains the leader of each view of the scenario.
+	// If the scenario was executed with a leader rotation module, a leader is present only for the views
+	// in which a node asked for it; if nodes disagreed, the most recent choice is reported.
+	Leaders map[consensus.View]hotstuff.ID
+	// MessageEvents contains what the network did with each message sent between the nodes, in order.
+	MessageEvents []MessageEvent
+	// QuorumFormationTicks contains, for each view in which a block was proposed, the number of ticks
+	// between the first proposal of the view and the first quorum certificate formed for a block of the view,
+	// or QuorumNeverFormed if no quorum certificate was formed.
+	QuorumFormationTicks map[consensus.View]int
+	// SlowQuorums contains the views, in increasing order, in which no quorum certificate was formed
+	// within ScenarioOptions.QuorumWindow ticks of the first proposal.
+	SlowQuorums []consensus.View
+	// AbsentLeaders contains, for each view whose leader does not have any node, how the nodes left that view.
+	// It is only recorded if ScenarioOptions.AbsentLeaders is set.
+	AbsentLeaders map[consensus.View]AbsentLeaderRecovery
+	// StreamCommits contains the commands executed by each node, by stream name, in the order they were executed.
+	// It is only recorded if ScenarioOptions.Streams is greater than one.
+	StreamCommits map[NodeID]map[string][]consensus.Command
+
+	replay      *replayData
+	blockEvents map[consensus.Hash][]BlockEvent
 }
 
-// ExecuteScenario executes a twins scenario.
-func ExecuteScenario(scenario Scenario, numNodes, numTwins uint8, numTicks int, consensusName string) (result ScenarioResult, err error) {
-	// Network simulator that blocks proposals, votes, and fetch requests between nodes that are in different partitions.
-	// Timeout and NewView messages are permitted.
-	network := NewPartitionedNetwork(scenario,
+// ScenarioOptions contains optional settings for executing a scenario.
+type ScenarioOptions struct {
+	// Learners are nodes that receive and execute blocks, but never vote or propose.
+	// Learners are not counted when computing the size of the configuration or the quorum size.
+	Learners []NodeID
+	// DropTypes contains the types of messages that are dropped when sent between nodes in different partitions.
+	// The types are specified using the zero value of each type, e.g. consensus.NewViewMsg{}.
+	// If nil, DefaultDropTypes is used.
+	DropTypes []interface{}
+	// HoldTypes contains the types of messages that are held, instead of dropped or delivered,
+	// when sent between nodes in different partitions. A held message is buffered on its link,
+	// and is sent once the partitions of a later view of the sender place the sender and the receiver together.
+	// The types are specified in the same way as DropTypes.
+	HoldTypes []interface{}
+	// Stake contains the stake of each replica. Replicas that are not present have a stake of 1.
+	// The stake is used to decide which partitions hold a quorum, and thus should be able to make progress.
+	Stake map[hotstuff.ID]uint64
+	// DuplicateProposals decides what happens when a node proposes more than once in the same view.
+	// By default, duplicate proposals are not detected.
+	DuplicateProposals DuplicateProposalPolicy
+	// Twins contains the IDs of the replicas that should have a twin.
+	// If set, it must contain exactly numTwins distinct replica IDs.
+	// If nil, the replicas with the lowest IDs are twinned.
+	Twins []hotstuff.ID
+	// Latency specifies additional delays for messages, per link and per message type.
+	Latency Latency
+	// PartitionFunc decides the partitions of each view based on the state of the network while the scenario runs,
+	// instead of using the partitions of the scenario. If nil, the partitions of the scenario are used.
+	PartitionFunc PartitionFunc
+	// AbsentLeaders allows the leader of a view to be a replica ID that does not have any node,
+	// such as to test that the nodes time out and move on when the leader schedule is misconfigured,
+	// or the leader crashed before the scenario started. Such leaders are not counted when computing the quorum size.
+	// By default, the leader of each view must be one of the replicas.
+	AbsentLeaders bool
+	// FIFOLinks makes the network deliver the messages sent from one node to another in the order they were sent,
+	// as a TCP connection would, by delaying a message until the messages sent before it on the same link are delivered.
+	// Messages on different links may still be reordered by their latencies.
+	// By default, a message with a smaller latency can overtake earlier messages on the same link.
+	FIFOLinks bool
+	// RecordEvents enables recording of the events processed by each node.
+	// The recorded events are stored in ScenarioResult.NodeEventTrace,
+	// and the result can be replayed using ReplayEventTrace.
+	RecordEvents bool
+	// CommandQueueCapacity is the capacity of each node's command queue.
+	// A node can have at most this many of its own proposed commands awaiting execution;
+	// when its queue is empty, the node cannot propose.
+	// If zero, the nodes have an unlimited supply of commands.
+	CommandQueueCapacity int
+	// CommandArrivalRate is the mean number of commands that arrive at each node per tick.
+	// Arrivals follow a Poisson process, and a node cannot propose until a command has arrived.
+	// If zero, commands are always available.
+	CommandArrivalRate float64
+	// Streams is the number of independent command streams, as in a sharded system where one consensus instance
+	// orders the commands of several shards. Each stream has its own command generator, and the command of each block
+	// is taken from the stream selected by the block's view, in round-robin order. The commands executed by each node
+	// are reported by stream in ScenarioResult.StreamCommits. If zero or one, the nodes propose from a single stream.
+	Streams int
+	// StarvationPolicy decides what a leader does when its command queue is empty.
+	// It only applies if CommandQueueCapacity or CommandArrivalRate is set.
+	// By default, the leader does not propose.
+	StarvationPolicy StarvationPolicy
+	// ArrivalSeed is the seed of the random arrivals of commands.
+	// Each node draws its arrivals from its own source, seeded by ArrivalSeed and the node's network ID.
+	ArrivalSeed int64
+	// Seed is the seed from which the private key of each node is derived, together with the node's ID.
+	// The nodes also derive the nonces of their signatures from their keys, instead of drawing them at random,
+	// so the same scenario executed with the same seed creates the same keys and signatures.
+	// If zero, the nodes use random keys and nonces.
+	Seed int64
+	// FragmentSize is the maximum size in bytes of a proposal before it is split into fragments.
+	// Each fragment leaves the sender in its own tick, and is subject to drops and latency independently.
+	// The receiver reassembles the proposal when it has received all fragments.
+	// If zero, messages are never fragmented.
+	FragmentSize int
+	// LeaderRotation is the name of a registered leader rotation module, such as "round-robin" or "carousel".
+	// If set, the nodes choose the leader of each view using this module, and the Leader of each view in the scenario is ignored.
+	// The leaders that were chosen are reported in ScenarioResult.Leaders.
+	// If empty, the leaders are taken from the scenario.
+	LeaderRotation string
+	// ChainLength is the number of consecutive views needed to commit a block, as passed to the consensus implementation.
+	// Leader rotation modules that depend on the commit rule, such as "carousel", take it into account.
+	// If zero, the consensus implementation's default is used.
+	ChainLength int
+	// ClockRates contains the rate of each node's clock, by network ID, relative to the network's ticks.
+	// A node whose clock runs at half rate needs twice as many ticks before its view times out.
+	// Nodes that are not present have a rate of 1.
+	ClockRates map[uint32]float64
+	// ProposalDelays contains an additional delay, in ticks, of the proposals sent by each node, by network ID.
+	// The delay is added to the latency of the proposals, which are still delivered,
+	// and can be used to model a leader that is connected, but slow to propose.
+	ProposalDelays map[uint32]int
+	// PersistenceDelays contains the number of ticks that each node needs to persist a proposed block before it
+	// can vote for it, by network ID, as if the node had to wait for a slow disk. The votes of a node leave it
+	// the given number of ticks after the proposal was processed, and are subject to the partitions of that tick.
+	// A leader's vote for its own proposal is not deferred; the leader's persistence latency can be modeled with
+	// ProposalDelays instead. Nodes that are not present persist their blocks instantly.
+	PersistenceDelays map[uint32]int
+	// BlockCacheSizes contains the maximum number of blocks stored by each node, by network ID.
+	// A node fetches the blocks that it has evicted from the other nodes if it needs them again.
+	// Nodes that are not present store all blocks.
+	BlockCacheSizes map[uint32]int
+	// PruneDepth makes the nodes remove the blocks whose views are more than PruneDepth views below their
+	// committed block from their blockchains, as a long-running replica would to bound its storage.
+	// A node that lags behind must then fetch the blocks that it is missing from nodes that have not pruned them yet.
+	// Pruning a block that another node does not have and still needs, as it is not below that node's committed block,
+	// and failing to fetch a pruned block, are reported in ScenarioResult.PruneViolations. The committed blocks are
+	// recorded as they are executed, before they can be pruned, so pruning does not affect their comparison.
+	// By default, the nodes do not prune their blockchains.
+	PruneDepth int
+	// MaxBlockSizes contains the maximum size in bytes of the blocks accepted by each node, by network ID.
+	// A node rejects proposals whose blocks exceed its limit, as counted in ScenarioResult.NodeOversizedProposals.
+	// The size of a block is the length of its serialized form, and the limits must be positive.
+	// Nodes that are not present accept all blocks.
+	MaxBlockSizes map[uint32]int
+	// Metadata contains the connection metadata of each node, by network ID.
+	// The metadata is available to the node's modules through Options().ConnectionMetadata(),
+	// and to the other nodes through the Metadata method of the node's replica.
+	Metadata map[uint32]map[string]string
+	// DebugInjectCommitMismatch makes a node commit a forged block in place of one of the blocks in its committed chain.
+	// It exists to test that safety violations are detected, and should never be set otherwise.
+	// If nil, the nodes commit the blocks chosen by the consensus implementation.
+	DebugInjectCommitMismatch *CommitMismatch
+	// DebugInjectGenesisMismatch is the network ID of a node that starts from a forged initial block,
+	// instead of the genesis block that the other nodes start from.
+	// It exists to test that genesis disagreements are detected, and should never be set otherwise.
+	// If zero, all nodes start from the genesis block.
+	DebugInjectGenesisMismatch uint32
+	// FaultModel decides how many faulty replicas the protocol tolerates, and thus the size of a quorum.
+	// It is used by the nodes, including leader rotation modules such as "carousel",
+	// and when deciding which partitions hold a quorum and should be able to make progress.
+	// By default, a configuration of n = 3f+1 replicas tolerates f faults.
+	FaultModel FaultModel
+	// AsyncVoteVerification makes the nodes verify votes in separate goroutines, as replicas do outside of twins,
+	// instead of in their event loops. The network does not wait for the verifications to finish,
+	// so a quorum certificate formed from verified votes may take effect in a later tick than it would otherwise,
+	// and executions are no longer reproducible. The goroutines only verify the signatures of the votes,
+	// and the results are collected by the event loops of the nodes.
+	// By default, votes are verified synchronously.
+	AsyncVoteVerification bool
+	// QuorumWindow is the number of ticks after the first proposal of a view within which a quorum certificate
+	// must be formed for the view not to be reported in ScenarioResult.SlowQuorums.
+	// With the default of zero, every view whose quorum certificate was not formed in the tick of the proposal is reported.
+	QuorumWindow int
+	// MaxMessages is the maximum number of messages that the network accepts for delivery, including released
+	// held messages. A fragmented message counts once. Once the budget is exhausted, the next message that would
+	// have been delivered is dropped, with the reason DropBudget, and the scenario stops at the end of that tick,
+	// with ScenarioResult.BudgetExceeded set. This bounds the time spent on pathological scenarios, such as when fuzzing.
+	// By default, the number of messages is not limited.
+	MaxMessages int
+	// AbortOnSafetyViolation makes the network compare the blocks committed by the replicas without twins as they are committed,
+	// and stop the scenario at the end of the tick in which the replicas first diverge, instead of running all ticks.
+	// The result of an aborted scenario is unsafe, and its Ticks field tells how many ticks were executed.
+	AbortOnSafetyViolation bool
+	// LogWriter receives the network log while the scenario is running, in addition to ScenarioResult.NetworkLog.
+	// It must be safe for concurrent use if it is shared by scenarios that run in parallel.
+	// If nil, the network log is only available in the result.
+	LogWriter io.Writer
+}
+
+// CommitMismatch describes a forged block to be committed by a node, as injected by ScenarioOptions.DebugInjectCommitMismatch.
+type CommitMismatch struct {
+	// NetworkID is the network ID of the node that commits the forged block.
+	// The node must belong to a replica without a twin, since the commits of twinned replicas are not compared.
+	NetworkID uint32
+	// Position is the position in the node's committed chain, starting at 0, where the forged block is committed.
+	Position int
+}
+
+// FaultModel describes the number of faulty replicas that a protocol tolerates.
+// A configuration of n replicas tolerates f = (n-1)/Resilience faults, and a quorum consists of n-f replicas.
+type FaultModel struct {
+	// Resilience is the number of replicas needed for each faulty replica, e.g. 5 for a protocol that requires n = 5f+1.
+	// If zero, the standard resilience of 3 is used.
+	Resilience int
+}
+
+// NumFaulty returns the number of faulty replicas tolerated by a configuration of n replicas.
+func (m FaultModel) NumFaulty(n int) int {
+	if m.Resilience == 0 {
+		return hotstuff.NumFaulty(n)
+	}
+	return (n - 1) / m.Resilience
+}
+
+// QuorumSize returns the size of a quorum in a configuration of n replicas.
+func (m FaultModel) QuorumSize(n int) int {
+	return n - m.NumFaulty(n)
+}
+
+// DuplicateProposalPolicy decides how the network handles a node that proposes more than once in the same view.
+type DuplicateProposalPolicy uint8
+
+const (
+	// IgnoreDuplicateProposals disables the detection of duplicate proposals.
+	IgnoreDuplicateProposals DuplicateProposalPolicy = iota
+	// LogDuplicateProposals logs duplicate proposals to the network log.
+	LogDuplicateProposals
+	// FailOnDuplicateProposals logs duplicate proposals and causes the scenario to return ErrDuplicateProposal.
+	FailOnDuplicateProposals
+)
+
+// StarvationPolicy decides what a leader does when it has no command to propose.
+type StarvationPolicy uint8
+
+const (
+	// StallOnStarvation makes the leader skip its proposal, such that the view eventually times out.
+	StallOnStarvation StarvationPolicy = iota
+	// ProposeEmptyBlocks makes the leader propose a block with an empty command, which keeps the chain live
+	// at the cost of blocks that carry no commands.
+	ProposeEmptyBlocks
+)
+
+// DefaultDropTypes returns the message types that are dropped between partitions by default:
+// proposals, votes, fetch requests, new view messages, timeout messages, and application messages.
+func DefaultDropTypes() []interface{} {
+	return []interface{}{
 		consensus.ProposeMsg{},
 		consensus.VoteMsg{},
 		consensus.Hash{},
 		consensus.NewViewMsg{},
 		consensus.TimeoutMsg{},
-	)
+		consensus.AppMsg{},
+	}
+}
+
+// ExecuteScenario executes a twins scenario.
+func ExecuteScenario(scenario Scenario, numNodes, numTwins uint8, numTicks int, consensusName string) (result ScenarioResult, err error) {
+	return ExecuteScenarioWithOptions(scenario, numNodes, numTwins, numTicks, consensusName, ScenarioOptions{})
+}
 
-	nodes, twins := assignNodeIDs(numNodes, numTwins)
+// ExecuteScenarioWithOptions executes a twins scenario using the given options.
+func ExecuteScenarioWithOptions(
+	scenario Scenario,
+	numNodes, numTwins uint8,
+	numTicks int,
+	consensusName string,
+	opts ScenarioOptions,
+) (result ScenarioResult, err error) {
+	return ExecuteScenarioContext(context.Background(), scenario, numNodes, numTwins, numTicks, consensusName, opts)
+}
+
+// ExecuteScenarioContext executes a twins scenario using the given options.
+// The scenario is stopped if the context is canceled, in which case the context's error is returned.
+func ExecuteScenarioContext(
+	ctx context.Context,
+	scenario Scenario,
+	numNodes, numTwins uint8,
+	numTicks int,
+	consensusName string,
+	opts ScenarioOptions,
+) (result ScenarioResult, err error) {
+	return executeScenario(ctx, scenario, numNodes, numTwins, numTicks, consensusName, opts, nil)
+}
+
+func executeScenario(
+	ctx context.Context,
+	scenario Scenario,
+	numNodes, numTwins uint8,
+	numTicks int,
+	consensusName string,
+	opts ScenarioOptions,
+	replay *replayData,
+) (result ScenarioResult, err error) {
+	runner, err := newScenarioRunner(scenario, numNodes, numTwins, consensusName, opts, replay)
+	if err != nil {
+		return ScenarioResult{}, err
+	}
+	err = runner.run(ctx, numTicks)
+	if err != nil {
+		return ScenarioResult{}, err
+	}
+	result, err = runner.Result()
+	if err != nil {
+		return ScenarioResult{}, err
+	}
+	result.QuiescentAtEnd, err = runner.network.checkQuiescence()
+	if err != nil {
+		return ScenarioResult{}, err
+	}
+	// the logs include the messages that the nodes tried to send after the end.
+	result.NetworkLog = runner.network.log.String()
+	for _, node := range runner.network.nodes {
+		result.NodeLogs[node.id] = node.log.String()
+	}
+	return result, nil
+}
+
+// newScenarioNetwork validates the scenario and creates a network with the nodes of the scenario.
+func newScenarioNetwork(
+	scenario Scenario,
+	numNodes, numTwins uint8,
+	consensusName string,
+	opts ScenarioOptions,
+	replay *replayData,
+) (*Network, error) {
+	err := validateScenario(scenario, numNodes, numTwins, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	dropTypes := opts.DropTypes
+	if dropTypes == nil {
+		dropTypes = DefaultDropTypes()
+	}
+
+	// Network simulator that blocks the specified message types between nodes that are in different partitions.
+	network := NewPartitionedNetwork(scenario, dropTypes...)
+
+	for _, t := range opts.HoldTypes {
+		network.holdTypes[reflect.TypeOf(t)] = struct{}{}
+	}
+	for _, id := range opts.Learners {
+		network.learners.Add(id.ReplicaID)
+	}
+	network.stake = opts.Stake
+	network.duplicateProposals = opts.DuplicateProposals
+	network.latency = opts.Latency
+	network.fifoLinks = opts.FIFOLinks
+	if opts.PartitionFunc != nil {
+		network.SetPartitionFunc(opts.PartitionFunc)
+	}
+	network.recordEvents = opts.RecordEvents
+	network.commandQueueCapacity = opts.CommandQueueCapacity
+	network.commandArrivalRate = opts.CommandArrivalRate
+	network.streams = opts.Streams
+	network.starvationPolicy = opts.StarvationPolicy
+	network.arrivalSeed = opts.ArrivalSeed
+	network.keySeed = opts.Seed
+	network.fragmentSize = opts.FragmentSize
+	network.chainLength = opts.ChainLength
+	network.clockRates = opts.ClockRates
+	network.proposalDelays = opts.ProposalDelays
+	network.persistenceDelays = opts.PersistenceDelays
+	network.blockCacheSizes = opts.BlockCacheSizes
+	network.pruneDepth = opts.PruneDepth
+	network.maxBlockSizes = opts.MaxBlockSizes
+	network.metadata = opts.Metadata
+	network.commitMismatch = opts.DebugInjectCommitMismatch
+	network.genesisMismatch = opts.DebugInjectGenesisMismatch
+	network.abortOnSafetyViolation = opts.AbortOnSafetyViolation
+	network.faultModel = opts.FaultModel
+	network.asyncVoteVerification = opts.AsyncVoteVerification
+	network.maxMessages = opts.MaxMessages
+	if opts.LogWriter != nil {
+		network.SetLogWriter(opts.LogWriter)
+	}
+	if opts.LeaderRotation != "" {
+		network.leaderRotation = opts.LeaderRotation
+		network.leaders = make(map[consensus.View]hotstuff.ID)
+	}
+	network.replay = replay
+
+	var nodes, twins []NodeID
+	if opts.Twins != nil {
+		nodes, twins = assignTwinIDs(numNodes, opts.Twins)
+	} else {
+		nodes, twins = assignNodeIDs(numNodes, numTwins)
+	}
 	nodes = append(nodes, twins...)
 
 	err = network.createTwinsNodes(nodes, scenario, consensusName)
 	if err != nil {
-		return ScenarioResult{}, err
+		return nil, err
 	}
 
-	network.run(numTicks)
+	if m := opts.DebugInjectCommitMismatch; m != nil {
+		if replica := network.nodes[m.NetworkID].id.ReplicaID; len(network.replicas[replica]) != 1 {
+			return nil, fmt.Errorf("%w: commit mismatch injected at node %d, whose replica has a twin", ErrInvalidScenario, m.NetworkID)
+		}
+	}
+	return network, nil
+}
+
+// scenarioResult collects the result of a scenario from the network that executed it.
+func scenarioResult(network *Network, opts ScenarioOptions) (result ScenarioResult, err error) {
+	if network.duplicateProposalErr != nil {
+		return ScenarioResult{}, network.duplicateProposalErr
+	}
 
 	nodeLogs := make(map[NodeID]string)
+	initialBlocks := make(map[NodeID]*consensus.Block)
+	viewHistory := make(map[NodeID][]ViewTransition)
+	timeouts := make(map[NodeID]int)
+	emptyProposals := make(map[NodeID]int)
+	fetches := make(map[NodeID]int)
+	prunedBlocks := make(map[NodeID]int)
+	timeoutCerts := make(map[NodeID][]TimeoutCertRecord)
+	oversizedProposals := make(map[NodeID]int)
+	eventStats := make(map[NodeID]EventStats)
+	timeoutCertsValid := true
+	partialMessages := 0
 	for _, node := range network.nodes {
 		nodeLogs[node.id] = node.log.String()
+		initialBlocks[node.id] = node.initialBlock
+		viewHistory[node.id] = node.viewHistory
+		timeouts[node.id] = node.timeouts
+		emptyProposals[node.id] = node.emptyProposals
+		fetches[node.id] = node.fetches
+		prunedBlocks[node.id] = node.prunedBlocks
+		timeoutCerts[node.id] = node.timeoutCerts
+		oversizedProposals[node.id] = node.oversizedProposals
+		stats := node.eventStats
+		stats.Requeued = int(node.mods.EventLoop().Requeued())
+		eventStats[node.id] = stats
+		for _, tc := range node.timeoutCerts {
+			timeoutCertsValid = timeoutCertsValid && tc.Valid
+		}
+		partialMessages += len(node.fragments)
+	}
+
+	// check that the nodes started from the same block before comparing the blocks that they committed after it
+	genesisAgreed := checkGenesis(network)
+	safe, commits := genesisAgreed, 0
+	if genesisAgreed {
+		// check if the majority of replicas have committed the same blocks
+		safe, commits = checkCommits(network)
+	}
+
+	result = ScenarioResult{
+		Safe:                   safe,
+		Commits:                commits,
+		GenesisAgreed:          genesisAgreed,
+		NodeInitialBlocks:      initialBlocks,
+		NetworkLog:             network.log.String(),
+		NodeLogs:               nodeLogs,
+		NodeCommits:            getBlocks(network),
+		NodeViewHistory:        viewHistory,
+		CommitQCDepth:          getCommitDepths(network),
+		QCParticipantCounts:    getQCParticipantCounts(network),
+		Live:                   checkLiveness(network),
+		Drops:                  network.drops,
+		PartitionCrossings:     network.partitionCrossings,
+		PartialMessages:        partialMessages,
+		HeldMessages:           network.heldMessageCount(),
+		NodeTimeouts:           timeouts,
+		NodeFetches:            fetches,
+		NodePrunedBlocks:       prunedBlocks,
+		PruneViolations:        network.sortedPruneViolations(),
+		NodeTimeoutCerts:       timeoutCerts,
+		NodeOversizedProposals: oversizedProposals,
+		NodeEventStats:         eventStats,
+		TimeoutCertsValid:      timeoutCertsValid,
+		NodeEmptyProposals:     emptyProposals,
+		Ticks:                  network.currentTick,
+		BudgetExceeded:         network.budgetExceeded,
+		MessageEvents:          network.messageEvents,
+		blockEvents:            network.blockEvents,
+		Leaders:                network.scenarioLeaders(),
+	}
+	result.QuorumFormationTicks = network.quorumFormationTicks()
+	result.SlowQuorums = slowQuorums(result.QuorumFormationTicks, opts.QuorumWindow)
+	result.AbsentLeaders = network.absentLeaderRecoveries(opts.AbsentLeaders)
+	result.ChainViolations = checkChains(network)
+	result.ChainsValid = len(result.ChainViolations) == 0
+	if opts.Streams > 1 {
+		result.StreamCommits = getStreamCommits(network)
+	}
+
+	if opts.RecordEvents {
+		result.NodeEventTrace = make(map[NodeID][]EventRecord)
+		for _, node := range network.nodes {
+			result.NodeEventTrace[node.id] = node.eventTrace
+		}
+		result.replay = collectReplayData(network)
+	}
+
+	return result, nil
+}
+
+// validateNodeOption checks that a per-node option only refers to nodes that exist,
+// and that the value of each node is accepted by valid, unless valid is nil.
+func validateNodeOption[V any](name string, values map[uint32]V, numNetworkNodes uint32, valid func(V) bool) error {
+	for id, v := range values {
+		if id < 1 || id > numNetworkNodes {
+			return fmt.Errorf("%w: %s of node %d, which does not exist", ErrInvalidScenario, name, id)
+		}
+		if valid != nil && !valid(v) {
+			return fmt.Errorf("%w: node %d: invalid %s %v", ErrInvalidScenario, id, name, v)
+		}
 	}
+	return nil
+}
 
-	// check if the majority of replicas have committed the same blocks
-	safe, commits := checkCommits(network)
+// validateScenario checks that the leaders, partitions, and options of the scenario
+// only refer to nodes that exist.
+func validateScenario(scenario Scenario, numNodes, numTwins uint8, opts ScenarioOptions) error {
+	if numNodes == 0 {
+		return fmt.Errorf("%w: no nodes", ErrInvalidScenario)
+	}
+	if numTwins > numNodes {
+		return fmt.Errorf("%w: %d twins, but only %d nodes", ErrInvalidScenario, numTwins, numNodes)
+	}
+	numNetworkNodes := uint32(numNodes) + uint32(numTwins)
+	for i, view := range scenario {
+		if opts.LeaderRotation == "" && (view.Leader < 1 || (view.Leader > hotstuff.ID(numNodes) && !opts.AbsentLeaders)) {
+			return fmt.Errorf("%w: view %d: leader %d does not exist", ErrInvalidScenario, i+1, view.Leader)
+		}
+		seen := make(NodeSet)
+		for _, partition := range view.Partitions {
+			for id := range partition {
+				if id < 1 || id > numNetworkNodes {
+					return fmt.Errorf("%w: view %d: node %d does not exist", ErrInvalidScenario, i+1, id)
+				}
+				if seen.Contains(id) {
+					return fmt.Errorf("%w: view %d: node %d is in multiple partitions", ErrInvalidScenario, i+1, id)
+				}
+				seen.Add(id)
+			}
+		}
+	}
+	if opts.CommandQueueCapacity < 0 {
+		return fmt.Errorf("%w: negative command queue capacity %d", ErrInvalidScenario, opts.CommandQueueCapacity)
+	}
+	if opts.CommandArrivalRate < 0 || math.IsNaN(opts.CommandArrivalRate) || math.IsInf(opts.CommandArrivalRate, 0) {
+		return fmt.Errorf("%w: invalid command arrival rate %v", ErrInvalidScenario, opts.CommandArrivalRate)
+	}
+	if opts.FragmentSize < 0 {
+		return fmt.Errorf("%w: negative fragment size %d", ErrInvalidScenario, opts.FragmentSize)
+	}
+	if r := opts.FaultModel.Resilience; r < 0 || r == 1 {
+		return fmt.Errorf("%w: invalid fault model resilience %d", ErrInvalidScenario, r)
+	}
+	if opts.Streams < 0 {
+		return fmt.Errorf("%w: negative number of command streams %d", ErrInvalidScenario, opts.Streams)
+	}
+	if opts.ChainLength < 0 {
+		return fmt.Errorf("%w: negative chain length %d", ErrInvalidScenario, opts.ChainLength)
+	}
+	if opts.MaxMessages < 0 {
+		return fmt.Errorf("%w: negative message budget %d", ErrInvalidScenario, opts.MaxMessages)
+	}
+	if opts.QuorumWindow < 0 {
+		return fmt.Errorf("%w: negative quorum window %d", ErrInvalidScenario, opts.QuorumWindow)
+	}
+	if opts.PruneDepth < 0 {
+		return fmt.Errorf("%w: negative prune depth %d", ErrInvalidScenario, opts.PruneDepth)
+	}
+	validRate := func(rate float64) bool { return rate > 0 && !math.IsNaN(rate) && !math.IsInf(rate, 0) }
+	notNegative := func(v int) bool { return v >= 0 }
+	positive := func(v int) bool { return v > 0 }
+	for _, err := range []error{
+		validateNodeOption("clock rate", opts.ClockRates, numNetworkNodes, validRate),
+		validateNodeOption("proposal delay", opts.ProposalDelays, numNetworkNodes, notNegative),
+		validateNodeOption("persistence delay", opts.PersistenceDelays, numNetworkNodes, notNegative),
+		validateNodeOption("block cache size", opts.BlockCacheSizes, numNetworkNodes, notNegative),
+		validateNodeOption("maximum block size", opts.MaxBlockSizes, numNetworkNodes, positive),
+		validateNodeOption("metadata", opts.Metadata, numNetworkNodes, nil),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	if m := opts.DebugInjectCommitMismatch; m != nil {
+		if m.NetworkID < 1 || m.NetworkID > numNetworkNodes {
+			return fmt.Errorf("%w: commit mismatch injected at node %d, which does not exist", ErrInvalidScenario, m.NetworkID)
+		}
+		if m.Position < 0 {
+			return fmt.Errorf("%w: commit mismatch injected at negative position %d", ErrInvalidScenario, m.Position)
+		}
+	}
+	if id := opts.DebugInjectGenesisMismatch; id > numNetworkNodes {
+		return fmt.Errorf("%w: genesis mismatch injected at node %d, which does not exist", ErrInvalidScenario, id)
+	}
+	if opts.Twins != nil {
+		if len(opts.Twins) != int(numTwins) {
+			return fmt.Errorf("%w: %d twins requested, but %d replicas to twin", ErrInvalidScenario, numTwins, len(opts.Twins))
+		}
+		twinned := consensus.NewIDSet()
+		for _, id := range opts.Twins {
+			if id < 1 || id > hotstuff.ID(numNodes) {
+				return fmt.Errorf("%w: twinned replica %d does not exist", ErrInvalidScenario, id)
+			}
+			if twinned.Contains(id) {
+				return fmt.Errorf("%w: replica %d is twinned more than once", ErrInvalidScenario, id)
+			}
+			twinned.Add(id)
+		}
+	}
+	for _, id := range opts.Learners {
+		if id.ReplicaID < 1 || id.ReplicaID > hotstuff.ID(numNodes) {
+			return fmt.Errorf("%w: learner %v does not exist", ErrInvalidScenario, id)
+		}
+	}
+	return nil
+}
 
-	return ScenarioResult{
-		Safe:        safe,
-		Commits:     commits,
-		NetworkLog:  network.log.String(),
-		NodeLogs:    nodeLogs,
-		NodeCommits: getBlocks(network),
-	}, nil
+// checkGenesis checks that all nodes, including twins, started from the same initial committed block.
+func checkGenesis(network *Network) bool {
+	var initial *consensus.Block
+	for _, node := range network.nodes {
+		if initial == nil {
+			initial = node.initialBlock
+		} else if node.initialBlock.Hash() != initial.Hash() {
+			return false
+		}
+	}
+	return true
 }
 
 func checkCommits(network *Network) (safe bool, commits int) {
@@ -90,6 +794,7 @@ func checkCommits(network *Network) (safe bool, commits int) {
 	for {
 		noCommits := true
 		commitCount := make(map[consensus.Hash]int)
+		// learners are checked as well, since they should execute the same blocks as the other replicas.
 		for _, replica := range network.replicas {
 			if len(replica) != 1 {
 				// TODO: should we be skipping replicas with twins?
@@ -118,6 +823,111 @@ func checkCommits(network *Network) (safe bool, commits int) {
 	return true, i
 }
 
+// ChainViolation describes a block executed by a node whose parent is not the block that the node executed before it.
+type ChainViolation struct {
+	Node NodeID
+	// Position is the index of the block in the node's executed blocks.
+	Position int
+	// Parent is the parent of the block.
+	Parent consensus.Hash
+	// Previous is the hash of the block executed before it, or of the node's initial block if it was executed first.
+	Previous consensus.Hash
+}
+
+// checkChains checks that the blocks executed by each node form a chain of parent links from the node's initial block.
+func checkChains(network *Network) (violations []ChainViolation) {
+	for _, node := range network.nodes {
+		previous := node.initialBlock.Hash()
+		for i, block := range node.executedBlocks {
+			if block.Parent() != previous {
+				violations = append(violations, ChainViolation{Node: node.id, Position: i, Parent: block.Parent(), Previous: previous})
+			}
+			previous = block.Hash()
+		}
+	}
+	slices.SortFunc(violations, func(a, b ChainViolation) bool {
+		if a.Node.NetworkID != b.Node.NetworkID {
+			return a.Node.NetworkID < b.Node.NetworkID
+		}
+		return a.Position < b.Position
+	})
+	return violations
+}
+
+// monitorCommit compares a block committed by a node with the blocks committed at the same position by the other
+// replicas without twins, if the network aborts on safety violations. The comparison is the same as in checkCommits.
+func (n *Network) monitorCommit(node *node, position int, block *consensus.Block) {
+	if !n.abortOnSafetyViolation || n.safetyViolated || len(n.replicas[node.id.ReplicaID]) != 1 {
+		return
+	}
+	if n.committedHashes == nil {
+		n.committedHashes = make(map[int]consensus.Hash)
+	}
+	hash, ok := n.committedHashes[position]
+	if !ok {
+		n.committedHashes[position] = block.Hash()
+		return
+	}
+	if hash != block.Hash() {
+		n.safetyViolated = true
+		n.logger.Infof("node %v: DIVERGED at position %d in tick %d, aborting", node.id, position, n.currentTick)
+	}
+}
+
+// checkLiveness checks that the replicas in a partition that held a quorum of stake for
+// enough consecutive views to commit a block, did in fact commit a block.
+func checkLiveness(network *Network) bool {
+	var chainLength int
+	for _, node := range network.nodes {
+		chainLength = node.mods.Consensus().ChainLength()
+		break
+	}
+
+	var (
+		run      int
+		quorum   NodeSet
+		expected NodeSet
+	)
+	for i, view := range network.views {
+		partition, ok := network.leaderQuorumPartition(network.leader(consensus.View(i+1)), view)
+		if ok && run > 0 {
+			partition = intersect(quorum, partition)
+			ok = network.hasQuorum(partition)
+		}
+		if !ok {
+			run = 0
+			continue
+		}
+		quorum = partition
+		run++
+		// a block can be committed once there are enough consecutive views to form a chain on top of it.
+		if run > chainLength {
+			expected = quorum
+		}
+	}
+
+	if expected == nil {
+		return true
+	}
+
+	for id := range expected {
+		if len(network.nodes[id].executedBlocks) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func intersect(a, b NodeSet) NodeSet {
+	c := make(NodeSet)
+	for id := range a {
+		if b.Contains(id) {
+			c.Add(id)
+		}
+	}
+	return c
+}
+
 type leaderRotation []View
 
 // GetLeader returns the id of the leader in the given view.
@@ -131,6 +941,25 @@ func (lr leaderRotation) GetLeader(view consensus.View) hotstuff.ID {
 	return 0
 }
 
+// recordingLeaderRotation is a leader rotation module that records the leaders chosen by another module in the network.
+type recordingLeaderRotation struct {
+	consensus.LeaderRotation
+	network *Network
+}
+
+func (lr recordingLeaderRotation) InitConsensusModule(mods *consensus.Modules, opts *consensus.OptionsBuilder) {
+	if mod, ok := lr.LeaderRotation.(consensus.Module); ok {
+		mod.InitConsensusModule(mods, opts)
+	}
+}
+
+// GetLeader returns the id of the leader in the given view.
+func (lr recordingLeaderRotation) GetLeader(view consensus.View) hotstuff.ID {
+	leader := lr.LeaderRotation.GetLeader(view)
+	lr.network.leaders[view] = leader
+	return leader
+}
+
 func getBlocks(network *Network) map[NodeID][]*consensus.Block {
 	m := make(map[NodeID][]*consensus.Block)
 	for _, node := range network.nodes {
@@ -139,6 +968,30 @@ func getBlocks(network *Network) map[NodeID][]*consensus.Block {
 	return m
 }
 
+func getCommitDepths(network *Network) map[consensus.Hash]int {
+	m := make(map[consensus.Hash]int)
+	for _, node := range network.nodes {
+		for hash, depth := range node.commitDepths {
+			if depth > m[hash] {
+				m[hash] = depth
+			}
+		}
+	}
+	return m
+}
+
+func getQCParticipantCounts(network *Network) map[consensus.Hash]int {
+	m := make(map[consensus.Hash]int)
+	for _, node := range network.nodes {
+		for _, block := range node.executedBlocks {
+			if sig := block.QuorumCert().Signature(); sig != nil {
+				m[block.Hash()] = sig.Participants().Len()
+			}
+		}
+	}
+	return m
+}
+
 type commandGenerator struct {
 	mut     sync.Mutex
 	nextCmd uint64
@@ -152,9 +1005,107 @@ func (cg *commandGenerator) next() consensus.Command {
 	return cmd
 }
 
+// commandQueue models the command queue of a single node.
+//
+// If the queue has a capacity, it is filled to capacity when the scenario starts.
+// Commands leave the queue when they are proposed, and a new command is submitted
+// only when a command proposed by the node has been executed or forked,
+// as if the queue were fed by closed-loop clients.
+//
+// If the queue has arrivals, commands are instead submitted by open-loop clients,
+// and become available to the node as they arrive over the ticks.
+type commandQueue struct {
+	// the maximum number of commands in flight, or zero if unbounded.
+	capacity int
+	inFlight map[consensus.Command]struct{}
+	// the arrival process of commands, or nil if commands are always available.
+	arrivals *arrivalProcess
+	// the number of commands that have arrived, but have not been proposed.
+	arrived int
+	// whether to propose an empty block when the queue is empty.
+	proposeEmpty bool
+}
+
+// empty returns true if all commands in the queue are in flight, or no commands have arrived.
+func (q *commandQueue) empty() bool {
+	if q == nil {
+		return false
+	}
+	if q.capacity > 0 && len(q.inFlight) >= q.capacity {
+		return true
+	}
+	return q.arrivals != nil && q.arrived == 0
+}
+
+// take marks the command as in flight.
+func (q *commandQueue) take(cmd consensus.Command) {
+	if q == nil {
+		return
+	}
+	if q.capacity > 0 {
+		q.inFlight[cmd] = struct{}{}
+	}
+	if q.arrivals != nil {
+		q.arrived--
+	}
+}
+
+// done frees the slot of the command, if it was proposed by this node.
+func (q *commandQueue) done(cmd consensus.Command) {
+	if q != nil {
+		delete(q.inFlight, cmd)
+	}
+}
+
+// tick adds the commands that arrived during a tick to the queue.
+func (q *commandQueue) tick() {
+	if q != nil && q.arrivals != nil {
+		q.arrived += q.arrivals.next()
+	}
+}
+
+// maxPoissonMean is the largest mean that is sampled directly by arrivalProcess.
+// Larger means are split into multiple samples, because exp(-mean) underflows for large means.
+const maxPoissonMean = 500
+
+// arrivalProcess draws the number of commands that arrive per tick from a Poisson distribution.
+type arrivalProcess struct {
+	rate float64
+	rnd  *rand.Rand
+}
+
+func newArrivalProcess(rate float64, seed int64) *arrivalProcess {
+	return &arrivalProcess{rate: rate, rnd: rand.New(rand.NewSource(seed))}
+}
+
+// next returns the number of commands that arrived during the next tick.
+func (a *arrivalProcess) next() int {
+	n := 0
+	mean := a.rate
+	for mean > maxPoissonMean {
+		n += a.poisson(maxPoissonMean)
+		mean -= maxPoissonMean
+	}
+	return n + a.poisson(mean)
+}
+
+// poisson samples a Poisson distribution with the given mean using Knuth's algorithm.
+func (a *arrivalProcess) poisson(mean float64) int {
+	limit := math.Exp(-mean)
+	n := 0
+	for p := a.rnd.Float64(); p > limit; p *= a.rnd.Float64() {
+		n++
+	}
+	return n
+}
+
 type commandModule struct {
-	commandGenerator *commandGenerator
-	node             *node
+	streams commandStreams
+	network *Network
+	node    *node
+	queue   *commandQueue
+	// the forged block to commit, or nil if the node commits the blocks it is given.
+	mismatch *CommitMismatch
 }
 
 // Accept returns true if the replica should accept the command, false otherwise.
@@ -170,12 +1121,91 @@ func (commandModule) Proposed(_ consensus.Command) {}
 // It may run until the context is cancelled.
 // If no command is available, the 'ok' return value should be false.
 func (cm commandModule) Get(_ context.Context) (cmd consensus.Command, ok bool) {
-	return cm.commandGenerator.next(), true
+	if cm.queue.empty() {
+		if cm.queue.proposeEmpty {
+			cm.node.emptyProposals++
+			return "", true
+		}
+		return "", false
+	}
+	cmd = cm.streams.next(cm.node.mods.Synchronizer().View())
+	cm.queue.take(cmd)
+	return cmd, true
 }
 
 // Exec executes the given command.
 func (cm commandModule) Exec(block *consensus.Block) {
+	cm.queue.done(block.Command())
+	if cm.mismatch != nil && len(cm.node.executedBlocks) == cm.mismatch.Position {
+		block = forgeBlock(block)
+		cm.node.mods.Logger().Infof("debug: committing forged block %v", block)
+	}
+	cm.network.monitorCommit(cm.node, len(cm.node.executedBlocks), block)
+	cm.network.recordBlockEvent(block.Hash(), BlockCommitted, cm.node.id)
 	cm.node.executedBlocks = append(cm.node.executedBlocks, block)
+	cm.node.commitDepths[block.Hash()] = qcDepth(cm.node, block)
+}
+
+// forgeBlock returns a block that has the same parent, certificate, view, and proposer as the given block,
+// but a different command, and thus a different hash.
+func forgeBlock(block *consensus.Block) *consensus.Block {
+	return consensus.NewBlock(block.Parent(), block.QuorumCert(), block.Command()+"-forged", block.View(), block.Proposer())
+}
+
+// qcDepth returns the number of quorum certificates that must be followed
+// to get from the block that caused the current commit to the committed block.
+func qcDepth(node *node, committed *consensus.Block) int {
+	depth := 0
+	current := node.commitTrigger
+	for current != nil && current.View() > committed.View() {
+		var ok bool
+		current, ok = node.mods.BlockChain().LocalGet(current.QuorumCert().BlockHash())
+		if !ok {
+			break
+		}
+		depth++
+	}
+	return depth
 }
 
-func (commandModule) Fork(block *consensus.Block) {}
+// commitObserver wraps a consensus.Rules implementation in order to record the block that caused a commit.
+type commitObserver struct {
+	consensus.Rules
+	node *node
+}
+
+// observeCommits wraps the rules such that the block that causes a commit is recorded by the node.
+func observeCommits(rules consensus.Rules, node *node) consensus.Rules {
+	co := commitObserver{Rules: rules, node: node}
+	if proposer, ok := rules.(consensus.ProposeRuler); ok {
+		return commitObserverProposer{co, proposer}
+	}
+	return co
+}
+
+// InitConsensusModule gives the module a reference to the Modules object.
+// It also allows the module to set module options using the OptionsBuilder.
+func (co commitObserver) InitConsensusModule(mods *consensus.Modules, opts *consensus.OptionsBuilder) {
+	if mod, ok := co.Rules.(consensus.Module); ok {
+		mod.InitConsensusModule(mods, opts)
+	}
+}
+
+// CommitRule decides whether any ancestor of the block can be committed.
+func (co commitObserver) CommitRule(block *consensus.Block) *consensus.Block {
+	committed := co.Rules.CommitRule(block)
+	if committed != nil {
+		co.node.commitTrigger = block
+	}
+	return committed
+}
+
+// commitObserverProposer is a commitObserver for rules that also implement consensus.ProposeRuler.
+type commitObserverProposer struct {
+	commitObserver
+	consensus.ProposeRuler
+}
+
+func (cm commandModule) Fork(block *consensus.Block) {
+	cm.queue.done(block.Command())
+}
@@ -1,9 +1,19 @@
 package twins
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
 	_ "github.com/relab/hotstuff/consensus/chainedhotstuff"
+	_ "github.com/relab/hotstuff/leaderrotation"
+	"github.com/relab/hotstuff/modules"
 )
 
 func TestBasicScenario(t *testing.T) {
@@ -26,7 +36,1482 @@ func TestBasicScenario(t *testing.T) {
 		t.Errorf("Expected no safety violations")
 	}
 
+	if !result.GenesisAgreed {
+		t.Errorf("Expected the nodes to agree on the genesis block")
+	}
+
+	if !result.ChainsValid {
+		t.Errorf("Expected no chain violations, got %v", result.ChainViolations)
+	}
+
 	if result.Commits != 1 {
 		t.Error("Expected one commit")
 	}
 }
+
+func TestQCParticipantCounts(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	quorum := NodeSet{1: {}, 2: {}, 3: {}}
+	for _, tc := range []struct {
+		name       string
+		partitions []NodeSet
+		min, max   int
+	}{
+		// the leader forms a QC as soon as it has a quorum of votes, but may have more
+		{name: "NoPartition", partitions: []NodeSet{allNodes}, min: 3, max: 4},
+		{name: "BareQuorum", partitions: []NodeSet{quorum, {4: {}}}, min: 3, max: 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var s Scenario
+			for i := 0; i < 8; i++ {
+				s = append(s, View{Leader: 1, Partitions: tc.partitions})
+			}
+			result, err := ExecuteScenario(s, 4, 0, 100, "chainedhotstuff")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.QCParticipantCounts) == 0 {
+				t.Fatal("expected participant counts for the committed blocks")
+			}
+			for _, blocks := range result.NodeCommits {
+				for _, block := range blocks {
+					count, ok := result.QCParticipantCounts[block.Hash()]
+					if block.QuorumCert().Signature() == nil {
+						if ok {
+							t.Errorf("block %.8s carries the genesis QC, but has a participant count of %d", block.Hash(), count)
+						}
+						continue
+					}
+					if count < tc.min || count > tc.max {
+						t.Errorf("the QC of block %.8s has %d participants, want between %d and %d", block.Hash(), count, tc.min, tc.max)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestLearner(t *testing.T) {
+	s := Scenario{}
+	allNodesSet := make(NodeSet)
+	for i := 1; i <= 5; i++ {
+		allNodesSet.Add(uint32(i))
+	}
+	for i := 0; i < 4; i++ {
+		s = append(s, View{Leader: 1, Partitions: []NodeSet{allNodesSet}})
+	}
+
+	learner := NodeID{ReplicaID: 5, NetworkID: 5}
+	result, err := ExecuteScenarioWithOptions(s, 5, 0, 100, "chainedhotstuff", ScenarioOptions{
+		Learners: []NodeID{learner},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.Safe {
+		t.Errorf("Expected no safety violations")
+	}
+
+	if result.Commits != 1 {
+		t.Errorf("Expected one commit, got %d", result.Commits)
+	}
+
+	if len(result.NodeCommits[learner]) != 1 {
+		t.Errorf("Expected the learner to execute one block, got %d", len(result.NodeCommits[learner]))
+	}
+
+	if strings.Contains(result.NetworkLog, "node r5n5 -> node r1n1: SEND consensus.VoteMsg") {
+		t.Error("Expected the learner not to vote")
+	}
+}
+
+func TestViewHistory(t *testing.T) {
+	s := Scenario{}
+	allNodesSet := make(NodeSet)
+	for i := 1; i <= 4; i++ {
+		allNodesSet.Add(uint32(i))
+	}
+	s = append(s, View{Leader: 1, Partitions: []NodeSet{allNodesSet}})
+	s = append(s, View{Leader: 1, Partitions: []NodeSet{allNodesSet}})
+	// isolate the leader so that the other nodes have to time out
+	for i := 0; i < 4; i++ {
+		s = append(s, View{Leader: 1, Partitions: []NodeSet{{1: {}}, {2: {}, 3: {}, 4: {}}}})
+	}
+
+	result, err := ExecuteScenario(s, 4, 0, 100, "chainedhotstuff")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for id, history := range result.NodeViewHistory {
+		if len(history) == 0 {
+			t.Fatalf("node %v: expected view transitions", id)
+		}
+		if history[0].View != 2 || history[0].Timeout {
+			t.Errorf("node %v: expected to enter view 2 after voting, got %+v", id, history[0])
+		}
+		sawTimeout := false
+		for i := 1; i < len(history); i++ {
+			if history[i].Tick < history[i-1].Tick {
+				t.Errorf("node %v: view transitions are out of order: %+v", id, history)
+			}
+			sawTimeout = sawTimeout || history[i].Timeout
+		}
+		if id.ReplicaID != 1 && !sawTimeout {
+			t.Errorf("node %v: expected at least one view transition due to timeout: %+v", id, history)
+		}
+	}
+}
+
+func TestFirstViewTimeout(t *testing.T) {
+	s := Scenario{}
+	// isolate the leader of the first view, such that it never proposes to the other nodes.
+	for i := 0; i < 4; i++ {
+		s = append(s, View{Leader: 1, Partitions: []NodeSet{{1: {}}, {2: {}, 3: {}, 4: {}}}})
+	}
+
+	result, err := ExecuteScenario(s, 4, 0, 100, "chainedhotstuff")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for id, history := range result.NodeViewHistory {
+		if id.ReplicaID == 1 {
+			continue
+		}
+		if len(history) == 0 || history[0].View != 2 || !history[0].Timeout {
+			t.Errorf("node %v: expected to enter view 2 after timing out, got %+v", id, history)
+		}
+	}
+}
+
+func TestDropViewSyncMessages(t *testing.T) {
+	s := Scenario{}
+	allNodesSet := make(NodeSet)
+	for i := 1; i <= 4; i++ {
+		allNodesSet.Add(uint32(i))
+	}
+	s = append(s, View{Leader: 1, Partitions: []NodeSet{allNodesSet}})
+	s = append(s, View{Leader: 1, Partitions: []NodeSet{allNodesSet}})
+	// isolate node 4 from the rest
+	for i := 0; i < 6; i++ {
+		s = append(s, View{
+			Leader:     hotstuff.ID(i%3 + 2),
+			Partitions: []NodeSet{{1: {}, 2: {}, 3: {}}, {4: {}}},
+		})
+	}
+
+	isolated := NodeID{ReplicaID: 4, NetworkID: 4}
+
+	lastView := func(dropTypes ...interface{}) consensus.View {
+		result, err := ExecuteScenarioWithOptions(s, 4, 0, 100, "chainedhotstuff", ScenarioOptions{DropTypes: dropTypes})
+		if err != nil {
+			t.Fatal(err)
+		}
+		history := result.NodeViewHistory[isolated]
+		if len(history) == 0 {
+			return 1
+		}
+		return history[len(history)-1].View
+	}
+
+	// when timeout and new view messages are permitted, the isolated node can still synchronize its view.
+	permitted := lastView(consensus.ProposeMsg{}, consensus.VoteMsg{})
+	if permitted <= 2 {
+		t.Errorf("expected the isolated node to synchronize beyond view 2, but it ended in view %d", permitted)
+	}
+
+	dropped := lastView(consensus.ProposeMsg{}, consensus.VoteMsg{}, consensus.NewViewMsg{}, consensus.TimeoutMsg{})
+	if dropped > 2 {
+		t.Errorf("expected the isolated node to be stuck in view 2, but it ended in view %d", dropped)
+	}
+}
+
+func TestCommitQCDepth(t *testing.T) {
+	s := Scenario{}
+	allNodesSet := make(NodeSet)
+	for i := 1; i <= 4; i++ {
+		allNodesSet.Add(uint32(i))
+	}
+	for i := 0; i < 6; i++ {
+		s = append(s, View{Leader: 1, Partitions: []NodeSet{allNodesSet}})
+	}
+
+	result, err := ExecuteScenario(s, 4, 0, 100, "chainedhotstuff")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules, _ := modules.GetModule[consensus.Rules]("chainedhotstuff")
+	want := rules.ChainLength()
+
+	if len(result.CommitQCDepth) == 0 {
+		t.Fatal("expected at least one commit")
+	}
+
+	for hash, depth := range result.CommitQCDepth {
+		if depth != want {
+			t.Errorf("block %.8s: got depth %d, want %d", hash, depth, want)
+		}
+	}
+}
+
+func TestStakeLiveness(t *testing.T) {
+	allNodesSet := make(NodeSet)
+	for i := 1; i <= 4; i++ {
+		allNodesSet.Add(uint32(i))
+	}
+	connected := Scenario{}
+	isolated := Scenario{}
+	for i := 0; i < 6; i++ {
+		connected = append(connected, View{Leader: 1, Partitions: []NodeSet{allNodesSet}})
+		isolated = append(isolated, View{Leader: 1, Partitions: []NodeSet{{1: {}}, {2: {}, 3: {}, 4: {}}}})
+	}
+
+	// replica 1 holds a quorum of the stake by itself
+	stake := map[hotstuff.ID]uint64{1: 10}
+
+	tests := []struct {
+		name     string
+		scenario Scenario
+		stake    map[hotstuff.ID]uint64
+		wantLive bool
+	}{
+		{"Connected", connected, nil, true},
+		{"ConnectedWithStake", connected, stake, true},
+		// without stake, the leader's partition does not have a quorum, so no progress is expected
+		{"Isolated", isolated, nil, true},
+		// with stake, the leader's partition should make progress, but the protocol does not account for stake.
+		{"IsolatedWithStake", isolated, stake, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := ExecuteScenarioWithOptions(test.scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{Stake: test.stake})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Live != test.wantLive {
+				t.Errorf("got live: %t, want: %t", result.Live, test.wantLive)
+			}
+		})
+	}
+}
+
+func TestExecuteScenarioErrors(t *testing.T) {
+	allNodesSet := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	valid := Scenario{{Leader: 1, Partitions: []NodeSet{allNodesSet}}}
+
+	t.Run("UnknownConsensus", func(t *testing.T) {
+		_, err := ExecuteScenario(valid, 4, 0, 10, "nonexistent")
+		if !errors.Is(err, ErrUnknownConsensus) {
+			t.Errorf("got: %v, want: %v", err, ErrUnknownConsensus)
+		}
+	})
+
+	t.Run("UnknownLeaderRotation", func(t *testing.T) {
+		_, err := ExecuteScenarioWithOptions(valid, 4, 0, 10, "chainedhotstuff", ScenarioOptions{LeaderRotation: "nonexistent"})
+		if !errors.Is(err, ErrUnknownLeaderRotation) {
+			t.Errorf("got: %v, want: %v", err, ErrUnknownLeaderRotation)
+		}
+	})
+
+	t.Run("InvalidLeader", func(t *testing.T) {
+		s := Scenario{{Leader: 5, Partitions: []NodeSet{allNodesSet}}}
+		_, err := ExecuteScenario(s, 4, 0, 10, "chainedhotstuff")
+		if !errors.Is(err, ErrInvalidScenario) {
+			t.Errorf("got: %v, want: %v", err, ErrInvalidScenario)
+		}
+	})
+
+	t.Run("InvalidPartition", func(t *testing.T) {
+		s := Scenario{{Leader: 1, Partitions: []NodeSet{{1: {}, 2: {}}, {2: {}, 3: {}, 4: {}}}}}
+		_, err := ExecuteScenario(s, 4, 0, 10, "chainedhotstuff")
+		if !errors.Is(err, ErrInvalidScenario) {
+			t.Errorf("got: %v, want: %v", err, ErrInvalidScenario)
+		}
+	})
+
+	t.Run("InvalidMetadata", func(t *testing.T) {
+		_, err := ExecuteScenarioWithOptions(valid, 4, 0, 10, "chainedhotstuff", ScenarioOptions{
+			Metadata: map[uint32]map[string]string{5: {"region": "eu-north"}},
+		})
+		if !errors.Is(err, ErrInvalidScenario) {
+			t.Errorf("got: %v, want: %v", err, ErrInvalidScenario)
+		}
+	})
+
+	t.Run("KeyGen", func(t *testing.T) {
+		defer func(f func() (consensus.PrivateKey, error)) { generateKey = f }(generateKey)
+		generateKey = func() (consensus.PrivateKey, error) {
+			return nil, errors.New("no entropy")
+		}
+		_, err := ExecuteScenario(valid, 4, 0, 10, "chainedhotstuff")
+		if !errors.Is(err, ErrKeyGen) {
+			t.Errorf("got: %v, want: %v", err, ErrKeyGen)
+		}
+	})
+}
+
+func TestDuplicateProposal(t *testing.T) {
+	proposal := consensus.ProposeMsg{
+		ID:    1,
+		Block: consensus.NewBlock(consensus.GetGenesis().Hash(), consensus.NewQuorumCert(nil, 0, consensus.GetGenesis().Hash()), "foo", 1, 1),
+	}
+	for _, policy := range []DuplicateProposalPolicy{IgnoreDuplicateProposals, LogDuplicateProposals, FailOnDuplicateProposals} {
+		network := NewPartitionedNetwork(nil)
+		network.duplicateProposals = policy
+		if _, err := network.GetNodeBuilder(NodeID{ReplicaID: 1, NetworkID: 1}, nil); err != nil {
+			t.Fatal(err)
+		}
+		cfg := &configuration{network: network, node: network.nodes[1]}
+
+		cfg.Propose(proposal)
+		if network.duplicateProposalErr != nil {
+			t.Fatalf("policy %d: unexpected error after first proposal: %v", policy, network.duplicateProposalErr)
+		}
+		cfg.Propose(proposal)
+
+		logged := strings.Contains(network.log.String(), "DUPLICATE PROPOSAL")
+		if logged != (policy != IgnoreDuplicateProposals) {
+			t.Errorf("policy %d: duplicate proposal logged: %v", policy, logged)
+		}
+		failed := errors.Is(network.duplicateProposalErr, ErrDuplicateProposal)
+		if failed != (policy == FailOnDuplicateProposals) {
+			t.Errorf("policy %d: got error %v", policy, network.duplicateProposalErr)
+		}
+	}
+}
+
+func TestDuplicateProposalScenario(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	scenario := Scenario{
+		{Leader: 1, Partitions: []NodeSet{allNodes}},
+		{Leader: 2, Partitions: []NodeSet{allNodes}},
+		{Leader: 3, Partitions: []NodeSet{allNodes}},
+		{Leader: 4, Partitions: []NodeSet{allNodes}},
+	}
+	_, err := ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{
+		DuplicateProposals: FailOnDuplicateProposals,
+	})
+	if err != nil {
+		t.Errorf("expected no duplicate proposals from chainedhotstuff: %v", err)
+	}
+}
+
+type cascadeEvent struct{}
+
+func TestEventCascade(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	scenario := Scenario{{Leader: 1, Partitions: []NodeSet{allNodes}}}
+	network := NewPartitionedNetwork(scenario, DefaultDropTypes()...)
+	nodes, _ := assignNodeIDs(4, 0)
+	if err := network.createTwinsNodes(nodes, scenario, "chainedhotstuff"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a module that re-adds its own event forever
+	el := network.nodes[1].mods.EventLoop()
+	el.RegisterHandler(cascadeEvent{}, func(_ interface{}) {
+		el.AddEvent(cascadeEvent{})
+	})
+	el.AddEvent(cascadeEvent{})
+
+	err := network.run(10)
+	if !errors.Is(err, ErrEventCascade) {
+		t.Errorf("expected %v, got %v", ErrEventCascade, err)
+	}
+}
+
+func TestExecuteScenarioCanceled(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	scenario := Scenario{{Leader: 1, Partitions: []NodeSet{allNodes}}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := ExecuteScenarioContext(ctx, scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestTwinAssignment(t *testing.T) {
+	// replica 3 is the leader of the first view, and its twins have network IDs 3 and 4.
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}, 5: {}}
+	scenario := Scenario{
+		{Leader: 3, Partitions: []NodeSet{allNodes}},
+		{Leader: 1, Partitions: []NodeSet{allNodes}},
+	}
+	result, err := ExecuteScenarioWithOptions(scenario, 4, 1, 50, "chainedhotstuff", ScenarioOptions{
+		Twins: []hotstuff.ID{3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var twins []NodeID
+	for id := range result.NodeLogs {
+		if id.ReplicaID == 3 {
+			twins = append(twins, id)
+		}
+	}
+	if len(twins) != 2 {
+		t.Errorf("expected two nodes with replica ID 3, got %v", twins)
+	}
+
+	_, err = ExecuteScenarioWithOptions(scenario, 4, 1, 50, "chainedhotstuff", ScenarioOptions{
+		Twins: []hotstuff.ID{3, 4},
+	})
+	if !errors.Is(err, ErrInvalidScenario) {
+		t.Errorf("expected %v, got %v", ErrInvalidScenario, err)
+	}
+}
+
+type latencyProbe struct{}
+
+func TestLatency(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	scenario := Scenario{{Leader: 1, Partitions: []NodeSet{allNodes}}}
+	network := NewPartitionedNetwork(scenario, DefaultDropTypes()...)
+	nodes, _ := assignNodeIDs(4, 0)
+	if err := network.createTwinsNodes(nodes, scenario, "chainedhotstuff"); err != nil {
+		t.Fatal(err)
+	}
+	network.latency = Latency{
+		Links: map[Link]int{{From: 1, To: 2}: 1},
+		Types: map[reflect.Type]int{reflect.TypeOf(latencyProbe{}): 2},
+	}
+
+	received := make(map[uint32]int)
+	for _, id := range []uint32{2, 3} {
+		id := id
+		network.nodes[id].mods.EventLoop().RegisterHandler(latencyProbe{}, func(_ interface{}) {
+			received[id] = network.currentTick
+		})
+	}
+
+	cfg := &configuration{network: network, node: network.nodes[1]}
+	cfg.sendMessage(2, latencyProbe{})
+	cfg.sendMessage(3, latencyProbe{})
+	for i := 0; i < 5; i++ {
+		if err := network.tick(); err != nil {
+			t.Fatal(err)
+		}
+		network.currentTick++
+	}
+
+	// the link and type latencies are added together.
+	want := map[uint32]int{2: 3, 3: 2}
+	for id, tick := range want {
+		if got, ok := received[id]; !ok || got != tick {
+			t.Errorf("node %d: received at tick %d (received: %v), want %d", id, got, ok, tick)
+		}
+	}
+}
+
+type fifoProbe struct{ from uint32 }
+
+func TestFIFOLinks(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	scenario := Scenario{{Leader: 1, Partitions: []NodeSet{allNodes}}}
+
+	for _, fifo := range []bool{false, true} {
+		network := NewPartitionedNetwork(scenario, DefaultDropTypes()...)
+		nodes, _ := assignNodeIDs(4, 0)
+		if err := network.createTwinsNodes(nodes, scenario, "chainedhotstuff"); err != nil {
+			t.Fatal(err)
+		}
+		network.latency = Latency{Types: map[reflect.Type]int{reflect.TypeOf(latencyProbe{}): 2}}
+		network.fifoLinks = fifo
+
+		var received []string
+		network.nodes[2].mods.EventLoop().RegisterHandler(latencyProbe{}, func(_ interface{}) {
+			received = append(received, "slow from 1")
+		})
+		network.nodes[2].mods.EventLoop().RegisterHandler(fifoProbe{}, func(event interface{}) {
+			received = append(received, fmt.Sprintf("fast from %d", event.(fifoProbe).from))
+		})
+
+		// node 1 sends a slow message followed by a fast one, while node 3 sends a fast message on another link.
+		cfg := &configuration{network: network, node: network.nodes[1]}
+		cfg.sendMessage(2, latencyProbe{})
+		cfg.sendMessage(2, fifoProbe{from: 1})
+		cfg = &configuration{network: network, node: network.nodes[3]}
+		cfg.sendMessage(2, fifoProbe{from: 3})
+		for i := 0; i < 5; i++ {
+			if err := network.tick(); err != nil {
+				t.Fatal(err)
+			}
+			network.currentTick++
+		}
+
+		// the fast message from node 3 is not held back by the slow message from node 1.
+		want := []string{"fast from 1", "fast from 3", "slow from 1"}
+		if fifo {
+			want = []string{"fast from 3", "slow from 1", "fast from 1"}
+		}
+		if !reflect.DeepEqual(received, want) {
+			t.Errorf("fifo=%v: got %v, want %v", fifo, received, want)
+		}
+	}
+}
+
+func TestRegionLatency(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	scenario := Scenario{{Leader: 1, Partitions: []NodeSet{allNodes}}}
+	network := NewPartitionedNetwork(scenario, DefaultDropTypes()...)
+	network.metadata = map[uint32]map[string]string{
+		1: {RegionKey: "eu"},
+		2: {RegionKey: "eu"},
+		3: {RegionKey: "us"},
+		4: {RegionKey: "us"},
+	}
+	nodes, _ := assignNodeIDs(4, 0)
+	if err := network.createTwinsNodes(nodes, scenario, "chainedhotstuff"); err != nil {
+		t.Fatal(err)
+	}
+	network.latency = Latency{
+		// only one direction is given, so the same delay is used in the other direction.
+		Regions: map[RegionLink]int{{From: "eu", To: "eu"}: 1, {From: "us", To: "eu"}: 4},
+	}
+
+	received := make(map[Link]int)
+	for _, link := range []Link{{From: 1, To: 2}, {From: 1, To: 3}, {From: 3, To: 4}} {
+		link := link
+		network.nodes[link.To].mods.EventLoop().RegisterHandler(latencyProbe{}, func(_ interface{}) {
+			received[link] = network.currentTick
+		})
+		cfg := &configuration{network: network, node: network.nodes[link.From]}
+		cfg.sendMessage(hotstuff.ID(link.To), latencyProbe{})
+	}
+	for i := 0; i < 6; i++ {
+		if err := network.tick(); err != nil {
+			t.Fatal(err)
+		}
+		network.currentTick++
+	}
+
+	want := map[Link]int{
+		{From: 1, To: 2}: 1, // within eu
+		{From: 1, To: 3}: 4, // from eu to us
+		{From: 3, To: 4}: 0, // within us, which has no delay
+	}
+	for link, tick := range want {
+		if got, ok := received[link]; !ok || got != tick {
+			t.Errorf("%v: received at tick %d (received: %v), want %d", link, got, ok, tick)
+		}
+	}
+	if received[Link{From: 1, To: 3}] <= received[Link{From: 1, To: 2}] {
+		t.Error("expected messages between regions to take longer than messages within a region")
+	}
+}
+
+func TestCommandStarvation(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	var scenario Scenario
+	for i := 0; i < 4; i++ {
+		scenario = append(scenario, View{Leader: 1, Partitions: []NodeSet{allNodes}})
+	}
+
+	result, err := ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Commits == 0 {
+		t.Fatal("expected commits with an unlimited command queue")
+	}
+
+	// the leader's only command stays in flight, because it can never be committed
+	// without further proposals from the leader.
+	result, err = ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{
+		CommandQueueCapacity: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Safe {
+		t.Error("expected no safety violations")
+	}
+	if result.Commits != 0 {
+		t.Errorf("got %d commits, want 0 when the leader's command queue is empty", result.Commits)
+	}
+}
+
+func TestStarvationPolicy(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	var scenario Scenario
+	for i := 0; i < 8; i++ {
+		scenario = append(scenario, View{Leader: 1, Partitions: []NodeSet{allNodes}})
+	}
+
+	run := func(policy StarvationPolicy) ScenarioResult {
+		t.Helper()
+		result, err := ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{
+			CommandQueueCapacity: 1,
+			StarvationPolicy:     policy,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Safe {
+			t.Errorf("policy %d: expected no safety violations", policy)
+		}
+		return result
+	}
+
+	result := run(StallOnStarvation)
+	if result.Live {
+		t.Error("expected a liveness violation when the leader stalls")
+	}
+	if n := result.NodeEmptyProposals[NodeID{ReplicaID: 1, NetworkID: 1}]; n != 0 {
+		t.Errorf("got %d empty proposals, want 0 when the leader stalls", n)
+	}
+
+	result = run(ProposeEmptyBlocks)
+	if !result.Live {
+		t.Error("expected the leader to keep the chain live by proposing empty blocks")
+	}
+	if result.NodeEmptyProposals[NodeID{ReplicaID: 1, NetworkID: 1}] == 0 {
+		t.Error("expected the leader to propose empty blocks")
+	}
+	// each command is committed once empty blocks are chained on top of it,
+	// which frees the slot for the leader's next command.
+	var commands, empty int
+	for _, block := range result.NodeCommits[NodeID{ReplicaID: 2, NetworkID: 2}] {
+		if block.Command() == "" {
+			empty++
+		} else {
+			commands++
+		}
+	}
+	if commands < 2 || empty == 0 {
+		t.Errorf("got %d blocks with commands and %d empty blocks, want at least 2 and 1", commands, empty)
+	}
+}
+
+func TestCommandArrivals(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	var scenario Scenario
+	// the initial proposal is made before any commands arrive, so the first view times out.
+	for i := 0; i < 6; i++ {
+		scenario = append(scenario, View{Leader: 1, Partitions: []NodeSet{allNodes}})
+	}
+
+	run := func(rate float64) ScenarioResult {
+		t.Helper()
+		result, err := ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{
+			CommandArrivalRate: rate,
+			ArrivalSeed:        1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Safe {
+			t.Errorf("rate %v: expected no safety violations", rate)
+		}
+		return result
+	}
+
+	// commands arrive at every tick, so the leader can always propose.
+	if result := run(10); result.Commits == 0 {
+		t.Error("expected commits when commands arrive frequently")
+	}
+	// no command arrives during the scenario, so the leader cannot propose.
+	if result := run(1e-9); result.Commits != 0 {
+		t.Errorf("got %d commits, want 0 when no commands arrive", result.Commits)
+	}
+
+	_, err := ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{CommandArrivalRate: -1})
+	if !errors.Is(err, ErrInvalidScenario) {
+		t.Errorf("got error %v, want %v for a negative arrival rate", err, ErrInvalidScenario)
+	}
+}
+
+func TestArrivalProcess(t *testing.T) {
+	for _, rate := range []float64{0.5, 3, 1200} {
+		a := newArrivalProcess(rate, 1)
+		const samples = 10000
+		total := 0
+		for i := 0; i < samples; i++ {
+			total += a.next()
+		}
+		if mean := float64(total) / samples; math.Abs(mean-rate) > 0.05*rate {
+			t.Errorf("rate %v: got mean %v arrivals per tick", rate, mean)
+		}
+	}
+}
+
+func TestDropReasons(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	split := []NodeSet{{1: {}, 2: {}}, {3: {}, 4: {}}}
+
+	// all nodes are connected, so messages are only dropped after the last view.
+	connected := Scenario{{Leader: 1, Partitions: []NodeSet{allNodes}}, {Leader: 1, Partitions: []NodeSet{allNodes}}}
+	result, err := ExecuteScenario(connected, 4, 0, 100, "chainedhotstuff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Drops[DropPartition] != 0 || result.PartitionCrossings != 0 {
+		t.Errorf("got %d partition drops and %d crossings without partitions", result.Drops[DropPartition], result.PartitionCrossings)
+	}
+	if result.Drops[DropPostScenario] == 0 {
+		t.Error("expected messages to be dropped after the last view")
+	}
+
+	partitioned := Scenario{{Leader: 1, Partitions: split}, {Leader: 1, Partitions: split}}
+	result, err = ExecuteScenario(partitioned, 4, 0, 100, "chainedhotstuff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Drops[DropPartition] == 0 {
+		t.Error("expected messages to be dropped by the partition")
+	}
+	if !strings.Contains(result.NetworkLog, "DROP") || !strings.Contains(result.NetworkLog, "(partition)") {
+		t.Error("expected the network log to contain the drop reason")
+	}
+
+	// proposals are not dropped, so they cross the partition.
+	result, err = ExecuteScenarioWithOptions(partitioned, 4, 0, 100, "chainedhotstuff", ScenarioOptions{
+		DropTypes: []interface{}{consensus.VoteMsg{}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.PartitionCrossings == 0 {
+		t.Error("expected proposals to cross the partition")
+	}
+}
+
+func TestFragmentedProposals(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	var scenario Scenario
+	for i := 0; i < 8; i++ {
+		scenario = append(scenario, View{Leader: 1, Partitions: []NodeSet{allNodes}})
+	}
+
+	result, err := ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{FragmentSize: 128})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Safe {
+		t.Error("expected no safety violations")
+	}
+	if result.Commits == 0 {
+		t.Error("expected commits when all fragments are delivered")
+	}
+	if !strings.Contains(result.NetworkLog, "REASSEMBLED") {
+		t.Error("expected the network log to contain reassembled proposals")
+	}
+
+	// the leader times out long before all fragments have been sent,
+	// and the remaining fragments are dropped because the leader is past the last view of the scenario.
+	result, err = ExecuteScenarioWithOptions(scenario[:1], 4, 0, 100, "chainedhotstuff", ScenarioOptions{FragmentSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.PartialMessages == 0 {
+		t.Error("expected proposals to be received only in part")
+	}
+	if result.Commits != 0 {
+		t.Errorf("got %d commits, want 0 when proposals are never reassembled", result.Commits)
+	}
+
+	_, err = ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{FragmentSize: -1})
+	if !errors.Is(err, ErrInvalidScenario) {
+		t.Errorf("got error %v, want %v for a negative fragment size", err, ErrInvalidScenario)
+	}
+}
+
+func TestDynamicLeaderRotation(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	var scenario Scenario
+	for i := 0; i < 12; i++ {
+		// the leader is chosen by the rotation module.
+		scenario = append(scenario, View{Partitions: []NodeSet{allNodes}})
+	}
+
+	result, err := ExecuteScenarioWithOptions(scenario, 4, 0, 200, "chainedhotstuff", ScenarioOptions{
+		LeaderRotation: "round-robin",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Safe {
+		t.Error("expected no safety violations")
+	}
+	if result.Commits == 0 {
+		t.Error("expected commits with round-robin leaders")
+	}
+	for i := range scenario {
+		view := consensus.View(i + 1)
+		if got, want := result.Leaders[view], hotstuff.ID(view%4+1); got != want {
+			t.Errorf("view %d: got leader %d, want %d", view, got, want)
+		}
+	}
+
+	for _, chainLength := range []int{2, 3, 4} {
+		result, err := ExecuteScenarioWithOptions(scenario, 4, 0, 200, "chainedhotstuff", ScenarioOptions{
+			LeaderRotation: "carousel",
+			ChainLength:    chainLength,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Safe {
+			t.Errorf("chain length %d: expected no safety violations", chainLength)
+		}
+		if !result.Live || result.Commits == 0 {
+			t.Errorf("chain length %d: expected commits with carousel leaders", chainLength)
+		}
+		// carousel falls back to round-robin unless the block committed last is exactly chainLength views old,
+		// so some leader must differ from round-robin once blocks are committed in every view.
+		carousel := false
+		for view, leader := range result.Leaders {
+			if leader != hotstuff.ID(view%4+1) {
+				carousel = true
+			}
+		}
+		if !carousel {
+			t.Errorf("chain length %d: expected carousel to choose a leader other than the round-robin leader", chainLength)
+		}
+	}
+
+	_, err = ExecuteScenarioWithOptions(scenario, 4, 0, 200, "chainedhotstuff", ScenarioOptions{ChainLength: -1})
+	if !errors.Is(err, ErrInvalidScenario) {
+		t.Errorf("got error %v, want %v for a negative chain length", err, ErrInvalidScenario)
+	}
+}
+
+func TestClockRates(t *testing.T) {
+	// node 4 leads every view, but is cut off from the others, so the views only end by timing out.
+	var scenario Scenario
+	for i := 0; i < 30; i++ {
+		scenario = append(scenario, View{Leader: 4, Partitions: []NodeSet{{1: {}, 2: {}, 3: {}}, {4: {}}}})
+	}
+	slow := NodeID{ReplicaID: 4, NetworkID: 4}
+	peer := NodeID{ReplicaID: 1, NetworkID: 1}
+
+	lastView := func(history []ViewTransition) consensus.View {
+		if len(history) == 0 {
+			return 1
+		}
+		return history[len(history)-1].View
+	}
+
+	normal, err := ExecuteScenario(scenario, 4, 0, 100, "chainedhotstuff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	skewed, err := ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{
+		ClockRates: map[uint32]float64{slow.NetworkID: 0.5},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := skewed.NodeTimeouts[slow], normal.NodeTimeouts[slow]/2; got != want {
+		t.Errorf("got %d timeouts at half rate, want %d (half of %d)", got, want, normal.NodeTimeouts[slow])
+	}
+	if skewed.NodeTimeouts[slow] >= skewed.NodeTimeouts[peer] {
+		t.Errorf("slow node timed out %d times, want fewer than its peer's %d", skewed.NodeTimeouts[slow], skewed.NodeTimeouts[peer])
+	}
+	if skewed.NodeTimeouts[peer] != normal.NodeTimeouts[peer] {
+		t.Errorf("peer timed out %d times, want %d regardless of the slow node's clock", skewed.NodeTimeouts[peer], normal.NodeTimeouts[peer])
+	}
+	if slowView, peerView := lastView(skewed.NodeViewHistory[slow]), lastView(skewed.NodeViewHistory[peer]); slowView >= peerView {
+		t.Errorf("slow node is in view %d, want it behind its peer in view %d", slowView, peerView)
+	}
+
+	for _, rate := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		_, err = ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{
+			ClockRates: map[uint32]float64{slow.NetworkID: rate},
+		})
+		if !errors.Is(err, ErrInvalidScenario) {
+			t.Errorf("got error %v, want %v for clock rate %v", err, ErrInvalidScenario, rate)
+		}
+	}
+	_, err = ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{
+		ClockRates: map[uint32]float64{5: 1},
+	})
+	if !errors.Is(err, ErrInvalidScenario) {
+		t.Errorf("got error %v, want %v for the clock rate of a nonexistent node", err, ErrInvalidScenario)
+	}
+}
+
+func TestProposalDelays(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	scenario := Scenario{
+		{Leader: 1, Partitions: []NodeSet{allNodes}},
+		{Leader: 2, Partitions: []NodeSet{allNodes}},
+		{Leader: 3, Partitions: []NodeSet{allNodes}},
+		{Leader: 4, Partitions: []NodeSet{allNodes}},
+		{Leader: 1, Partitions: []NodeSet{allNodes}},
+	}
+	replica := NodeID{ReplicaID: 2, NetworkID: 2}
+
+	run := func(delay int) ScenarioResult {
+		t.Helper()
+		result, err := ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{
+			ProposalDelays: map[uint32]int{1: delay},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Safe {
+			t.Errorf("delay %d: expected no safety violations", delay)
+		}
+		return result
+	}
+
+	// the proposal of view 1 arrives in the last tick before the view times out.
+	result := run(3)
+	if history := result.NodeViewHistory[replica]; len(history) == 0 || history[0].Timeout {
+		t.Errorf("got view history %v, want view 2 to be entered without a timeout", history)
+	}
+	if blocks := result.NodeCommits[replica]; len(blocks) == 0 || blocks[0].View() != 1 || blocks[0].Proposer() != 1 {
+		t.Error("expected the slow leader's proposal in view 1 to be committed")
+	}
+
+	// the proposal of view 1 arrives too late.
+	result = run(4)
+	if history := result.NodeViewHistory[replica]; len(history) == 0 || !history[0].Timeout {
+		t.Errorf("got view history %v, want view 1 to time out", history)
+	}
+
+	for _, delays := range []map[uint32]int{{1: -1}, {5: 1}} {
+		_, err := ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{ProposalDelays: delays})
+		if !errors.Is(err, ErrInvalidScenario) {
+			t.Errorf("got error %v, want %v for proposal delays %v", err, ErrInvalidScenario, delays)
+		}
+	}
+}
+
+func TestBlockCacheSizes(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	var scenario Scenario
+	for i := 0; i < 10; i++ {
+		scenario = append(scenario, View{Leader: hotstuff.ID(i%4 + 1), Partitions: []NodeSet{allNodes}})
+	}
+	small := NodeID{ReplicaID: 4, NetworkID: 4}
+
+	result, err := ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{
+		BlockCacheSizes: map[uint32]int{small.NetworkID: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Safe {
+		t.Error("expected no safety violations")
+	}
+	// the node evicts the blocks that it needs in order to vote and commit, and must fetch them from its peers.
+	if result.NodeFetches[small] == 0 {
+		t.Error("expected the node with a small block cache to fetch evicted blocks")
+	}
+	for id, fetches := range result.NodeFetches {
+		if id != small && fetches != 0 {
+			t.Errorf("node %v fetched %d blocks, want 0 without a cache limit", id, fetches)
+		}
+	}
+	if len(result.NodeCommits[small]) == 0 {
+		t.Error("expected the node with a small block cache to commit")
+	}
+
+	_, err = ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{
+		BlockCacheSizes: map[uint32]int{small.NetworkID: -1},
+	})
+	if !errors.Is(err, ErrInvalidScenario) {
+		t.Errorf("got error %v, want %v for a negative block cache size", err, ErrInvalidScenario)
+	}
+}
+
+func TestMaxBlockSizes(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	var scenario Scenario
+	for i := 0; i < 10; i++ {
+		scenario = append(scenario, View{Leader: hotstuff.ID(i%4 + 1), Partitions: []NodeSet{allNodes}})
+	}
+	strict := NodeID{ReplicaID: 4, NetworkID: 4}
+
+	result, err := ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{
+		MaxBlockSizes: map[uint32]int{strict.NetworkID: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Safe {
+		t.Error("expected no safety violations")
+	}
+	if result.NodeOversizedProposals[strict] == 0 {
+		t.Error("expected the node with a maximum block size to reject proposals")
+	}
+	for id, rejected := range result.NodeOversizedProposals {
+		if id != strict && rejected != 0 {
+			t.Errorf("node %v rejected %d proposals, want 0 without a maximum block size", id, rejected)
+		}
+	}
+	// the remaining nodes form a quorum without the node that rejects proposals.
+	for id, commits := range result.NodeCommits {
+		if id != strict && len(commits) == 0 {
+			t.Errorf("expected node %v to commit", id)
+		}
+	}
+
+	// a limit of zero would reject every proposal, so only positive limits are valid.
+	for _, limit := range []int{-1, 0} {
+		_, err = ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{
+			MaxBlockSizes: map[uint32]int{strict.NetworkID: limit},
+		})
+		if !errors.Is(err, ErrInvalidScenario) {
+			t.Errorf("got error %v, want %v for a maximum block size of %d", err, ErrInvalidScenario, limit)
+		}
+	}
+}
+
+func TestDebugInjectCommitMismatch(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	var scenario Scenario
+	for i := 0; i < 10; i++ {
+		scenario = append(scenario, View{Leader: hotstuff.ID(i%4 + 1), Partitions: []NodeSet{allNodes}})
+	}
+	forger := NodeID{ReplicaID: 3, NetworkID: 3}
+	const position = 1
+
+	result, err := ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{
+		DebugInjectCommitMismatch: &CommitMismatch{NetworkID: forger.NetworkID, Position: position},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Safe {
+		t.Fatal("expected the forged block to be reported as a safety violation")
+	}
+	if result.Commits != position {
+		t.Errorf("got divergence at position %d, want %d", result.Commits, position)
+	}
+	// the forger must execute the forged block and the block after it.
+	if got := len(result.NodeCommits[forger]); got <= position+1 {
+		t.Fatalf("the forger executed %d blocks, want more than %d", got, position+1)
+	}
+	forged := result.NodeCommits[forger][position]
+	if !strings.HasSuffix(string(forged.Command()), "-forged") {
+		t.Errorf("got block %v at position %d, want a forged block", forged, position)
+	}
+	if detail := result.divergence(); !strings.Contains(detail, describeBlock(forged)) {
+		t.Errorf("expected the divergence to describe the forged block, got %q", detail)
+	}
+	// the block that the forger executes after the forged block extends the original block instead.
+	want := []ChainViolation{{
+		Node:     forger,
+		Position: position + 1,
+		Parent:   result.NodeCommits[forger][position+1].Parent(),
+		Previous: forged.Hash(),
+	}}
+	if result.ChainsValid || !reflect.DeepEqual(result.ChainViolations, want) {
+		t.Errorf("got chain violations %v, want %v", result.ChainViolations, want)
+	}
+
+	for _, m := range []CommitMismatch{{NetworkID: 5}, {NetworkID: 1, Position: -1}} {
+		_, err = ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{DebugInjectCommitMismatch: &m})
+		if !errors.Is(err, ErrInvalidScenario) {
+			t.Errorf("got error %v, want %v for commit mismatch %+v", err, ErrInvalidScenario, m)
+		}
+	}
+	// the commits of twinned replicas are not compared, so the forged block would not be detected.
+	_, err = ExecuteScenarioWithOptions(scenario, 4, 1, 100, "chainedhotstuff", ScenarioOptions{
+		DebugInjectCommitMismatch: &CommitMismatch{NetworkID: 1},
+	})
+	if !errors.Is(err, ErrInvalidScenario) {
+		t.Errorf("got error %v, want %v for a commit mismatch at a twin", err, ErrInvalidScenario)
+	}
+}
+
+func TestAbortOnSafetyViolation(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	var scenario Scenario
+	for i := 0; i < 100; i++ {
+		scenario = append(scenario, View{Leader: hotstuff.ID(i%4 + 1), Partitions: []NodeSet{allNodes}})
+	}
+	const numTicks = 1000
+	opts := ScenarioOptions{
+		DebugInjectCommitMismatch: &CommitMismatch{NetworkID: 2, Position: 0},
+		AbortOnSafetyViolation:    true,
+	}
+
+	result, err := ExecuteScenarioWithOptions(scenario, 4, 0, numTicks, "chainedhotstuff", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Safe {
+		t.Error("expected a safety violation")
+	}
+	if result.Commits != 0 {
+		t.Errorf("got divergence at position %d, want 0", result.Commits)
+	}
+	// the replicas commit their first block within a few views.
+	if result.Ticks >= numTicks/10 {
+		t.Errorf("scenario ran for %d ticks, want it to abort well before %d ticks", result.Ticks, numTicks)
+	}
+
+	opts.AbortOnSafetyViolation = false
+	result, err = ExecuteScenarioWithOptions(scenario, 4, 0, numTicks, "chainedhotstuff", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Safe || result.Ticks != numTicks {
+		t.Errorf("got safe=%v after %d ticks, want an unsafe result after %d ticks", result.Safe, result.Ticks, numTicks)
+	}
+}
+
+func TestDebugInjectGenesisMismatch(t *testing.T) {
+	scenario, err := NewCleanScenario(4, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forger := NodeID{ReplicaID: 3, NetworkID: 3}
+
+	result, err := ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{
+		DebugInjectGenesisMismatch: forger.NetworkID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Safe || result.GenesisAgreed {
+		t.Fatalf("got safe=%v, genesisAgreed=%v, want a genesis disagreement", result.Safe, result.GenesisAgreed)
+	}
+	if result.Commits != 0 {
+		t.Errorf("got %d commits, want none to be compared", result.Commits)
+	}
+	if got := result.NodeInitialBlocks[forger]; got.Hash() == consensus.GetGenesis().Hash() {
+		t.Errorf("expected node %v to start from a forged block", forger)
+	}
+	detail := result.divergence()
+	if !strings.HasPrefix(detail, "nodes disagree on the genesis block") || !strings.Contains(detail, "genesis-forged") {
+		t.Errorf("expected the divergence to describe the forged initial block, got %q", detail)
+	}
+
+	// the forged block is the committed block of the node, and is stored in its blockchain.
+	network := NewPartitionedNetwork(scenario, DefaultDropTypes()...)
+	network.genesisMismatch = forger.NetworkID
+	nodes, _ := assignNodeIDs(4, 0)
+	if err := network.createTwinsNodes(nodes, scenario, "chainedhotstuff"); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range nodes {
+		mods := network.nodes[id.NetworkID].mods
+		committed := mods.Consensus().CommittedBlock()
+		if forged := id == forger; forged != (committed.Hash() != consensus.GetGenesis().Hash()) {
+			t.Errorf("node %v: got committed block %v, want forged: %v", id, committed, forged)
+		}
+		if _, ok := mods.BlockChain().LocalGet(committed.Hash()); !ok {
+			t.Errorf("node %v: the committed block %v is not in the blockchain", id, committed)
+		}
+	}
+
+	// a divergence after the genesis block is reported as such.
+	result, err = ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{
+		DebugInjectCommitMismatch: &CommitMismatch{NetworkID: forger.NetworkID, Position: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Safe || !result.GenesisAgreed {
+		t.Fatalf("got safe=%v, genesisAgreed=%v, want a divergence after the genesis block", result.Safe, result.GenesisAgreed)
+	}
+	if detail := result.divergence(); !strings.HasPrefix(detail, "replicas diverge at position 1") {
+		t.Errorf("expected the divergence to be at position 1, got %q", detail)
+	}
+
+	_, err = ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{DebugInjectGenesisMismatch: 5})
+	if !errors.Is(err, ErrInvalidScenario) {
+		t.Errorf("got error %v for a genesis mismatch at a node that does not exist, want ErrInvalidScenario", err)
+	}
+}
+
+func TestFaultModel(t *testing.T) {
+	// with seven replicas, the standard fault model tolerates two faults, but n = 5f+1 only tolerates one.
+	majority := NodeSet{1: {}, 2: {}, 3: {}, 4: {}, 5: {}}
+	minority := NodeSet{6: {}, 7: {}}
+	var scenario Scenario
+	for i := 0; i < 10; i++ {
+		scenario = append(scenario, View{Leader: hotstuff.ID(i%5 + 1), Partitions: []NodeSet{majority, minority}})
+	}
+
+	result, err := ExecuteScenario(scenario, 7, 0, 100, "chainedhotstuff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Commits == 0 || !result.Live {
+		t.Errorf("got %d commits (live: %v), want the partition of five replicas to commit", result.Commits, result.Live)
+	}
+
+	result, err = ExecuteScenarioWithOptions(scenario, 7, 0, 100, "chainedhotstuff", ScenarioOptions{
+		FaultModel: FaultModel{Resilience: 5},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Commits != 0 {
+		t.Errorf("got %d commits, want none without a quorum of six replicas", result.Commits)
+	}
+	// the partition of five replicas is not expected to make progress.
+	if !result.Live || !result.Safe {
+		t.Errorf("got live: %v, safe: %v, want a live and safe result", result.Live, result.Safe)
+	}
+
+	_, err = ExecuteScenarioWithOptions(scenario, 7, 0, 100, "chainedhotstuff", ScenarioOptions{
+		FaultModel: FaultModel{Resilience: 1},
+	})
+	if !errors.Is(err, ErrInvalidScenario) {
+		t.Errorf("got error %v, want %v for a resilience of 1", err, ErrInvalidScenario)
+	}
+}
+
+func TestNodeEventStats(t *testing.T) {
+	// node 4 is isolated for the whole scenario.
+	partitions := []NodeSet{{1: {}, 2: {}, 3: {}}, {4: {}}}
+	var scenario Scenario
+	for i := 0; i < 10; i++ {
+		scenario = append(scenario, View{Leader: hotstuff.ID(i%3 + 1), Partitions: partitions})
+	}
+	connected := NodeID{ReplicaID: 1, NetworkID: 1}
+	isolated := NodeID{ReplicaID: 4, NetworkID: 4}
+
+	result, err := ExecuteScenario(scenario, 4, 0, 100, "chainedhotstuff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, stats := range result.NodeEventStats {
+		if stats.Processed == 0 || stats.PeakDepth == 0 {
+			t.Errorf("node %v: got %+v, want processed events and a non-zero peak depth", id, stats)
+		}
+	}
+	if got, other := result.NodeEventStats[isolated].Processed, result.NodeEventStats[connected].Processed; got >= other {
+		t.Errorf("isolated node processed %d events, want fewer than the %d processed by a connected node", got, other)
+	}
+}
+
+func TestAsyncVoteVerification(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	var scenario Scenario
+	for i := 0; i < 10; i++ {
+		scenario = append(scenario, View{Leader: hotstuff.ID(i%4 + 1), Partitions: []NodeSet{allNodes}})
+	}
+
+	for _, async := range []bool{false, true} {
+		result, err := ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{
+			AsyncVoteVerification: async,
+		})
+		if err != nil {
+			t.Fatalf("async: %v: %v", async, err)
+		}
+		if !result.Safe {
+			t.Errorf("async: %v: expected no safety violations", async)
+		}
+		// with asynchronous verification, a quorum certificate is only formed once the verifying goroutines
+		// have finished, which may be after the leader's event loop has run out of events for the tick.
+		// The views then last longer, or time out, and the number of commits varies between executions.
+		if !async && result.Commits == 0 {
+			t.Error("expected commits with synchronous vote verification")
+		}
+		t.Logf("async: %v: %d commits, %d timeouts", async, result.Commits, result.NodeTimeouts[NodeID{ReplicaID: 1, NetworkID: 1}])
+	}
+}
+
+func TestTimeoutCerts(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	// the leader of the first view is isolated, so the first view times out.
+	// The timeouts are sent in the second view, where node 1 is still isolated.
+	split := []NodeSet{{1: {}}, {2: {}, 3: {}, 4: {}}}
+	scenario := Scenario{
+		{Leader: 1, Partitions: split},
+		{Leader: 2, Partitions: split},
+		{Leader: 3, Partitions: []NodeSet{allNodes}},
+		{Leader: 4, Partitions: []NodeSet{allNodes}},
+	}
+	result, err := ExecuteScenario(scenario, 4, 0, 20, "chainedhotstuff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.TimeoutCertsValid {
+		t.Error("expected all timeout certificates to be valid")
+	}
+
+	isolated := NodeID{ReplicaID: 1, NetworkID: 1}
+	for id, records := range result.NodeTimeoutCerts {
+		var formed bool
+		for _, record := range records {
+			if record.View != 1 {
+				continue
+			}
+			formed = true
+			if !record.Valid || !reflect.DeepEqual(record.Signers, []hotstuff.ID{2, 3, 4}) {
+				t.Errorf("node %v: got timeout certificate %+v, want a valid certificate signed by 2, 3, and 4", id, record)
+			}
+		}
+		// the isolated node only receives its own timeout, which is not a quorum.
+		if formed == (id == isolated) {
+			t.Errorf("node %v: formed a timeout certificate for view 1: %v", id, formed)
+		}
+	}
+}
+
+func TestHeldMessages(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	split := []NodeSet{{1: {}, 2: {}, 3: {}}, {4: {}}}
+	var scenario Scenario
+	for i := 0; i < 4; i++ {
+		scenario = append(scenario, View{Leader: 1, Partitions: split})
+	}
+	for i := 0; i < 4; i++ {
+		scenario = append(scenario, View{Leader: 1, Partitions: []NodeSet{allNodes}})
+	}
+
+	dropped, err := ExecuteScenario(scenario, 4, 0, 100, "chainedhotstuff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dropped.Drops[DropPartition] == 0 {
+		t.Fatal("expected messages to be dropped between partitions")
+	}
+
+	held, err := ExecuteScenarioWithOptions(scenario, 4, 0, 100, "chainedhotstuff", ScenarioOptions{
+		HoldTypes: []interface{}{consensus.ProposeMsg{}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !held.Safe {
+		t.Error("expected no safety violations")
+	}
+	if held.Drops[DropPartition] >= dropped.Drops[DropPartition] {
+		t.Errorf("got %d messages dropped between partitions, want fewer than %d when proposals are held",
+			held.Drops[DropPartition], dropped.Drops[DropPartition])
+	}
+	if !strings.Contains(held.NetworkLog, "RELEASE consensus.ProposeMsg") {
+		t.Error("expected the held proposals to be released when the partition heals")
+	}
+	if held.HeldMessages != 0 {
+		t.Errorf("got %d messages held at the end of the scenario, want 0 after the partition healed", held.HeldMessages)
+	}
+
+	// the partition never heals, so the proposals sent to node 4 are never released.
+	held, err = ExecuteScenarioWithOptions(scenario[:4], 4, 0, 100, "chainedhotstuff", ScenarioOptions{
+		HoldTypes: []interface{}{consensus.ProposeMsg{}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if held.HeldMessages == 0 {
+		t.Error("expected messages to remain held when the partition never heals")
+	}
+	if strings.Contains(held.NetworkLog, "RELEASE") {
+		t.Error("expected no messages to be released when the partition never heals")
+	}
+}
+
+func TestMaxMessages(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}}
+	var scenario Scenario
+	for i := 0; i < 100; i++ {
+		scenario = append(scenario, View{Leader: hotstuff.ID(i%4 + 1), Partitions: []NodeSet{allNodes}})
+	}
+	const (
+		numTicks    = 1000
+		maxMessages = 10
+	)
+
+	result, err := ExecuteScenarioWithOptions(scenario, 4, 0, numTicks, "chainedhotstuff", ScenarioOptions{MaxMessages: maxMessages})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.BudgetExceeded {
+		t.Error("expected the message budget to be exceeded")
+	}
+	if result.Ticks >= numTicks {
+		t.Errorf("scenario ran for %d ticks, want it to abort before %d ticks", result.Ticks, numTicks)
+	}
+	if sent := result.CountEvents(func(e MessageEvent) bool {
+		return e.Action == MessageSent || e.Action == MessageReleased
+	}); sent != maxMessages {
+		t.Errorf("got %d messages sent, want %d", sent, maxMessages)
+	}
+	if result.Drops[DropBudget] == 0 {
+		t.Error("expected messages to be dropped because of the budget")
+	}
+
+	result, err = ExecuteScenarioWithOptions(scenario, 4, 0, numTicks, "chainedhotstuff", ScenarioOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.BudgetExceeded || result.Ticks != numTicks {
+		t.Errorf("got budget exceeded=%v after %d ticks without a budget, want %d ticks", result.BudgetExceeded, result.Ticks, numTicks)
+	}
+
+	_, err = ExecuteScenarioWithOptions(scenario, 4, 0, numTicks, "chainedhotstuff", ScenarioOptions{MaxMessages: -1})
+	if !errors.Is(err, ErrInvalidScenario) {
+		t.Errorf("got error %v, want %v for a negative message budget", err, ErrInvalidScenario)
+	}
+}
+
+func TestReachabilityMatrix(t *testing.T) {
+	nodes := []uint32{1, 2, 3, 4}
+	view := View{Leader: 1, Partitions: []NodeSet{{1: {}, 3: {}}, {2: {}, 4: {}}}}
+
+	matrix := view.ReachabilityMatrix(nodes)
+	for i := range nodes {
+		for j := range nodes {
+			if matrix[i][j] != matrix[j][i] {
+				t.Errorf("node %d reaches node %d: %v, but node %d reaches node %d: %v",
+					nodes[i], nodes[j], matrix[i][j], nodes[j], nodes[i], matrix[j][i])
+			}
+		}
+	}
+
+	// the 2-2 split forms the two components {1, 3} and {2, 4}.
+	want := [][]bool{
+		{true, false, true, false},
+		{false, true, false, true},
+		{true, false, true, false},
+		{false, true, false, true},
+	}
+	if !reflect.DeepEqual(matrix, want) {
+		t.Errorf("got %v, want %v", matrix, want)
+	}
+}
+
+func TestNewCleanScenario(t *testing.T) {
+	const numNodes, numViews = 4, 10
+	scenario, err := NewCleanScenario(numNodes, numViews)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scenario) != numViews {
+		t.Fatalf("got %d views, want %d", len(scenario), numViews)
+	}
+	for i, view := range scenario {
+		if want := hotstuff.ID(i%numNodes + 1); view.Leader != want {
+			t.Errorf("view %d: got leader %d, want %d", i+1, view.Leader, want)
+		}
+		if len(view.Partitions) != 1 || len(view.Partitions[0]) != numNodes {
+			t.Errorf("view %d: got partitions %v, want a single partition with all nodes", i+1, view.Partitions)
+		}
+	}
+
+	result, err := ExecuteScenario(scenario, numNodes, 0, 100, "chainedhotstuff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Safe || result.Commits == 0 {
+		t.Errorf("got safe=%v with %d commits, want a safe result with commits", result.Safe, result.Commits)
+	}
+}
+
+func TestNewCleanScenarioInvalid(t *testing.T) {
+	for _, args := range []struct {
+		numNodes uint8
+		numViews int
+	}{{0, 10}, {0, 0}, {4, -1}} {
+		if _, err := NewCleanScenario(args.numNodes, args.numViews); !errors.Is(err, ErrInvalidScenario) {
+			t.Errorf("%d nodes and %d views: got error %v, want %v", args.numNodes, args.numViews, err, ErrInvalidScenario)
+		}
+	}
+}
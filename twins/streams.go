@@ -0,0 +1,61 @@
+package twins
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/relab/hotstuff/consensus"
+)
+
+// commandStreams generates the commands of the nodes from one or more independent streams.
+// With a single stream, the commands are numbered sequentially. With multiple streams,
+// each stream has its own generator, and its commands are prefixed by the stream's name.
+type commandStreams []*commandGenerator
+
+func newCommandStreams(streams int) commandStreams {
+	if streams < 1 {
+		streams = 1
+	}
+	s := make(commandStreams, streams)
+	for i := range s {
+		s[i] = &commandGenerator{}
+	}
+	return s
+}
+
+// next returns the next command of the stream that is selected for the view.
+// The streams take turns in round-robin order of the views.
+func (s commandStreams) next(view consensus.View) consensus.Command {
+	if len(s) == 1 {
+		return s[0].next()
+	}
+	i := int(view % consensus.View(len(s)))
+	return consensus.Command(StreamName(i)+"/") + s[i].next()
+}
+
+// StreamName returns the name of the command stream with the given index.
+func StreamName(i int) string {
+	return "s" + strconv.Itoa(i)
+}
+
+// CommandStream returns the name of the stream that the command was taken from,
+// or false if the command does not belong to a named stream.
+func CommandStream(cmd consensus.Command) (stream string, ok bool) {
+	stream, _, ok = strings.Cut(string(cmd), "/")
+	return stream, ok
+}
+
+// getStreamCommits returns the commands executed by each node, by stream, in the order they were executed.
+func getStreamCommits(network *Network) map[NodeID]map[string][]consensus.Command {
+	m := make(map[NodeID]map[string][]consensus.Command)
+	for _, node := range network.nodes {
+		streams := make(map[string][]consensus.Command)
+		for _, block := range node.executedBlocks {
+			if stream, ok := CommandStream(block.Command()); ok {
+				streams[stream] = append(streams[stream], block.Command())
+			}
+		}
+		m[node.id] = streams
+	}
+	return m
+}
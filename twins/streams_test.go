@@ -0,0 +1,83 @@
+package twins
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	_ "github.com/relab/hotstuff/consensus/chainedhotstuff"
+)
+
+func TestStreams(t *testing.T) {
+	const streams = 3
+	scenario, err := NewCleanScenario(4, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ExecuteScenarioWithOptions(scenario, 4, 0, 200, "chainedhotstuff", ScenarioOptions{Streams: streams})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Safe {
+		t.Fatal("expected a safe result")
+	}
+	if len(result.StreamCommits) != 4 {
+		t.Fatalf("got stream commits of %d nodes, want 4", len(result.StreamCommits))
+	}
+
+	for id, blocks := range result.NodeCommits {
+		byStream := result.StreamCommits[id]
+		total := 0
+		for i := 0; i < streams; i++ {
+			cmds := byStream[StreamName(i)]
+			if len(cmds) == 0 {
+				t.Errorf("node %v: no commands committed from stream %s", id, StreamName(i))
+			}
+			total += len(cmds)
+			// the commands of each stream are committed in the order they were generated.
+			prev := -1
+			for _, cmd := range cmds {
+				seq, err := strconv.Atoi(strings.TrimPrefix(string(cmd), StreamName(i)+"/"))
+				if err != nil {
+					t.Fatalf("node %v: malformed command %q: %v", id, cmd, err)
+				}
+				if seq <= prev {
+					t.Errorf("node %v: stream %s: command %d committed after %d", id, StreamName(i), seq, prev)
+				}
+				prev = seq
+			}
+		}
+		if total != len(blocks) {
+			t.Errorf("node %v: got %d commands in streams, but %d committed blocks", id, total, len(blocks))
+		}
+		// each block carries a command from the stream selected by its view.
+		for _, block := range blocks {
+			stream, ok := CommandStream(block.Command())
+			if want := StreamName(int(block.View() % streams)); !ok || stream != want {
+				t.Errorf("node %v: block in view %d has command %q, want a command from stream %s",
+					id, block.View(), block.Command(), want)
+			}
+		}
+	}
+
+	// by default, there is a single stream.
+	result, err = ExecuteScenarioWithOptions(scenario, 4, 0, 200, "chainedhotstuff", ScenarioOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.StreamCommits != nil {
+		t.Errorf("got stream commits %v without multiple streams", result.StreamCommits)
+	}
+	for id, blocks := range result.NodeCommits {
+		for _, block := range blocks {
+			if stream, ok := CommandStream(block.Command()); ok {
+				t.Errorf("node %v: got command %q from stream %s without multiple streams", id, block.Command(), stream)
+			}
+		}
+	}
+
+	if _, err := ExecuteScenarioWithOptions(scenario, 4, 0, 200, "chainedhotstuff", ScenarioOptions{Streams: -1}); err == nil {
+		t.Error("expected an error for a negative number of streams")
+	}
+}
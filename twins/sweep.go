@@ -0,0 +1,51 @@
+package twins
+
+import (
+	"fmt"
+	"time"
+)
+
+// sweepViews is the number of views in the scenarios executed by SweepNodes.
+const sweepViews = 8
+
+// SweepResult contains the outcome of executing a scenario with a specific number of nodes.
+type SweepResult struct {
+	NumNodes uint8
+	NumTicks int
+	Commits  int
+	Duration time.Duration
+}
+
+// TicksPerSecond returns the simulation throughput measured in ticks per second.
+func (r SweepResult) TicksPerSecond() float64 {
+	return float64(r.NumTicks) / r.Duration.Seconds()
+}
+
+// SweepNodes executes a representative scenario once for each number of nodes in the range [minNodes, maxNodes],
+// and reports the time taken and the number of commits for each configuration.
+// The scenario has all nodes in a single partition, with the leader chosen in a round-robin fashion.
+func SweepNodes(minNodes, maxNodes uint8, numTicks int, consensusName string, opts ScenarioOptions) ([]SweepResult, error) {
+	if minNodes == 0 || minNodes > maxNodes {
+		return nil, fmt.Errorf("%w: invalid node range [%d, %d]", ErrInvalidScenario, minNodes, maxNodes)
+	}
+	results := make([]SweepResult, 0, int(maxNodes-minNodes)+1)
+	for n := int(minNodes); n <= int(maxNodes); n++ {
+		numNodes := uint8(n)
+		scenario, err := NewCleanScenario(numNodes, sweepViews)
+		if err != nil {
+			return results, err
+		}
+		start := time.Now()
+		result, err := ExecuteScenarioWithOptions(scenario, numNodes, 0, numTicks, consensusName, opts)
+		if err != nil {
+			return results, fmt.Errorf("failed to execute scenario with %d nodes: %w", numNodes, err)
+		}
+		results = append(results, SweepResult{
+			NumNodes: numNodes,
+			NumTicks: numTicks,
+			Commits:  result.Commits,
+			Duration: time.Since(start),
+		})
+	}
+	return results, nil
+}
@@ -0,0 +1,44 @@
+package twins
+
+import (
+	"fmt"
+	"testing"
+
+	_ "github.com/relab/hotstuff/consensus/chainedhotstuff"
+)
+
+func TestSweepNodes(t *testing.T) {
+	results, err := SweepNodes(4, 7, 50, "chainedhotstuff", ScenarioOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("got %d results, want %d", len(results), 4)
+	}
+	for i, result := range results {
+		if want := uint8(4 + i); result.NumNodes != want {
+			t.Errorf("got result for %d nodes, want %d", result.NumNodes, want)
+		}
+		if result.Commits == 0 {
+			t.Errorf("%d nodes: expected at least one commit", result.NumNodes)
+		}
+		t.Logf("%d nodes: %d commits, %.0f ticks/s", result.NumNodes, result.Commits, result.TicksPerSecond())
+	}
+}
+
+func BenchmarkScenarioSweep(b *testing.B) {
+	for n := uint8(4); n <= 16; n += 4 {
+		scenario, err := NewCleanScenario(n, sweepViews)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fmt.Sprintf("Nodes=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, err := ExecuteScenario(scenario, n, 0, 100, "chainedhotstuff")
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package twins
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/modules"
+)
+
+// TargetedSettings configures the scenarios generated by GenerateTargetedScenarios.
+type TargetedSettings struct {
+	NumNodes uint8
+	NumTwins uint8
+	Views    uint8
+	// Scenarios is the number of scenarios to generate.
+	Scenarios int
+	// Seed makes the generated scenarios reproducible: the same settings and seed give the same scenarios.
+	Seed int64
+	// FaultModel decides the size of the quorums that are kept together, and should match the fault model
+	// of the ScenarioOptions that the scenarios are executed with.
+	FaultModel FaultModel
+}
+
+// GenerateTargetedScenarios generates scenarios that target the transitions that the safety of a consensus rule
+// depends on, instead of enumerating all scenarios like Generator. Each scenario consists of phases in which
+// a quorum of replicas is kept together for long enough to lock on a block, but not to commit it, followed by
+// a partition flip to a quorum that overlaps the previous one as little as possible, led by a replica that was
+// outside the previous quorum, such that the new quorum must respect or safely override the lock.
+// A replica with a twin may have a twin on each side of a partition, such that both twins propose when it leads.
+// Some scenarios end by joining all nodes, such that the nodes can commit blocks, and any divergence is detected.
+//
+// The number of views to keep a quorum together is the lock depth of rules that implement consensus.LockingRules,
+// and one less than the chain length of other rules. The scenarios are generated one at a time as they are received
+// from the channel, which is closed when all of them have been generated or the context is done.
+// It is not necessary to receive all of them, but the context must be canceled to stop the generator.
+func GenerateTargetedScenarios(ctx context.Context, ruleName string, settings TargetedSettings) (<-chan Scenario, error) {
+	rules, ok := modules.GetModule[consensus.Rules](ruleName)
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'; available: %v", ErrUnknownConsensus, ruleName, modules.ListModulesOf[consensus.Rules]())
+	}
+	if settings.NumNodes < 1 || settings.NumTwins > settings.NumNodes {
+		return nil, fmt.Errorf("%w: %d nodes with %d twins", ErrInvalidScenario, settings.NumNodes, settings.NumTwins)
+	}
+	if settings.Views < 1 {
+		return nil, fmt.Errorf("%w: scenarios must have at least one view", ErrInvalidScenario)
+	}
+	if settings.Scenarios < 0 {
+		return nil, fmt.Errorf("%w: negative number of scenarios %d", ErrInvalidScenario, settings.Scenarios)
+	}
+	if r := settings.FaultModel.Resilience; r < 0 || r == 1 {
+		return nil, fmt.Errorf("%w: invalid fault model resilience %d", ErrInvalidScenario, r)
+	}
+
+	lockDepth := rules.ChainLength() - 1
+	if locking, ok := rules.(consensus.LockingRules); ok {
+		lockDepth = locking.LockDepth()
+	}
+	if lockDepth < 1 {
+		lockDepth = 1
+	}
+
+	g := newTargetedGenerator(settings, lockDepth)
+	scenarios := make(chan Scenario)
+	go func() {
+		defer close(scenarios)
+		for i := 0; i < settings.Scenarios; i++ {
+			select {
+			case scenarios <- g.next():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return scenarios, nil
+}
+
+// targetedGenerator generates the scenarios of GenerateTargetedScenarios.
+type targetedGenerator struct {
+	rnd       *rand.Rand
+	views     int
+	lockDepth int
+	numNodes  int
+	quorum    int
+	// the network IDs of the nodes of each replica; a replica with a twin has two nodes.
+	nodes map[hotstuff.ID][]uint32
+}
+
+func newTargetedGenerator(settings TargetedSettings, lockDepth int) *targetedGenerator {
+	nodes, twins := assignNodeIDs(settings.NumNodes, settings.NumTwins)
+	g := &targetedGenerator{
+		rnd:       rand.New(rand.NewSource(settings.Seed)),
+		views:     int(settings.Views),
+		lockDepth: lockDepth,
+		numNodes:  int(settings.NumNodes),
+		quorum:    settings.FaultModel.QuorumSize(int(settings.NumNodes)),
+		nodes:     make(map[hotstuff.ID][]uint32),
+	}
+	for _, id := range append(nodes, twins...) {
+		g.nodes[id.ReplicaID] = append(g.nodes[id.ReplicaID], id.NetworkID)
+	}
+	return g
+}
+
+// next generates a scenario as a sequence of lock phases separated by partition flips.
+func (g *targetedGenerator) next() Scenario {
+	scenario := make(Scenario, 0, g.views)
+	// the final views may join all nodes, such that blocks can be committed.
+	healViews := 0
+	if g.rnd.Intn(2) == 0 {
+		healViews = g.lockDepth + 1
+		if healViews > g.views {
+			healViews = g.views
+		}
+	}
+
+	var previous []hotstuff.ID
+	for len(scenario) < g.views-healViews {
+		replicas := g.pickQuorum(previous)
+		partitions := g.split(replicas)
+		leaders := g.pickLeaders(replicas, previous)
+		// keep the quorum together long enough to lock, and sometimes one view longer.
+		length := g.lockDepth + g.rnd.Intn(2)
+		for i := 0; i < length && len(scenario) < g.views-healViews; i++ {
+			scenario = append(scenario, View{Leader: leaders[i%len(leaders)], Partitions: partitions})
+		}
+		previous = replicas
+	}
+
+	allNodes := make(NodeSet)
+	for _, networkIDs := range g.nodes {
+		for _, id := range networkIDs {
+			allNodes.Add(id)
+		}
+	}
+	for len(scenario) < g.views {
+		leader := hotstuff.ID(g.rnd.Intn(g.numNodes) + 1)
+		scenario = append(scenario, View{Leader: leader, Partitions: []NodeSet{allNodes}})
+	}
+	return scenario
+}
+
+// pickQuorum returns the replicas of the next quorum. Most quorums are bare quorums, as they are the most likely to
+// be affected by a lock held by a replica outside them. The replicas that were outside the previous quorum are
+// picked first, such that the quorums overlap as little as possible.
+func (g *targetedGenerator) pickQuorum(previous []hotstuff.ID) []hotstuff.ID {
+	size := g.quorum
+	if g.rnd.Intn(4) == 0 {
+		size += g.rnd.Intn(g.numNodes - g.quorum + 1)
+	}
+	inPrevious := consensus.NewIDSet()
+	for _, id := range previous {
+		inPrevious.Add(id)
+	}
+	var outside, inside []hotstuff.ID
+	for _, i := range g.rnd.Perm(g.numNodes) {
+		id := hotstuff.ID(i + 1)
+		if inPrevious.Contains(id) {
+			inside = append(inside, id)
+		} else {
+			outside = append(outside, id)
+		}
+	}
+	return append(outside, inside...)[:size]
+}
+
+// split returns the partitions in which the given replicas form a partition, and the other nodes form another.
+// For each replica with a twin, one twin is kept with the quorum and the other is left out, or both are kept.
+func (g *targetedGenerator) split(replicas []hotstuff.ID) []NodeSet {
+	quorum := make(NodeSet)
+	rest := make(NodeSet)
+	inQuorum := consensus.NewIDSet()
+	for _, id := range replicas {
+		inQuorum.Add(id)
+	}
+	for id := hotstuff.ID(1); id <= hotstuff.ID(g.numNodes); id++ {
+		networkIDs := g.nodes[id]
+		switch {
+		case !inQuorum.Contains(id):
+			for _, networkID := range networkIDs {
+				rest.Add(networkID)
+			}
+		case len(networkIDs) == 2 && g.rnd.Intn(2) == 0:
+			i := g.rnd.Intn(2)
+			quorum.Add(networkIDs[i])
+			rest.Add(networkIDs[1-i])
+		default:
+			for _, networkID := range networkIDs {
+				quorum.Add(networkID)
+			}
+		}
+	}
+	if len(rest) == 0 {
+		return []NodeSet{quorum}
+	}
+	return []NodeSet{quorum, rest}
+}
+
+// pickLeaders returns the order in which the replicas of the quorum lead its views.
+// The first leader is preferably a replica that was outside the previous quorum,
+// since it may not know of the block that the previous quorum locked on.
+func (g *targetedGenerator) pickLeaders(replicas, previous []hotstuff.ID) []hotstuff.ID {
+	leaders := make([]hotstuff.ID, len(replicas))
+	copy(leaders, replicas)
+	g.rnd.Shuffle(len(leaders), func(i, j int) { leaders[i], leaders[j] = leaders[j], leaders[i] })
+	inPrevious := consensus.NewIDSet()
+	for _, id := range previous {
+		inPrevious.Add(id)
+	}
+	for i, id := range leaders {
+		if !inPrevious.Contains(id) {
+			leaders[0], leaders[i] = leaders[i], leaders[0]
+			break
+		}
+	}
+	return leaders
+}
@@ -0,0 +1,136 @@
+package twins
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	_ "github.com/relab/hotstuff/consensus/chainedhotstuff"
+)
+
+func collectTargeted(t *testing.T, ruleName string, settings TargetedSettings) []Scenario {
+	t.Helper()
+	ch, err := GenerateTargetedScenarios(context.Background(), ruleName, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var scenarios []Scenario
+	for s := range ch {
+		scenarios = append(scenarios, s)
+	}
+	return scenarios
+}
+
+func TestGenerateTargetedScenarios(t *testing.T) {
+	settings := TargetedSettings{NumNodes: 4, NumTwins: 1, Views: 8, Scenarios: 20, Seed: 1}
+	scenarios := collectTargeted(t, "chainedhotstuff", settings)
+	if len(scenarios) != settings.Scenarios {
+		t.Fatalf("got %d scenarios, want %d", len(scenarios), settings.Scenarios)
+	}
+
+	flips := 0
+	for i, s := range scenarios {
+		if len(s) != int(settings.Views) {
+			t.Errorf("scenario %d has %d views, want %d", i, len(s), settings.Views)
+		}
+		for j, view := range s {
+			if view.Leader < 1 || view.Leader > 4 {
+				t.Errorf("scenario %d view %d has invalid leader %d", i, j, view.Leader)
+			}
+			seen := make(map[uint32]int)
+			for _, p := range view.Partitions {
+				for id := range p {
+					seen[id]++
+				}
+			}
+			// 4 replicas and 1 twin give 5 nodes.
+			for id := uint32(1); id <= 5; id++ {
+				if seen[id] != 1 {
+					t.Errorf("scenario %d view %d has node %d in %d partitions", i, j, id, seen[id])
+				}
+			}
+			if j > 0 && !reflect.DeepEqual(view.Partitions, s[j-1].Partitions) {
+				flips++
+			}
+		}
+		result, err := ExecuteScenario(s, settings.NumNodes, settings.NumTwins, 100, "chainedhotstuff")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Safe {
+			t.Errorf("scenario %d is not safe: %v", i, s)
+		}
+	}
+	if flips == 0 {
+		t.Error("expected the scenarios to flip partitions")
+	}
+
+	if again := collectTargeted(t, "chainedhotstuff", settings); !reflect.DeepEqual(again, scenarios) {
+		t.Error("expected the same seed to generate the same scenarios")
+	}
+}
+
+func TestGenerateTargetedScenariosInvalid(t *testing.T) {
+	if _, err := GenerateTargetedScenarios(context.Background(), "unknown", TargetedSettings{NumNodes: 4, Views: 4}); !errors.Is(err, ErrUnknownConsensus) {
+		t.Errorf("got %v, want %v", err, ErrUnknownConsensus)
+	}
+	for _, settings := range []TargetedSettings{
+		{NumNodes: 0, Views: 4},
+		{NumNodes: 4, NumTwins: 5, Views: 4},
+		{NumNodes: 4, Views: 0},
+		{NumNodes: 4, Views: 4, Scenarios: -1},
+		{NumNodes: 4, Views: 4, FaultModel: FaultModel{Resilience: 1}},
+	} {
+		if _, err := GenerateTargetedScenarios(context.Background(), "chainedhotstuff", settings); !errors.Is(err, ErrInvalidScenario) {
+			t.Errorf("settings %+v: got %v, want %v", settings, err, ErrInvalidScenario)
+		}
+	}
+}
+
+func TestGenerateTargetedScenariosCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	settings := TargetedSettings{NumNodes: 4, Views: 8, Scenarios: 1000, Seed: 1}
+	ch, err := GenerateTargetedScenarios(ctx, "chainedhotstuff", settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-ch
+	cancel()
+
+	received := 1
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				if received >= settings.Scenarios {
+					t.Errorf("received all %d scenarios after canceling", received)
+				}
+				return
+			}
+			received++
+		case <-timeout:
+			t.Fatal("the channel was not closed after canceling")
+		}
+	}
+}
+
+func TestGenerateTargetedScenariosFaultModel(t *testing.T) {
+	// with 7 replicas, the standard fault model gives quorums of 5, but a resilience of 5 gives quorums of 6.
+	settings := TargetedSettings{NumNodes: 7, Views: 8, Scenarios: 20, Seed: 1, FaultModel: FaultModel{Resilience: 5}}
+	smallest := int(settings.NumNodes)
+	for _, s := range collectTargeted(t, "chainedhotstuff", settings) {
+		for _, view := range s {
+			for _, p := range view.Partitions {
+				if _, ok := p[uint32(view.Leader)]; ok && len(p) < smallest {
+					smallest = len(p)
+				}
+			}
+		}
+	}
+	if smallest != 6 {
+		t.Errorf("got smallest quorum of %d replicas, want 6", smallest)
+	}
+}
@@ -0,0 +1,67 @@
+package twins
+
+import (
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+	"golang.org/x/exp/slices"
+)
+
+// TimeoutCertRecord describes a timeout certificate formed by a node.
+type TimeoutCertRecord struct {
+	// View is the view that timed out.
+	View consensus.View
+	// Tick is the network tick during which the certificate was formed.
+	Tick int
+	// Signers contains the replicas whose timeout messages for the view had valid signatures, in increasing order.
+	Signers []hotstuff.ID
+	// Valid is true if the signers form a quorum and the certificate can be verified.
+	Valid bool
+}
+
+// timeoutCertRecorder records the timeout certificates created by a node.
+type timeoutCertRecorder struct {
+	consensus.Crypto
+	mods    *consensus.Modules
+	node    *node
+	network *Network
+}
+
+// InitConsensusModule gives the module a reference to the Modules object.
+// It also allows the module to set module options using the OptionsBuilder.
+func (r *timeoutCertRecorder) InitConsensusModule(mods *consensus.Modules, opts *consensus.OptionsBuilder) {
+	r.mods = mods
+	if mod, ok := r.Crypto.(consensus.Module); ok {
+		mod.InitConsensusModule(mods, opts)
+	}
+}
+
+// CreateTimeoutCert creates a timeout certificate and records it, along with the timeouts that it was created from.
+func (r *timeoutCertRecorder) CreateTimeoutCert(view consensus.View, timeouts []consensus.TimeoutMsg) (consensus.TimeoutCert, error) {
+	tc, err := r.Crypto.CreateTimeoutCert(view, timeouts)
+	// the certificate for view 0 is created by the synchronizer without any timeouts.
+	if err != nil || view == 0 {
+		return tc, err
+	}
+
+	signers := consensus.NewIDSet()
+	for _, timeout := range timeouts {
+		if timeout.View == view && r.Crypto.Verify(timeout.ViewSignature, view.ToBytes()) {
+			signers.Add(timeout.ID)
+		}
+	}
+	record := TimeoutCertRecord{
+		View:  view,
+		Tick:  r.network.currentTick,
+		Valid: consensus.IsQuorum(r.mods.Configuration(), signers) && r.Crypto.VerifyTimeoutCert(tc),
+	}
+	signers.ForEach(func(id hotstuff.ID) {
+		record.Signers = append(record.Signers, id)
+	})
+	slices.Sort(record.Signers)
+
+	r.node.timeoutCerts = append(r.node.timeoutCerts, record)
+	if !record.Valid {
+		r.network.logger.Infof("node %v: INVALID timeout certificate for view %d from %v", r.node.id, view, record.Signers)
+	}
+	return tc, nil
+}
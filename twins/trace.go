@@ -0,0 +1,112 @@
+package twins
+
+import (
+	"context"
+	"crypto/sha256"
+
+	"github.com/relab/hotstuff/consensus"
+)
+
+// EventRecord records an event that was processed by the event loop of a node.
+type EventRecord struct {
+	// Tick is the network tick during which the event was processed.
+	Tick int
+	// Event is the event that was processed.
+	Event interface{}
+}
+
+// replayData contains the information needed to replay a scenario.
+// All maps are indexed by network ID.
+type replayData struct {
+	// the private key of each node.
+	keys map[uint32]consensus.PrivateKey
+	// the signatures created by each node, indexed by the hash of the signed message.
+	signatures map[uint32]map[consensus.Hash]consensus.QuorumSignature
+	// the messages that were delivered to each node by the network, in the order they were delivered.
+	deliveries map[uint32][]EventRecord
+}
+
+func collectReplayData(network *Network) *replayData {
+	data := &replayData{
+		keys:       make(map[uint32]consensus.PrivateKey),
+		signatures: make(map[uint32]map[consensus.Hash]consensus.QuorumSignature),
+		deliveries: make(map[uint32][]EventRecord),
+	}
+	for id, node := range network.nodes {
+		data.keys[id] = node.mods.PrivateKey()
+		data.signatures[id] = node.signatures
+		data.deliveries[id] = node.deliveries
+	}
+	return data
+}
+
+// ReplayEventTrace replays a scenario that was executed with ScenarioOptions.RecordEvents.
+// Instead of delivering the messages sent by the nodes, the network delivers the messages recorded in the trace,
+// at the same ticks and in the same order as in the original execution.
+// The nodes reuse the keys and signatures of the original execution,
+// such that they process the exact same sequence of events, regardless of any randomness.
+// The scenario and parameters must be the same as in the original execution.
+func ReplayEventTrace(
+	ctx context.Context,
+	trace ScenarioResult,
+	scenario Scenario,
+	numNodes, numTwins uint8,
+	numTicks int,
+	consensusName string,
+	opts ScenarioOptions,
+) (result ScenarioResult, err error) {
+	if trace.replay == nil {
+		return ScenarioResult{}, ErrNoEventTrace
+	}
+	opts.RecordEvents = true
+	return executeScenario(ctx, scenario, numNodes, numTwins, numTicks, consensusName, opts, trace.replay)
+}
+
+// traceSigner records the signatures created by a node, and reuses them when replaying a scenario.
+type traceSigner struct {
+	consensus.CryptoBase
+	node   *node
+	replay map[consensus.Hash]consensus.QuorumSignature
+}
+
+// InitConsensusModule gives the module a reference to the Modules object.
+// It also allows the module to set module options using the OptionsBuilder.
+func (s *traceSigner) InitConsensusModule(mods *consensus.Modules, opts *consensus.OptionsBuilder) {
+	if mod, ok := s.CryptoBase.(consensus.Module); ok {
+		mod.InitConsensusModule(mods, opts)
+	}
+}
+
+// Sign creates a cryptographic signature of the given message.
+func (s *traceSigner) Sign(message []byte) (signature consensus.QuorumSignature, err error) {
+	hash := consensus.Hash(sha256.Sum256(message))
+	signature, ok := s.replay[hash]
+	if !ok {
+		signature, err = s.CryptoBase.Sign(message)
+		if err != nil {
+			return nil, err
+		}
+	}
+	s.node.signatures[hash] = signature
+	return signature, nil
+}
+
+// deliver adds a message to the event loop of the node, and records the delivery if enabled.
+func (n *Network) deliver(node *node, message interface{}) {
+	if n.recordEvents {
+		node.deliveries = append(node.deliveries, EventRecord{Tick: n.currentTick, Event: message})
+	}
+	if proposal, ok := message.(consensus.ProposeMsg); ok {
+		n.recordBlockEvent(proposal.Block.Hash(), BlockDelivered, node.id)
+	}
+	node.mods.EventLoop().AddEvent(message)
+}
+
+// deliverReplay delivers the recorded messages for the current tick to the node.
+func (n *Network) deliverReplay(node *node) {
+	recorded := n.replay.deliveries[node.id.NetworkID]
+	for node.replayPos < len(recorded) && recorded[node.replayPos].Tick == n.currentTick {
+		n.deliver(node, recorded[node.replayPos].Event)
+		node.replayPos++
+	}
+}
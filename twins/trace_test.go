@@ -0,0 +1,74 @@
+package twins
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	_ "github.com/relab/hotstuff/consensus/chainedhotstuff"
+)
+
+func TestReplayEventTrace(t *testing.T) {
+	allNodes := NodeSet{1: {}, 2: {}, 3: {}, 4: {}, 5: {}}
+	scenario := Scenario{
+		{Leader: 1, Partitions: []NodeSet{{1: {}, 3: {}, 4: {}}, {2: {}, 5: {}}}},
+		{Leader: 1, Partitions: []NodeSet{{2: {}, 3: {}, 4: {}}, {1: {}, 5: {}}}},
+		{Leader: 3, Partitions: []NodeSet{allNodes}},
+		{Leader: 4, Partitions: []NodeSet{allNodes}},
+		{Leader: 3, Partitions: []NodeSet{allNodes}},
+		{Leader: 4, Partitions: []NodeSet{allNodes}},
+	}
+	opts := ScenarioOptions{RecordEvents: true}
+	original, err := ExecuteScenarioWithOptions(scenario, 4, 1, 100, "chainedhotstuff", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if original.Commits == 0 {
+		t.Fatal("expected some commits")
+	}
+	if len(original.NodeEventTrace) != 5 {
+		t.Fatalf("expected event traces for 5 nodes, got %d", len(original.NodeEventTrace))
+	}
+
+	replayed, err := ReplayEventTrace(context.Background(), original, scenario, 4, 1, 100, "chainedhotstuff", ScenarioOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for id, trace := range original.NodeEventTrace {
+		replayedTrace := replayed.NodeEventTrace[id]
+		if len(trace) != len(replayedTrace) {
+			t.Errorf("node %v: processed %d events, but %d when replayed", id, len(trace), len(replayedTrace))
+			continue
+		}
+		for i := range trace {
+			if trace[i].Tick != replayedTrace[i].Tick || reflect.TypeOf(trace[i].Event) != reflect.TypeOf(replayedTrace[i].Event) {
+				t.Errorf("node %v: event %d differs: %d:%T != %d:%T", id, i,
+					trace[i].Tick, trace[i].Event, replayedTrace[i].Tick, replayedTrace[i].Event)
+				break
+			}
+		}
+	}
+
+	// the committed blocks must be identical, including their hashes.
+	for id, blocks := range original.NodeCommits {
+		replayedBlocks := replayed.NodeCommits[id]
+		if len(blocks) != len(replayedBlocks) {
+			t.Errorf("node %v: committed %d blocks, but %d when replayed", id, len(blocks), len(replayedBlocks))
+			continue
+		}
+		for i := range blocks {
+			if blocks[i].Hash() != replayedBlocks[i].Hash() {
+				t.Errorf("node %v: block %d differs when replayed", id, i)
+			}
+		}
+	}
+}
+
+func TestReplayWithoutTrace(t *testing.T) {
+	_, err := ReplayEventTrace(context.Background(), ScenarioResult{}, nil, 4, 0, 10, "chainedhotstuff", ScenarioOptions{})
+	if !errors.Is(err, ErrNoEventTrace) {
+		t.Errorf("expected %v, got %v", ErrNoEventTrace, err)
+	}
+}